@@ -0,0 +1,219 @@
+// Package auth protects MCP servers served over HTTP. A Handler checks the
+// bearer token of every request with a TokenVerifier and passes the
+// authenticated Principal on through the request context. The HTTP
+// transports hand that context to the server, so tools and method handlers
+// can read the caller with PrincipalFromContext.
+package auth
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Principal is an authenticated caller
+type Principal struct {
+	// Subject identifies the caller, such as the "sub" claim of a JWT
+	Subject string
+
+	// Scopes are the scopes granted to the token
+	Scopes []string
+
+	// Claims holds all claims of a JWT, or nil for other tokens
+	Claims map[string]interface{}
+}
+
+// HasScope reports whether the principal was granted scope
+func (p *Principal) HasScope(scope string) bool {
+	for _, s := range p.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// principalKey is the context key for the authenticated principal
+type principalKey struct{}
+
+// PrincipalFromContext returns the authenticated caller, or nil if the
+// request was not authenticated
+func PrincipalFromContext(ctx context.Context) *Principal {
+	p, _ := ctx.Value(principalKey{}).(*Principal)
+	return p
+}
+
+// ContextWithPrincipal returns a context carrying p
+func ContextWithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// SameSubject reports whether two contexts were authenticated as the same
+// caller. Two unauthenticated contexts are the same caller too.
+func SameSubject(a, b context.Context) bool {
+	pa, pb := PrincipalFromContext(a), PrincipalFromContext(b)
+	if pa == nil || pb == nil {
+		return pa == pb
+	}
+	return pa.Subject == pb.Subject
+}
+
+// ErrInvalidToken is returned by verifiers for tokens they do not accept
+var ErrInvalidToken = errors.New("invalid token")
+
+// TokenVerifier checks a bearer token and returns the principal it
+// authenticates. Errors should wrap ErrInvalidToken when the token itself is
+// at fault.
+type TokenVerifier interface {
+	Verify(ctx context.Context, token string) (*Principal, error)
+}
+
+// VerifierFunc adapts a function to the TokenVerifier interface
+type VerifierFunc func(ctx context.Context, token string) (*Principal, error)
+
+// Verify implements TokenVerifier
+func (f VerifierFunc) Verify(ctx context.Context, token string) (*Principal, error) {
+	return f(ctx, token)
+}
+
+// StaticTokens accepts a fixed set of bearer tokens, each mapped to the
+// principal it authenticates
+type StaticTokens map[string]*Principal
+
+// Verify implements TokenVerifier. Tokens are compared in constant time.
+func (s StaticTokens) Verify(ctx context.Context, token string) (*Principal, error) {
+	var found *Principal
+	for t, p := range s {
+		if subtle.ConstantTimeCompare([]byte(t), []byte(token)) == 1 {
+			found = p
+		}
+	}
+	if found == nil {
+		return nil, ErrInvalidToken
+	}
+	return found, nil
+}
+
+// AnyOf returns a verifier that accepts a token if one of verifiers does,
+// such as a static token or a JWT. When all of them refuse it, the error of
+// a verifier that failed itself is returned over ErrInvalidToken.
+func AnyOf(verifiers ...TokenVerifier) TokenVerifier {
+	return VerifierFunc(func(ctx context.Context, token string) (*Principal, error) {
+		err := ErrInvalidToken
+		for _, v := range verifiers {
+			p, verr := v.Verify(ctx, token)
+			if verr == nil {
+				return p, nil
+			}
+			// Report the most specific reason, but keep failures of
+			// the verifier itself over rejected tokens
+			if errors.Is(err, ErrInvalidToken) {
+				err = verr
+			}
+		}
+		return nil, err
+	})
+}
+
+// Config represents configuration options for the auth handler
+type Config struct {
+	// ResourceMetadataURL is the URL of the protected resource metadata,
+	// announced to clients in the WWW-Authenticate header of 401 responses
+	ResourceMetadataURL string
+
+	// RequiredScopes are scopes every token must carry. Tokens missing one
+	// get 403 Forbidden.
+	RequiredScopes []string
+}
+
+// DefaultConfig returns the default auth configuration
+func DefaultConfig() *Config {
+	return &Config{}
+}
+
+// Handler authenticates requests before passing them to the wrapped handler
+type Handler struct {
+	next     http.Handler
+	verifier TokenVerifier
+	config   *Config
+}
+
+// NewHandler creates a handler that requires a bearer token accepted by
+// verifier on every request to next
+func NewHandler(next http.Handler, verifier TokenVerifier, config *Config) *Handler {
+	if config == nil {
+		config = DefaultConfig()
+	}
+
+	return &Handler{
+		next:     next,
+		verifier: verifier,
+		config:   config,
+	}
+}
+
+// ServeHTTP implements http.Handler
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	token, ok := bearerToken(r)
+	if !ok {
+		h.challenge(w, http.StatusUnauthorized, "", "")
+		return
+	}
+
+	p, err := h.verifier.Verify(r.Context(), token)
+	if err != nil {
+		if !errors.Is(err, ErrInvalidToken) {
+			http.Error(w, "Failed to verify token", http.StatusInternalServerError)
+			return
+		}
+		h.challenge(w, http.StatusUnauthorized, "invalid_token", err.Error())
+		return
+	}
+
+	for _, scope := range h.config.RequiredScopes {
+		if !p.HasScope(scope) {
+			h.challenge(w, http.StatusForbidden, "insufficient_scope", "missing scope "+scope)
+			return
+		}
+	}
+
+	h.next.ServeHTTP(w, r.WithContext(ContextWithPrincipal(r.Context(), p)))
+}
+
+// challenge rejects a request with a WWW-Authenticate header as described in
+// RFC 6750 and RFC 9728
+func (h *Handler) challenge(w http.ResponseWriter, status int, code, description string) {
+	var params []string
+	if h.config.ResourceMetadataURL != "" {
+		params = append(params, fmt.Sprintf("resource_metadata=%q", h.config.ResourceMetadataURL))
+	}
+	if code != "" {
+		params = append(params, fmt.Sprintf("error=%q", code))
+	}
+	if description != "" {
+		params = append(params, fmt.Sprintf("error_description=%q", description))
+	}
+	if len(h.config.RequiredScopes) > 0 {
+		params = append(params, fmt.Sprintf("scope=%q", strings.Join(h.config.RequiredScopes, " ")))
+	}
+
+	challenge := "Bearer"
+	if len(params) > 0 {
+		challenge += " " + strings.Join(params, ", ")
+	}
+	w.Header().Set("WWW-Authenticate", challenge)
+	http.Error(w, http.StatusText(status), status)
+}
+
+// bearerToken extracts the token from an Authorization: Bearer header
+func bearerToken(r *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
@@ -0,0 +1,198 @@
+package auth_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"mcp-go-sdk/auth"
+	"mcp-go-sdk/server"
+	"mcp-go-sdk/transport"
+)
+
+func TestHandler(t *testing.T) {
+	var seen *auth.Principal
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = auth.PrincipalFromContext(r.Context())
+	})
+	tokens := auth.StaticTokens{
+		"secret":   {Subject: "alice", Scopes: []string{"mcp"}},
+		"no-scope": {Subject: "bob"},
+	}
+	h := auth.NewHandler(next, tokens, &auth.Config{
+		ResourceMetadataURL: "https://mcp.test" + auth.MetadataPath,
+		RequiredScopes:      []string{"mcp"},
+	})
+
+	tests := []struct {
+		name          string
+		header        string
+		status        int
+		authenticate  string
+		expectSubject string
+	}{
+		{"no token", "", 401, `Bearer resource_metadata="https://mcp.test/.well-known/oauth-protected-resource", scope="mcp"`, ""},
+		{"other scheme", "Basic c2VjcmV0", 401, `Bearer resource_metadata=`, ""},
+		{"unknown token", "Bearer wrong", 401, `error="invalid_token"`, ""},
+		{"missing scope", "Bearer no-scope", 403, `error="insufficient_scope"`, ""},
+		{"valid token", "bearer secret", 200, "", "alice"},
+	}
+	for _, tt := range tests {
+		seen = nil
+		req := httptest.NewRequest(http.MethodPost, "/mcp", nil)
+		if tt.header != "" {
+			req.Header.Set("Authorization", tt.header)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+
+		if rec.Code != tt.status {
+			t.Errorf("%s: expected status %d, got %d", tt.name, tt.status, rec.Code)
+		}
+		if got := rec.Header().Get("WWW-Authenticate"); !strings.Contains(got, tt.authenticate) {
+			t.Errorf("%s: expected WWW-Authenticate to contain %q, got %q", tt.name, tt.authenticate, got)
+		}
+		if tt.expectSubject == "" && seen != nil {
+			t.Errorf("%s: request should not have reached the handler", tt.name)
+		}
+		if tt.expectSubject != "" && (seen == nil || seen.Subject != tt.expectSubject) {
+			t.Errorf("%s: expected principal %s, got %+v", tt.name, tt.expectSubject, seen)
+		}
+	}
+
+	// Verifier failures are not the client's fault
+	failing := auth.VerifierFunc(func(ctx context.Context, token string) (*auth.Principal, error) {
+		return nil, errors.New("issuer unreachable")
+	})
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/mcp", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	auth.NewHandler(next, failing, nil).ServeHTTP(rec, req)
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("Expected 500 when the verifier fails, got %d", rec.Code)
+	}
+}
+
+func TestAnyOf(t *testing.T) {
+	ctx := context.Background()
+	failing := auth.VerifierFunc(func(ctx context.Context, token string) (*auth.Principal, error) {
+		return nil, errors.New("issuer unreachable")
+	})
+	v := auth.AnyOf(auth.StaticTokens{"a": {Subject: "alice"}}, failing, auth.StaticTokens{"b": {Subject: "bob"}})
+	if p, err := v.Verify(ctx, "b"); err != nil || p.Subject != "bob" {
+		t.Errorf("Expected the second token verifier to accept, got %+v %v", p, err)
+	}
+	if _, err := v.Verify(ctx, "c"); err == nil || err.Error() != "issuer unreachable" {
+		t.Errorf("Expected the verifier failure over the invalid token, got %v", err)
+	}
+	if _, err := auth.AnyOf(auth.StaticTokens{}).Verify(ctx, "c"); !errors.Is(err, auth.ErrInvalidToken) {
+		t.Errorf("Expected ErrInvalidToken, got %v", err)
+	}
+	if _, err := auth.AnyOf().Verify(ctx, "c"); !errors.Is(err, auth.ErrInvalidToken) {
+		t.Errorf("Expected no verifiers to accept nothing, got %v", err)
+	}
+}
+
+func TestMetadataHandler(t *testing.T) {
+	h := auth.NewMetadataHandler(&auth.ResourceMetadata{
+		Resource:               "https://mcp.test/mcp",
+		AuthorizationServers:   []string{"https://issuer.test"},
+		ScopesSupported:        []string{"tools:read"},
+		BearerMethodsSupported: []string{"header"},
+	})
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, auth.MetadataPath, nil))
+
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("Unexpected response %d %s", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	expected := `{"resource":"https://mcp.test/mcp","authorization_servers":["https://issuer.test"],"scopes_supported":["tools:read"],"bearer_methods_supported":["header"]}`
+	if rec.Body.String() != expected {
+		t.Errorf("Expected %s, got %s", expected, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, auth.MetadataPath, nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected 405 for POST, got %d", rec.Code)
+	}
+}
+
+// whoamiTool implements mcp.ContextTool by returning the caller
+type whoamiTool struct{}
+
+func (t *whoamiTool) Name() string            { return "whoami" }
+func (t *whoamiTool) Description() string     { return "Returns the caller" }
+func (t *whoamiTool) Schema() json.RawMessage { return json.RawMessage(`{"type":"object"}`) }
+func (t *whoamiTool) Execute(params json.RawMessage) (interface{}, error) {
+	return nil, errors.New("called without a context")
+}
+func (t *whoamiTool) ExecuteContext(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	p := auth.PrincipalFromContext(ctx)
+	if p == nil {
+		return nil, errors.New("not authenticated")
+	}
+	if !p.HasScope("tools:call") {
+		return nil, errors.New("tools:call scope required")
+	}
+	return p.Subject, nil
+}
+
+func TestPrincipalReachesTools(t *testing.T) {
+	iss := newIssuer(t)
+	verifier, err := auth.NewJWTVerifier(&auth.JWTConfig{JWKSFile: iss.path, Issuer: iss.url})
+	if err != nil {
+		t.Fatalf("NewJWTVerifier failed: %v", err)
+	}
+
+	srv := server.NewServer(nil)
+	srv.RegisterTool(&whoamiTool{})
+	mcpHandler := transport.NewStreamableHTTPHandler(srv.ServeTransport, &transport.StreamableHTTPConfig{JSONResponse: true})
+	defer mcpHandler.Close()
+	ts := httptest.NewServer(auth.NewHandler(mcpHandler, verifier, nil))
+	defer ts.Close()
+
+	post := func(token, sessionID, body string) (*http.Response, string) {
+		req, _ := http.NewRequest(http.MethodPost, ts.URL, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		if sessionID != "" {
+			req.Header.Set(transport.HeaderSessionID, sessionID)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(resp.Body)
+		return resp, string(data)
+	}
+
+	alice := iss.sign("rsa-1", iss.claims("alice"))
+	resp, _ := post(alice, "", `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26"}}`)
+	sessionID := resp.Header.Get(transport.HeaderSessionID)
+	if resp.StatusCode != http.StatusOK || sessionID == "" {
+		t.Fatalf("Initialize failed with status %d", resp.StatusCode)
+	}
+
+	call := `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"whoami"}}`
+	if _, body := post(alice, sessionID, call); !strings.Contains(body, `"result":"alice"`) {
+		t.Errorf("Expected the tool to see alice, got %s", body)
+	}
+
+	// A fresh token for the same subject may continue the session
+	if _, body := post(iss.sign("ec-1", iss.claims("alice")), sessionID, call); !strings.Contains(body, `"result":"alice"`) {
+		t.Errorf("Expected a new token for alice to work, got %s", body)
+	}
+
+	// Another caller cannot use the session
+	if resp, _ := post(iss.sign("ed-1", iss.claims("mallory")), sessionID, call); resp.StatusCode != http.StatusForbidden {
+		t.Errorf("Expected 403 for another caller's session, got %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,165 @@
+package auth_test
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// issuer stands in for an authorization server. It signs tokens with its
+// keys and publishes them in a JWKS file.
+type issuer struct {
+	t    *testing.T
+	url  string
+	path string
+	keys map[string]crypto.Signer
+}
+
+// newIssuer creates an issuer with an RSA, an EC and an Ed25519 key
+func newIssuer(t *testing.T) *issuer {
+	t.Helper()
+	iss := &issuer{
+		t:    t,
+		url:  "https://issuer.test",
+		path: filepath.Join(t.TempDir(), "jwks.json"),
+		keys: make(map[string]crypto.Signer),
+	}
+	iss.addKey("rsa-1", generate(t, "RSA"))
+	iss.addKey("ec-1", generate(t, "EC"))
+	iss.addKey("ed-1", generate(t, "OKP"))
+	return iss
+}
+
+// generate returns a new key of the given JWK type
+func generate(t *testing.T, kty string) crypto.Signer {
+	t.Helper()
+	var (
+		key crypto.Signer
+		err error
+	)
+	switch kty {
+	case "RSA":
+		key, err = rsa.GenerateKey(rand.Reader, 2048)
+	case "EC":
+		key, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case "OKP":
+		_, key, err = ed25519.GenerateKey(rand.Reader)
+	}
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	return key
+}
+
+// addKey publishes a key under kid
+func (iss *issuer) addKey(kid string, key crypto.Signer) {
+	iss.keys[kid] = key
+	iss.publish()
+}
+
+// removeKey stops publishing a key
+func (iss *issuer) removeKey(kid string) {
+	delete(iss.keys, kid)
+	iss.publish()
+}
+
+// publish writes the JWKS file
+func (iss *issuer) publish() {
+	var keys []map[string]string
+	for kid, key := range iss.keys {
+		jwk := map[string]string{"kid": kid, "use": "sig"}
+		switch pub := key.Public().(type) {
+		case *rsa.PublicKey:
+			jwk["kty"] = "RSA"
+			jwk["n"] = b64(pub.N.Bytes())
+			jwk["e"] = b64(big.NewInt(int64(pub.E)).Bytes())
+		case *ecdsa.PublicKey:
+			jwk["kty"] = "EC"
+			jwk["crv"] = "P-256"
+			jwk["x"] = b64(pub.X.FillBytes(make([]byte, 32)))
+			jwk["y"] = b64(pub.Y.FillBytes(make([]byte, 32)))
+		case ed25519.PublicKey:
+			jwk["kty"] = "OKP"
+			jwk["crv"] = "Ed25519"
+			jwk["x"] = b64(pub)
+		}
+		keys = append(keys, jwk)
+	}
+	data, _ := json.Marshal(map[string]interface{}{"keys": keys})
+
+	// Write next to the file and rename, the way key rotation tools do
+	tmp := iss.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		iss.t.Fatalf("Failed to write JWKS: %v", err)
+	}
+	if err := os.Rename(tmp, iss.path); err != nil {
+		iss.t.Fatalf("Failed to write JWKS: %v", err)
+	}
+}
+
+// claims returns valid claims for subject, which callers may change
+func (iss *issuer) claims(subject string) map[string]interface{} {
+	now := time.Now()
+	return map[string]interface{}{
+		"iss":   iss.url,
+		"sub":   subject,
+		"aud":   "https://mcp.test/mcp",
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+		"scope": "tools:read tools:call",
+	}
+}
+
+// sign issues a token signed by the key kid
+func (iss *issuer) sign(kid string, claims map[string]interface{}) string {
+	key, ok := iss.keys[kid]
+	if !ok {
+		iss.t.Fatalf("Unknown key %s", kid)
+	}
+	alg := map[string]string{"rsa-1": "RS256", "rsa-2": "RS256", "ec-1": "ES256", "ed-1": "EdDSA"}[kid]
+	return signWith(iss.t, key, alg, kid, claims)
+}
+
+// signWith signs claims with key under any header
+func signWith(t *testing.T, key crypto.Signer, alg, kid string, claims map[string]interface{}) string {
+	t.Helper()
+	header, _ := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	signed := b64(header) + "." + b64(payload)
+
+	var sig []byte
+	var err error
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		h := sha256.Sum256([]byte(signed))
+		sig, err = rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, h[:])
+	case *ecdsa.PrivateKey:
+		h := sha256.Sum256([]byte(signed))
+		var r, s *big.Int
+		r, s, err = ecdsa.Sign(rand.Reader, k, h[:])
+		if err == nil {
+			sig = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+		}
+	case ed25519.PrivateKey:
+		sig = ed25519.Sign(k, []byte(signed))
+	}
+	if err != nil {
+		t.Fatalf("Failed to sign token: %v", err)
+	}
+	return signed + "." + b64(sig)
+}
+
+func b64(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
@@ -0,0 +1,318 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// JWTConfig represents configuration options for JWT validation
+type JWTConfig struct {
+	// JWKSFile is the path of a JSON Web Key Set holding the issuer's
+	// public keys. The file is read again when it changes, so keys can be
+	// rotated without a restart.
+	JWKSFile string
+
+	// Issuer is the required "iss" claim. Empty accepts any issuer.
+	Issuer string
+
+	// Audience must be one of the "aud" claims, normally the URL of the
+	// MCP endpoint. Empty accepts any audience.
+	Audience string
+
+	// Leeway is the clock skew allowed when checking "exp", "nbf" and "iat"
+	Leeway time.Duration
+}
+
+// DefaultJWTConfig returns the default JWT configuration
+func DefaultJWTConfig() *JWTConfig {
+	return &JWTConfig{
+		Leeway: time.Minute,
+	}
+}
+
+// JWTVerifier verifies JWTs signed with RS256, RS384, RS512, PS256, PS384,
+// PS512, ES256, ES384, ES512 or EdDSA against a local JWKS file
+type JWTVerifier struct {
+	config *JWTConfig
+	now    func() time.Time
+
+	mu      sync.Mutex
+	keys    []publicKey
+	modTime time.Time
+	size    int64
+}
+
+// NewJWTVerifier creates a verifier and loads its key set
+func NewJWTVerifier(config *JWTConfig) (*JWTVerifier, error) {
+	if config == nil || config.JWKSFile == "" {
+		return nil, fmt.Errorf("JWKSFile is required")
+	}
+
+	v := &JWTVerifier{
+		config: config,
+		now:    time.Now,
+	}
+	if _, err := v.loadKeys(); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// loadKeys reads the key set if the file changed since it was last read
+func (v *JWTVerifier) loadKeys() ([]publicKey, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	info, err := os.Stat(v.config.JWKSFile)
+	if err != nil {
+		if v.keys != nil {
+			// Keep the last good keys while the file is being replaced
+			return v.keys, nil
+		}
+		return nil, fmt.Errorf("failed to read JWKS: %w", err)
+	}
+	if v.keys != nil && info.ModTime().Equal(v.modTime) && info.Size() == v.size {
+		return v.keys, nil
+	}
+
+	data, err := os.ReadFile(v.config.JWKSFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read JWKS: %w", err)
+	}
+	keys, err := parseJWKS(data)
+	if err != nil {
+		if v.keys != nil {
+			return v.keys, nil
+		}
+		return nil, err
+	}
+	v.keys, v.modTime, v.size = keys, info.ModTime(), info.Size()
+	return keys, nil
+}
+
+// jwtHeader is the JOSE header of a JWT
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	Typ string `json:"typ"`
+}
+
+// Verify implements TokenVerifier
+func (v *JWTVerifier) Verify(ctx context.Context, token string) (*Principal, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: malformed JWT", ErrInvalidToken)
+	}
+
+	var header jwtHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("%w: malformed header", ErrInvalidToken)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed signature", ErrInvalidToken)
+	}
+
+	keys, err := v.loadKeys()
+	if err != nil {
+		return nil, err
+	}
+	signed := []byte(parts[0] + "." + parts[1])
+	verified := false
+	for _, k := range keys {
+		if header.Kid != "" && k.kid != header.Kid {
+			continue
+		}
+		if k.alg != "" && k.alg != header.Alg {
+			continue
+		}
+		if verifySignature(header.Alg, k.key, signed, signature) {
+			verified = true
+			break
+		}
+	}
+	if !verified {
+		return nil, fmt.Errorf("%w: signature not verified", ErrInvalidToken)
+	}
+
+	var claims map[string]interface{}
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("%w: malformed claims", ErrInvalidToken)
+	}
+	if err := v.checkClaims(claims); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	subject, _ := claims["sub"].(string)
+	return &Principal{
+		Subject: subject,
+		Scopes:  scopes(claims),
+		Claims:  claims,
+	}, nil
+}
+
+// checkClaims validates the registered claims
+func (v *JWTVerifier) checkClaims(claims map[string]interface{}) error {
+	now := v.now()
+	leeway := v.config.Leeway
+
+	exp, ok := numericDate(claims["exp"])
+	if !ok {
+		return fmt.Errorf("missing exp claim")
+	}
+	if now.After(exp.Add(leeway)) {
+		return fmt.Errorf("token expired")
+	}
+	if nbf, ok := numericDate(claims["nbf"]); ok && now.Add(leeway).Before(nbf) {
+		return fmt.Errorf("token not valid yet")
+	}
+	if iat, ok := numericDate(claims["iat"]); ok && now.Add(leeway).Before(iat) {
+		return fmt.Errorf("token issued in the future")
+	}
+
+	if sub, _ := claims["sub"].(string); sub == "" {
+		return fmt.Errorf("missing sub claim")
+	}
+	if v.config.Issuer != "" && claims["iss"] != v.config.Issuer {
+		return fmt.Errorf("unexpected issuer")
+	}
+	if v.config.Audience != "" && !hasAudience(claims["aud"], v.config.Audience) {
+		return fmt.Errorf("token is not meant for this resource")
+	}
+	return nil
+}
+
+// decodeSegment decodes a base64url encoded JSON segment of a JWT
+func decodeSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	return dec.Decode(v)
+}
+
+// verifySignature checks a JWS signature with the given algorithm
+func verifySignature(alg string, key interface{}, signed, signature []byte) bool {
+	var hash crypto.Hash
+	switch alg {
+	case "RS256", "PS256", "ES256":
+		hash = crypto.SHA256
+	case "RS384", "PS384", "ES384":
+		hash = crypto.SHA384
+	case "RS512", "PS512", "ES512":
+		hash = crypto.SHA512
+	}
+
+	switch {
+	case strings.HasPrefix(alg, "RS"), strings.HasPrefix(alg, "PS"):
+		pub, ok := key.(*rsa.PublicKey)
+		if !ok || hash == 0 {
+			return false
+		}
+		h := hash.New()
+		h.Write(signed)
+		if alg[0] == 'P' {
+			return rsa.VerifyPSS(pub, hash, h.Sum(nil), signature, nil) == nil
+		}
+		return rsa.VerifyPKCS1v15(pub, hash, h.Sum(nil), signature) == nil
+
+	case strings.HasPrefix(alg, "ES"):
+		pub, ok := key.(*ecdsa.PublicKey)
+		if !ok || hash == 0 || pub.Curve.Params().BitSize != ecdsaBits(alg) {
+			return false
+		}
+		size := (pub.Curve.Params().BitSize + 7) / 8
+		if len(signature) != 2*size {
+			return false
+		}
+		h := hash.New()
+		h.Write(signed)
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		return ecdsa.Verify(pub, h.Sum(nil), r, s)
+
+	case alg == "EdDSA":
+		pub, ok := key.(ed25519.PublicKey)
+		return ok && ed25519.Verify(pub, signed, signature)
+	}
+	return false
+}
+
+// ecdsaBits returns the curve size an ES algorithm requires
+func ecdsaBits(alg string) int {
+	switch alg {
+	case "ES256":
+		return 256
+	case "ES384":
+		return 384
+	case "ES512":
+		return 521
+	}
+	return 0
+}
+
+// numericDate reads a NumericDate claim
+func numericDate(v interface{}) (time.Time, bool) {
+	n, ok := v.(json.Number)
+	if !ok {
+		return time.Time{}, false
+	}
+	f, err := n.Float64()
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(0, int64(f*float64(time.Second))), true
+}
+
+// hasAudience reports whether the "aud" claim, a string or an array of
+// strings, contains audience
+func hasAudience(aud interface{}, audience string) bool {
+	switch a := aud.(type) {
+	case string:
+		return a == audience
+	case []interface{}:
+		for _, v := range a {
+			if v == audience {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// scopes reads the granted scopes from the "scope" claim (RFC 8693) or the
+// "scp" claim used by some issuers
+func scopes(claims map[string]interface{}) []string {
+	if s, ok := claims["scope"].(string); ok {
+		return strings.Fields(s)
+	}
+	switch s := claims["scp"].(type) {
+	case string:
+		return strings.Fields(s)
+	case []interface{}:
+		var out []string
+		for _, v := range s {
+			if str, ok := v.(string); ok {
+				out = append(out, str)
+			}
+		}
+		return out
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package auth_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"mcp-go-sdk/auth"
+)
+
+func TestJWTVerifier(t *testing.T) {
+	iss := newIssuer(t)
+	verifier, err := auth.NewJWTVerifier(&auth.JWTConfig{
+		JWKSFile: iss.path,
+		Issuer:   iss.url,
+		Audience: "https://mcp.test/mcp",
+		Leeway:   time.Minute,
+	})
+	if err != nil {
+		t.Fatalf("NewJWTVerifier failed: %v", err)
+	}
+	ctx := context.Background()
+
+	for _, kid := range []string{"rsa-1", "ec-1", "ed-1"} {
+		p, err := verifier.Verify(ctx, iss.sign(kid, iss.claims("alice")))
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", kid, err)
+			continue
+		}
+		if p.Subject != "alice" || !p.HasScope("tools:call") || p.Claims["iss"] != iss.url {
+			t.Errorf("%s: unexpected principal %+v", kid, p)
+		}
+	}
+
+	invalid := map[string]func(c map[string]interface{}){
+		"expired":        func(c map[string]interface{}) { c["exp"] = time.Now().Add(-2 * time.Minute).Unix() },
+		"not yet valid":  func(c map[string]interface{}) { c["nbf"] = time.Now().Add(5 * time.Minute).Unix() },
+		"no expiry":      func(c map[string]interface{}) { delete(c, "exp") },
+		"no subject":     func(c map[string]interface{}) { delete(c, "sub") },
+		"wrong issuer":   func(c map[string]interface{}) { c["iss"] = "https://evil.test" },
+		"wrong audience": func(c map[string]interface{}) { c["aud"] = []string{"https://other.test"} },
+	}
+	for name, change := range invalid {
+		claims := iss.claims("alice")
+		change(claims)
+		if _, err := verifier.Verify(ctx, iss.sign("rsa-1", claims)); !errors.Is(err, auth.ErrInvalidToken) {
+			t.Errorf("%s: expected ErrInvalidToken, got %v", name, err)
+		}
+	}
+
+	// Tokens within the leeway are accepted, and aud may be a list
+	claims := iss.claims("alice")
+	claims["exp"] = time.Now().Add(-30 * time.Second).Unix()
+	claims["aud"] = []string{"https://other.test", "https://mcp.test/mcp"}
+	if _, err := verifier.Verify(ctx, iss.sign("rsa-1", claims)); err != nil {
+		t.Errorf("Expected a token within the leeway to be accepted, got %v", err)
+	}
+
+	token := iss.sign("rsa-1", iss.claims("alice"))
+	parts := strings.Split(token, ".")
+	forged := map[string]string{
+		"tampered payload": parts[0] + "." + b64([]byte(`{"sub":"admin","exp":9999999999}`)) + "." + parts[2],
+		"alg none":         b64([]byte(`{"alg":"none"}`)) + "." + parts[1] + ".",
+		"unknown key":      signWith(t, generate(t, "RSA"), "RS256", "rsa-1", iss.claims("alice")),
+		"alg mismatch":     b64([]byte(`{"alg":"ES256","kid":"rsa-1"}`)) + "." + parts[1] + "." + parts[2],
+		"malformed":        "not.a.jwt",
+		"two parts":        parts[0] + "." + parts[1],
+	}
+	for name, token := range forged {
+		if _, err := verifier.Verify(ctx, token); !errors.Is(err, auth.ErrInvalidToken) {
+			t.Errorf("%s: expected ErrInvalidToken, got %v", name, err)
+		}
+	}
+}
+
+func TestJWTKeyRotation(t *testing.T) {
+	iss := newIssuer(t)
+	verifier, err := auth.NewJWTVerifier(&auth.JWTConfig{JWKSFile: iss.path})
+	if err != nil {
+		t.Fatalf("NewJWTVerifier failed: %v", err)
+	}
+	ctx := context.Background()
+
+	// A key published after startup is picked up
+	iss.addKey("rsa-2", generate(t, "RSA"))
+	if _, err := verifier.Verify(ctx, iss.sign("rsa-2", iss.claims("alice"))); err != nil {
+		t.Errorf("Expected the new key to be accepted, got %v", err)
+	}
+
+	// A retired key is no longer trusted
+	old := iss.sign("rsa-1", iss.claims("alice"))
+	iss.removeKey("rsa-1")
+	if _, err := verifier.Verify(ctx, old); !errors.Is(err, auth.ErrInvalidToken) {
+		t.Errorf("Expected the retired key to be rejected, got %v", err)
+	}
+
+	// A broken file keeps the last good keys
+	os.WriteFile(iss.path, []byte("{"), 0644)
+	if _, err := verifier.Verify(ctx, iss.sign("rsa-2", iss.claims("alice"))); err != nil {
+		t.Errorf("Expected the last good keys to be kept, got %v", err)
+	}
+}
+
+func TestNewJWTVerifierErrors(t *testing.T) {
+	if _, err := auth.NewJWTVerifier(nil); err == nil {
+		t.Error("Expected error without a JWKS file")
+	}
+	if _, err := auth.NewJWTVerifier(&auth.JWTConfig{JWKSFile: filepath.Join(t.TempDir(), "missing.json")}); err == nil {
+		t.Error("Expected error for a missing JWKS file")
+	}
+
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	for _, jwks := range []string{
+		`{"keys":[]}`,
+		`{"keys":[{"kty":"RSA","n":"AQAB","e":"AQAB"}]}`,
+		`{"keys":[{"kty":"EC","crv":"P-256","x":"AQ","y":"AQ"}]}`,
+	} {
+		os.WriteFile(path, []byte(jwks), 0644)
+		if _, err := auth.NewJWTVerifier(&auth.JWTConfig{JWKSFile: path}); err == nil {
+			t.Errorf("Expected error for JWKS %s", jwks)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package auth
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// MetadataPath is where OAuth 2.0 protected resource metadata is served
+// (RFC 9728)
+const MetadataPath = "/.well-known/oauth-protected-resource"
+
+// ResourceMetadata describes a protected resource so that clients can find
+// the authorization servers that issue tokens for it
+type ResourceMetadata struct {
+	// Resource is the URL of the protected MCP endpoint
+	Resource string `json:"resource"`
+
+	// AuthorizationServers are the issuers of tokens for the resource
+	AuthorizationServers []string `json:"authorization_servers,omitempty"`
+
+	// ScopesSupported are the scopes clients may request
+	ScopesSupported []string `json:"scopes_supported,omitempty"`
+
+	// BearerMethodsSupported lists how tokens may be sent, "header" for
+	// the SDK's handler
+	BearerMethodsSupported []string `json:"bearer_methods_supported,omitempty"`
+
+	// ResourceName is a human-readable name of the resource
+	ResourceName string `json:"resource_name,omitempty"`
+
+	// ResourceDocumentation is a URL of documentation for developers
+	ResourceDocumentation string `json:"resource_documentation,omitempty"`
+}
+
+// NewMetadataHandler serves meta as JSON. Mount it on MetadataPath, outside
+// the auth Handler, since clients fetch it before they have a token.
+func NewMetadataHandler(meta *ResourceMetadata) http.Handler {
+	body, err := json.Marshal(meta)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.Write(body)
+	})
+}
@@ -0,0 +1,329 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"sync"
+
+	"mcp-go-sdk"
+)
+
+// ErrClosed is returned for calls made after the connection has ended
+var ErrClosed = errors.New("client is closed")
+
+// ProtocolVersion is the protocol version the client asks for
+const ProtocolVersion = "2025-03-26"
+
+// SupportedProtocolVersions lists the protocol versions the client accepts
+// from a server
+var SupportedProtocolVersions = []string{"2025-03-26", "2024-11-05"}
+
+// Config represents configuration options for a client
+type Config struct {
+	// ClientInfo identifies the client to the server
+	ClientInfo mcp.ClientInfo
+
+	// Experimental capabilities declared during initialization
+	Experimental map[string]json.RawMessage
+}
+
+// DefaultConfig returns the default client configuration
+func DefaultConfig() *Config {
+	return &Config{
+		ClientInfo: mcp.ClientInfo{
+			Name:    "MCP Client",
+			Version: "1.0.0",
+		},
+	}
+}
+
+// Client is a connection to an MCP server. It is safe for concurrent use.
+// Register handlers before calling Initialize, since the capabilities the
+// client declares depend on them.
+type Client struct {
+	transport mcp.Transport
+	config    *Config
+
+	mu            sync.Mutex
+	nextID        int64
+	pending       map[string]chan *response
+	notifications map[string]NotificationHandler
+	requests      map[string]RequestHandler
+	capabilities  mcp.ClientCapabilities
+	result        *mcp.InitializeResult
+
+	startOnce sync.Once
+	done      chan struct{}
+	err       error
+}
+
+// response is a JSON-RPC response received from the server
+type response struct {
+	Result json.RawMessage `json:"result"`
+	Error  *mcp.Error      `json:"error"`
+}
+
+// message is any JSON-RPC message received from the server
+type message struct {
+	ID     json.RawMessage `json:"id,omitempty"`
+	Method string          `json:"method,omitempty"`
+	Params json.RawMessage `json:"params,omitempty"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  *mcp.Error      `json:"error,omitempty"`
+}
+
+// NewClient creates a client that talks to a server over t
+func NewClient(t mcp.Transport, config *Config) *Client {
+	if config == nil {
+		config = DefaultConfig()
+	}
+
+	return &Client{
+		transport:     t,
+		config:        config,
+		pending:       make(map[string]chan *response),
+		notifications: make(map[string]NotificationHandler),
+		requests:      make(map[string]RequestHandler),
+		capabilities:  mcp.ClientCapabilities{Experimental: config.Experimental},
+		done:          make(chan struct{}),
+	}
+}
+
+// Initialize performs the initialize handshake. It fails if the server
+// answers with a protocol version the client does not support.
+func (c *Client) Initialize(ctx context.Context) (*mcp.InitializeResult, error) {
+	c.mu.Lock()
+	params := mcp.InitializeParams{
+		ProtocolVersion: ProtocolVersion,
+		Capabilities:    c.capabilities,
+		ClientInfo:      c.config.ClientInfo,
+	}
+	c.mu.Unlock()
+
+	var result mcp.InitializeResult
+	if err := c.Call(ctx, methodInitialize, params, &result); err != nil {
+		return nil, err
+	}
+	if !supported(result.ProtocolVersion) {
+		return nil, fmt.Errorf("unsupported protocol version %q", result.ProtocolVersion)
+	}
+
+	c.mu.Lock()
+	c.result = &result
+	c.mu.Unlock()
+
+	if err := c.Notify(notificationInitialized, nil); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// InitializeResult returns the server's answer to Initialize, or nil before
+// the handshake has completed
+func (c *Client) InitializeResult() *mcp.InitializeResult {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.result
+}
+
+// Call sends a request and decodes its result into result, which may be nil.
+// JSON-RPC errors are returned as *mcp.Error. If ctx is cancelled before the
+// response arrives, the server is told to stop working on the request.
+func (c *Client) Call(ctx context.Context, method string, params interface{}, result interface{}) error {
+	c.start()
+
+	ch := make(chan *response, 1)
+	c.mu.Lock()
+	if c.isDone() {
+		c.mu.Unlock()
+		return c.closedErr()
+	}
+	c.nextID++
+	id := c.nextID
+	key := strconv.FormatInt(id, 10)
+	c.pending[key] = ch
+	c.mu.Unlock()
+
+	defer func() {
+		c.mu.Lock()
+		delete(c.pending, key)
+		c.mu.Unlock()
+	}()
+
+	req := map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      id,
+		"method":  method,
+	}
+	if params != nil {
+		req["params"] = params
+	}
+	if err := c.transport.Send(req); err != nil {
+		return err
+	}
+
+	select {
+	case resp := <-ch:
+		if resp.Error != nil {
+			return resp.Error
+		}
+		if result == nil || len(resp.Result) == 0 {
+			return nil
+		}
+		if err := json.Unmarshal(resp.Result, result); err != nil {
+			return fmt.Errorf("failed to decode %s result: %w", method, err)
+		}
+		return nil
+	case <-ctx.Done():
+		c.Notify(notificationCancelled, map[string]interface{}{
+			"requestId": id,
+			"reason":    ctx.Err().Error(),
+		})
+		return ctx.Err()
+	case <-c.done:
+		return c.closedErr()
+	}
+}
+
+// Notify sends a notification to the server
+func (c *Client) Notify(method string, params interface{}) error {
+	msg := map[string]interface{}{
+		"jsonrpc": "2.0",
+		"method":  method,
+	}
+	if params != nil {
+		msg["params"] = params
+	}
+	return c.transport.Send(msg)
+}
+
+// Close closes the connection. Calls still waiting for a response fail with
+// ErrClosed.
+func (c *Client) Close() error {
+	err := c.transport.Close()
+	c.finish(nil)
+	return err
+}
+
+// Done returns a channel that is closed when the connection has ended
+func (c *Client) Done() <-chan struct{} {
+	return c.done
+}
+
+// Err returns why the connection ended, or nil while it is open or if it
+// ended normally
+func (c *Client) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+// start launches the read loop on first use
+func (c *Client) start() {
+	c.startOnce.Do(func() {
+		go c.readLoop()
+	})
+}
+
+// readLoop dispatches incoming messages until the transport fails
+func (c *Client) readLoop() {
+	for {
+		data, err := c.transport.Receive()
+		if err != nil {
+			var malformed *mcp.MalformedMessageError
+			if errors.As(err, &malformed) || errors.Is(err, mcp.ErrMessageTooLarge) {
+				fmt.Fprintf(os.Stderr, "Ignoring message from server: %v\n", err)
+				continue
+			}
+			if err == io.EOF {
+				err = nil
+			}
+			c.finish(err)
+			return
+		}
+
+		var msg message
+		if err := json.Unmarshal(data, &msg); err != nil {
+			fmt.Fprintf(os.Stderr, "Ignoring message from server: %v\n", err)
+			continue
+		}
+
+		switch {
+		case msg.Method == "" && len(msg.ID) > 0:
+			c.deliver(&msg)
+		case msg.Method != "" && len(msg.ID) > 0:
+			go c.handleRequest(&msg)
+		case msg.Method != "":
+			c.handleNotification(&msg)
+		}
+	}
+}
+
+// deliver hands a response to the call waiting for it
+func (c *Client) deliver(msg *message) {
+	key := idKey(msg.ID)
+	c.mu.Lock()
+	ch, ok := c.pending[key]
+	c.mu.Unlock()
+	if ok {
+		ch <- &response{Result: msg.Result, Error: msg.Error}
+	}
+}
+
+// finish marks the connection as ended
+func (c *Client) finish(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.isDone() {
+		return
+	}
+	c.err = err
+	close(c.done)
+}
+
+// isDone reports whether the connection has ended
+func (c *Client) isDone() bool {
+	select {
+	case <-c.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// closedErr returns the error for calls on an ended connection
+func (c *Client) closedErr() error {
+	if err := c.Err(); err != nil {
+		return fmt.Errorf("%w: %v", ErrClosed, err)
+	}
+	return ErrClosed
+}
+
+// supported reports whether the client accepts a protocol version
+func supported(version string) bool {
+	for _, v := range SupportedProtocolVersions {
+		if v == version {
+			return true
+		}
+	}
+	return false
+}
+
+// idKey normalizes a JSON-RPC id so that 1 and "1" are told apart but
+// formatting differences are not
+func idKey(id json.RawMessage) string {
+	var v interface{}
+	if err := json.Unmarshal(id, &v); err != nil {
+		return string(id)
+	}
+	if s, ok := v.(string); ok {
+		return strconv.Quote(s)
+	}
+	b, _ := json.Marshal(v)
+	return string(b)
+}
@@ -0,0 +1,238 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"mcp-go-sdk"
+	"mcp-go-sdk/server"
+	"mcp-go-sdk/transport"
+)
+
+// upperTool implements mcp.Tool for testing
+type upperTool struct{}
+
+func (t *upperTool) Name() string            { return "upper" }
+func (t *upperTool) Description() string     { return "Upper-cases text" }
+func (t *upperTool) Schema() json.RawMessage { return json.RawMessage(`{"type":"object"}`) }
+func (t *upperTool) Execute(params json.RawMessage) (interface{}, error) {
+	var args struct {
+		Text string `json:"text"`
+	}
+	if err := json.Unmarshal(params, &args); err != nil {
+		return nil, err
+	}
+	return mcp.ToolResponse{Content: []mcp.ToolContent{{Type: "text", Text: strings.ToUpper(args.Text)}}}, nil
+}
+
+// newTestClient connects a client to srv over an in-memory pair
+func newTestClient(t *testing.T, srv server.Server) *Client {
+	t.Helper()
+	clientEnd, serverEnd := transport.NewInMemoryPair()
+	go srv.ServeTransport(serverEnd)
+	c := NewClient(clientEnd, nil)
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+func testContext(t *testing.T) context.Context {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	t.Cleanup(cancel)
+	return ctx
+}
+
+func TestClientAgainstServer(t *testing.T) {
+	srv := server.NewServer(nil)
+	srv.RegisterTool(&upperTool{})
+	srv.HandleMethod("session/capabilities", func(ctx context.Context, params json.RawMessage) (interface{}, error) {
+		return server.SessionFromContext(ctx).ClientCapabilities(), nil
+	})
+	c := newTestClient(t, srv)
+	c.HandleSampling(func(ctx context.Context, p *mcp.CreateMessageParams) (*mcp.CreateMessageResult, error) {
+		return nil, nil
+	})
+	ctx := testContext(t)
+
+	result, err := c.Initialize(ctx)
+	if err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	if result.ProtocolVersion != ProtocolVersion || result.ServerInfo.Name != "MCP Server" {
+		t.Errorf("Unexpected initialize result %+v", result)
+	}
+
+	var caps mcp.ClientCapabilities
+	if err := c.Call(ctx, "session/capabilities", nil, &caps); err != nil {
+		t.Fatalf("Call failed: %v", err)
+	}
+	if caps.Sampling == nil || caps.Roots != nil {
+		t.Errorf("Expected only the sampling capability, got %+v", caps)
+	}
+
+	tools, err := c.ListTools(ctx)
+	if err != nil {
+		t.Fatalf("ListTools failed: %v", err)
+	}
+	if len(tools) != 1 || tools[0].Name != "upper" {
+		t.Errorf("Unexpected tools %+v", tools)
+	}
+
+	res, err := c.CallTool(ctx, "upper", map[string]string{"text": "hi"})
+	if err != nil {
+		t.Fatalf("CallTool failed: %v", err)
+	}
+	if len(res.Content) != 1 || res.Content[0].Text != "HI" || res.IsError {
+		t.Errorf("Unexpected tool result %+v", res)
+	}
+
+	var rpcErr *mcp.Error
+	if _, err := c.CallTool(ctx, "missing", nil); !errors.As(err, &rpcErr) || rpcErr.Code != -32601 {
+		t.Errorf("Expected method not found error, got %v", err)
+	}
+}
+
+func TestResourcesAndPrompts(t *testing.T) {
+	srv := server.NewServer(nil)
+	srv.HandleMethod("resources/list", server.TypedMethod(func(ctx context.Context, p mcp.PaginatedParams) (*mcp.ListResourcesResult, error) {
+		if p.Cursor == "" {
+			return &mcp.ListResourcesResult{Resources: []mcp.Resource{{URI: "mem://a", Name: "a"}}, NextCursor: "2"}, nil
+		}
+		return &mcp.ListResourcesResult{Resources: []mcp.Resource{{URI: "mem://b", Name: "b"}}}, nil
+	}))
+	srv.HandleMethod("resources/read", server.TypedMethod(func(ctx context.Context, p mcp.ReadResourceParams) (*mcp.ReadResourceResult, error) {
+		return &mcp.ReadResourceResult{Contents: []mcp.ResourceContents{{URI: p.URI, Text: "contents of " + p.URI}}}, nil
+	}))
+	srv.HandleMethod("prompts/list", func(ctx context.Context, params json.RawMessage) (interface{}, error) {
+		return &mcp.ListPromptsResult{Prompts: []mcp.Prompt{{Name: "greet", Arguments: []mcp.PromptArgument{{Name: "who", Required: true}}}}}, nil
+	})
+	srv.HandleMethod("prompts/get", server.TypedMethod(func(ctx context.Context, p mcp.GetPromptParams) (*mcp.GetPromptResult, error) {
+		return &mcp.GetPromptResult{Messages: []mcp.PromptMessage{{Role: "user", Content: mcp.ToolContent{Type: "text", Text: "Hello " + p.Arguments["who"]}}}}, nil
+	}))
+	c := newTestClient(t, srv)
+	ctx := testContext(t)
+
+	resources, err := c.ListResources(ctx)
+	if err != nil {
+		t.Fatalf("ListResources failed: %v", err)
+	}
+	if len(resources) != 2 || resources[1].URI != "mem://b" {
+		t.Errorf("Expected both pages of resources, got %+v", resources)
+	}
+	contents, err := c.ReadResource(ctx, "mem://a")
+	if err != nil {
+		t.Fatalf("ReadResource failed: %v", err)
+	}
+	if len(contents.Contents) != 1 || contents.Contents[0].Text != "contents of mem://a" {
+		t.Errorf("Unexpected contents %+v", contents)
+	}
+
+	prompts, err := c.ListPrompts(ctx)
+	if err != nil {
+		t.Fatalf("ListPrompts failed: %v", err)
+	}
+	if len(prompts) != 1 || !prompts[0].Arguments[0].Required {
+		t.Errorf("Unexpected prompts %+v", prompts)
+	}
+	prompt, err := c.GetPrompt(ctx, "greet", map[string]string{"who": "world"})
+	if err != nil {
+		t.Fatalf("GetPrompt failed: %v", err)
+	}
+	if len(prompt.Messages) != 1 || prompt.Messages[0].Content.Text != "Hello world" {
+		t.Errorf("Unexpected prompt %+v", prompt)
+	}
+}
+
+func TestServerRequestsAndNotifications(t *testing.T) {
+	clientEnd, serverEnd := transport.NewInMemoryPair()
+	c := NewClient(clientEnd, nil)
+	defer c.Close()
+
+	c.HandleRoots(func(ctx context.Context) ([]mcp.Root, error) {
+		return []mcp.Root{{URI: "file:///work", Name: "work"}}, nil
+	})
+	c.HandleElicitation(func(ctx context.Context, p *mcp.ElicitParams) (*mcp.ElicitResult, error) {
+		return &mcp.ElicitResult{Action: mcp.ElicitAccept, Content: map[string]interface{}{"confirm": true}}, nil
+	})
+	changed := make(chan string, 1)
+	c.HandleNotification("notifications/tools/list_changed", func(ctx context.Context, params json.RawMessage) {
+		changed <- "tools"
+	})
+	c.start()
+
+	exchange := func(msg, expected string) {
+		t.Helper()
+		if err := serverEnd.SendRaw([]byte(msg)); err != nil {
+			t.Fatalf("Send failed: %v", err)
+		}
+		resp, err := serverEnd.Receive()
+		if err != nil {
+			t.Fatalf("Receive failed: %v", err)
+		}
+		var got, want interface{}
+		json.Unmarshal(resp, &got)
+		json.Unmarshal([]byte(expected), &want)
+		gotJSON, _ := json.Marshal(got)
+		wantJSON, _ := json.Marshal(want)
+		if string(gotJSON) != string(wantJSON) {
+			t.Errorf("Expected %s, got %s", wantJSON, gotJSON)
+		}
+	}
+
+	exchange(`{"jsonrpc":"2.0","id":"r1","method":"roots/list"}`,
+		`{"jsonrpc":"2.0","id":"r1","result":{"roots":[{"uri":"file:///work","name":"work"}]}}`)
+	exchange(`{"jsonrpc":"2.0","id":2,"method":"elicitation/create","params":{"message":"Delete?","requestedSchema":{"type":"object"}}}`,
+		`{"jsonrpc":"2.0","id":2,"result":{"action":"accept","content":{"confirm":true}}}`)
+	exchange(`{"jsonrpc":"2.0","id":3,"method":"sampling/createMessage","params":{"messages":[],"maxTokens":10}}`,
+		`{"jsonrpc":"2.0","id":3,"error":{"code":-32601,"message":"Method not found","data":"sampling/createMessage"}}`)
+	exchange(`{"jsonrpc":"2.0","id":4,"method":"ping"}`, `{"jsonrpc":"2.0","id":4,"result":{}}`)
+
+	serverEnd.SendRaw([]byte(`{"jsonrpc":"2.0","method":"notifications/tools/list_changed"}`))
+	select {
+	case <-changed:
+	case <-time.After(5 * time.Second):
+		t.Error("Notification handler was not called")
+	}
+}
+
+func TestCancelAndClose(t *testing.T) {
+	clientEnd, serverEnd := transport.NewInMemoryPair()
+	c := NewClient(clientEnd, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- c.Call(ctx, "slow/method", nil, nil)
+	}()
+	if _, err := serverEnd.Receive(); err != nil {
+		t.Fatalf("Receive failed: %v", err)
+	}
+	cancel()
+	if err := <-errCh; !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+	msg, err := serverEnd.Receive()
+	if err != nil {
+		t.Fatalf("Receive failed: %v", err)
+	}
+	if !strings.Contains(string(msg), `"method":"notifications/cancelled"`) || !strings.Contains(string(msg), `"requestId":1`) {
+		t.Errorf("Expected cancellation notification, got %s", msg)
+	}
+
+	go func() {
+		errCh <- c.Call(context.Background(), "slow/method", nil, nil)
+	}()
+	if _, err := serverEnd.Receive(); err != nil {
+		t.Fatalf("Receive failed: %v", err)
+	}
+	serverEnd.Close()
+	if err := <-errCh; !errors.Is(err, ErrClosed) {
+		t.Errorf("Expected ErrClosed, got %v", err)
+	}
+	if err := c.Call(context.Background(), "slow/method", nil, nil); !errors.Is(err, ErrClosed) {
+		t.Errorf("Expected ErrClosed after the connection ended, got %v", err)
+	}
+}
@@ -0,0 +1,183 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"mcp-go-sdk"
+)
+
+// Methods and notifications exchanged with the server
+const (
+	methodInitialize        = "initialize"
+	methodListTools         = "tools/list"
+	methodCallTool          = "tools/call"
+	methodListResources     = "resources/list"
+	methodReadResource      = "resources/read"
+	methodListPrompts       = "prompts/list"
+	methodGetPrompt         = "prompts/get"
+	methodPing              = "ping"
+	methodCreateMessage     = "sampling/createMessage"
+	methodListRoots         = "roots/list"
+	methodElicit            = "elicitation/create"
+	notificationInitialized = "notifications/initialized"
+	notificationCancelled   = "notifications/cancelled"
+	notificationRootsList   = "notifications/roots/list_changed"
+)
+
+// JSON-RPC error codes sent in answers to server requests
+const (
+	errMethodNotFound = -32601
+	errInvalidParams  = -32602
+	errInternal       = -32603
+)
+
+// NotificationHandler handles a notification sent by the server. Handlers
+// run on the goroutine that reads from the transport, so they must not
+// block for long.
+type NotificationHandler func(ctx context.Context, params json.RawMessage)
+
+// RequestHandler answers a request sent by the server. Returning an
+// *mcp.Error sends that error to the server as-is.
+type RequestHandler func(ctx context.Context, params json.RawMessage) (interface{}, error)
+
+// SamplingHandler asks the client's model for a completion on behalf of the
+// server
+type SamplingHandler func(ctx context.Context, params *mcp.CreateMessageParams) (*mcp.CreateMessageResult, error)
+
+// RootsHandler returns the roots the client exposes to the server
+type RootsHandler func(ctx context.Context) ([]mcp.Root, error)
+
+// ElicitationHandler asks the user for the input the server requested
+type ElicitationHandler func(ctx context.Context, params *mcp.ElicitParams) (*mcp.ElicitResult, error)
+
+// HandleNotification registers a callback for a server notification such as
+// "notifications/tools/list_changed". A later registration for the same
+// method replaces the earlier one.
+func (c *Client) HandleNotification(method string, handler NotificationHandler) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.notifications[method] = handler
+}
+
+// HandleRequest registers a handler for a request method sent by the server.
+// Prefer HandleSampling, HandleRoots and HandleElicitation for the standard
+// methods, since they also declare the matching capability.
+func (c *Client) HandleRequest(method string, handler RequestHandler) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.requests[method] = handler
+}
+
+// HandleSampling answers sampling/createMessage requests and declares the
+// sampling capability
+func (c *Client) HandleSampling(handler SamplingHandler) {
+	c.HandleRequest(methodCreateMessage, func(ctx context.Context, params json.RawMessage) (interface{}, error) {
+		var p mcp.CreateMessageParams
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		return handler(ctx, &p)
+	})
+	c.mu.Lock()
+	c.capabilities.Sampling = &mcp.SamplingCapability{}
+	c.mu.Unlock()
+}
+
+// HandleRoots answers roots/list requests and declares the roots capability.
+// Call NotifyRootsChanged when the roots change.
+func (c *Client) HandleRoots(handler RootsHandler) {
+	c.HandleRequest(methodListRoots, func(ctx context.Context, params json.RawMessage) (interface{}, error) {
+		roots, err := handler(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if roots == nil {
+			roots = []mcp.Root{}
+		}
+		return &mcp.ListRootsResult{Roots: roots}, nil
+	})
+	c.mu.Lock()
+	c.capabilities.Roots = &mcp.RootsCapability{ListChanged: true}
+	c.mu.Unlock()
+}
+
+// HandleElicitation answers elicitation/create requests and declares the
+// elicitation capability
+func (c *Client) HandleElicitation(handler ElicitationHandler) {
+	c.HandleRequest(methodElicit, func(ctx context.Context, params json.RawMessage) (interface{}, error) {
+		var p mcp.ElicitParams
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		return handler(ctx, &p)
+	})
+	c.mu.Lock()
+	c.capabilities.Elicitation = &mcp.ElicitationCapability{}
+	c.mu.Unlock()
+}
+
+// NotifyRootsChanged tells the server that the client's roots have changed
+func (c *Client) NotifyRootsChanged() error {
+	return c.Notify(notificationRootsList, nil)
+}
+
+// handleRequest answers a request sent by the server
+func (c *Client) handleRequest(msg *message) {
+	c.mu.Lock()
+	handler, ok := c.requests[msg.Method]
+	c.mu.Unlock()
+
+	var result interface{}
+	var err error
+	switch {
+	case ok:
+		result, err = handler(context.Background(), msg.Params)
+	case msg.Method == methodPing:
+		result = struct{}{}
+	default:
+		err = &mcp.Error{Code: errMethodNotFound, Message: "Method not found", Data: msg.Method}
+	}
+
+	resp := &mcp.Response{JsonRPC: "2.0", ID: msg.ID}
+	if err != nil {
+		var rpcErr *mcp.Error
+		if !errors.As(err, &rpcErr) {
+			rpcErr = &mcp.Error{Code: errInternal, Message: "Internal error", Data: err.Error()}
+		}
+		resp.Error = rpcErr
+	} else {
+		if result == nil {
+			result = struct{}{}
+		}
+		resp.Result = result
+	}
+
+	if err := c.transport.Send(resp); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to answer %s request: %v\n", msg.Method, err)
+	}
+}
+
+// handleNotification runs the callback registered for a notification
+func (c *Client) handleNotification(msg *message) {
+	c.mu.Lock()
+	handler, ok := c.notifications[msg.Method]
+	c.mu.Unlock()
+	if ok {
+		handler(context.Background(), msg.Params)
+	}
+}
+
+// decodeParams decodes request params, reporting failures as invalid params
+func decodeParams(params json.RawMessage, v interface{}) error {
+	if len(params) == 0 {
+		return &mcp.Error{Code: errInvalidParams, Message: "Invalid parameters", Data: "missing params"}
+	}
+	if err := json.Unmarshal(params, v); err != nil {
+		return &mcp.Error{Code: errInvalidParams, Message: "Invalid parameters", Data: err.Error()}
+	}
+	return nil
+}
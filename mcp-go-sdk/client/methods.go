@@ -0,0 +1,114 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+
+	"mcp-go-sdk"
+)
+
+// Ping checks that the server is responsive
+func (c *Client) Ping(ctx context.Context) error {
+	return c.Call(ctx, methodPing, nil, nil)
+}
+
+// ListTools returns all tools of the server, following pagination
+func (c *Client) ListTools(ctx context.Context) ([]mcp.ToolInfo, error) {
+	var tools []mcp.ToolInfo
+	err := c.paginate(ctx, methodListTools, func(raw json.RawMessage) (string, error) {
+		var page mcp.ListToolsResponse
+		if err := json.Unmarshal(raw, &page); err != nil {
+			return "", err
+		}
+		tools = append(tools, page.Tools...)
+		return page.NextCursor, nil
+	})
+	return tools, err
+}
+
+// CallTool calls a tool with the given arguments, which are marshaled to
+// JSON. A tool that fails reports it through IsError on the result, not
+// through the returned error.
+func (c *Client) CallTool(ctx context.Context, name string, arguments interface{}) (*mcp.CallToolResult, error) {
+	params := map[string]interface{}{"name": name}
+	if arguments != nil {
+		params["arguments"] = arguments
+	}
+
+	var result mcp.CallToolResult
+	if err := c.Call(ctx, methodCallTool, params, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// ListResources returns all resources of the server, following pagination
+func (c *Client) ListResources(ctx context.Context) ([]mcp.Resource, error) {
+	var resources []mcp.Resource
+	err := c.paginate(ctx, methodListResources, func(raw json.RawMessage) (string, error) {
+		var page mcp.ListResourcesResult
+		if err := json.Unmarshal(raw, &page); err != nil {
+			return "", err
+		}
+		resources = append(resources, page.Resources...)
+		return page.NextCursor, nil
+	})
+	return resources, err
+}
+
+// ReadResource reads the contents of a resource
+func (c *Client) ReadResource(ctx context.Context, uri string) (*mcp.ReadResourceResult, error) {
+	var result mcp.ReadResourceResult
+	if err := c.Call(ctx, methodReadResource, &mcp.ReadResourceParams{URI: uri}, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// ListPrompts returns all prompts of the server, following pagination
+func (c *Client) ListPrompts(ctx context.Context) ([]mcp.Prompt, error) {
+	var prompts []mcp.Prompt
+	err := c.paginate(ctx, methodListPrompts, func(raw json.RawMessage) (string, error) {
+		var page mcp.ListPromptsResult
+		if err := json.Unmarshal(raw, &page); err != nil {
+			return "", err
+		}
+		prompts = append(prompts, page.Prompts...)
+		return page.NextCursor, nil
+	})
+	return prompts, err
+}
+
+// GetPrompt renders a prompt with the given arguments
+func (c *Client) GetPrompt(ctx context.Context, name string, arguments map[string]string) (*mcp.GetPromptResult, error) {
+	var result mcp.GetPromptResult
+	params := &mcp.GetPromptParams{Name: name, Arguments: arguments}
+	if err := c.Call(ctx, methodGetPrompt, params, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// paginate calls a list method until the server stops returning a cursor.
+// page decodes one result and returns the next cursor.
+func (c *Client) paginate(ctx context.Context, method string, page func(json.RawMessage) (string, error)) error {
+	cursor := ""
+	for {
+		var params interface{}
+		if cursor != "" {
+			params = &mcp.PaginatedParams{Cursor: cursor}
+		}
+		var raw json.RawMessage
+		if err := c.Call(ctx, method, params, &raw); err != nil {
+			return err
+		}
+		next, err := page(raw)
+		if err != nil {
+			return err
+		}
+		if next == "" || next == cursor {
+			return nil
+		}
+		cursor = next
+	}
+}
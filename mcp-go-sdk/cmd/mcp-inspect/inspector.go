@@ -0,0 +1,189 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"mcp-go-sdk"
+	"mcp-go-sdk/client"
+)
+
+// inspector prints what a server offers and the results of calls to it
+type inspector struct {
+	client  *client.Client
+	timeout time.Duration
+
+	// mu serializes output, since notifications arrive at any time
+	mu  sync.Mutex
+	out io.Writer
+}
+
+// printf writes formatted output
+func (in *inspector) printf(format string, args ...interface{}) {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	fmt.Fprintf(in.out, format, args...)
+}
+
+// printJSON writes v as indented JSON
+func (in *inspector) printJSON(v interface{}) {
+	in.printf("%s\n", indent(v))
+}
+
+// context returns a context bounded by the request timeout
+func (in *inspector) context() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), in.timeout)
+}
+
+// watchNotifications prints the notifications the server sends
+func (in *inspector) watchNotifications() {
+	for _, method := range []string{
+		"notifications/tools/list_changed",
+		"notifications/resources/list_changed",
+		"notifications/resources/updated",
+		"notifications/prompts/list_changed",
+		"notifications/message",
+		"notifications/progress",
+	} {
+		method := method
+		in.client.HandleNotification(method, func(ctx context.Context, params json.RawMessage) {
+			if len(params) == 0 {
+				in.printf("<- %s\n", method)
+				return
+			}
+			in.printf("<- %s %s\n", method, params)
+		})
+	}
+}
+
+// printInfo prints the server's identity and capabilities
+func (in *inspector) printInfo() {
+	result := in.client.InitializeResult()
+	in.printf("Server: %s %s (protocol %s)\n", result.ServerInfo.Name, result.ServerInfo.Version, result.ProtocolVersion)
+	in.printf("Capabilities:\n")
+	in.printJSON(result.Capabilities)
+}
+
+// printTools lists the tools, with their input schemas if schema is set
+func (in *inspector) printTools(schema bool) error {
+	ctx, cancel := in.context()
+	defer cancel()
+	tools, err := in.client.ListTools(ctx)
+	if err != nil {
+		return err
+	}
+
+	in.printf("Tools (%d):\n", len(tools))
+	width := 0
+	for _, tool := range tools {
+		width = max(width, len(tool.Name))
+	}
+	for _, tool := range tools {
+		in.printf("  %-*s  %s\n", width, tool.Name, firstLine(tool.Description))
+		if schema {
+			in.printf("%s\n", prefixLines(indent(tool.InputSchema), "    "))
+		}
+	}
+	return nil
+}
+
+// printSchema prints the input schema of one tool
+func (in *inspector) printSchema(name string) error {
+	ctx, cancel := in.context()
+	defer cancel()
+	tools, err := in.client.ListTools(ctx)
+	if err != nil {
+		return err
+	}
+	for _, tool := range tools {
+		if tool.Name == name {
+			in.printf("%s\n", tool.Description)
+			in.printJSON(tool.InputSchema)
+			return nil
+		}
+	}
+	return fmt.Errorf("no tool named %s", name)
+}
+
+// callTool calls a tool and prints its result as sent by the server, so that
+// fields outside the specification stay visible. It reports whether the
+// tool flagged the result as an error.
+func (in *inspector) callTool(name string, args json.RawMessage) (bool, error) {
+	params := map[string]interface{}{"name": name}
+	if args != nil {
+		params["arguments"] = args
+	}
+
+	ctx, cancel := in.context()
+	defer cancel()
+	start := time.Now()
+	var result json.RawMessage
+	if err := in.client.Call(ctx, "tools/call", params, &result); err != nil {
+		return false, err
+	}
+	in.printJSON(result)
+
+	var status mcp.CallToolResult
+	json.Unmarshal(result, &status)
+	if status.IsError {
+		in.printf("Tool reported an error after %v\n", time.Since(start).Round(time.Millisecond))
+	}
+	return status.IsError, nil
+}
+
+// call sends any request and prints its result
+func (in *inspector) call(method string, params json.RawMessage) error {
+	ctx, cancel := in.context()
+	defer cancel()
+	var p interface{}
+	if params != nil {
+		p = params
+	}
+	var result json.RawMessage
+	if err := in.client.Call(ctx, method, p, &result); err != nil {
+		return err
+	}
+	in.printJSON(result)
+	return nil
+}
+
+// indent formats v as indented JSON
+func indent(v interface{}) string {
+	if raw, ok := v.(json.RawMessage); ok {
+		var decoded interface{}
+		if json.Unmarshal(raw, &decoded) == nil {
+			v = decoded
+		}
+	}
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
+
+// prefixLines prepends prefix to every line of s
+func prefixLines(s, prefix string) string {
+	return prefix + strings.ReplaceAll(s, "\n", "\n"+prefix)
+}
+
+// firstLine returns the first line of s
+func firstLine(s string) string {
+	s, _, _ = strings.Cut(strings.TrimSpace(s), "\n")
+	return s
+}
+
+// readArgsFile reads tool arguments from a file named with @ in the REPL
+func readArgsFile(path string) (json.RawMessage, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return parseArgs(string(data))
+}
@@ -0,0 +1,200 @@
+// Command mcp-inspect starts or connects to an MCP server, performs the
+// handshake and shows what the server offers:
+//
+//	mcp-inspect mcp-memory --path memory.json       # server info, capabilities and tools
+//	mcp-inspect -schema mcp-memory                  # tools with their input schemas
+//	mcp-inspect -call echo -args '{"message":"hi"}' echo
+//	mcp-inspect -call query -args-file query.json mcp-duckdb
+//	mcp-inspect -repl mcp-memory                    # interactive session
+//	mcp-inspect -raw -connect unix:/tmp/memory.sock # send JSON-RPC lines as-is
+//
+// Everything after the flags is the server command and its arguments. With
+// -connect the server is reached over a TCP address or unix:/path socket
+// instead.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"mcp-go-sdk"
+	"mcp-go-sdk/client"
+	"mcp-go-sdk/transport"
+)
+
+// options are the command-line flags
+type options struct {
+	connect  string
+	call     string
+	args     string
+	argsFile string
+	schema   bool
+	repl     bool
+	raw      bool
+	quiet    bool
+	history  string
+	timeout  time.Duration
+}
+
+func main() {
+	var opts options
+	flag.StringVar(&opts.connect, "connect", "", "Connect to a TCP address or unix:/path socket instead of starting a server")
+	flag.StringVar(&opts.call, "call", "", "Call the named tool and print its result")
+	flag.StringVar(&opts.args, "args", "", "JSON arguments for --call")
+	flag.StringVar(&opts.argsFile, "args-file", "", "File with JSON arguments for --call, - for stdin")
+	flag.BoolVar(&opts.schema, "schema", false, "Print the input schema of every tool")
+	flag.BoolVar(&opts.repl, "repl", false, "Start an interactive session")
+	flag.BoolVar(&opts.raw, "raw", false, "Send JSON-RPC messages read from stdin as-is and print everything the server sends")
+	flag.BoolVar(&opts.quiet, "quiet", false, "Do not show the server's stderr")
+	flag.StringVar(&opts.history, "history", defaultHistoryPath(), "File that keeps the REPL history, empty to disable")
+	flag.DurationVar(&opts.timeout, "timeout", 30*time.Second, "Timeout for each request")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: mcp-inspect [flags] [server command [args...]]\n\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if err := run(&opts, flag.Args()); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// run connects to the server and performs the selected mode
+func run(opts *options, command []string) error {
+	t, err := connect(opts, command)
+	if err != nil {
+		return err
+	}
+	defer t.Close()
+
+	if opts.raw {
+		return runRaw(t, os.Stdin, os.Stdout, opts.timeout)
+	}
+
+	c := client.NewClient(t, &client.Config{
+		ClientInfo: mcp.ClientInfo{Name: "mcp-inspect", Version: "1.0.0"},
+	})
+	defer c.Close()
+
+	in := &inspector{client: c, out: os.Stdout, timeout: opts.timeout}
+	if opts.repl {
+		in.watchNotifications()
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), opts.timeout)
+	_, err = c.Initialize(ctx)
+	cancel()
+	if err != nil {
+		return fmt.Errorf("initialize failed: %w", err)
+	}
+
+	switch {
+	case opts.repl:
+		history, err := loadHistory(opts.history)
+		if err != nil {
+			return err
+		}
+		return in.repl(os.Stdin, history)
+	case opts.call != "":
+		args, err := readArgs(opts.args, opts.argsFile)
+		if err != nil {
+			return err
+		}
+		failed, err := in.callTool(opts.call, args)
+		if err == nil && failed {
+			err = errors.New("tool reported an error")
+		}
+		return err
+	default:
+		in.printInfo()
+		return in.printTools(opts.schema)
+	}
+}
+
+// connect starts the server command or dials the -connect address
+func connect(opts *options, command []string) (mcp.Transport, error) {
+	if opts.connect != "" {
+		network, address := parseAddr(opts.connect)
+		conn, err := net.Dial(network, address)
+		if err != nil {
+			return nil, err
+		}
+		return transport.NewBaseTransport(conn, conn, conn, nil), nil
+	}
+	if len(command) == 0 {
+		flag.Usage()
+		return nil, errors.New("a server command or --connect address is required")
+	}
+
+	logs := io.Writer(os.Stderr)
+	if opts.quiet {
+		logs = io.Discard
+	}
+	return transport.NewCommandTransport(&transport.CommandConfig{
+		Command: command[0],
+		Args:    command[1:],
+		Logger:  log.New(logs, "[server] ", 0),
+	})
+}
+
+// parseAddr splits an address such as "unix:/tmp/memory.sock" or
+// "tcp:localhost:7000" into network and address. Addresses without a prefix
+// are TCP.
+func parseAddr(addr string) (string, string) {
+	if strings.HasPrefix(addr, "unix:") {
+		return "unix", strings.TrimPrefix(addr, "unix:")
+	}
+	return "tcp", strings.TrimPrefix(addr, "tcp:")
+}
+
+// readArgs returns the tool arguments given with -args or -args-file
+func readArgs(args, file string) (json.RawMessage, error) {
+	if args != "" && file != "" {
+		return nil, errors.New("use either --args or --args-file")
+	}
+	data := []byte(args)
+	if file != "" {
+		var err error
+		if file == "-" {
+			data, err = io.ReadAll(os.Stdin)
+		} else {
+			data, err = os.ReadFile(file)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return parseArgs(string(data))
+}
+
+// parseArgs validates JSON tool arguments. Empty input means no arguments.
+func parseArgs(s string) (json.RawMessage, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, nil
+	}
+	if !json.Valid([]byte(s)) {
+		return nil, fmt.Errorf("arguments are not valid JSON: %s", s)
+	}
+	return json.RawMessage(s), nil
+}
+
+// defaultHistoryPath returns ~/.mcp_inspect_history, or empty if there is no
+// home directory
+func defaultHistoryPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".mcp_inspect_history")
+}
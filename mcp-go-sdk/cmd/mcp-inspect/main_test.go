@@ -0,0 +1,184 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"mcp-go-sdk"
+	"mcp-go-sdk/client"
+	"mcp-go-sdk/server"
+	"mcp-go-sdk/transport"
+)
+
+// echoTool implements mcp.Tool by echoing its message
+type echoTool struct{}
+
+func (t *echoTool) Name() string        { return "echo" }
+func (t *echoTool) Description() string { return "Echoes a message\nSecond line" }
+func (t *echoTool) Schema() json.RawMessage {
+	return json.RawMessage(`{"type":"object","properties":{"message":{"type":"string"}}}`)
+}
+func (t *echoTool) Execute(params json.RawMessage) (interface{}, error) {
+	var args struct {
+		Message string `json:"message"`
+	}
+	json.Unmarshal(params, &args)
+	return map[string]interface{}{
+		"content":  []mcp.ToolContent{{Type: "text", Text: args.Message}},
+		"isError":  args.Message == "",
+		"metadata": map[string]int{"length": len(args.Message)},
+	}, nil
+}
+
+// newTestInspector connects an inspector to an in-process server
+func newTestInspector(t *testing.T) (*inspector, *bytes.Buffer) {
+	t.Helper()
+	srv := server.NewServer(nil)
+	srv.RegisterTool(&echoTool{})
+	clientEnd, serverEnd := transport.NewInMemoryPair()
+	go srv.ServeTransport(serverEnd)
+
+	c := client.NewClient(clientEnd, nil)
+	t.Cleanup(func() { c.Close() })
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := c.Initialize(ctx); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+
+	var out bytes.Buffer
+	return &inspector{client: c, out: &out, timeout: 5 * time.Second}, &out
+}
+
+func TestInfoAndTools(t *testing.T) {
+	in, out := newTestInspector(t)
+	in.printInfo()
+	if err := in.printTools(true); err != nil {
+		t.Fatalf("printTools failed: %v", err)
+	}
+
+	for _, expected := range []string{
+		"Server: MCP Server 1.0.0 (protocol 2025-03-26)",
+		`"listChanged": false`,
+		"Tools (1):\n  echo  Echoes a message\n",
+		`      "message": {`,
+	} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("Expected output to contain %q, got:\n%s", expected, out.String())
+		}
+	}
+}
+
+func TestCallTool(t *testing.T) {
+	in, out := newTestInspector(t)
+
+	failed, err := in.callTool("echo", json.RawMessage(`{"message":"hi"}`))
+	if err != nil || failed {
+		t.Fatalf("Expected a successful call, got %v, %v", failed, err)
+	}
+	// Fields outside the specification are shown too
+	if !strings.Contains(out.String(), `"length": 2`) {
+		t.Errorf("Expected the full result, got:\n%s", out.String())
+	}
+
+	if failed, err := in.callTool("echo", nil); err != nil || !failed {
+		t.Errorf("Expected the tool to report an error, got %v, %v", failed, err)
+	}
+	if _, err := in.callTool("missing", nil); err == nil {
+		t.Error("Expected error for an unknown tool")
+	}
+}
+
+func TestREPL(t *testing.T) {
+	in, out := newTestInspector(t)
+	dir := t.TempDir()
+	argsFile := filepath.Join(dir, "args.json")
+	os.WriteFile(argsFile, []byte(`{"message":"from file"}`), 0644)
+
+	history, err := loadHistory(filepath.Join(dir, "history"))
+	if err != nil {
+		t.Fatalf("loadHistory failed: %v", err)
+	}
+	input := strings.Join([]string{
+		`call echo {"message": "hello world"}`,
+		`call echo @` + argsFile,
+		`!1`,
+		`rpc ping`,
+		`schema echo`,
+		`call echo {not json`,
+		`bogus`,
+		`history`,
+		`quit`,
+		`call echo {"message": "never sent"}`,
+	}, "\n")
+	if err := in.repl(strings.NewReader(input), history); err != nil {
+		t.Fatalf("repl failed: %v", err)
+	}
+
+	output := out.String()
+	for _, expected := range []string{
+		`"text": "hello world"`,
+		`"text": "from file"`,
+		"mcp> " + `call echo {"message": "hello world"}` + "\n{",
+		"{}",
+		`"type": "object"`,
+		"Error: arguments are not valid JSON",
+		`Error: unknown command "bogus"`,
+		"   2  call echo @",
+	} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Expected output to contain %q, got:\n%s", expected, output)
+		}
+	}
+	if strings.Contains(output, "never sent") {
+		t.Error("Expected quit to end the session")
+	}
+
+	// The history survives a restart
+	reloaded, err := loadHistory(filepath.Join(dir, "history"))
+	if err != nil {
+		t.Fatalf("loadHistory failed: %v", err)
+	}
+	if len(reloaded.entries) != 9 || reloaded.entries[2] != `call echo {"message": "hello world"}` {
+		t.Errorf("Unexpected history %q", reloaded.entries)
+	}
+	if line, err := reloaded.expand("!!"); err != nil || line != "quit" {
+		t.Errorf("Expected !! to repeat quit, got %q, %v", line, err)
+	}
+}
+
+func TestRaw(t *testing.T) {
+	srv := server.NewServer(nil)
+	clientEnd, serverEnd := transport.NewInMemoryPair()
+	go srv.ServeTransport(serverEnd)
+	defer clientEnd.Close()
+
+	input := strings.Join([]string{
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26"}}`,
+		`not json`,
+		`{"jsonrpc":"2.0","method":"notifications/initialized"}`,
+		`{"jsonrpc":"2.0","id":"two","method":"vendor/missing"}`,
+	}, "\n")
+	var out bytes.Buffer
+	if err := runRaw(clientEnd, strings.NewReader(input), &out, 5*time.Second); err != nil {
+		t.Fatalf("runRaw failed: %v", err)
+	}
+
+	output := out.String()
+	for _, expected := range []string{
+		`-> {"jsonrpc":"2.0","id":1,"method":"initialize"`,
+		`<- {"jsonrpc":"2.0","result":{"protocolVersion":"2025-03-26"`,
+		"!! not valid JSON, not sent: not json",
+		`"error":{"code":-32601,"message":"Method not found","data":"vendor/missing"},"id":"two"}`,
+	} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Expected output to contain %q, got:\n%s", expected, output)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"mcp-go-sdk"
+)
+
+// runRaw sends every line read from r to the server as-is and prints every
+// message the server sends, without performing the handshake. Once r ends,
+// it waits up to timeout for the answers to the requests it sent.
+func runRaw(t mcp.Transport, r io.Reader, w io.Writer, timeout time.Duration) error {
+	var (
+		mu      sync.Mutex
+		pending = make(map[string]bool)
+		settled = make(chan struct{}, 1)
+		done    = make(chan error, 1)
+	)
+
+	go func() {
+		for {
+			msg, err := t.Receive()
+			if err != nil {
+				done <- err
+				return
+			}
+			mu.Lock()
+			fmt.Fprintf(w, "<- %s\n", msg)
+			var resp struct {
+				ID     json.RawMessage `json:"id"`
+				Method string          `json:"method"`
+			}
+			if json.Unmarshal(msg, &resp) == nil && resp.Method == "" && len(resp.ID) > 0 {
+				delete(pending, string(resp.ID))
+				if len(pending) == 0 {
+					select {
+					case settled <- struct{}{}:
+					default:
+					}
+				}
+			}
+			mu.Unlock()
+		}
+	}()
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		if !json.Valid(line) {
+			fmt.Fprintf(w, "!! not valid JSON, not sent: %s\n", line)
+			continue
+		}
+
+		var req struct {
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+		}
+		json.Unmarshal(line, &req)
+		mu.Lock()
+		if req.Method != "" && len(req.ID) > 0 {
+			pending[string(req.ID)] = true
+		}
+		fmt.Fprintf(w, "-> %s\n", line)
+		mu.Unlock()
+
+		if err := t.Send(json.RawMessage(append([]byte(nil), line...))); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	deadline := time.After(timeout)
+	for {
+		mu.Lock()
+		waiting := len(pending)
+		mu.Unlock()
+		if waiting == 0 {
+			return nil
+		}
+		select {
+		case <-settled:
+		case err := <-done:
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		case <-deadline:
+			return fmt.Errorf("%d requests were not answered within %v", waiting, timeout)
+		}
+	}
+}
@@ -0,0 +1,232 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// errQuit ends the REPL
+var errQuit = errors.New("quit")
+
+// replHelp describes the REPL commands
+const replHelp = `Commands:
+  info                          server info and capabilities
+  tools [-schema]               list tools
+  schema <tool>                 show the input schema of a tool
+  call <tool> [json | @file]    call a tool
+  resources                     list resources
+  read <uri>                    read a resource
+  prompts                       list prompts
+  prompt <name> [json]          get a prompt with string arguments
+  rpc <method> [json]           send any request and print the result
+  notify <method> [json]        send a notification
+  ping                          check that the server responds
+  history                       list previous commands
+  !! / !<n>                     repeat the last or the n-th command
+  help                          show this help
+  quit                          leave
+`
+
+// repl reads commands from r until it ends or the user quits
+func (in *inspector) repl(r io.Reader, history *history) error {
+	in.printf("Connected to %s. Type help for commands.\n", in.client.InitializeResult().ServerInfo.Name)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	for {
+		in.printf("mcp> ")
+		if !scanner.Scan() {
+			in.printf("\n")
+			return scanner.Err()
+		}
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "!") {
+			repeated, err := history.expand(line)
+			if err != nil {
+				in.printf("Error: %v\n", err)
+				continue
+			}
+			in.printf("%s\n", repeated)
+			line = repeated
+		}
+		history.add(line)
+
+		err := in.exec(line, history)
+		if err == errQuit {
+			return nil
+		}
+		if err != nil {
+			in.printf("Error: %v\n", err)
+		}
+	}
+}
+
+// exec runs a single REPL command
+func (in *inspector) exec(line string, history *history) error {
+	command, rest, _ := strings.Cut(line, " ")
+	rest = strings.TrimSpace(rest)
+
+	switch command {
+	case "help", "?":
+		in.printf("%s", replHelp)
+	case "quit", "exit":
+		return errQuit
+	case "info":
+		in.printInfo()
+	case "tools":
+		return in.printTools(rest == "-schema")
+	case "schema":
+		if rest == "" {
+			return errors.New("usage: schema <tool>")
+		}
+		return in.printSchema(rest)
+	case "call":
+		name, argText, _ := strings.Cut(rest, " ")
+		if name == "" {
+			return errors.New("usage: call <tool> [json | @file]")
+		}
+		args, err := replArgs(argText)
+		if err != nil {
+			return err
+		}
+		_, err = in.callTool(name, args)
+		return err
+	case "resources":
+		return in.call("resources/list", nil)
+	case "read":
+		if rest == "" {
+			return errors.New("usage: read <uri>")
+		}
+		params, _ := json.Marshal(map[string]string{"uri": rest})
+		return in.call("resources/read", params)
+	case "prompts":
+		return in.call("prompts/list", nil)
+	case "prompt":
+		name, argText, _ := strings.Cut(rest, " ")
+		if name == "" {
+			return errors.New("usage: prompt <name> [json]")
+		}
+		args, err := replArgs(argText)
+		if err != nil {
+			return err
+		}
+		params, _ := json.Marshal(map[string]interface{}{"name": name, "arguments": args})
+		return in.call("prompts/get", params)
+	case "rpc", "notify":
+		method, paramText, _ := strings.Cut(rest, " ")
+		if method == "" {
+			return fmt.Errorf("usage: %s <method> [json]", command)
+		}
+		params, err := parseArgs(paramText)
+		if err != nil {
+			return err
+		}
+		if command == "rpc" {
+			return in.call(method, params)
+		}
+		var p interface{}
+		if params != nil {
+			p = params
+		}
+		return in.client.Notify(method, p)
+	case "ping":
+		return in.call("ping", nil)
+	case "history":
+		for i, entry := range history.entries {
+			in.printf("%4d  %s\n", i+1, entry)
+		}
+	default:
+		return fmt.Errorf("unknown command %q, type help for commands", command)
+	}
+	return nil
+}
+
+// replArgs parses inline JSON arguments or reads them from @file
+func replArgs(s string) (json.RawMessage, error) {
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "@") {
+		return readArgsFile(s[1:])
+	}
+	return parseArgs(s)
+}
+
+// historyLimit is the number of commands kept in the history file
+const historyLimit = 1000
+
+// history keeps the REPL commands, optionally in a file so that they survive
+// restarts
+type history struct {
+	path    string
+	entries []string
+}
+
+// loadHistory reads the history file. A missing file starts an empty
+// history and an empty path keeps the history in memory only.
+func loadHistory(path string) (*history, error) {
+	h := &history{path: path}
+	if path == "" {
+		return h, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return h, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history: %w", err)
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if line != "" {
+			h.entries = append(h.entries, line)
+		}
+	}
+	if len(h.entries) > historyLimit {
+		h.entries = h.entries[len(h.entries)-historyLimit:]
+	}
+	return h, nil
+}
+
+// add records a command, skipping immediate repeats, and appends it to the
+// history file
+func (h *history) add(line string) {
+	if n := len(h.entries); n > 0 && h.entries[n-1] == line {
+		return
+	}
+	h.entries = append(h.entries, line)
+	if len(h.entries) > historyLimit {
+		h.entries = h.entries[len(h.entries)-historyLimit:]
+	}
+	if h.path == "" {
+		return
+	}
+	f, err := os.OpenFile(h.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	fmt.Fprintln(f, line)
+}
+
+// expand resolves !! and !<n> to a previous command
+func (h *history) expand(line string) (string, error) {
+	if len(h.entries) == 0 {
+		return "", errors.New("history is empty")
+	}
+	if line == "!!" {
+		return h.entries[len(h.entries)-1], nil
+	}
+	n, err := strconv.Atoi(line[1:])
+	if err != nil || n < 1 || n > len(h.entries) {
+		return "", fmt.Errorf("no command %s in history", line)
+	}
+	return h.entries[n-1], nil
+}
@@ -0,0 +1,156 @@
+// Command mcp-openapi serves the operations of an HTTP API as MCP tools. It
+// reads an OpenAPI 3 document in JSON from a file or URL and offers one tool
+// per operation:
+//
+//	mcp-openapi -base-url https://api.internal/v1 \
+//	  -credential apiKey=BILLING_API_KEY \
+//	  -header "X-Team: agents" \
+//	  https://api.internal/v1/openapi.json
+//
+// Credentials are read from the named environment variables, so that they do
+// not show up in process listings.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"mcp-go-sdk/openapi"
+	"mcp-go-sdk/server"
+	"mcp-go-sdk/transport"
+)
+
+// listFlag collects the values of a repeated flag
+type listFlag []string
+
+func (l *listFlag) String() string {
+	return strings.Join(*l, ", ")
+}
+
+func (l *listFlag) Set(v string) error {
+	*l = append(*l, v)
+	return nil
+}
+
+// options are the command-line options
+type options struct {
+	baseURL     string
+	headers     listFlag
+	credentials listFlag
+	operations  string
+	timeout     time.Duration
+	maxOutput   int
+	listen      string
+}
+
+// toolConfig returns the configuration of the tools. Credentials are read
+// from the environment.
+func (o *options) toolConfig(getenv func(string) string) (*openapi.Config, error) {
+	config := &openapi.Config{
+		BaseURL:     o.baseURL,
+		Client:      &http.Client{Timeout: o.timeout},
+		Header:      http.Header{},
+		Credentials: make(map[string]string),
+	}
+	for _, h := range o.headers {
+		name, value, ok := strings.Cut(h, ":")
+		if !ok || strings.TrimSpace(name) == "" {
+			return nil, fmt.Errorf("invalid header %q, expected \"Name: value\"", h)
+		}
+		config.Header.Add(strings.TrimSpace(name), strings.TrimSpace(value))
+	}
+	for _, c := range o.credentials {
+		scheme, env, ok := strings.Cut(c, "=")
+		if !ok || scheme == "" || env == "" {
+			return nil, fmt.Errorf("invalid credential %q, expected scheme=ENV_VAR", c)
+		}
+		value := getenv(env)
+		if value == "" {
+			return nil, fmt.Errorf("credential %s: environment variable %s is not set", scheme, env)
+		}
+		config.Credentials[scheme] = value
+	}
+	if o.operations != "" {
+		for _, id := range strings.Split(o.operations, ",") {
+			if id = strings.TrimSpace(id); id != "" {
+				config.Operations = append(config.Operations, id)
+			}
+		}
+	}
+	return config, nil
+}
+
+func main() {
+	var opts options
+	flag.StringVar(&opts.baseURL, "base-url", "", "URL of the API, by default the first server of the document")
+	flag.Var(&opts.headers, "header", "Header added to every request, as \"Name: value\" (repeatable)")
+	flag.Var(&opts.credentials, "credential", "Credential of a security scheme read from an environment variable, as scheme=ENV_VAR (repeatable)")
+	flag.StringVar(&opts.operations, "operations", "", "Comma-separated operation ids to offer, all if empty")
+	flag.DurationVar(&opts.timeout, "timeout", 30*time.Second, "Timeout for each API request")
+	flag.IntVar(&opts.maxOutput, "max-output", 64<<10, "Bytes of text a tool result may carry before it is cut, 0 for no limit")
+	flag.StringVar(&opts.listen, "listen", "", "Accept connections on a TCP address or unix:/path socket instead of stdio")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: mcp-openapi [flags] <openapi.json or URL>\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err := run(&opts, flag.Arg(0)); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// run serves the operations of the document at location
+func run(opts *options, location string) error {
+	doc, err := openapi.Load(location)
+	if err != nil {
+		return err
+	}
+	toolConfig, err := opts.toolConfig(os.Getenv)
+	if err != nil {
+		return err
+	}
+	tools, err := doc.NewTools(toolConfig)
+	if err != nil {
+		return err
+	}
+
+	config := server.DefaultConfig()
+	config.Info.Name = "OpenAPI MCP"
+	if doc.Info.Title != "" {
+		config.Info.Name = doc.Info.Title
+	}
+	if doc.Info.Version != "" {
+		config.Info.Version = doc.Info.Version
+	}
+	if opts.maxOutput > 0 {
+		config.Output = &server.OutputConfig{MaxBytes: opts.maxOutput}
+	}
+	srv := server.NewServerWithConfig(nil, config)
+	for _, tool := range tools {
+		if err := srv.RegisterTool(tool); err != nil {
+			return fmt.Errorf("failed to register tool %s: %v", tool.Name(), err)
+		}
+	}
+
+	if opts.listen != "" {
+		// Serve newline-delimited JSON-RPC, one session per connection
+		network, address := "tcp", strings.TrimPrefix(opts.listen, "tcp:")
+		if strings.HasPrefix(opts.listen, "unix:") {
+			network, address = "unix", strings.TrimPrefix(opts.listen, "unix:")
+		}
+		fmt.Fprintf(os.Stderr, "Serving %d operations of %s on %s %s\n", len(tools), location, network, address)
+		return srv.ListenAndServe(network, address)
+	}
+	// Serve a single client over stdin/stdout
+	return srv.ServeTransport(transport.NewStdioTransport())
+}
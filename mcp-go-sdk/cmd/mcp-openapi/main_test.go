@@ -0,0 +1,39 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestToolConfig(t *testing.T) {
+	env := map[string]string{"BILLING_KEY": "secret"}
+	opts := &options{
+		baseURL:     "https://api.internal/v1",
+		headers:     listFlag{"X-Team: agents", "X-Trace:1"},
+		credentials: listFlag{"apiKey=BILLING_KEY"},
+		operations:  "listInvoices, getInvoice,",
+	}
+	config, err := opts.toolConfig(func(name string) string { return env[name] })
+	if err != nil {
+		t.Fatalf("toolConfig failed: %v", err)
+	}
+	if config.Header.Get("X-Team") != "agents" || config.Header.Get("X-Trace") != "1" {
+		t.Errorf("Unexpected headers %v", config.Header)
+	}
+	if config.Credentials["apiKey"] != "secret" {
+		t.Errorf("Expected the credential from the environment, got %v", config.Credentials)
+	}
+	if len(config.Operations) != 2 || config.Operations[1] != "getInvoice" {
+		t.Errorf("Unexpected operations %v", config.Operations)
+	}
+
+	invalid := []*options{
+		{headers: listFlag{"X-Team agents"}},
+		{credentials: listFlag{"apiKey"}},
+		{credentials: listFlag{"apiKey=MISSING"}},
+	}
+	for _, o := range invalid {
+		if _, err := o.toolConfig(func(name string) string { return env[name] }); err == nil {
+			t.Errorf("Expected %+v to be rejected", o)
+		}
+	}
+}
@@ -0,0 +1,183 @@
+// Command mcp-proxy serves several MCP servers as one. The servers are
+// started as subprocesses from a configuration file in the mcpServers format
+// used by hosts such as Cursor:
+//
+//	{
+//	  "separator": "_",
+//	  "mcpServers": {
+//	    "memory": {"command": "mcp-memory", "args": ["--path", "/data/memory.json"]},
+//	    "duckdb": {"command": "mcp-duckdb", "namespace": "db"},
+//	    "echo": {"command": "echo", "namespace": ""}
+//	  }
+//	}
+//
+// The tools and prompts of each server are exposed as namespace_name. The
+// namespace defaults to the server's key and an empty namespace exposes the
+// names unchanged.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"mcp-go-sdk/proxy"
+	"mcp-go-sdk/transport"
+)
+
+// fileConfig is the configuration file of the proxy
+type fileConfig struct {
+	Separator string                 `json:"separator,omitempty"`
+	Servers   map[string]serverEntry `json:"mcpServers"`
+}
+
+// serverEntry describes one downstream server
+type serverEntry struct {
+	Command   string            `json:"command"`
+	Args      []string          `json:"args,omitempty"`
+	Env       map[string]string `json:"env,omitempty"`
+	Dir       string            `json:"cwd,omitempty"`
+	Namespace *string           `json:"namespace,omitempty"`
+	Disabled  bool              `json:"disabled,omitempty"`
+}
+
+// loadConfig reads and validates a configuration file
+func loadConfig(path string) (*fileConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var config fileConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("invalid config %s: %v", path, err)
+	}
+	if len(config.Servers) == 0 {
+		return nil, fmt.Errorf("config %s has no mcpServers", path)
+	}
+	for name, entry := range config.Servers {
+		if entry.Command == "" {
+			return nil, fmt.Errorf("server %s has no command", name)
+		}
+	}
+	return &config, nil
+}
+
+// serverConfigs returns the enabled servers sorted by name, so that name
+// conflicts are resolved the same way on every start
+func (c *fileConfig) serverConfigs() []proxy.ServerConfig {
+	names := make([]string, 0, len(c.Servers))
+	for name := range c.Servers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var servers []proxy.ServerConfig
+	for _, name := range names {
+		entry := c.Servers[name]
+		if entry.Disabled {
+			continue
+		}
+		namespace := name
+		if entry.Namespace != nil {
+			namespace = *entry.Namespace
+		}
+		servers = append(servers, proxy.ServerConfig{
+			Name:      name,
+			Namespace: namespace,
+			Connect: proxy.Command(&transport.CommandConfig{
+				Command: entry.Command,
+				Args:    entry.Args,
+				Env:     environ(entry.Env),
+				Dir:     entry.Dir,
+				Logger:  log.New(os.Stderr, "["+name+"] ", log.LstdFlags),
+			}),
+		})
+	}
+	return servers
+}
+
+// environ returns the proxy's environment with the given variables added,
+// or nil to inherit it unchanged
+func environ(vars map[string]string) []string {
+	if len(vars) == 0 {
+		return nil
+	}
+	env := os.Environ()
+	for k, v := range vars {
+		env = append(env, k+"="+v)
+	}
+	return env
+}
+
+func main() {
+	var configPath, listenAddr string
+	flag.StringVar(&configPath, "config", os.Getenv("MCP_PROXY_CONFIG"), "Path to the configuration file (required)")
+	flag.StringVar(&listenAddr, "listen", "", "Accept connections on a TCP address or unix:/path socket instead of stdio")
+	flag.Parse()
+
+	if configPath == "" {
+		fmt.Fprintf(os.Stderr, "Error: provide a configuration file using --config flag or MCP_PROXY_CONFIG environment variable.\n")
+		os.Exit(1)
+	}
+	config, err := loadConfig(configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
+		os.Exit(1)
+	}
+
+	p, err := proxy.New(config.serverConfigs(), &proxy.Config{Separator: config.Separator})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error creating proxy: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Servers that fail to start are retried in the background
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	if err := p.Start(ctx); err != nil {
+		fmt.Fprintf(os.Stderr, "Some servers are not available yet: %v\n", err)
+	}
+	cancel()
+
+	// Stop the servers when the proxy is told to exit
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-signals
+		p.Close()
+	}()
+
+	srv := p.Server()
+	if listenAddr != "" {
+		// Serve newline-delimited JSON-RPC, one session per connection
+		network, address := parseListenAddr(listenAddr)
+		fmt.Fprintf(os.Stderr, "Serving proxy on %s %s\n", network, address)
+		err = srv.ListenAndServe(network, address)
+	} else {
+		// Serve a single client over stdin/stdout
+		err = srv.ServeTransport(transport.NewStdioTransport())
+	}
+	p.Close()
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error serving proxy: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// parseListenAddr splits a listen address such as "unix:/tmp/proxy.sock" or
+// "tcp:localhost:7000" into network and address. Addresses without a prefix
+// are TCP.
+func parseListenAddr(addr string) (string, string) {
+	if strings.HasPrefix(addr, "unix:") {
+		return "unix", strings.TrimPrefix(addr, "unix:")
+	}
+	return "tcp", strings.TrimPrefix(addr, "tcp:")
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "proxy.json")
+	os.WriteFile(path, []byte(`{
+		"mcpServers": {
+			"memory": {"command": "mcp-memory", "args": ["--path", "memory.json"]},
+			"echo": {"command": "echo", "namespace": ""},
+			"duckdb": {"command": "mcp-duckdb", "namespace": "db"},
+			"groq": {"command": "mcp-groq", "disabled": true}
+		}
+	}`), 0644)
+
+	config, err := loadConfig(path)
+	if err != nil {
+		t.Fatalf("loadConfig failed: %v", err)
+	}
+	servers := config.serverConfigs()
+	expected := []struct{ name, namespace string }{
+		{"duckdb", "db"},
+		{"echo", ""},
+		{"memory", "memory"},
+	}
+	if len(servers) != len(expected) {
+		t.Fatalf("Expected %d servers, got %d", len(expected), len(servers))
+	}
+	for i, e := range expected {
+		if servers[i].Name != e.name || servers[i].Namespace != e.namespace {
+			t.Errorf("Expected server %s with namespace %q, got %s with %q", e.name, e.namespace, servers[i].Name, servers[i].Namespace)
+		}
+	}
+
+	os.WriteFile(path, []byte(`{"mcpServers": {"memory": {"args": ["x"]}}}`), 0644)
+	if _, err := loadConfig(path); err == nil {
+		t.Error("Expected error for a server without command")
+	}
+}
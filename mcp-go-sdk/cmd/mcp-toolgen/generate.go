@@ -0,0 +1,294 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/build"
+	"go/format"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"unicode"
+)
+
+// header starts every generated file
+const header = "// Code generated by mcp-toolgen. DO NOT EDIT."
+
+// schemaPath returns the path of the schema file of a tool, relative to the
+// package directory
+func schemaPath(schemaDir string, def *toolDef) string {
+	return schemaDir + "/" + def.Name + ".json"
+}
+
+// schemaVar returns the name of the variable holding the schema of a tool,
+// such as createEntitiesSchemaJSON for create_entities
+func schemaVar(def *toolDef) string {
+	var b strings.Builder
+	upper := false
+	for i, r := range def.Name {
+		switch {
+		case r == '_' || r == '-':
+			upper = true
+		case upper:
+			b.WriteRune(unicode.ToUpper(r))
+			upper = false
+		case i == 0:
+			b.WriteRune(unicode.ToLower(r))
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String() + "SchemaJSON"
+}
+
+// goString returns a Go literal of s, a raw string if it reads better
+func goString(s string) string {
+	if strings.Contains(s, "\n") && !strings.Contains(s, "`") {
+		return "`" + s + "`"
+	}
+	return strconv.Quote(s)
+}
+
+// Annotated reports whether the tool has directives for mcp.ToolAnnotations
+func (def *toolDef) Annotated() bool {
+	return def.Title != "" || def.ReadOnly || def.Destructive != nil || def.Idempotent
+}
+
+// generateCode returns the source of the tool methods of the package
+func generateCode(p *pkg, schemaDir string) ([]byte, error) {
+	imports := map[string]string{
+		"embed":         "_",
+		"encoding/json": "",
+		"fmt":           "",
+	}
+	for _, def := range p.Tools {
+		if def.Context {
+			imports["context"] = ""
+		}
+		if def.Annotated() {
+			imports["mcp-go-sdk"] = ""
+		}
+	}
+	for path, name := range p.Imports {
+		imports[path] = name
+	}
+	return render(codeTemplate, p, imports, schemaDir)
+}
+
+// generateTests returns the source of the table-driven tests of the tools
+func generateTests(p *pkg) ([]byte, error) {
+	imports := map[string]string{
+		"encoding/json": "",
+		"testing":       "",
+		"mcp-go-sdk":    "",
+	}
+	return render(testTemplate, p, imports, "")
+}
+
+// render executes a template over the tools and formats the result
+func render(tmpl *template.Template, p *pkg, imports map[string]string, schemaDir string) ([]byte, error) {
+	// Standard packages come first, as goimports orders them
+	var std, other []string
+	for path, name := range imports {
+		line := strconv.Quote(path)
+		if name != "" {
+			line = name + " " + line
+		}
+		if bp, err := build.Import(path, p.Dir, build.FindOnly); err == nil && bp.Goroot {
+			std = append(std, line)
+		} else {
+			other = append(other, line)
+		}
+	}
+	sort.Slice(std, func(i, j int) bool { return importPath(std[i]) < importPath(std[j]) })
+	sort.Slice(other, func(i, j int) bool { return importPath(other[i]) < importPath(other[j]) })
+	lines := std
+	if len(std) > 0 && len(other) > 0 {
+		lines = append(lines, "")
+	}
+	lines = append(lines, other...)
+
+	var buf bytes.Buffer
+	err := tmpl.Execute(&buf, map[string]interface{}{
+		"Header":    header,
+		"Package":   p.Name,
+		"Imports":   lines,
+		"Tools":     p.Tools,
+		"SchemaDir": schemaDir,
+	})
+	if err != nil {
+		return nil, err
+	}
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("generated code does not parse: %v\n%s", err, buf.Bytes())
+	}
+	return src, nil
+}
+
+// importPath returns the path of an import line
+func importPath(line string) string {
+	return line[strings.Index(line, `"`):]
+}
+
+var funcs = template.FuncMap{
+	"schemaPath": schemaPath,
+	"schemaVar":  schemaVar,
+	"goString":   goString,
+	"quote":      strconv.Quote,
+	"join":       strings.Join,
+	"deref":      func(b *bool) bool { return *b },
+}
+
+var codeTemplate = template.Must(template.New("code").Funcs(funcs).Parse(`{{.Header}}
+
+package {{.Package}}
+
+import (
+{{- range .Imports}}
+{{if .}}	{{.}}{{end}}
+{{- end}}
+)
+{{range .Tools}}
+//go:embed {{schemaPath $.SchemaDir .}}
+var {{schemaVar .}} []byte
+
+// Name returns the name of the tool
+func (t *{{.Receiver}}) Name() string {
+	return {{quote .Name}}
+}
+
+// Description returns the description of the tool
+func (t *{{.Receiver}}) Description() string {
+	return {{goString .Description}}
+}
+
+// Schema returns the JSON schema for the tool's parameters
+func (t *{{.Receiver}}) Schema() json.RawMessage {
+	return {{schemaVar .}}
+}
+{{if .Annotated}}
+// Annotations returns the hints of the tool
+func (t *{{.Receiver}}) Annotations() mcp.ToolAnnotations {
+	{{- if .Destructive}}
+	destructive := {{deref .Destructive}}
+	{{- end}}
+	return mcp.ToolAnnotations{
+		{{- if .Title}}Title: {{quote .Title}},{{end}}
+		{{- if .ReadOnly}}ReadOnlyHint: true,{{end}}
+		{{- if .Destructive}}DestructiveHint: &destructive,{{end}}
+		{{- if .Idempotent}}IdempotentHint: true,{{end}}
+	}
+}
+{{end}}
+{{- if .Context}}
+// Execute calls ExecuteContext without a deadline
+func (t *{{.Receiver}}) Execute(params json.RawMessage) (interface{}, error) {
+	return t.ExecuteContext(context.Background(), params)
+}
+
+// ExecuteContext decodes and checks the input and calls {{.Method}}
+func (t *{{.Receiver}}) ExecuteContext(ctx context.Context, params json.RawMessage) (interface{}, error) {
+{{- else}}
+// Execute decodes and checks the input and calls {{.Method}}
+func (t *{{.Receiver}}) Execute(params json.RawMessage) (interface{}, error) {
+{{- end}}
+	var input {{.Input}}
+	if err := decodeToolInput(params, &input{{range .Schema.Required}}, {{quote .}}{{end}}); err != nil {
+		return nil, err
+	}
+	{{- range .Checks}}
+	{{- if .Enum}}
+	switch input.{{.Field}} {
+	case {{join .Enum ", "}}{{if .Optional}}, {{.Zero}}{{end}}:
+	default:
+		return nil, fmt.Errorf("argument %q must be one of %s", {{quote .Key}}, {{quote .Allowed}})
+	}
+	{{- end}}
+	{{- if .Min}}
+	if {{if .Optional}}input.{{.Field}} != 0 && {{end}}input.{{.Field}} < {{.Min}} {
+		return nil, fmt.Errorf("argument %q must be at least {{.Min}}", {{quote .Key}})
+	}
+	{{- end}}
+	{{- if .Max}}
+	if input.{{.Field}} > {{.Max}} {
+		return nil, fmt.Errorf("argument %q must be at most {{.Max}}", {{quote .Key}})
+	}
+	{{- end}}
+	{{- end}}
+	return t.{{.Method}}({{if .Context}}ctx, {{end}}input)
+}
+{{end}}
+// decodeToolInput decodes the parameters of a tool into input after checking
+// that the required arguments are present
+func decodeToolInput(params json.RawMessage, input interface{}, required ...string) error {
+	if len(params) == 0 {
+		params = json.RawMessage("{}")
+	}
+	var args map[string]json.RawMessage
+	if err := json.Unmarshal(params, &args); err != nil {
+		return fmt.Errorf("failed to parse input: %w", err)
+	}
+	for _, name := range required {
+		if _, ok := args[name]; !ok {
+			return fmt.Errorf("missing required argument %q", name)
+		}
+	}
+	if err := json.Unmarshal(params, input); err != nil {
+		return fmt.Errorf("failed to parse input: %w", err)
+	}
+	return nil
+}
+`))
+
+var testTemplate = template.Must(template.New("test").Funcs(funcs).Parse(`{{.Header}}
+
+package {{.Package}}
+
+import (
+{{- range .Imports}}
+{{if .}}	{{.}}{{end}}
+{{- end}}
+)
+
+func TestGeneratedTools(t *testing.T) {
+	tests := []struct {
+		tool     mcp.Tool
+		name     string
+		required bool
+	}{
+	{{- range .Tools}}
+		{&{{.Receiver}}{}, {{quote .Name}}, {{if .Schema.Required}}true{{else}}false{{end}}},
+	{{- end}}
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.tool.Name() != tt.name {
+				t.Errorf("Expected name %s, got %s", tt.name, tt.tool.Name())
+			}
+			if tt.tool.Description() == "" {
+				t.Error("Expected a description")
+			}
+			var schema struct {
+				Type string ` + "`json:\"type\"`" + `
+			}
+			if err := json.Unmarshal(tt.tool.Schema(), &schema); err != nil || schema.Type != "object" {
+				t.Errorf("Expected an object schema, got %s (%v)", tt.tool.Schema(), err)
+			}
+			if _, err := tt.tool.Execute(json.RawMessage("{")); err == nil {
+				t.Error("Expected invalid JSON to be rejected")
+			}
+			// The tools are zero values, so only inputs that are rejected
+			// before the method runs are tried
+			if !tt.required {
+				return
+			}
+			if _, err := tt.tool.Execute(json.RawMessage("{}")); err == nil {
+				t.Error("Expected missing arguments to be rejected")
+			}
+		})
+	}
+}
+`))
@@ -0,0 +1,323 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// generatedHeader marks generated files, which are left out when the
+// package is read
+var generatedHeader = regexp.MustCompile(`^// Code generated .* DO NOT EDIT\.$`)
+
+// toolName is the form of tool names
+var toolName = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// toolDef is a method annotated with //mcp:tool
+type toolDef struct {
+	// Name is the name of the tool
+	Name string
+
+	// Description is the doc comment of the method without its directives
+	Description string
+
+	// Receiver is the name of the type the method belongs to
+	Receiver string
+
+	// Method is the name of the annotated method
+	Method string
+
+	// Context reports whether the method takes a context.Context first
+	Context bool
+
+	// Input is the type of the method's input, as written in the package
+	Input string
+
+	// Schema is the input schema
+	Schema *schema
+
+	// Checks are the checks of top-level input fields
+	Checks []check
+
+	// Annotations are the directives that become mcp.ToolAnnotations
+	Title       string
+	ReadOnly    bool
+	Destructive *bool
+	Idempotent  bool
+}
+
+// pkg is a type-checked package with its annotated tools
+type pkg struct {
+	Name  string
+	Dir   string
+	Tools []*toolDef
+
+	// Imports are the packages the input types come from, by path
+	Imports map[string]string
+
+	fset  *token.FileSet
+	types *types.Package
+	files map[string]*ast.File
+}
+
+// loadPackage reads and type-checks the package in dir, leaving out test
+// files and generated files. Type errors are ignored, since the package may
+// not compile until the generated methods exist.
+func loadPackage(dir string) (*pkg, error) {
+	bp, err := build.ImportDir(dir, 0)
+	if err != nil {
+		return nil, err
+	}
+	p := &pkg{
+		Name:    bp.Name,
+		Dir:     dir,
+		fset:    token.NewFileSet(),
+		files:   make(map[string]*ast.File),
+		Imports: make(map[string]string),
+	}
+	var files []*ast.File
+	for _, name := range bp.GoFiles {
+		path := filepath.Join(dir, name)
+		if generated(path) {
+			continue
+		}
+		f, err := parser.ParseFile(p.fset, path, nil, parser.ParseComments)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, f)
+		p.files[path] = f
+	}
+
+	config := &types.Config{
+		Importer: importer.ForCompiler(p.fset, "source", nil),
+		Error:    func(error) {},
+	}
+	info := &types.Info{Defs: make(map[*ast.Ident]types.Object)}
+	p.types, _ = config.Check(bp.ImportPath, p.fset, files, info)
+
+	for _, f := range files {
+		for _, decl := range f.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Recv == nil || fn.Doc == nil {
+				continue
+			}
+			def, err := p.toolDef(fn, info)
+			if err != nil {
+				pos := p.fset.Position(fn.Pos())
+				return nil, fmt.Errorf("%s:%d: %v", pos.Filename, pos.Line, err)
+			}
+			if def != nil {
+				p.Tools = append(p.Tools, def)
+			}
+		}
+	}
+	sort.Slice(p.Tools, func(i, j int) bool { return p.Tools[i].Name < p.Tools[j].Name })
+	receivers := make(map[string]string)
+	for i, def := range p.Tools {
+		if i > 0 && def.Name == p.Tools[i-1].Name {
+			return nil, fmt.Errorf("tool %s is defined twice", def.Name)
+		}
+		// The generated methods are those of the receiver type
+		if other, ok := receivers[def.Receiver]; ok {
+			return nil, fmt.Errorf("tools %s and %s both belong to %s, give each tool a type of its own", other, def.Name, def.Receiver)
+		}
+		receivers[def.Receiver] = def.Name
+	}
+	return p, nil
+}
+
+// generated reports whether the file at path starts with the generated
+// code header
+func generated(path string) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "//go:build") || strings.HasPrefix(line, "// +build") || line == "" {
+			continue
+		}
+		return generatedHeader.MatchString(line)
+	}
+	return false
+}
+
+// toolDef returns the tool of a method annotated with //mcp:tool, or nil if
+// the method has no annotation
+func (p *pkg) toolDef(fn *ast.FuncDecl, info *types.Info) (*toolDef, error) {
+	def := &toolDef{Method: fn.Name.Name}
+	annotated := false
+	var doc []string
+	for _, c := range fn.Doc.List {
+		directive, args, _ := strings.Cut(strings.TrimPrefix(c.Text, "//"), " ")
+		if !strings.HasPrefix(directive, "mcp:") {
+			doc = append(doc, c.Text)
+			continue
+		}
+		args = strings.TrimSpace(args)
+		switch directive {
+		case "mcp:tool":
+			if !toolName.MatchString(args) {
+				return nil, fmt.Errorf("invalid tool name %q", args)
+			}
+			def.Name = args
+			annotated = true
+		case "mcp:title":
+			def.Title = args
+		case "mcp:readonly":
+			def.ReadOnly = true
+		case "mcp:idempotent":
+			def.Idempotent = true
+		case "mcp:destructive":
+			destructive := args != "false"
+			def.Destructive = &destructive
+		default:
+			return nil, fmt.Errorf("unknown directive %s", directive)
+		}
+	}
+	if !annotated {
+		return nil, nil
+	}
+	def.Description = description(fn.Name.Name, doc)
+	if def.Description == "" {
+		return nil, fmt.Errorf("tool %s has no description: write it in the doc comment", def.Name)
+	}
+
+	obj, ok := info.Defs[fn.Name].(*types.Func)
+	if !ok {
+		return nil, fmt.Errorf("cannot resolve method %s", fn.Name.Name)
+	}
+	sig := obj.Type().(*types.Signature)
+	recv := sig.Recv().Type()
+	if ptr, ok := recv.(*types.Pointer); ok {
+		recv = ptr.Elem()
+	}
+	named, ok := recv.(*types.Named)
+	if !ok {
+		return nil, fmt.Errorf("method %s has no named receiver", fn.Name.Name)
+	}
+	def.Receiver = named.Obj().Name()
+
+	params := sig.Params()
+	usage := fmt.Errorf("method %s must be func(input) (result, error) or func(context.Context, input) (result, error)", fn.Name.Name)
+	var input types.Type
+	switch params.Len() {
+	case 1:
+		input = params.At(0).Type()
+	case 2:
+		if !isContext(params.At(0).Type()) {
+			return nil, usage
+		}
+		def.Context = true
+		input = params.At(1).Type()
+	default:
+		return nil, usage
+	}
+	results := sig.Results()
+	if results.Len() != 2 || results.At(1).Type().String() != "error" {
+		return nil, usage
+	}
+	if _, ok := input.Underlying().(*types.Struct); !ok {
+		return nil, fmt.Errorf("input of %s must be a struct, got %s", fn.Name.Name, input)
+	}
+	def.Input = types.TypeString(input, p.qualifier)
+
+	b := &schemaBuilder{pkg: p}
+	s, err := b.build(input)
+	if err != nil {
+		return nil, fmt.Errorf("input of %s: %v", fn.Name.Name, err)
+	}
+	def.Schema = s
+	def.Checks = b.checks(input)
+	return def, nil
+}
+
+// qualifier names the packages of types in generated code, and records
+// the imports they need
+func (p *pkg) qualifier(other *types.Package) string {
+	if other == p.types || other.Path() == p.types.Path() {
+		return ""
+	}
+	p.Imports[other.Path()] = ""
+	return other.Name()
+}
+
+// isContext reports whether t is context.Context
+func isContext(t types.Type) bool {
+	named, ok := t.(*types.Named)
+	return ok && named.Obj().Pkg() != nil && named.Obj().Pkg().Path() == "context" && named.Obj().Name() == "Context"
+}
+
+// description turns the doc comment lines of a method into a tool
+// description. A leading method name is dropped, so that "createEntities
+// creates entities." becomes "Creates entities."
+func description(method string, lines []string) string {
+	var text []string
+	for _, line := range lines {
+		line = strings.TrimPrefix(line, "//")
+		line = strings.TrimPrefix(line, " ")
+		text = append(text, line)
+	}
+	desc := strings.TrimSpace(strings.Join(text, "\n"))
+	if rest := strings.TrimPrefix(desc, method+" "); rest != desc {
+		r, size := utf8.DecodeRuneInString(rest)
+		desc = string(unicode.ToUpper(r)) + rest[size:]
+	}
+	return desc
+}
+
+// fieldDoc returns the doc or line comment of the struct field declared at
+// pos, which may be in another package
+func (p *pkg) fieldDoc(pos token.Pos) string {
+	position := p.fset.Position(pos)
+	if position.Filename == "" {
+		return ""
+	}
+	f, ok := p.files[position.Filename]
+	if !ok {
+		// Fields of imported packages are parsed again, and found by line
+		// and column
+		f, _ = parser.ParseFile(p.fset, position.Filename, nil, parser.ParseComments)
+		p.files[position.Filename] = f
+	}
+	if f == nil {
+		return ""
+	}
+	var doc string
+	ast.Inspect(f, func(n ast.Node) bool {
+		field, ok := n.(*ast.Field)
+		if !ok || doc != "" {
+			return doc == ""
+		}
+		for _, name := range field.Names {
+			if fp := p.fset.Position(name.Pos()); fp.Line == position.Line && fp.Column == position.Column {
+				switch {
+				case field.Doc != nil:
+					doc = field.Doc.Text()
+				case field.Comment != nil:
+					doc = field.Comment.Text()
+				}
+				doc = strings.TrimSpace(strings.ReplaceAll(doc, "\n", " "))
+				return false
+			}
+		}
+		return true
+	})
+	return doc
+}
@@ -0,0 +1,148 @@
+// Command mcp-toolgen generates the boilerplate of MCP tools from annotated
+// Go methods. A tool is a method marked with //mcp:tool that takes an input
+// struct, and optionally a context, and returns a result and an error:
+//
+//	// CreateEntitiesTool creates entities in the knowledge graph
+//	type CreateEntitiesTool struct {
+//		manager *graph.KnowledgeGraphManager
+//	}
+//
+//	type createEntitiesInput struct {
+//		// List of entities to create
+//		Entities []types.Entity `json:"entities"`
+//	}
+//
+//	// createEntities creates new entities in the knowledge graph.
+//	//
+//	//mcp:tool create_entities
+//	func (t *CreateEntitiesTool) createEntities(input createEntitiesInput) (interface{}, error) {
+//		...
+//	}
+//
+// Running mcp-toolgen in the package, usually through
+//
+//	//go:generate go run mcp-go-sdk/cmd/mcp-toolgen
+//
+// writes the JSON schema of each input to schemas/<tool>.json, the Name,
+// Description, Schema and Execute methods to tools_gen.go, and table-driven
+// tests of them to tools_gen_test.go. The description of a tool is the doc
+// comment of its method, and the description of each property is the doc
+// comment of its field.
+//
+// A field is required unless it is a pointer, has the omitempty json option
+// or the optional mcp option. The mcp struct tag also takes required,
+// enum=a|b|c, min=N and max=N; enums and bounds of top-level fields are
+// checked before the method is called. These directives go next to
+// //mcp:tool:
+//
+//	//mcp:title <title>       a human-readable title
+//	//mcp:readonly            the tool does not change anything
+//	//mcp:idempotent          calling the tool twice does no more than once
+//	//mcp:destructive [false] the tool may destroy data, or surely not
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// options are the command-line options
+type options struct {
+	dir        string
+	output     string
+	testOutput string
+	schemas    string
+	check      bool
+}
+
+func main() {
+	var opts options
+	flag.StringVar(&opts.dir, "dir", ".", "Directory of the package holding the tools")
+	flag.StringVar(&opts.output, "output", "tools_gen.go", "File the tool methods are written to")
+	flag.StringVar(&opts.testOutput, "test-output", "tools_gen_test.go", "File the tests are written to, none if empty")
+	flag.StringVar(&opts.schemas, "schemas", "schemas", "Directory the JSON schemas are written to, relative to the package")
+	flag.BoolVar(&opts.check, "check", false, "Write nothing, and fail if a generated file is out of date")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: mcp-toolgen [flags]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if err := run(&opts); err != nil {
+		fmt.Fprintf(os.Stderr, "mcp-toolgen: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// run generates the files of the package in opts.dir
+func run(opts *options) error {
+	files, err := generate(opts)
+	if err != nil {
+		return err
+	}
+
+	var stale []string
+	for _, f := range files {
+		current, err := os.ReadFile(f.path)
+		if err == nil && bytes.Equal(current, f.data) {
+			continue
+		}
+		if opts.check {
+			stale = append(stale, f.path)
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(f.path), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(f.path, f.data, 0644); err != nil {
+			return err
+		}
+	}
+	if len(stale) > 0 {
+		return fmt.Errorf("generated files are out of date, run go generate: %s", strings.Join(stale, ", "))
+	}
+	return nil
+}
+
+// file is a generated file
+type file struct {
+	path string
+	data []byte
+}
+
+// generate returns the files generated for the package in opts.dir
+func generate(opts *options) ([]file, error) {
+	p, err := loadPackage(opts.dir)
+	if err != nil {
+		return nil, err
+	}
+	if len(p.Tools) == 0 {
+		return nil, fmt.Errorf("no //mcp:tool methods in %s", opts.dir)
+	}
+
+	var files []file
+	for _, def := range p.Tools {
+		data, err := marshalSchema(def.Schema)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, file{filepath.Join(opts.dir, filepath.FromSlash(schemaPath(opts.schemas, def))), data})
+	}
+	code, err := generateCode(p, filepath.ToSlash(opts.schemas))
+	if err != nil {
+		return nil, err
+	}
+	files = append(files, file{filepath.Join(opts.dir, opts.output), code})
+	if opts.testOutput != "" {
+		tests, err := generateTests(p)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, file{filepath.Join(opts.dir, opts.testOutput), tests})
+	}
+	return files, nil
+}
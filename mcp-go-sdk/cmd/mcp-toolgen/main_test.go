@@ -0,0 +1,207 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// copyPackage copies the files of a package in testdata to a new directory
+func copyPackage(t *testing.T, name string) string {
+	t.Helper()
+	dir := t.TempDir()
+	files, err := filepath.Glob(filepath.Join("testdata", name, "*.go"))
+	if err != nil || len(files) == 0 {
+		t.Fatalf("No files in testdata/%s: %v", name, err)
+	}
+	for _, f := range files {
+		data, err := os.ReadFile(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, filepath.Base(f)), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func testOptions(dir string) *options {
+	return &options{dir: dir, output: "tools_gen.go", testOutput: "tools_gen_test.go", schemas: "schemas"}
+}
+
+func TestGenerate(t *testing.T) {
+	dir := copyPackage(t, "notes")
+	if err := run(testOptions(dir)); err != nil {
+		t.Fatalf("Generation failed: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "schemas", "search_notes.json"))
+	if err != nil {
+		t.Fatalf("Expected a schema file: %v", err)
+	}
+	var schema struct {
+		Properties map[string]struct {
+			Type        string        `json:"type"`
+			Description string        `json:"description"`
+			Enum        []interface{} `json:"enum"`
+			Minimum     *float64      `json:"minimum"`
+		} `json:"properties"`
+		Required []string `json:"required"`
+	}
+	if err := json.Unmarshal(data, &schema); err != nil {
+		t.Fatalf("Invalid schema: %v", err)
+	}
+	if len(schema.Required) != 1 || schema.Required[0] != "query" {
+		t.Errorf("Expected only query to be required, got %v", schema.Required)
+	}
+	if p := schema.Properties["query"]; p.Type != "string" || p.Description != "Text to look for" {
+		t.Errorf("Unexpected query property %+v", p)
+	}
+	if p := schema.Properties["order"]; len(p.Enum) != 2 {
+		t.Errorf("Expected the order enum, got %+v", p)
+	}
+	if p := schema.Properties["limit"]; p.Type != "integer" || p.Minimum == nil || *p.Minimum != 1 {
+		t.Errorf("Expected the limit promoted from Paging with its bound, got %+v", p)
+	}
+	if _, ok := schema.Properties["Skipped"]; ok {
+		t.Error("Expected the field tagged json:\"-\" to be left out")
+	}
+
+	data, err = os.ReadFile(filepath.Join(dir, "schemas", "add_note.json"))
+	if err != nil {
+		t.Fatalf("Expected a schema file: %v", err)
+	}
+	if !strings.Contains(string(data), `"description": "The note to add"`) {
+		t.Errorf("Expected the line comment as description, got %s", data)
+	}
+	if !strings.Contains(string(data), `"format": "date-time"`) {
+		t.Errorf("Expected time.Time as a date-time string, got %s", data)
+	}
+
+	code, err := os.ReadFile(filepath.Join(dir, "tools_gen.go"))
+	if err != nil {
+		t.Fatalf("Expected the generated code: %v", err)
+	}
+	for _, want := range []string{
+		header,
+		"func (t *AddNoteTool) ExecuteContext(ctx context.Context, params json.RawMessage) (interface{}, error) {",
+		`return "Adds a note."`,
+		`mcp.ToolAnnotations{Title: "Add note", DestructiveHint: &destructive}`,
+		`case "newest", "oldest", "":`,
+		"return t.search(input)",
+	} {
+		if !strings.Contains(string(code), want) {
+			t.Errorf("Expected the generated code to contain %q", want)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "tools_gen_test.go")); err != nil {
+		t.Errorf("Expected the generated tests: %v", err)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	dir := copyPackage(t, "notes")
+	opts := testOptions(dir)
+	opts.check = true
+	if err := run(opts); err == nil {
+		t.Fatal("Expected missing files to be reported")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "tools_gen.go")); err == nil {
+		t.Error("Expected -check to write nothing")
+	}
+
+	opts.check = false
+	if err := run(opts); err != nil {
+		t.Fatalf("Generation failed: %v", err)
+	}
+	opts.check = true
+	if err := run(opts); err != nil {
+		t.Errorf("Expected the generated files to be up to date, got %v", err)
+	}
+
+	// A changed field comment changes the schema
+	path := filepath.Join(dir, "notes.go")
+	src, _ := os.ReadFile(path)
+	src = []byte(strings.Replace(string(src), "// Text to look for", "// Words to look for", 1))
+	if err := os.WriteFile(path, src, 0644); err != nil {
+		t.Fatal(err)
+	}
+	err := run(opts)
+	if err == nil || !strings.Contains(err.Error(), "search_notes.json") {
+		t.Errorf("Expected the schema to be out of date, got %v", err)
+	}
+}
+
+func TestInvalidTools(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{
+			name: "no tools",
+			src:  "package p\n\nfunc f() {}\n",
+			want: "no //mcp:tool methods",
+		},
+		{
+			name: "signature",
+			src:  "package p\n\ntype T struct{}\n\n// run runs\n//\n//mcp:tool run\nfunc (t *T) run(a, b string) (string, error) { return a, nil }\n",
+			want: "must be func(input)",
+		},
+		{
+			name: "input",
+			src:  "package p\n\ntype T struct{}\n\n// run runs\n//\n//mcp:tool run\nfunc (t *T) run(a string) (string, error) { return a, nil }\n",
+			want: "must be a struct",
+		},
+		{
+			name: "description",
+			src:  "package p\n\ntype T struct{}\n\ntype in struct{}\n\n//mcp:tool run\nfunc (t *T) run(in in) (string, error) { return \"\", nil }\n",
+			want: "no description",
+		},
+		{
+			name: "shared receiver",
+			src: "package p\n\ntype T struct{}\n\ntype in struct{}\n\n" +
+				"// a does a\n//\n//mcp:tool a\nfunc (t *T) a(in in) (string, error) { return \"\", nil }\n\n" +
+				"// b does b\n//\n//mcp:tool b\nfunc (t *T) b(in in) (string, error) { return \"\", nil }\n",
+			want: "both belong to T",
+		},
+		{
+			name: "tag",
+			src:  "package p\n\ntype T struct{}\n\ntype in struct {\n\tN string `mcp:\"min=1\"`\n}\n\n// run runs\n//\n//mcp:tool run\nfunc (t *T) run(in in) (string, error) { return \"\", nil }\n",
+			want: "min and max need a number field",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, "p.go"), []byte(tt.src), 0644); err != nil {
+				t.Fatal(err)
+			}
+			_, err := generate(testOptions(dir))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Expected an error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestDescription(t *testing.T) {
+	tests := []struct {
+		lines []string
+		want  string
+	}{
+		{[]string{"// createEntities creates new entities."}, "Creates new entities."},
+		{[]string{"// Creates new entities", "// in the graph"}, "Creates new entities\nin the graph"},
+		{[]string{"// createEntitiesTool is not the method name"}, "createEntitiesTool is not the method name"},
+		{[]string{"//"}, ""},
+	}
+	for _, tt := range tests {
+		if got := description("createEntities", tt.lines); got != tt.want {
+			t.Errorf("Expected %q for %q, got %q", tt.want, tt.lines, got)
+		}
+	}
+}
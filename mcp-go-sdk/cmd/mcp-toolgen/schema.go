@@ -0,0 +1,381 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/types"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// schema is a JSON schema whose keys keep a fixed order, so that generated
+// files only change when the Go types do
+type schema struct {
+	Type                 string        `json:"type,omitempty"`
+	Format               string        `json:"format,omitempty"`
+	Description          string        `json:"description,omitempty"`
+	Enum                 []interface{} `json:"enum,omitempty"`
+	Minimum              *float64      `json:"minimum,omitempty"`
+	Maximum              *float64      `json:"maximum,omitempty"`
+	Items                *schema       `json:"items,omitempty"`
+	Properties           *properties   `json:"properties,omitempty"`
+	AdditionalProperties interface{}   `json:"additionalProperties,omitempty"`
+	Required             []string      `json:"required,omitempty"`
+}
+
+// properties are the properties of an object schema in field order
+type properties struct {
+	keys    []string
+	schemas map[string]*schema
+}
+
+func (p *properties) set(key string, s *schema) {
+	if _, ok := p.schemas[key]; !ok {
+		p.keys = append(p.keys, key)
+	}
+	p.schemas[key] = s
+}
+
+func (p *properties) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range p.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		k, _ := json.Marshal(key)
+		buf.Write(k)
+		buf.WriteByte(':')
+		v, err := json.Marshal(p.schemas[key])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(v)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// marshalSchema returns the schema as tab-indented JSON
+func marshalSchema(s *schema) ([]byte, error) {
+	data, err := json.MarshalIndent(s, "", "\t")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// field is an exported struct field as encoding/json sees it
+type field struct {
+	v        *types.Var
+	key      string
+	optional bool
+	options  fieldOptions
+}
+
+// fieldOptions are the options of the mcp struct tag:
+//
+//	Limit int `json:"limit,omitempty" mcp:"min=1,max=100"`
+//	Order string `json:"order" mcp:"optional,enum=asc|desc"`
+type fieldOptions struct {
+	required bool
+	optional bool
+	enum     []string
+	min, max string
+}
+
+// check is a check of a top-level input field made before the method is
+// called
+type check struct {
+	// Field is the Go name of the field
+	Field string
+
+	// Key is the JSON key of the field
+	Key string
+
+	// Optional reports whether the field may be left out, in which case its
+	// zero value is accepted
+	Optional bool
+
+	// Enum are the accepted values as Go literals
+	Enum []string
+
+	// Allowed lists the accepted values for error messages
+	Allowed string
+
+	// Zero is the zero value of the field as a Go literal
+	Zero string
+
+	// Min and Max are the bounds as Go literals
+	Min, Max string
+}
+
+// schemaBuilder builds the schemas of Go types
+type schemaBuilder struct {
+	pkg *pkg
+
+	// building are the structs being built, to cut recursion
+	building []types.Type
+}
+
+// build returns the schema of t
+func (b *schemaBuilder) build(t types.Type) (*schema, error) {
+	if named, ok := t.(*types.Named); ok && named.Obj().Pkg() != nil {
+		switch named.Obj().Pkg().Path() + "." + named.Obj().Name() {
+		case "time.Time":
+			return &schema{Type: "string", Format: "date-time"}, nil
+		case "encoding/json.RawMessage":
+			return &schema{}, nil
+		}
+	}
+
+	switch u := t.Underlying().(type) {
+	case *types.Basic:
+		switch {
+		case u.Info()&types.IsBoolean != 0:
+			return &schema{Type: "boolean"}, nil
+		case u.Info()&types.IsInteger != 0:
+			return &schema{Type: "integer"}, nil
+		case u.Info()&types.IsFloat != 0:
+			return &schema{Type: "number"}, nil
+		case u.Info()&types.IsString != 0:
+			return &schema{Type: "string"}, nil
+		}
+		return nil, fmt.Errorf("type %s has no JSON form", t)
+	case *types.Pointer:
+		return b.build(u.Elem())
+	case *types.Interface:
+		return &schema{}, nil
+	case *types.Slice:
+		if basic, ok := u.Elem().(*types.Basic); ok && basic.Kind() == types.Byte {
+			return &schema{Type: "string", Format: "byte"}, nil
+		}
+		items, err := b.build(u.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return &schema{Type: "array", Items: items}, nil
+	case *types.Array:
+		items, err := b.build(u.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return &schema{Type: "array", Items: items}, nil
+	case *types.Map:
+		if key, ok := u.Key().Underlying().(*types.Basic); !ok || key.Info()&types.IsString == 0 {
+			return nil, fmt.Errorf("map %s must have string keys", t)
+		}
+		values, err := b.build(u.Elem())
+		if err != nil {
+			return nil, err
+		}
+		s := &schema{Type: "object", AdditionalProperties: true}
+		if !isEmpty(values) {
+			s.AdditionalProperties = values
+		}
+		return s, nil
+	case *types.Struct:
+		return b.buildStruct(t, u)
+	}
+	return nil, fmt.Errorf("type %s has no JSON form", t)
+}
+
+// buildStruct returns the object schema of a struct
+func (b *schemaBuilder) buildStruct(t types.Type, st *types.Struct) (*schema, error) {
+	for _, building := range b.building {
+		if types.Identical(building, t) {
+			return &schema{Type: "object"}, nil
+		}
+	}
+	b.building = append(b.building, t)
+	defer func() { b.building = b.building[:len(b.building)-1] }()
+
+	fields, err := b.fields(st)
+	if err != nil {
+		return nil, err
+	}
+	s := &schema{Type: "object", Properties: &properties{schemas: make(map[string]*schema)}}
+	for _, f := range fields {
+		fs, err := b.build(f.v.Type())
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %v", f.v.Name(), err)
+		}
+		// Copy, so that the description does not end up in shared schemas
+		prop := *fs
+		prop.Description = b.pkg.fieldDoc(f.v.Pos())
+		if err := f.options.apply(&prop); err != nil {
+			return nil, fmt.Errorf("field %s: %v", f.v.Name(), err)
+		}
+		s.Properties.set(f.key, &prop)
+		if !f.optional {
+			s.Required = append(s.Required, f.key)
+		}
+	}
+	return s, nil
+}
+
+// fields returns the fields of a struct as encoding/json encodes them,
+// including those promoted from embedded structs
+func (b *schemaBuilder) fields(st *types.Struct) ([]field, error) {
+	var fields []field
+	for i := 0; i < st.NumFields(); i++ {
+		v := st.Field(i)
+		tag := reflect.StructTag(st.Tag(i))
+		name, jsonOpts, _ := strings.Cut(tag.Get("json"), ",")
+		if name == "-" && jsonOpts == "" {
+			continue
+		}
+		if v.Embedded() && name == "" {
+			t := v.Type()
+			if ptr, ok := t.(*types.Pointer); ok {
+				t = ptr.Elem()
+			}
+			if embedded, ok := t.Underlying().(*types.Struct); ok {
+				promoted, err := b.fields(embedded)
+				if err != nil {
+					return nil, err
+				}
+				fields = append(fields, promoted...)
+				continue
+			}
+		}
+		if !v.Exported() {
+			continue
+		}
+		if name == "" {
+			name = v.Name()
+		}
+		options, err := parseOptions(tag.Get("mcp"))
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %v", v.Name(), err)
+		}
+		_, pointer := v.Type().(*types.Pointer)
+		optional := pointer || hasOption(jsonOpts, "omitempty") || options.optional
+		if options.required {
+			optional = false
+		}
+		fields = append(fields, field{v: v, key: name, optional: optional, options: options})
+	}
+	return fields, nil
+}
+
+// checks returns the checks of the top-level fields of the input type
+func (b *schemaBuilder) checks(t types.Type) []check {
+	fields, err := b.fields(t.Underlying().(*types.Struct))
+	if err != nil {
+		return nil
+	}
+	var checks []check
+	for _, f := range fields {
+		// The schema still describes pointer fields, but only values are
+		// checked
+		_, pointer := f.v.Type().(*types.Pointer)
+		if pointer || len(f.options.enum) == 0 && f.options.min == "" && f.options.max == "" {
+			continue
+		}
+		c := check{
+			Field:    f.v.Name(),
+			Key:      f.key,
+			Optional: f.optional,
+			Allowed:  strings.Join(f.options.enum, ", "),
+			Zero:     "0",
+			Min:      f.options.min,
+			Max:      f.options.max,
+		}
+		for _, value := range f.options.enum {
+			if isString(f.v.Type()) {
+				value = strconv.Quote(value)
+				c.Zero = `""`
+			}
+			c.Enum = append(c.Enum, value)
+		}
+		checks = append(checks, c)
+	}
+	return checks
+}
+
+// parseOptions parses the mcp struct tag
+func parseOptions(tag string) (fieldOptions, error) {
+	var o fieldOptions
+	if tag == "" {
+		return o, nil
+	}
+	for _, option := range strings.Split(tag, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(option), "=")
+		switch key {
+		case "required":
+			o.required = true
+		case "optional":
+			o.optional = true
+		case "enum":
+			o.enum = strings.Split(value, "|")
+		case "min":
+			o.min = value
+		case "max":
+			o.max = value
+		default:
+			return o, fmt.Errorf("unknown mcp tag option %q", key)
+		}
+	}
+	return o, nil
+}
+
+// apply adds the enum and bounds of the options to the schema of a field
+func (o fieldOptions) apply(s *schema) error {
+	numeric := s.Type == "integer" || s.Type == "number"
+	for _, value := range o.enum {
+		switch {
+		case s.Type == "string":
+			s.Enum = append(s.Enum, value)
+		case numeric:
+			n, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return fmt.Errorf("enum value %q is not a number", value)
+			}
+			s.Enum = append(s.Enum, n)
+		default:
+			return fmt.Errorf("enum needs a string or number field")
+		}
+	}
+	for _, bound := range []struct {
+		value string
+		dst   **float64
+	}{{o.min, &s.Minimum}, {o.max, &s.Maximum}} {
+		if bound.value == "" {
+			continue
+		}
+		if !numeric {
+			return fmt.Errorf("min and max need a number field")
+		}
+		n, err := strconv.ParseFloat(bound.value, 64)
+		if err != nil {
+			return fmt.Errorf("bound %q is not a number", bound.value)
+		}
+		*bound.dst = &n
+	}
+	return nil
+}
+
+// hasOption reports whether a comma-separated tag option list holds option
+func hasOption(options, option string) bool {
+	for _, o := range strings.Split(options, ",") {
+		if o == option {
+			return true
+		}
+	}
+	return false
+}
+
+// isString reports whether t is a string type
+func isString(t types.Type) bool {
+	basic, ok := t.Underlying().(*types.Basic)
+	return ok && basic.Info()&types.IsString != 0
+}
+
+// isEmpty reports whether s accepts any value
+func isEmpty(s *schema) bool {
+	data, _ := json.Marshal(s)
+	return string(data) == "{}"
+}
@@ -0,0 +1,78 @@
+// Package notes holds tools for the tests of mcp-toolgen
+package notes
+
+import (
+	"context"
+	"time"
+)
+
+// Note is a note
+type Note struct {
+	// Text of the note
+	Text string `json:"text"`
+
+	// Tags of the note
+	Tags []string `json:"tags,omitempty"`
+
+	// When the note was taken
+	Taken time.Time `json:"taken" mcp:"optional"`
+
+	// Notes that answer this one
+	Replies []*Note `json:"replies,omitempty"`
+}
+
+// AddNoteTool adds notes
+type AddNoteTool struct {
+	notes *[]Note
+}
+
+// SearchNotesTool finds notes
+type SearchNotesTool struct {
+	notes *[]Note
+}
+
+type addInput struct {
+	Note   Note   `json:"note"` // The note to add
+	Author string `json:"author,omitempty"`
+}
+
+type searchInput struct {
+	Paging
+
+	// Text to look for
+	Query string `json:"query"`
+
+	// Order of the results
+	Order string `json:"order" mcp:"optional,enum=newest|oldest"`
+
+	// Tags the notes must have
+	Tags map[string]bool `json:"tags,omitempty"`
+
+	// Skipped is not part of the input
+	Skipped string `json:"-"`
+}
+
+// Paging selects a page of results
+type Paging struct {
+	// Maximum number of results
+	Limit int `json:"limit,omitempty" mcp:"min=1,max=100"`
+}
+
+// add adds a note.
+//
+//mcp:tool add_note
+//mcp:title Add note
+//mcp:destructive false
+func (t *AddNoteTool) add(ctx context.Context, input addInput) (interface{}, error) {
+	*t.notes = append(*t.notes, input.Note)
+	return len(*t.notes), nil
+}
+
+// search finds notes by their text
+// and tags.
+//
+//mcp:tool search_notes
+//mcp:readonly
+func (t *SearchNotesTool) search(input searchInput) ([]Note, error) {
+	return *t.notes, nil
+}
@@ -0,0 +1,371 @@
+package exectool
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"mcp-go-sdk"
+)
+
+// TestMain lets the test binary stand in for the commands the tools run
+func TestMain(m *testing.M) {
+	if os.Getenv("EXECTOOL_HELPER") != "" {
+		os.Exit(helper(os.Args[1:]))
+	}
+	os.Exit(m.Run())
+}
+
+// helper runs a helper command and returns its exit code
+func helper(args []string) int {
+	switch args[0] {
+	case "args":
+		for _, arg := range args[1:] {
+			fmt.Printf("%q\n", arg)
+		}
+	case "exit":
+		code, _ := strconv.Atoi(args[1])
+		fmt.Println("out")
+		fmt.Fprintln(os.Stderr, "err")
+		return code
+	case "sleep":
+		d, _ := time.ParseDuration(args[1])
+		time.Sleep(d)
+	case "cat":
+		io.Copy(os.Stdout, os.Stdin)
+	case "env":
+		for _, name := range args[1:] {
+			fmt.Printf("%s=%s\n", name, os.Getenv(name))
+		}
+	case "pwd":
+		dir, _ := os.Getwd()
+		fmt.Print(dir)
+	case "spew":
+		n, _ := strconv.Atoi(args[1])
+		fmt.Print(strings.Repeat("x", n))
+	}
+	return 0
+}
+
+// helperTool creates a tool that runs the helper command
+func helperTool(t *testing.T, spec ToolSpec) *Tool {
+	t.Helper()
+	if spec.Name == "" {
+		spec.Name = "helper"
+	}
+	spec.Command = append([]string{os.Args[0]}, spec.Command...)
+	if spec.Env == nil {
+		spec.Env = map[string]string{}
+	}
+	spec.Env["EXECTOOL_HELPER"] = "1"
+	tool, err := NewTool(&spec)
+	if err != nil {
+		t.Fatalf("Failed to create tool: %v", err)
+	}
+	return tool
+}
+
+// run calls a tool and returns its result
+func run(t *testing.T, tool *Tool, args string) (mcp.CallToolResult, error) {
+	t.Helper()
+	result, err := tool.Execute(json.RawMessage(args))
+	if err != nil {
+		return mcp.CallToolResult{}, err
+	}
+	return result.(mcp.CallToolResult), nil
+}
+
+// output calls a tool that must succeed and returns its stdout
+func output(t *testing.T, tool *Tool, args string) string {
+	t.Helper()
+	result, err := run(t, tool, args)
+	if err != nil {
+		t.Fatalf("Call with %s failed: %v", args, err)
+	}
+	if result.IsError {
+		t.Fatalf("Call with %s failed: %+v", args, result.Content)
+	}
+	return result.Content[0].Text
+}
+
+func TestExpand(t *testing.T) {
+	tool := helperTool(t, ToolSpec{
+		Command: []string{"args", "{verbose}", "{mode}", "--limit={limit}", "{{literal}}", "{files}", "{tags}", "--", "{query}"},
+		Arguments: []Argument{
+			{Name: "query", Required: true},
+			{Name: "verbose", Type: "boolean", Flag: "-v"},
+			{Name: "mode", Enum: []interface{}{"fast", "slow"}, Flag: "--mode"},
+			{Name: "limit", Type: "integer", Default: float64(10)},
+			{Name: "files", Type: "array"},
+			{Name: "tags", Type: "array", Flag: "-t"},
+		},
+	})
+
+	tests := []struct {
+		args string
+		want string
+	}{
+		{`{"query":"a b; rm -rf /"}`, `"--limit=10" "{literal}" "--" "a b; rm -rf /"`},
+		{`{"query":"$(id)","verbose":true,"mode":"fast","limit":3}`, `"-v" "--mode" "fast" "--limit=3" "{literal}" "--" "$(id)"`},
+		{`{"query":"q","verbose":false,"files":["x","y"],"tags":["a",2]}`, `"--limit=10" "{literal}" "x" "y" "-t" "a" "-t" "2" "--" "q"`},
+		{`{"query":"q","limit":null}`, `"--limit=10" "{literal}" "--" "q"`},
+		{`{"query":"q","limit":9007199254740993}`, `"--limit=9007199254740993" "{literal}" "--" "q"`},
+	}
+	for _, tt := range tests {
+		got := strings.Join(strings.Fields(output(t, tool, tt.args)), " ")
+		if got != tt.want {
+			t.Errorf("Call with %s: expected %s, got %s", tt.args, tt.want, got)
+		}
+	}
+
+	invalid := map[string]string{
+		`{}`:                              `missing required argument "query"`,
+		`{"query":"-rf"}`:                 "may not start with a dash",
+		`{"query":"q","files":["--all"]}`: "may not start with a dash",
+		`{"query":"q","mode":"medium"}`:   "must be one of",
+		`{"query":"q","limit":1.5}`:       "must be an integer",
+		`{"query":"q","limit":"5"}`:       "must be of type integer",
+		`{"query":"q","verbose":"yes"}`:   "must be of type boolean",
+		`{"query":"q","files":"x"}`:       "must be an array",
+		`{"query":"q","other":1}`:         `unknown argument "other"`,
+		`{"query":"nul\u0000"}`:           "NUL",
+		`{"query":"q","files":[{"a":1}]}`: "strings or numbers",
+		`[]`:                              "invalid arguments",
+	}
+	for args, want := range invalid {
+		if _, err := run(t, tool, args); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Call with %s: expected an error containing %q, got %v", args, want, err)
+		}
+	}
+
+	// Values may start with a dash where the spec allows it, and values
+	// after a flag or a literal prefix are never taken for options
+	dash := helperTool(t, ToolSpec{
+		Command:   []string{"args", "{n}", "-e", "{p}", "x{q}"},
+		Arguments: []Argument{{Name: "n", Type: "number", AllowDash: true}, {Name: "p"}, {Name: "q"}},
+	})
+	if got := strings.Join(strings.Fields(output(t, dash, `{"n":-1.5,"q":"-y"}`)), " "); got != `"-1.5" "-e" "x-y"` {
+		t.Errorf("Unexpected arguments %s", got)
+	}
+}
+
+func TestPattern(t *testing.T) {
+	tool := helperTool(t, ToolSpec{
+		Command:   []string{"args", "{branch}"},
+		Arguments: []Argument{{Name: "branch", Pattern: `[a-z0-9/-]+`}},
+	})
+	if got := output(t, tool, `{"branch":"feature/x-1"}`); got != "\"feature/x-1\"\n" {
+		t.Errorf("Unexpected output %q", got)
+	}
+	if _, err := run(t, tool, `{"branch":"main; ls"}`); err == nil {
+		t.Error("Expected the whole value to be matched")
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	tool := helperTool(t, ToolSpec{
+		Command:      []string{"exit", "{code}"},
+		Arguments:    []Argument{{Name: "code", Type: "integer", Required: true}},
+		SuccessCodes: []int{0, 1},
+	})
+
+	result, err := run(t, tool, `{"code":1}`)
+	if err != nil || result.IsError {
+		t.Fatalf("Expected exit code 1 to succeed, got %+v, %v", result, err)
+	}
+	if len(result.Content) != 2 || result.Content[0].Text != "out\n" || result.Content[1].Text != "stderr:\nerr\n" {
+		t.Errorf("Expected stdout and stderr, got %+v", result.Content)
+	}
+
+	result, err = run(t, tool, `{"code":3}`)
+	if err != nil || !result.IsError {
+		t.Fatalf("Expected exit code 3 to fail, got %+v, %v", result, err)
+	}
+	if !strings.Contains(result.Content[0].Text, "exit status 3") || len(result.Content) != 3 {
+		t.Errorf("Expected the exit status and output, got %+v", result.Content)
+	}
+	if result.Content[1].Text != "stdout:\nout\n" {
+		t.Errorf("Expected stdout, got %q", result.Content[1].Text)
+	}
+
+	// Exit code 0 is an error when it is not listed
+	strict := helperTool(t, ToolSpec{Command: []string{"exit", "0"}, SuccessCodes: []int{1}})
+	if result, _ := run(t, strict, `{}`); !result.IsError {
+		t.Error("Expected exit code 0 to fail")
+	}
+
+	missing := &ToolSpec{Name: "missing", Command: []string{"/nonexistent/program"}}
+	tool, _ = NewTool(missing)
+	if _, err := run(t, tool, `{}`); err == nil {
+		t.Error("Expected a missing program to fail")
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	tool := helperTool(t, ToolSpec{
+		Command: []string{"sleep", "10s"},
+		Timeout: Duration(100 * time.Millisecond),
+	})
+	start := time.Now()
+	result, err := run(t, tool, `{}`)
+	if err != nil || !result.IsError || !strings.Contains(result.Content[0].Text, "timed out") {
+		t.Errorf("Expected a timeout, got %+v, %v", result, err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Expected the command to be killed, took %v", elapsed)
+	}
+}
+
+func TestEnvironment(t *testing.T) {
+	t.Setenv("EXECTOOL_PASSED", "passed")
+	t.Setenv("EXECTOOL_HIDDEN", "hidden")
+	dir := t.TempDir()
+
+	tool := helperTool(t, ToolSpec{
+		Command: []string{"env", "EXECTOOL_PASSED", "EXECTOOL_HIDDEN", "EXECTOOL_SET"},
+		PassEnv: []string{"EXECTOOL_PASSED"},
+		Env:     map[string]string{"EXECTOOL_SET": "set"},
+	})
+	if got := output(t, tool, `{}`); got != "EXECTOOL_PASSED=passed\nEXECTOOL_HIDDEN=\nEXECTOOL_SET=set\n" {
+		t.Errorf("Unexpected environment %q", got)
+	}
+
+	tool = helperTool(t, ToolSpec{Command: []string{"env", "EXECTOOL_HIDDEN"}, InheritEnv: true})
+	if got := output(t, tool, `{}`); got != "EXECTOOL_HIDDEN=hidden\n" {
+		t.Errorf("Expected the whole environment, got %q", got)
+	}
+
+	tool = helperTool(t, ToolSpec{Command: []string{"pwd"}, Dir: dir})
+	if got, _ := filepath.EvalSymlinks(output(t, tool, `{}`)); got != mustEvalSymlinks(t, dir) {
+		t.Errorf("Expected to run in %s, got %s", dir, got)
+	}
+}
+
+// mustEvalSymlinks resolves the symlinks in a path
+func mustEvalSymlinks(t *testing.T, path string) string {
+	t.Helper()
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resolved
+}
+
+func TestStdinAndOutputLimit(t *testing.T) {
+	tool := helperTool(t, ToolSpec{
+		Command:   []string{"cat"},
+		Stdin:     "SELECT * FROM t WHERE name = '{name}' LIMIT {limit}",
+		Arguments: []Argument{{Name: "name"}, {Name: "limit", Type: "integer"}},
+	})
+	if got := output(t, tool, `{"name":"-x"}`); got != "SELECT * FROM t WHERE name = '-x' LIMIT " {
+		t.Errorf("Unexpected stdin %q", got)
+	}
+
+	spew := helperTool(t, ToolSpec{Command: []string{"spew", "100"}, MaxOutput: 10})
+	if got := output(t, spew, `{}`); got != "xxxxxxxxxx\n[90 more bytes dropped]" {
+		t.Errorf("Expected the output to be capped, got %q", got)
+	}
+}
+
+func TestSchema(t *testing.T) {
+	destructive := false
+	tool := helperTool(t, ToolSpec{
+		Command: []string{"args", "{q}", "{n}"},
+		Arguments: []Argument{
+			{Name: "q", Description: "Query", Required: true, Pattern: "[a-z]+"},
+			{Name: "n", Type: "integer", Enum: []interface{}{float64(1), float64(2)}, Default: float64(1)},
+		},
+		ReadOnly:    true,
+		Destructive: &destructive,
+		Title:       "Helper",
+	})
+	var got, want interface{}
+	json.Unmarshal(tool.Schema(), &got)
+	json.Unmarshal([]byte(`{
+		"type": "object",
+		"properties": {
+			"q": {"type": "string", "description": "Query", "pattern": "^(?:[a-z]+)$"},
+			"n": {"type": "integer", "enum": [1, 2], "default": 1}
+		},
+		"required": ["q"],
+		"additionalProperties": false
+	}`), &want)
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("Expected schema %v, got %v", want, got)
+	}
+	if a := tool.Annotations(); !a.ReadOnlyHint || a.DestructiveHint == nil || *a.DestructiveHint || a.Title != "Helper" {
+		t.Errorf("Unexpected annotations %+v", a)
+	}
+}
+
+func TestInvalidSpec(t *testing.T) {
+	invalid := map[string]ToolSpec{
+		"no name":              {Command: []string{"ls"}},
+		"no command":           {Name: "t"},
+		"placeholder program":  {Name: "t", Command: []string{"{prog}"}},
+		"unknown placeholder":  {Name: "t", Command: []string{"ls", "{path}"}},
+		"unclosed placeholder": {Name: "t", Command: []string{"ls", "{path"}},
+		"unopened placeholder": {Name: "t", Command: []string{"ls", "path}"}},
+		"embedded array":       {Name: "t", Command: []string{"ls", "-{a}"}, Arguments: []Argument{{Name: "a", Type: "array"}}},
+		"embedded flag":        {Name: "t", Command: []string{"ls", "x{a}"}, Arguments: []Argument{{Name: "a", Flag: "-a"}}},
+		"invalid type":         {Name: "t", Command: []string{"ls"}, Arguments: []Argument{{Name: "a", Type: "object"}}},
+		"invalid name":         {Name: "t", Command: []string{"ls"}, Arguments: []Argument{{Name: "a-b"}}},
+		"duplicate argument":   {Name: "t", Command: []string{"ls"}, Arguments: []Argument{{Name: "a"}, {Name: "a"}}},
+		"invalid pattern":      {Name: "t", Command: []string{"ls"}, Arguments: []Argument{{Name: "a", Pattern: "("}}},
+		"invalid default":      {Name: "t", Command: []string{"ls"}, Arguments: []Argument{{Name: "a", Type: "integer", Default: "x"}}},
+		"invalid enum":         {Name: "t", Command: []string{"ls"}, Arguments: []Argument{{Name: "a", Type: "boolean", Enum: []interface{}{"x"}}}},
+		"array in stdin":       {Name: "t", Command: []string{"cat"}, Stdin: "{a}", Arguments: []Argument{{Name: "a", Type: "array"}}},
+	}
+	for name, spec := range invalid {
+		spec := spec
+		if _, err := NewTool(&spec); err == nil {
+			t.Errorf("Expected %s to be rejected", name)
+		}
+	}
+}
+
+func TestLoadSpec(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "tools.json")
+	os.WriteFile(path, []byte(`{
+		"name": "tools",
+		"tools": [
+			{"name": "list", "description": "Lists files", "command": ["ls", "{path}"], "dir": "data", "timeout": "5s",
+			 "arguments": [{"name": "path"}]},
+			{"name": "wait", "description": "Waits", "command": ["sleep", "1"], "timeout": 1.5}
+		]
+	}`), 0o644)
+
+	spec, err := LoadSpec(path)
+	if err != nil {
+		t.Fatalf("Failed to load spec: %v", err)
+	}
+	if spec.Tools[0].Dir != filepath.Join(dir, "data") {
+		t.Errorf("Expected the directory to be resolved against the spec, got %s", spec.Tools[0].Dir)
+	}
+	if time.Duration(spec.Tools[0].Timeout) != 5*time.Second || time.Duration(spec.Tools[1].Timeout) != 1500*time.Millisecond {
+		t.Errorf("Unexpected timeouts %v and %v", spec.Tools[0].Timeout, spec.Tools[1].Timeout)
+	}
+	tools, err := spec.NewTools()
+	if err != nil || len(tools) != 2 || tools[1].Name() != "wait" {
+		t.Errorf("Unexpected tools %v, %v", tools, err)
+	}
+
+	invalid := []string{
+		`{"tools": []}`,
+		`{"tools": [{"name": "a", "command": ["ls"]}, {"name": "a", "command": ["ls"]}]}`,
+		`{"tools": [{"name": "a", "command": ["ls"], "timeout": "soon"}]}`,
+		`{"tools": [{"name": "a", "command": ["ls", "{x}"]}]}`,
+	}
+	for _, data := range invalid {
+		if _, err := ParseSpec([]byte(data), dir); err == nil {
+			t.Errorf("Expected %s to be rejected", data)
+		}
+	}
+}
@@ -0,0 +1,221 @@
+// Package exectool exposes command-line programs as MCP tools. Each tool is
+// described by a spec: its name, description, arguments and a command
+// template into which the arguments are substituted. Commands are run
+// directly, never through a shell, so argument values cannot inject other
+// commands:
+//
+//	{
+//	  "tools": [{
+//	    "name": "grep",
+//	    "description": "Searches files for a pattern",
+//	    "command": ["grep", "-rn", "{ignoreCase}", "-e", "{pattern}", "--", "{path}"],
+//	    "arguments": [
+//	      {"name": "pattern", "type": "string", "required": true},
+//	      {"name": "path", "type": "string", "default": "."},
+//	      {"name": "ignoreCase", "type": "boolean", "flag": "-i"}
+//	    ],
+//	    "timeout": "10s",
+//	    "successCodes": [0, 1],
+//	    "readOnly": true
+//	  }]
+//	}
+package exectool
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// Spec describes a set of tools
+type Spec struct {
+	// Name and Version identify the server serving the tools, if any
+	Name    string `json:"name,omitempty"`
+	Version string `json:"version,omitempty"`
+
+	// Tools are the tools, in the order they are listed
+	Tools []ToolSpec `json:"tools"`
+}
+
+// ToolSpec describes a tool that runs a command
+type ToolSpec struct {
+	// Name and Description are listed with the tool
+	Name        string `json:"name"`
+	Description string `json:"description"`
+
+	// Title is a human-readable name for the tool
+	Title string `json:"title,omitempty"`
+
+	// Command is the program and its arguments. The program is looked up in
+	// PATH if it has no slash and may not contain placeholders. See
+	// Argument for how placeholders in the arguments are expanded.
+	Command []string `json:"command"`
+
+	// Arguments are the arguments the tool accepts
+	Arguments []Argument `json:"arguments,omitempty"`
+
+	// Stdin is written to the standard input of the command, with
+	// placeholders replaced. Placeholders of arguments that were not given
+	// are left empty. Empty gives the command no input.
+	Stdin string `json:"stdin,omitempty"`
+
+	// Timeout bounds the run time of the command, which is killed once it
+	// runs out. Zero means no limit.
+	Timeout Duration `json:"timeout,omitempty"`
+
+	// Dir is the working directory of the command. Relative directories are
+	// resolved against the directory of the spec file. Empty runs the
+	// command in the current directory.
+	Dir string `json:"dir,omitempty"`
+
+	// Env sets environment variables of the command
+	Env map[string]string `json:"env,omitempty"`
+
+	// PassEnv names the variables of the server's environment that the
+	// command sees. The command only sees PATH otherwise, unless InheritEnv
+	// is set.
+	PassEnv []string `json:"passEnv,omitempty"`
+
+	// InheritEnv passes the server's whole environment to the command
+	InheritEnv bool `json:"inheritEnv,omitempty"`
+
+	// SuccessCodes are the exit codes that mean success. Other codes give an
+	// isError result. Empty means only 0.
+	SuccessCodes []int `json:"successCodes,omitempty"`
+
+	// MaxOutput bounds the bytes kept of stdout and of stderr each, 1MB if
+	// zero. The rest is dropped and counted.
+	MaxOutput int `json:"maxOutput,omitempty"`
+
+	// ReadOnly, Destructive and Idempotent are announced as the tool's
+	// annotations
+	ReadOnly    bool  `json:"readOnly,omitempty"`
+	Destructive *bool `json:"destructive,omitempty"`
+	Idempotent  bool  `json:"idempotent,omitempty"`
+}
+
+// Argument describes an argument of a tool. A command element that is
+// exactly {name} becomes the argument's value, or one element per item of an
+// array. An element that only contains {name}, such as --out={name}, has the
+// value substituted in place. Elements naming an argument that was not given
+// and has no default are dropped. Write {{ and }} for literal braces.
+type Argument struct {
+	// Name is the name of the argument, used in placeholders
+	Name string `json:"name"`
+
+	// Type is string, number, integer, boolean or array, string if empty.
+	// Arrays hold strings or numbers.
+	Type string `json:"type,omitempty"`
+
+	// Description is listed in the tool's input schema
+	Description string `json:"description,omitempty"`
+
+	// Required arguments must be given
+	Required bool `json:"required,omitempty"`
+
+	// Default is used when the argument is not given
+	Default interface{} `json:"default,omitempty"`
+
+	// Enum lists the values the argument may take
+	Enum []interface{} `json:"enum,omitempty"`
+
+	// Pattern is a regular expression the whole value must match
+	Pattern string `json:"pattern,omitempty"`
+
+	// Flag turns a {name} element into the flag followed by the value. For
+	// booleans the element becomes the flag if the value is true and is
+	// dropped otherwise. For arrays the flag is repeated before every item.
+	Flag string `json:"flag,omitempty"`
+
+	// AllowDash lets a value that starts an element begin with a dash. It is
+	// rejected otherwise, so that values cannot pass as options.
+	AllowDash bool `json:"allowDash,omitempty"`
+}
+
+// Duration is a time.Duration that is written in JSON as a string such as
+// "30s", or as a number of seconds
+type Duration time.Duration
+
+// UnmarshalJSON implements json.Unmarshaler
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	switch v := v.(type) {
+	case string:
+		parsed, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q", v)
+		}
+		*d = Duration(parsed)
+	case float64:
+		*d = Duration(v * float64(time.Second))
+	case nil:
+		*d = 0
+	default:
+		return fmt.Errorf("invalid duration %s", data)
+	}
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Quote(time.Duration(d).String())), nil
+}
+
+// ParseSpec parses a JSON spec and checks it by creating its tools.
+// Relative working directories are resolved against dir.
+func ParseSpec(data []byte, dir string) (*Spec, error) {
+	var spec Spec
+	if err := json.Unmarshal(data, &spec); err != nil {
+		return nil, fmt.Errorf("invalid spec: %v", err)
+	}
+	if len(spec.Tools) == 0 {
+		return nil, fmt.Errorf("spec has no tools")
+	}
+	names := make(map[string]bool)
+	for i := range spec.Tools {
+		ts := &spec.Tools[i]
+		if names[ts.Name] {
+			return nil, fmt.Errorf("tool %s is defined twice", ts.Name)
+		}
+		names[ts.Name] = true
+		if ts.Dir != "" && !filepath.IsAbs(ts.Dir) {
+			ts.Dir = filepath.Join(dir, ts.Dir)
+		}
+		if _, err := NewTool(ts); err != nil {
+			return nil, err
+		}
+	}
+	return &spec, nil
+}
+
+// LoadSpec reads a JSON spec file
+func LoadSpec(path string) (*Spec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	spec, err := ParseSpec(data, filepath.Dir(path))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return spec, nil
+}
+
+// NewTools creates the tools of a spec
+func (s *Spec) NewTools() ([]*Tool, error) {
+	tools := make([]*Tool, 0, len(s.Tools))
+	for i := range s.Tools {
+		tool, err := NewTool(&s.Tools[i])
+		if err != nil {
+			return nil, err
+		}
+		tools = append(tools, tool)
+	}
+	return tools, nil
+}
@@ -0,0 +1,579 @@
+package exectool
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"mcp-go-sdk"
+)
+
+// argumentName is the form of argument names
+var argumentName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// argumentTypes lists the types an argument may have
+var argumentTypes = map[string]bool{
+	"string":  true,
+	"number":  true,
+	"integer": true,
+	"boolean": true,
+	"array":   true,
+}
+
+// segment is a literal text or, if arg is set, a placeholder
+type segment struct {
+	text string
+	arg  string
+}
+
+// template is a command element or stdin with its placeholders parsed
+type template []segment
+
+// exact returns the argument an element consisting of one placeholder names
+func (t template) exact() (string, bool) {
+	if len(t) == 1 && t[0].arg != "" {
+		return t[0].arg, true
+	}
+	return "", false
+}
+
+// parseTemplate splits s into literal texts and placeholders
+func parseTemplate(s string) (template, error) {
+	var t template
+	var text strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch {
+		case strings.HasPrefix(s[i:], "{{"), strings.HasPrefix(s[i:], "}}"):
+			text.WriteByte(s[i])
+			i++
+		case s[i] == '{':
+			end := strings.IndexByte(s[i:], '}')
+			if end < 0 {
+				return nil, fmt.Errorf("unclosed placeholder in %q", s)
+			}
+			if text.Len() > 0 {
+				t = append(t, segment{text: text.String()})
+				text.Reset()
+			}
+			t = append(t, segment{arg: s[i+1 : i+end]})
+			i += end
+		case s[i] == '}':
+			return nil, fmt.Errorf("unopened placeholder in %q", s)
+		default:
+			text.WriteByte(s[i])
+		}
+	}
+	if text.Len() > 0 || len(t) == 0 {
+		t = append(t, segment{text: text.String()})
+	}
+	return t, nil
+}
+
+// param is an argument with its pattern compiled
+type param struct {
+	Argument
+	pattern *regexp.Regexp
+}
+
+// Tool runs a command described by a ToolSpec. It implements mcp.Tool,
+// mcp.ContextTool and mcp.AnnotatedTool.
+type Tool struct {
+	spec         *ToolSpec
+	args         []template
+	stdin        template
+	params       map[string]*param
+	schema       json.RawMessage
+	successCodes map[int]bool
+}
+
+// NewTool creates the tool described by spec. It fails if the spec is
+// incomplete or its placeholders name unknown arguments.
+func NewTool(spec *ToolSpec) (*Tool, error) {
+	if spec.Name == "" {
+		return nil, errors.New("tool has no name")
+	}
+	if len(spec.Command) == 0 || spec.Command[0] == "" {
+		return nil, fmt.Errorf("tool %s has no command", spec.Name)
+	}
+	if strings.ContainsAny(spec.Command[0], "{}") {
+		return nil, fmt.Errorf("tool %s: the program may not contain placeholders", spec.Name)
+	}
+
+	t := &Tool{
+		spec:         spec,
+		params:       make(map[string]*param),
+		successCodes: map[int]bool{0: true},
+	}
+	if len(spec.SuccessCodes) > 0 {
+		t.successCodes = make(map[int]bool)
+		for _, code := range spec.SuccessCodes {
+			t.successCodes[code] = true
+		}
+	}
+
+	for _, arg := range spec.Arguments {
+		p, err := newParam(arg)
+		if err != nil {
+			return nil, fmt.Errorf("tool %s: %v", spec.Name, err)
+		}
+		if t.params[arg.Name] != nil {
+			return nil, fmt.Errorf("tool %s: argument %s is defined twice", spec.Name, arg.Name)
+		}
+		t.params[arg.Name] = p
+	}
+
+	for _, element := range spec.Command[1:] {
+		tmpl, err := t.parse(element, false)
+		if err != nil {
+			return nil, fmt.Errorf("tool %s: %v", spec.Name, err)
+		}
+		t.args = append(t.args, tmpl)
+	}
+	if spec.Stdin != "" {
+		tmpl, err := t.parse(spec.Stdin, true)
+		if err != nil {
+			return nil, fmt.Errorf("tool %s: stdin: %v", spec.Name, err)
+		}
+		t.stdin = tmpl
+	}
+
+	schema, err := t.buildSchema()
+	if err != nil {
+		return nil, fmt.Errorf("tool %s: %v", spec.Name, err)
+	}
+	t.schema = schema
+	return t, nil
+}
+
+// newParam checks an argument and compiles its pattern
+func newParam(arg Argument) (*param, error) {
+	if !argumentName.MatchString(arg.Name) {
+		return nil, fmt.Errorf("invalid argument name %q", arg.Name)
+	}
+	if arg.Type == "" {
+		arg.Type = "string"
+	}
+	if !argumentTypes[arg.Type] {
+		return nil, fmt.Errorf("argument %s has unsupported type %q", arg.Name, arg.Type)
+	}
+	p := &param{Argument: arg}
+	if arg.Pattern != "" {
+		re, err := regexp.Compile("^(?:" + arg.Pattern + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("argument %s has an invalid pattern: %v", arg.Name, err)
+		}
+		p.pattern = re
+	}
+	for _, v := range arg.Enum {
+		if _, err := p.values(v); err != nil {
+			return nil, fmt.Errorf("argument %s has an invalid enum value: %v", arg.Name, err)
+		}
+	}
+	if arg.Default != nil {
+		if _, err := p.values(arg.Default); err != nil {
+			return nil, fmt.Errorf("argument %s has an invalid default: %v", arg.Name, err)
+		}
+	}
+	return p, nil
+}
+
+// parse parses a template and checks its placeholders. Arrays and flags
+// need an element of their own.
+func (t *Tool) parse(s string, stdin bool) (template, error) {
+	tmpl, err := parseTemplate(s)
+	if err != nil {
+		return nil, err
+	}
+	_, exact := tmpl.exact()
+	for _, seg := range tmpl {
+		if seg.arg == "" {
+			continue
+		}
+		p, ok := t.params[seg.arg]
+		if !ok {
+			return nil, fmt.Errorf("placeholder {%s} names no argument", seg.arg)
+		}
+		if (p.Type == "array" || p.Flag != "") && (stdin || !exact) {
+			return nil, fmt.Errorf("placeholder {%s} must be a command element of its own", seg.arg)
+		}
+	}
+	return tmpl, nil
+}
+
+// buildSchema returns the input schema of the tool
+func (t *Tool) buildSchema() (json.RawMessage, error) {
+	properties := make(map[string]interface{})
+	required := []string{}
+	for _, arg := range t.spec.Arguments {
+		p := t.params[arg.Name]
+		prop := map[string]interface{}{"type": p.Type}
+		if p.Type == "array" {
+			prop["items"] = map[string]interface{}{"type": "string"}
+		}
+		if p.Description != "" {
+			prop["description"] = p.Description
+		}
+		if len(p.Enum) > 0 {
+			prop["enum"] = p.Enum
+		}
+		if p.Default != nil {
+			prop["default"] = p.Default
+		}
+		if p.pattern != nil {
+			prop["pattern"] = p.pattern.String()
+		}
+		properties[arg.Name] = prop
+		if p.Required {
+			required = append(required, arg.Name)
+		}
+	}
+	return json.Marshal(map[string]interface{}{
+		"type":                 "object",
+		"properties":           properties,
+		"required":             required,
+		"additionalProperties": false,
+	})
+}
+
+// Name implements mcp.Tool
+func (t *Tool) Name() string {
+	return t.spec.Name
+}
+
+// Description implements mcp.Tool
+func (t *Tool) Description() string {
+	return t.spec.Description
+}
+
+// Schema implements mcp.Tool
+func (t *Tool) Schema() json.RawMessage {
+	return t.schema
+}
+
+// Annotations implements mcp.AnnotatedTool
+func (t *Tool) Annotations() mcp.ToolAnnotations {
+	return mcp.ToolAnnotations{
+		Title:           t.spec.Title,
+		ReadOnlyHint:    t.spec.ReadOnly,
+		DestructiveHint: t.spec.Destructive,
+		IdempotentHint:  t.spec.Idempotent,
+	}
+}
+
+// Execute implements mcp.Tool
+func (t *Tool) Execute(params json.RawMessage) (interface{}, error) {
+	return t.ExecuteContext(context.Background(), params)
+}
+
+// ExecuteContext implements mcp.ContextTool. It runs the command and returns
+// its output. Exit codes outside SuccessCodes and timeouts give an isError
+// result; arguments that do not fit the spec give an error.
+func (t *Tool) ExecuteContext(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	input := make(map[string]interface{})
+	if len(params) > 0 && string(params) != "null" {
+		dec := json.NewDecoder(bytes.NewReader(params))
+		dec.UseNumber()
+		if err := dec.Decode(&input); err != nil {
+			return nil, fmt.Errorf("invalid arguments: %v", err)
+		}
+	}
+	argv, stdin, err := t.expand(input)
+	if err != nil {
+		return nil, err
+	}
+
+	if t.spec.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(t.spec.Timeout))
+		defer cancel()
+	}
+	cmd := exec.CommandContext(ctx, t.spec.Command[0], argv...)
+	cmd.Dir = t.spec.Dir
+	cmd.Env = t.environ()
+	if t.stdin != nil {
+		cmd.Stdin = strings.NewReader(stdin)
+	}
+	max := t.spec.MaxOutput
+	if max <= 0 {
+		max = 1 << 20
+	}
+	stdout := &capture{max: max}
+	stderr := &capture{max: max}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	// Children that keep the pipes open must not hold up the call
+	cmd.WaitDelay = time.Second
+
+	err = cmd.Run()
+	var exitErr *exec.ExitError
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return errorResult("Command timed out", stdout, stderr), nil
+	case ctx.Err() != nil:
+		return nil, ctx.Err()
+	case errors.As(err, &exitErr):
+		if !t.successCodes[exitErr.ExitCode()] {
+			return errorResult(fmt.Sprintf("Command failed: %v", exitErr), stdout, stderr), nil
+		}
+	case err != nil:
+		return nil, fmt.Errorf("failed to run %s: %v", t.spec.Command[0], err)
+	case !t.successCodes[0]:
+		return errorResult("Command failed: exit status 0", stdout, stderr), nil
+	}
+
+	content := []mcp.ToolContent{{Type: "text", Text: stdout.String()}}
+	if stderr.Len() > 0 {
+		content = append(content, mcp.ToolContent{Type: "text", Text: "stderr:\n" + stderr.String()})
+	}
+	return mcp.CallToolResult{Content: content}, nil
+}
+
+// errorResult returns an isError result with the output of a failed command
+func errorResult(message string, stdout, stderr *capture) mcp.CallToolResult {
+	content := []mcp.ToolContent{{Type: "text", Text: message}}
+	if stdout.Len() > 0 {
+		content = append(content, mcp.ToolContent{Type: "text", Text: "stdout:\n" + stdout.String()})
+	}
+	if stderr.Len() > 0 {
+		content = append(content, mcp.ToolContent{Type: "text", Text: "stderr:\n" + stderr.String()})
+	}
+	return mcp.CallToolResult{Content: content, IsError: true}
+}
+
+// expand checks the arguments and returns the command's arguments and stdin
+func (t *Tool) expand(input map[string]interface{}) ([]string, string, error) {
+	for name := range input {
+		if t.params[name] == nil {
+			return nil, "", fmt.Errorf("unknown argument %q", name)
+		}
+	}
+	values := make(map[string][]string)
+	for name, p := range t.params {
+		v, ok := input[name]
+		if !ok || v == nil {
+			v = p.Default
+		}
+		if v == nil {
+			if p.Required {
+				return nil, "", fmt.Errorf("missing required argument %q", name)
+			}
+			continue
+		}
+		vals, err := p.values(v)
+		if err != nil {
+			return nil, "", err
+		}
+		values[name] = vals
+	}
+
+	argv := []string{}
+	for _, tmpl := range t.args {
+		if name, ok := tmpl.exact(); ok {
+			vals, given := values[name]
+			if !given {
+				continue
+			}
+			p := t.params[name]
+			switch {
+			case p.Flag != "" && p.Type == "boolean":
+				if vals[0] == "true" {
+					argv = append(argv, p.Flag)
+				}
+			case p.Flag != "":
+				for _, v := range vals {
+					argv = append(argv, p.Flag, v)
+				}
+			default:
+				for _, v := range vals {
+					if strings.HasPrefix(v, "-") && !p.AllowDash {
+						return nil, "", fmt.Errorf("argument %q may not start with a dash", name)
+					}
+					argv = append(argv, v)
+				}
+			}
+			continue
+		}
+
+		var b strings.Builder
+		complete := true
+		for i, seg := range tmpl {
+			if seg.arg == "" {
+				b.WriteString(seg.text)
+				continue
+			}
+			vals, given := values[seg.arg]
+			if !given {
+				complete = false
+				break
+			}
+			if i == 0 && strings.HasPrefix(vals[0], "-") && !t.params[seg.arg].AllowDash {
+				return nil, "", fmt.Errorf("argument %q may not start with a dash", seg.arg)
+			}
+			b.WriteString(vals[0])
+		}
+		if complete {
+			argv = append(argv, b.String())
+		}
+	}
+
+	var stdin strings.Builder
+	for _, seg := range t.stdin {
+		if seg.arg == "" {
+			stdin.WriteString(seg.text)
+		} else if vals, given := values[seg.arg]; given {
+			stdin.WriteString(vals[0])
+		}
+	}
+	return argv, stdin.String(), nil
+}
+
+// values checks a value of the argument and returns it as command-line
+// strings: one, or one per item of an array
+func (p *param) values(v interface{}) ([]string, error) {
+	if p.Type == "array" {
+		items, ok := v.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("argument %q must be an array", p.Name)
+		}
+		vals := make([]string, 0, len(items))
+		for _, item := range items {
+			s, ok := formatScalar(item)
+			if !ok {
+				return nil, fmt.Errorf("argument %q must hold strings or numbers", p.Name)
+			}
+			if err := p.check(s); err != nil {
+				return nil, err
+			}
+			vals = append(vals, s)
+		}
+		return vals, nil
+	}
+
+	var s string
+	switch v := v.(type) {
+	case string:
+		if p.Type != "string" {
+			return nil, fmt.Errorf("argument %q must be of type %s", p.Name, p.Type)
+		}
+		s = v
+	case bool:
+		if p.Type != "boolean" {
+			return nil, fmt.Errorf("argument %q must be of type %s", p.Name, p.Type)
+		}
+		s = strconv.FormatBool(v)
+	case json.Number, float64:
+		if p.Type != "number" && p.Type != "integer" {
+			return nil, fmt.Errorf("argument %q must be of type %s", p.Name, p.Type)
+		}
+		s, _ = formatScalar(v)
+		if _, err := strconv.ParseInt(s, 10, 64); p.Type == "integer" && err != nil {
+			return nil, fmt.Errorf("argument %q must be an integer", p.Name)
+		}
+	default:
+		return nil, fmt.Errorf("argument %q must be of type %s", p.Name, p.Type)
+	}
+	if err := p.check(s); err != nil {
+		return nil, err
+	}
+	return []string{s}, nil
+}
+
+// check checks a formatted value against the enum and pattern
+func (p *param) check(s string) error {
+	if strings.IndexByte(s, 0) >= 0 {
+		return fmt.Errorf("argument %q may not contain NUL bytes", p.Name)
+	}
+	if len(p.Enum) > 0 {
+		found := false
+		for _, e := range p.Enum {
+			if es, ok := formatScalar(e); ok && es == s {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("argument %q must be one of %v", p.Name, p.Enum)
+		}
+	}
+	if p.pattern != nil && !p.pattern.MatchString(s) {
+		return fmt.Errorf("argument %q must match %s", p.Name, p.Pattern)
+	}
+	return nil
+}
+
+// formatScalar formats a string, number or boolean for the command line.
+// Numbers in arguments are written as the client sent them, and numbers from
+// the spec without exponents.
+func formatScalar(v interface{}) (string, bool) {
+	switch v := v.(type) {
+	case string:
+		return v, true
+	case bool:
+		return strconv.FormatBool(v), true
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	case json.Number:
+		// The literal keeps integers beyond float64 precision intact
+		return v.String(), true
+	}
+	return "", false
+}
+
+// environ returns the environment of the command
+func (t *Tool) environ() []string {
+	var env []string
+	if t.spec.InheritEnv {
+		env = os.Environ()
+	} else {
+		env = []string{}
+		for _, name := range append([]string{"PATH"}, t.spec.PassEnv...) {
+			if v, ok := os.LookupEnv(name); ok {
+				env = append(env, name+"="+v)
+			}
+		}
+	}
+	// Later entries win
+	for k, v := range t.spec.Env {
+		env = append(env, k+"="+v)
+	}
+	return env
+}
+
+// capture keeps the first max bytes written to it and counts the rest
+type capture struct {
+	max     int
+	buf     bytes.Buffer
+	dropped int
+}
+
+// Write implements io.Writer
+func (c *capture) Write(p []byte) (int, error) {
+	n := len(p)
+	if room := c.max - c.buf.Len(); room < len(p) {
+		c.dropped += len(p) - room
+		p = p[:room]
+	}
+	c.buf.Write(p)
+	return n, nil
+}
+
+// Len returns the number of bytes written
+func (c *capture) Len() int {
+	return c.buf.Len() + c.dropped
+}
+
+// String returns the kept output and says how much was dropped
+func (c *capture) String() string {
+	if c.dropped == 0 {
+		return c.buf.String()
+	}
+	return fmt.Sprintf("%s\n[%d more bytes dropped]", c.buf.String(), c.dropped)
+}
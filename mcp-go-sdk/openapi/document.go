@@ -0,0 +1,352 @@
+// Package openapi offers the operations of an HTTP API described by an
+// OpenAPI 3 document as MCP tools. Every operation becomes a tool whose input
+// schema is built from the operation's parameters and request body. Calling
+// the tool sends the request to the API and returns the response as text,
+// and as structured content if the response is a JSON object.
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+)
+
+// methods lists the HTTP methods of a path item, in the order their
+// operations are offered
+var methods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+// Document is a parsed OpenAPI 3 document. References to its components are
+// resolved.
+type Document struct {
+	OpenAPI  string                `json:"openapi"`
+	Info     Info                  `json:"info"`
+	Servers  []Server              `json:"servers"`
+	Security []map[string][]string `json:"security"`
+
+	// Operations are the operations of all paths, in path order
+	Operations []*Operation `json:"-"`
+
+	// SecuritySchemes are the schemes of components.securitySchemes
+	SecuritySchemes map[string]*SecurityScheme `json:"-"`
+
+	// location is where the document was loaded from, if it is a URL
+	location *url.URL
+}
+
+// Info identifies the API
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description"`
+}
+
+// Server is a base URL of the API. Variables in the URL take their default
+// values.
+type Server struct {
+	URL       string `json:"url"`
+	Variables map[string]struct {
+		Default string `json:"default"`
+	} `json:"variables"`
+}
+
+// Operation is an operation of a path
+type Operation struct {
+	// Method is the HTTP method in upper case, Path the path template
+	Method string `json:"-"`
+	Path   string `json:"-"`
+
+	OperationID string                 `json:"operationId"`
+	Summary     string                 `json:"summary"`
+	Description string                 `json:"description"`
+	Tags        []string               `json:"tags"`
+	Parameters  []*Parameter           `json:"parameters"`
+	RequestBody *RequestBody           `json:"requestBody"`
+	Deprecated  bool                   `json:"deprecated"`
+	Security    *[]map[string][]string `json:"security"`
+}
+
+// Parameter is a path, query, header or cookie parameter
+type Parameter struct {
+	Name        string          `json:"name"`
+	In          string          `json:"in"`
+	Description string          `json:"description"`
+	Required    bool            `json:"required"`
+	Schema      json.RawMessage `json:"schema"`
+	Style       string          `json:"style"`
+	Explode     *bool           `json:"explode"`
+}
+
+// RequestBody is the body of an operation's request, by media type
+type RequestBody struct {
+	Description string               `json:"description"`
+	Required    bool                 `json:"required"`
+	Content     map[string]MediaType `json:"content"`
+}
+
+// MediaType holds the schema of a body in one media type
+type MediaType struct {
+	Schema json.RawMessage `json:"schema"`
+}
+
+// SecurityScheme says how a credential is sent
+type SecurityScheme struct {
+	// Type is apiKey, http, oauth2 or openIdConnect
+	Type string `json:"type"`
+
+	// Scheme is the HTTP authentication scheme, such as bearer or basic
+	Scheme string `json:"scheme"`
+
+	// Name and In are the parameter that carries an API key
+	Name string `json:"name"`
+	In   string `json:"in"`
+}
+
+// Parse parses an OpenAPI 3 document in JSON
+func Parse(data []byte) (*Document, error) {
+	var root map[string]interface{}
+	if err := json.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("invalid document: %v", err)
+	}
+	if v, _ := root["openapi"].(string); !strings.HasPrefix(v, "3.") {
+		return nil, fmt.Errorf("unsupported OpenAPI version %q", v)
+	}
+
+	r := &resolver{root: root}
+	var doc Document
+	top := map[string]interface{}{}
+	for _, k := range []string{"openapi", "info", "servers", "security"} {
+		top[k] = root[k]
+	}
+	if err := r.decode(top, &doc); err != nil {
+		return nil, err
+	}
+	components, _ := root["components"].(map[string]interface{})
+	if err := r.decode(components["securitySchemes"], &doc.SecuritySchemes); err != nil {
+		return nil, err
+	}
+
+	paths, _ := root["paths"].(map[string]interface{})
+	for _, path := range sortedKeys(paths) {
+		item, err := r.deref(paths[path])
+		if err != nil {
+			return nil, fmt.Errorf("path %s: %v", path, err)
+		}
+		fields, _ := item.(map[string]interface{})
+		var common []*Parameter
+		if err := r.decode(fields["parameters"], &common); err != nil {
+			return nil, fmt.Errorf("path %s: %v", path, err)
+		}
+		for _, method := range methods {
+			node, _ := fields[method].(map[string]interface{})
+			if node == nil {
+				continue
+			}
+			// Responses are not needed and may reference much of the
+			// document
+			fields := make(map[string]interface{}, len(node))
+			for k, v := range node {
+				if k != "responses" && k != "callbacks" {
+					fields[k] = v
+				}
+			}
+			op := &Operation{Method: strings.ToUpper(method), Path: path}
+			if err := r.decode(fields, op); err != nil {
+				return nil, fmt.Errorf("%s %s: %v", op.Method, path, err)
+			}
+			op.Parameters = mergeParameters(common, op.Parameters)
+			doc.Operations = append(doc.Operations, op)
+		}
+	}
+	return &doc, nil
+}
+
+// Load reads an OpenAPI 3 document in JSON from a file or an http(s) URL
+func Load(location string) (*Document, error) {
+	var data []byte
+	var err error
+	u, _ := url.Parse(location)
+	if u != nil && (u.Scheme == "http" || u.Scheme == "https") {
+		data, err = fetch(location)
+	} else {
+		u = nil
+		data, err = os.ReadFile(location)
+	}
+	if err != nil {
+		return nil, err
+	}
+	doc, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", location, err)
+	}
+	doc.location = u
+	return doc, nil
+}
+
+// fetch downloads a document
+func fetch(location string) ([]byte, error) {
+	resp, err := http.Get(location)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch %s: %s", location, resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// BaseURL returns the URL of the document's first server with its variables
+// set to their defaults. Relative URLs are resolved against the location the
+// document was loaded from.
+func (d *Document) BaseURL() string {
+	if len(d.Servers) == 0 {
+		return ""
+	}
+	server := d.Servers[0]
+	base := server.URL
+	for name, v := range server.Variables {
+		base = strings.ReplaceAll(base, "{"+name+"}", v.Default)
+	}
+	if d.location != nil {
+		if ref, err := url.Parse(base); err == nil {
+			base = d.location.ResolveReference(ref).String()
+		}
+	}
+	return base
+}
+
+// mergeParameters returns the parameters of an operation with the path
+// item's parameters it does not override
+func mergeParameters(common, own []*Parameter) []*Parameter {
+	merged := append([]*Parameter(nil), own...)
+	for _, p := range common {
+		overridden := false
+		for _, o := range own {
+			if o.Name == p.Name && o.In == p.In {
+				overridden = true
+				break
+			}
+		}
+		if !overridden {
+			merged = append(merged, p)
+		}
+	}
+	return merged
+}
+
+// maxRefDepth bounds the nesting of resolved references. Recursive schemas
+// are cut off at this depth.
+const maxRefDepth = 8
+
+// resolver inlines the local references of a document
+type resolver struct {
+	root map[string]interface{}
+}
+
+// decode resolves the references in node and decodes it into v
+func (r *resolver) decode(node interface{}, v interface{}) error {
+	if node == nil {
+		return nil
+	}
+	resolved, err := r.resolve(node, nil)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(resolved)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// resolve returns node with its references replaced by what they point to.
+// refs are the references being resolved; a reference to one of them, or
+// one nested too deep, becomes an empty schema.
+func (r *resolver) resolve(node interface{}, refs []string) (interface{}, error) {
+	switch n := node.(type) {
+	case map[string]interface{}:
+		if ref, ok := n["$ref"].(string); ok {
+			for _, seen := range refs {
+				if seen == ref {
+					return map[string]interface{}{}, nil
+				}
+			}
+			if len(refs) >= maxRefDepth {
+				return map[string]interface{}{}, nil
+			}
+			target, err := r.lookup(ref)
+			if err != nil {
+				return nil, err
+			}
+			return r.resolve(target, append(refs, ref))
+		}
+		resolved := make(map[string]interface{}, len(n))
+		for k, v := range n {
+			rv, err := r.resolve(v, refs)
+			if err != nil {
+				return nil, err
+			}
+			resolved[k] = rv
+		}
+		return resolved, nil
+	case []interface{}:
+		resolved := make([]interface{}, len(n))
+		for i, v := range n {
+			rv, err := r.resolve(v, refs)
+			if err != nil {
+				return nil, err
+			}
+			resolved[i] = rv
+		}
+		return resolved, nil
+	}
+	return node, nil
+}
+
+// deref returns what node points to if it is a reference, or node
+func (r *resolver) deref(node interface{}) (interface{}, error) {
+	if m, ok := node.(map[string]interface{}); ok {
+		if ref, ok := m["$ref"].(string); ok {
+			return r.lookup(ref)
+		}
+	}
+	return node, nil
+}
+
+// lookup returns the node a local reference such as
+// #/components/schemas/Pet points to
+func (r *resolver) lookup(ref string) (interface{}, error) {
+	if !strings.HasPrefix(ref, "#/") {
+		return nil, fmt.Errorf("unsupported reference %q: only references within the document are supported", ref)
+	}
+	var node interface{} = r.root
+	for _, token := range strings.Split(ref[2:], "/") {
+		token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+		if unescaped, err := url.PathUnescape(token); err == nil {
+			token = unescaped
+		}
+		m, ok := node.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("unresolvable reference %q", ref)
+		}
+		if node, ok = m[token]; !ok {
+			return nil, fmt.Errorf("unresolvable reference %q", ref)
+		}
+	}
+	return node, nil
+}
+
+// sortedKeys returns the keys of m in order
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,370 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"mcp-go-sdk"
+	"mcp-go-sdk/server"
+	"mcp-go-sdk/servertest"
+)
+
+const petstore = `{
+	"openapi": "3.0.3",
+	"info": {"title": "Petstore", "version": "1.2.0"},
+	"servers": [{"url": "{scheme}://example.com/v1", "variables": {"scheme": {"default": "https"}}}],
+	"security": [{"apiKey": []}],
+	"paths": {
+		"/pets": {
+			"get": {
+				"operationId": "listPets",
+				"summary": "List pets",
+				"parameters": [
+					{"name": "limit", "in": "query", "schema": {"type": "integer"}},
+					{"name": "tags", "in": "query", "schema": {"type": "array", "items": {"type": "string"}}},
+					{"name": "filter", "in": "query", "style": "deepObject", "schema": {"type": "object"}},
+					{"name": "Accept", "in": "header", "schema": {"type": "string"}}
+				]
+			},
+			"post": {
+				"operationId": "createPet",
+				"requestBody": {
+					"required": true,
+					"content": {
+						"text/plain": {"schema": {"type": "string"}},
+						"application/json": {"schema": {"$ref": "#/components/schemas/Pet"}}
+					}
+				},
+				"security": [{"bearer": []}, {"apiKey": []}]
+			}
+		},
+		"/pets/{id}": {
+			"parameters": [
+				{"$ref": "#/components/parameters/PetID"},
+				{"name": "id", "in": "header", "schema": {"type": "string"}, "description": "Request id"}
+			],
+			"get": {"operationId": "getPet", "description": "Returns a pet", "security": []},
+			"delete": {"deprecated": true, "security": [{"basic": []}]}
+		},
+		"/pets/{id}/photo": {
+			"get": {"operationId": "getPhoto", "parameters": [{"$ref": "#/components/parameters/PetID"}]}
+		},
+		"/login": {
+			"post": {
+				"operationId": "login",
+				"requestBody": {"content": {"application/x-www-form-urlencoded": {"schema": {"type": "object"}}}},
+				"security": [{"session": []}]
+			}
+		}
+	},
+	"components": {
+		"parameters": {
+			"PetID": {"name": "id", "in": "path", "required": true, "schema": {"type": "integer"}}
+		},
+		"schemas": {
+			"Pet": {
+				"type": "object",
+				"required": ["name"],
+				"properties": {
+					"name": {"type": "string"},
+					"parent": {"$ref": "#/components/schemas/Pet"}
+				}
+			}
+		},
+		"securitySchemes": {
+			"apiKey": {"type": "apiKey", "in": "header", "name": "X-API-Key"},
+			"bearer": {"type": "http", "scheme": "bearer"},
+			"basic": {"type": "http", "scheme": "basic"},
+			"session": {"type": "apiKey", "in": "cookie", "name": "session"}
+		}
+	}
+}`
+
+// backend records the requests it gets and answers them
+type backend struct {
+	requests chan string
+}
+
+func (b *backend) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	var cookies []string
+	for _, c := range r.Cookies() {
+		cookies = append(cookies, c.String())
+	}
+	b.requests <- fmt.Sprintf("%s %s key=%s auth=%s id=%s type=%s cookies=%s body=%s",
+		r.Method, r.URL.RequestURI(), r.Header.Get("X-API-Key"), r.Header.Get("Authorization"),
+		r.Header.Get("id"), r.Header.Get("Content-Type"), strings.Join(cookies, ";"), body)
+
+	switch {
+	case r.URL.Path == "/v1/pets/404":
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"error":"no such pet"}`))
+	case strings.HasSuffix(r.URL.Path, "/photo"):
+		w.Header().Set("Content-Type", "image/png")
+		w.Write([]byte("png"))
+	case r.Method == http.MethodDelete:
+		w.WriteHeader(http.StatusNoContent)
+	case r.URL.Path == "/v1/pets" && r.Method == http.MethodGet:
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`[{"name":"Rex"}]`))
+	default:
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.Write([]byte(`{"name":"Rex","id":1}`))
+	}
+}
+
+// newTools creates the petstore tools against a test backend
+func newTools(t *testing.T, config *Config) (map[string]*Tool, chan string) {
+	t.Helper()
+	b := &backend{requests: make(chan string, 1)}
+	srv := httptest.NewServer(b)
+	t.Cleanup(srv.Close)
+
+	doc, err := Parse([]byte(petstore))
+	if err != nil {
+		t.Fatalf("Failed to parse document: %v", err)
+	}
+	config.BaseURL = srv.URL + "/v1/"
+	list, err := doc.NewTools(config)
+	if err != nil {
+		t.Fatalf("Failed to create tools: %v", err)
+	}
+	tools := make(map[string]*Tool)
+	for _, tool := range list {
+		tools[tool.Name()] = tool
+	}
+	return tools, b.requests
+}
+
+// call calls a tool and returns its result
+func call(t *testing.T, tool *Tool, args string) mcp.CallToolResult {
+	t.Helper()
+	result, err := tool.Execute(json.RawMessage(args))
+	if err != nil {
+		t.Fatalf("Call of %s with %s failed: %v", tool.Name(), args, err)
+	}
+	return result.(mcp.CallToolResult)
+}
+
+func TestTools(t *testing.T) {
+	tools, requests := newTools(t, &Config{
+		Credentials: map[string]string{"apiKey": "secret", "basic": "ada:pw", "session": "s1"},
+		Header:      http.Header{"User-Agent": {"mcp-test"}},
+	})
+	var names []string
+	for name := range tools {
+		names = append(names, name)
+	}
+	if len(tools) != 6 || tools["delete_pets_id"] == nil {
+		t.Fatalf("Unexpected tools %v", names)
+	}
+
+	tests := []struct {
+		tool string
+		args string
+		want string
+	}{
+		{"listPets", `{"limit":10,"tags":["a","b"],"filter":{"color":"red"}}`,
+			"GET /v1/pets?filter%5Bcolor%5D=red&limit=10&tags=a&tags=b key=secret auth= id= type= cookies= body="},
+		{"getPet", `{"id":7,"header_id":"r1"}`,
+			"GET /v1/pets/7 key= auth= id=r1 type= cookies= body="},
+		{"getPet", `{"id":9007199254740993}`,
+			"GET /v1/pets/9007199254740993 key= auth= id= type= cookies= body="},
+		{"createPet", `{"body":{"name":"Rex"}}`,
+			`POST /v1/pets key=secret auth= id= type=application/json cookies= body={"name":"Rex"}`},
+		{"delete_pets_id", `{"id":7}`,
+			"DELETE /v1/pets/7 key= auth=Basic YWRhOnB3 id= type= cookies= body="},
+		{"login", `{"body":{"user":"ada","remember":true}}`,
+			"POST /v1/login key= auth= id= type=application/x-www-form-urlencoded cookies=session=s1 body=remember=true&user=ada"},
+	}
+	for _, tt := range tests {
+		result := call(t, tools[tt.tool], tt.args)
+		if got := <-requests; got != tt.want {
+			t.Errorf("Call of %s: expected request %q, got %q", tt.tool, tt.want, got)
+		}
+		if result.IsError {
+			t.Errorf("Call of %s failed: %+v", tt.tool, result)
+		}
+	}
+
+	// JSON objects are structured content too
+	result := call(t, tools["getPet"], `{"id":1}`)
+	<-requests
+	if result.Content[0].Text != `{"name":"Rex","id":1}` {
+		t.Errorf("Expected the body as text, got %+v", result.Content)
+	}
+	if obj, ok := result.StructuredContent.(map[string]interface{}); !ok || obj["name"] != "Rex" {
+		t.Errorf("Expected structured content, got %#v", result.StructuredContent)
+	}
+	result = call(t, tools["listPets"], `{}`)
+	<-requests
+	if result.StructuredContent != nil || result.Content[0].Text != `[{"name":"Rex"}]` {
+		t.Errorf("Expected arrays as text only, got %+v", result)
+	}
+
+	result = call(t, tools["getPet"], `{"id":404}`)
+	<-requests
+	if !result.IsError || result.Content[0].Text != "HTTP 404 Not Found" || result.Content[1].Text != `{"error":"no such pet"}` {
+		t.Errorf("Expected an error result, got %+v", result)
+	}
+
+	result = call(t, tools["getPhoto"], `{"id":1}`)
+	<-requests
+	if result.Content[0].Type != "image" || result.Content[0].MimeType != "image/png" || result.Content[0].Data != "cG5n" {
+		t.Errorf("Expected an image, got %+v", result.Content)
+	}
+
+	invalid := map[string]string{
+		`{"id":1,"owner":"x"}`: `unknown argument "owner"`,
+		`{"header_id":"x"}`:    `missing required argument "id"`,
+		`{"id":{"a":1}}`:       "must be a scalar",
+		`{"id":".."}`:          `may not be ".."`,
+		`{"id":"."}`:           `may not be "."`,
+		`[1]`:                  "invalid arguments",
+	}
+	for args, want := range invalid {
+		if _, err := tools["getPet"].Execute(json.RawMessage(args)); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Call with %s: expected an error containing %q, got %v", args, want, err)
+		}
+	}
+	if _, err := tools["createPet"].Execute(json.RawMessage(`{}`)); err == nil || !strings.Contains(err.Error(), `missing required argument "body"`) {
+		t.Errorf("Expected the required body to be missing, got %v", err)
+	}
+}
+
+func TestBearerAuth(t *testing.T) {
+	// The first requirement the credentials satisfy is used
+	tools, requests := newTools(t, &Config{Credentials: map[string]string{"bearer": "tok", "apiKey": "secret"}})
+	call(t, tools["createPet"], `{"body":{"name":"Rex"}}`)
+	if got := <-requests; !strings.Contains(got, "key= auth=Bearer tok") {
+		t.Errorf("Expected a bearer token, got %q", got)
+	}
+
+	// Requirements that cannot be met send no credentials
+	tools, requests = newTools(t, &Config{})
+	call(t, tools["listPets"], `{}`)
+	if got := <-requests; !strings.Contains(got, "key= auth= ") {
+		t.Errorf("Expected no credentials, got %q", got)
+	}
+}
+
+func TestMaxResponseBytes(t *testing.T) {
+	tools, requests := newTools(t, &Config{MaxResponseBytes: 5})
+	result := call(t, tools["getPet"], `{"id":1}`)
+	<-requests
+	if result.Content[0].Text != "{\"nam\n[Response cut at 5 bytes]" || result.StructuredContent != nil {
+		t.Errorf("Expected the response to be cut, got %+v", result)
+	}
+}
+
+func TestSchema(t *testing.T) {
+	tools, _ := newTools(t, &Config{Operations: []string{"getPet", "createPet"}})
+	if len(tools) != 2 {
+		t.Fatalf("Expected the operations to be filtered, got %d tools", len(tools))
+	}
+
+	var got, want interface{}
+	json.Unmarshal(tools["getPet"].Schema(), &got)
+	json.Unmarshal([]byte(`{
+		"type": "object",
+		"properties": {
+			"id": {"type": "integer"},
+			"header_id": {"type": "string", "description": "Request id"}
+		},
+		"required": ["id"],
+		"additionalProperties": false
+	}`), &want)
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("Expected schema %v, got %v", want, got)
+	}
+
+	// The JSON body is preferred and recursive references are cut off
+	var schema struct {
+		Properties struct {
+			Body struct {
+				Type       string                     `json:"type"`
+				Properties map[string]json.RawMessage `json:"properties"`
+			} `json:"body"`
+		} `json:"properties"`
+		Required []string `json:"required"`
+	}
+	json.Unmarshal(tools["createPet"].Schema(), &schema)
+	if schema.Properties.Body.Type != "object" || schema.Properties.Body.Properties["parent"] == nil || fmt.Sprint(schema.Required) != "[body]" {
+		t.Errorf("Unexpected body schema %s", tools["createPet"].Schema())
+	}
+
+	if a := tools["getPet"].Annotations(); !a.ReadOnlyHint {
+		t.Errorf("Expected GET to be read-only, got %+v", a)
+	}
+	if desc := tools["getPet"].Description(); desc != "Returns a pet\n\nGET /pets/{id}" {
+		t.Errorf("Unexpected description %q", desc)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "petstore.json")
+	os.WriteFile(path, []byte(petstore), 0o644)
+	doc, err := Load(path)
+	if err != nil {
+		t.Fatalf("Failed to load document: %v", err)
+	}
+	if doc.Info.Title != "Petstore" || doc.BaseURL() != "https://example.com/v1" {
+		t.Errorf("Unexpected document %+v with base URL %s", doc.Info, doc.BaseURL())
+	}
+
+	// Relative servers are resolved against the document's URL
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"openapi":"3.1.0","servers":[{"url":"/api"}],"paths":{"/x":{"get":{}}}}`))
+	}))
+	defer srv.Close()
+	doc, err = Load(srv.URL + "/spec/openapi.json")
+	if err != nil {
+		t.Fatalf("Failed to load document: %v", err)
+	}
+	if doc.BaseURL() != srv.URL+"/api" {
+		t.Errorf("Expected the server to be resolved, got %s", doc.BaseURL())
+	}
+	if tools, err := doc.NewTools(nil); err != nil || tools[0].Name() != "get_x" {
+		t.Errorf("Unexpected tools %v, %v", tools, err)
+	}
+
+	invalid := []string{
+		`{"swagger":"2.0"}`,
+		`{"openapi":"3.0.0","paths":{"/x":{"get":{"parameters":[{"$ref":"#/components/parameters/missing"}]}}}}`,
+		`{"openapi":"3.0.0","paths":{"/x":{"get":{"parameters":[{"$ref":"other.json#/x"}]}}}}`,
+		`{`,
+	}
+	for _, data := range invalid {
+		if _, err := Parse([]byte(data)); err == nil {
+			t.Errorf("Expected %s to be rejected", data)
+		}
+	}
+
+	doc, _ = Parse([]byte(`{"openapi":"3.0.0","paths":{"/x":{"get":{}}}}`))
+	if _, err := doc.NewTools(nil); err == nil {
+		t.Error("Expected a document without a server to need a base URL")
+	}
+}
+
+func TestConformance(t *testing.T) {
+	tools, requests := newTools(t, &Config{})
+	go func() {
+		for range requests {
+		}
+	}()
+	srv := server.NewServer(nil)
+	for _, tool := range tools {
+		srv.RegisterTool(tool)
+	}
+	servertest.Run(t, srv, &servertest.Options{
+		ErrorCall: &servertest.ToolCall{Name: "getPet", Arguments: map[string]int{"id": 404}},
+	})
+}
@@ -0,0 +1,656 @@
+package openapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"mcp-go-sdk"
+)
+
+// invalidNameChars matches the characters tool names may not contain
+var invalidNameChars = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+
+// maxNameLength bounds the length of tool names
+const maxNameLength = 64
+
+// Config represents configuration options for the tools of a document
+type Config struct {
+	// BaseURL is the URL the operation paths are appended to. Empty uses the
+	// document's first server.
+	BaseURL string
+
+	// Client sends the requests. Nil uses http.DefaultClient.
+	Client *http.Client
+
+	// Header is added to every request
+	Header http.Header
+
+	// Credentials are the secrets of the document's security schemes, by
+	// scheme name: API keys, bearer tokens, OAuth access tokens, or
+	// user:password for basic authentication. Every request carries the
+	// credentials of the first security requirement of its operation that
+	// they satisfy.
+	Credentials map[string]string
+
+	// Operations limits the tools to the operations with these ids. Empty
+	// offers every operation.
+	Operations []string
+
+	// MaxResponseBytes bounds the bytes read of a response body, 1MB if
+	// zero. The rest is dropped.
+	MaxResponseBytes int64
+}
+
+// Tool calls an operation of an API. It implements mcp.Tool,
+// mcp.ContextTool and mcp.AnnotatedTool.
+type Tool struct {
+	doc       *Document
+	op        *Operation
+	config    *Config
+	base      string
+	name      string
+	args      map[string]*Parameter
+	bodyArg   string
+	mediaType string
+	schema    json.RawMessage
+}
+
+// NewTools creates a tool for every operation of the document. A nil config
+// uses the defaults.
+func (d *Document) NewTools(config *Config) ([]*Tool, error) {
+	if config == nil {
+		config = &Config{}
+	}
+	base := config.BaseURL
+	if base == "" {
+		base = d.BaseURL()
+	}
+	if u, err := url.Parse(base); err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("invalid base URL %q: set one or give the document an absolute server URL", base)
+	}
+	base = strings.TrimSuffix(base, "/")
+
+	include := make(map[string]bool)
+	for _, id := range config.Operations {
+		include[id] = true
+	}
+	var tools []*Tool
+	names := make(map[string]bool)
+	for _, op := range d.Operations {
+		if len(include) > 0 && !include[op.OperationID] {
+			continue
+		}
+		tool, err := newTool(d, op, config, base)
+		if err != nil {
+			return nil, err
+		}
+		// Operations whose names clash are numbered
+		name := tool.name
+		for i := 2; names[tool.name]; i++ {
+			suffix := "_" + strconv.Itoa(i)
+			tool.name = truncate(name, maxNameLength-len(suffix)) + suffix
+		}
+		names[tool.name] = true
+		tools = append(tools, tool)
+	}
+	if len(tools) == 0 {
+		return nil, errors.New("document has no operations to offer")
+	}
+	return tools, nil
+}
+
+// newTool creates the tool of an operation
+func newTool(doc *Document, op *Operation, config *Config, base string) (*Tool, error) {
+	t := &Tool{
+		doc:    doc,
+		op:     op,
+		config: config,
+		base:   base,
+		name:   toolName(op),
+		args:   make(map[string]*Parameter),
+	}
+
+	properties := make(map[string]interface{})
+	required := []string{}
+	for _, p := range op.Parameters {
+		if p.In == "header" && ignoredHeader(p.Name) {
+			continue
+		}
+		if p.In != "path" && p.In != "query" && p.In != "header" && p.In != "cookie" {
+			return nil, fmt.Errorf("%s %s: parameter %s is in unknown location %q", op.Method, op.Path, p.Name, p.In)
+		}
+		// Parameters with the same name in different places are told
+		// apart by their place
+		name := p.Name
+		if t.args[name] != nil {
+			name = p.In + "_" + p.Name
+		}
+		t.args[name] = p
+		properties[name] = propertySchema(p.Schema, p.Description)
+		if p.Required || p.In == "path" {
+			required = append(required, name)
+		}
+	}
+
+	if body := op.RequestBody; body != nil {
+		t.mediaType = pickMediaType(body.Content)
+		if t.mediaType != "" {
+			t.bodyArg = "body"
+			if t.args["body"] != nil {
+				t.bodyArg = "requestBody"
+			}
+			properties[t.bodyArg] = propertySchema(body.Content[t.mediaType].Schema, body.Description)
+			if body.Required {
+				required = append(required, t.bodyArg)
+			}
+		}
+	}
+
+	schema, err := json.Marshal(map[string]interface{}{
+		"type":                 "object",
+		"properties":           properties,
+		"required":             required,
+		"additionalProperties": false,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s %s: %v", op.Method, op.Path, err)
+	}
+	t.schema = schema
+	return t, nil
+}
+
+// toolName returns the name of an operation's tool: its id, or the method
+// and path if it has none
+func toolName(op *Operation) string {
+	name := op.OperationID
+	if name == "" {
+		name = strings.ToLower(op.Method) + "_" + strings.NewReplacer("{", "", "}", "").Replace(strings.Trim(op.Path, "/"))
+	}
+	name = strings.Trim(invalidNameChars.ReplaceAllString(name, "_"), "_")
+	return truncate(name, maxNameLength)
+}
+
+// truncate cuts s to n bytes
+func truncate(s string, n int) string {
+	if len(s) > n {
+		return s[:n]
+	}
+	return s
+}
+
+// ignoredHeader reports whether a header parameter is set by the HTTP layer
+// rather than by the caller, as the specification requires
+func ignoredHeader(name string) bool {
+	switch http.CanonicalHeaderKey(name) {
+	case "Accept", "Content-Type", "Authorization":
+		return true
+	}
+	return false
+}
+
+// propertySchema returns the schema of a parameter or body with its
+// description
+func propertySchema(raw json.RawMessage, description string) map[string]interface{} {
+	schema := map[string]interface{}{}
+	if len(raw) > 0 {
+		json.Unmarshal(raw, &schema)
+	}
+	if description != "" {
+		if _, ok := schema["description"]; !ok {
+			schema["description"] = description
+		}
+	}
+	return schema
+}
+
+// pickMediaType returns the media type the body is sent in: JSON if the
+// operation accepts it, then form data, then anything else
+func pickMediaType(content map[string]MediaType) string {
+	var picked string
+	rank := 0
+	for mt := range content {
+		r := 1
+		switch {
+		case isJSON(mt):
+			r = 4
+		case mt == "application/x-www-form-urlencoded":
+			r = 3
+		case strings.HasPrefix(mt, "text/"):
+			r = 2
+		}
+		if r > rank || (r == rank && mt < picked) {
+			picked, rank = mt, r
+		}
+	}
+	return picked
+}
+
+// isJSON reports whether a media type is JSON
+func isJSON(mediaType string) bool {
+	mt, _, _ := mime.ParseMediaType(mediaType)
+	return mt == "application/json" || strings.HasSuffix(mt, "+json")
+}
+
+// Name implements mcp.Tool
+func (t *Tool) Name() string {
+	return t.name
+}
+
+// Description implements mcp.Tool
+func (t *Tool) Description() string {
+	var parts []string
+	if t.op.Deprecated {
+		parts = append(parts, "Deprecated.")
+	}
+	if t.op.Summary != "" {
+		parts = append(parts, t.op.Summary)
+	}
+	if t.op.Description != "" && t.op.Description != t.op.Summary {
+		parts = append(parts, t.op.Description)
+	}
+	parts = append(parts, t.op.Method+" "+t.op.Path)
+	return strings.Join(parts, "\n\n")
+}
+
+// Schema implements mcp.Tool
+func (t *Tool) Schema() json.RawMessage {
+	return t.schema
+}
+
+// Annotations implements mcp.AnnotatedTool. GET, HEAD and OPTIONS are
+// read-only; PUT and DELETE are idempotent.
+func (t *Tool) Annotations() mcp.ToolAnnotations {
+	a := mcp.ToolAnnotations{Title: t.op.Summary}
+	switch t.op.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		a.ReadOnlyHint = true
+	case http.MethodPut, http.MethodDelete:
+		a.IdempotentHint = true
+	}
+	return a
+}
+
+// Operation returns the operation the tool calls
+func (t *Tool) Operation() *Operation {
+	return t.op
+}
+
+// Execute implements mcp.Tool
+func (t *Tool) Execute(params json.RawMessage) (interface{}, error) {
+	return t.ExecuteContext(context.Background(), params)
+}
+
+// ExecuteContext implements mcp.ContextTool. Responses with an error status
+// give an isError result; arguments that do not fit the operation and
+// requests that fail give an error.
+func (t *Tool) ExecuteContext(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	args := make(map[string]json.RawMessage)
+	if len(params) > 0 && string(params) != "null" {
+		if err := json.Unmarshal(params, &args); err != nil {
+			return nil, fmt.Errorf("invalid arguments: %v", err)
+		}
+	}
+	req, err := t.newRequest(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+
+	client := t.config.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%s %s failed: %v", t.op.Method, t.op.Path, err)
+	}
+	defer resp.Body.Close()
+
+	max := t.config.MaxResponseBytes
+	if max <= 0 {
+		max = 1 << 20
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, max+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %v", err)
+	}
+	truncated := int64(len(body)) > max
+	if truncated {
+		body = body[:max]
+	}
+	return t.result(resp, body, truncated), nil
+}
+
+// newRequest builds the request for the given arguments
+func (t *Tool) newRequest(ctx context.Context, args map[string]json.RawMessage) (*http.Request, error) {
+	for name := range args {
+		if t.args[name] == nil && (name != t.bodyArg || t.bodyArg == "") {
+			return nil, fmt.Errorf("unknown argument %q", name)
+		}
+	}
+
+	path := t.op.Path
+	query := url.Values{}
+	header := http.Header{}
+	var cookies []*http.Cookie
+	for name, p := range t.args {
+		raw, ok := args[name]
+		if !ok || string(raw) == "null" {
+			if p.Required || p.In == "path" {
+				return nil, fmt.Errorf("missing required argument %q", name)
+			}
+			continue
+		}
+		v, err := decodeValue(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid argument %q: %v", name, err)
+		}
+		switch p.In {
+		case "path":
+			s, err := joinValue(name, v)
+			if err != nil {
+				return nil, err
+			}
+			// PathEscape leaves dots alone, and these would move the
+			// request to another path
+			if s == "." || s == ".." {
+				return nil, fmt.Errorf("argument %q may not be %q", name, s)
+			}
+			path = strings.ReplaceAll(path, "{"+p.Name+"}", url.PathEscape(s))
+		case "query":
+			if err := addQuery(query, p, v); err != nil {
+				return nil, err
+			}
+		case "header":
+			s, err := joinValue(name, v)
+			if err != nil {
+				return nil, err
+			}
+			header.Set(p.Name, s)
+		case "cookie":
+			s, err := joinValue(name, v)
+			if err != nil {
+				return nil, err
+			}
+			cookies = append(cookies, &http.Cookie{Name: p.Name, Value: s})
+		}
+	}
+
+	var body io.Reader
+	if raw, ok := args[t.bodyArg]; ok && t.bodyArg != "" && string(raw) != "null" {
+		encoded, err := encodeBody(t.mediaType, raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid argument %q: %v", t.bodyArg, err)
+		}
+		body = bytes.NewReader(encoded)
+	} else if t.bodyArg != "" && t.op.RequestBody.Required {
+		return nil, fmt.Errorf("missing required argument %q", t.bodyArg)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, t.op.Method, t.base+path, body)
+	if err != nil {
+		return nil, err
+	}
+	for k, vs := range t.config.Header {
+		for _, v := range vs {
+			req.Header.Add(k, v)
+		}
+	}
+	for k, vs := range header {
+		req.Header[k] = vs
+	}
+	for _, c := range cookies {
+		req.AddCookie(c)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", t.mediaType)
+	}
+	req.Header.Set("Accept", "application/json, */*;q=0.8")
+	if err := t.authorize(req, query); err != nil {
+		return nil, err
+	}
+	req.URL.RawQuery = query.Encode()
+	return req, nil
+}
+
+// decodeValue decodes an argument, keeping numbers as they were sent so
+// that large integers are not rounded
+func decodeValue(raw json.RawMessage) (interface{}, error) {
+	var v interface{}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// joinValue formats a scalar, or an array as comma-separated values
+func joinValue(name string, v interface{}) (string, error) {
+	if items, ok := v.([]interface{}); ok {
+		parts := make([]string, len(items))
+		for i, item := range items {
+			s, ok := formatScalar(item)
+			if !ok {
+				return "", fmt.Errorf("argument %q must hold scalars", name)
+			}
+			parts[i] = s
+		}
+		return strings.Join(parts, ","), nil
+	}
+	s, ok := formatScalar(v)
+	if !ok {
+		return "", fmt.Errorf("argument %q must be a scalar or an array", name)
+	}
+	return s, nil
+}
+
+// addQuery adds a query parameter. Arrays are repeated and objects are sent
+// as one parameter per property, unless explode is off; deepObject sends
+// objects as name[property].
+func addQuery(query url.Values, p *Parameter, v interface{}) error {
+	explode := p.Explode == nil || *p.Explode
+	switch v := v.(type) {
+	case []interface{}:
+		if !explode {
+			s, err := joinValue(p.Name, v)
+			if err != nil {
+				return err
+			}
+			query.Add(p.Name, s)
+			return nil
+		}
+		for _, item := range v {
+			s, ok := formatScalar(item)
+			if !ok {
+				return fmt.Errorf("argument %q must hold scalars", p.Name)
+			}
+			query.Add(p.Name, s)
+		}
+	case map[string]interface{}:
+		var pairs []string
+		for _, k := range sortedKeys(v) {
+			s, ok := formatScalar(v[k])
+			if !ok {
+				return fmt.Errorf("argument %q must hold scalars", p.Name)
+			}
+			switch {
+			case p.Style == "deepObject":
+				query.Add(p.Name+"["+k+"]", s)
+			case explode:
+				query.Add(k, s)
+			default:
+				pairs = append(pairs, k, s)
+			}
+		}
+		if len(pairs) > 0 {
+			query.Add(p.Name, strings.Join(pairs, ","))
+		}
+	default:
+		s, ok := formatScalar(v)
+		if !ok {
+			return fmt.Errorf("argument %q must be a scalar, an array or an object", p.Name)
+		}
+		query.Add(p.Name, s)
+	}
+	return nil
+}
+
+// formatScalar formats a string, number or boolean
+func formatScalar(v interface{}) (string, bool) {
+	switch v := v.(type) {
+	case string:
+		return v, true
+	case bool:
+		return strconv.FormatBool(v), true
+	case json.Number:
+		return v.String(), true
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	}
+	return "", false
+}
+
+// encodeBody encodes the body argument in the media type
+func encodeBody(mediaType string, raw json.RawMessage) ([]byte, error) {
+	switch {
+	case isJSON(mediaType):
+		return raw, nil
+	case mediaType == "application/x-www-form-urlencoded":
+		v, _ := decodeValue(raw)
+		fields, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, errors.New("must be an object")
+		}
+		form := url.Values{}
+		for _, k := range sortedKeys(fields) {
+			s, err := joinValue(k, fields[k])
+			if err != nil {
+				return nil, err
+			}
+			form.Set(k, s)
+		}
+		return []byte(form.Encode()), nil
+	}
+	var s string
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return nil, errors.New("must be a string")
+	}
+	return []byte(s), nil
+}
+
+// authorize adds the credentials of the first security requirement of the
+// operation that the configured credentials satisfy. Operations without
+// requirements, or whose requirements cannot be met, are sent as they are.
+func (t *Tool) authorize(req *http.Request, query url.Values) error {
+	requirements := t.doc.Security
+	if t.op.Security != nil {
+		requirements = *t.op.Security
+	}
+	for _, requirement := range requirements {
+		satisfied := true
+		for name := range requirement {
+			if _, ok := t.config.Credentials[name]; !ok || t.doc.SecuritySchemes[name] == nil {
+				satisfied = false
+				break
+			}
+		}
+		if !satisfied {
+			continue
+		}
+		for name := range requirement {
+			if err := apply(t.doc.SecuritySchemes[name], t.config.Credentials[name], req, query); err != nil {
+				return fmt.Errorf("security scheme %s: %v", name, err)
+			}
+		}
+		return nil
+	}
+	return nil
+}
+
+// apply adds a credential to a request as the scheme says
+func apply(scheme *SecurityScheme, credential string, req *http.Request, query url.Values) error {
+	switch scheme.Type {
+	case "apiKey":
+		switch scheme.In {
+		case "header":
+			req.Header.Set(scheme.Name, credential)
+		case "query":
+			query.Set(scheme.Name, credential)
+		case "cookie":
+			req.AddCookie(&http.Cookie{Name: scheme.Name, Value: credential})
+		default:
+			return fmt.Errorf("unknown API key location %q", scheme.In)
+		}
+	case "http":
+		switch strings.ToLower(scheme.Scheme) {
+		case "basic":
+			user, password, _ := strings.Cut(credential, ":")
+			req.SetBasicAuth(user, password)
+		case "bearer":
+			req.Header.Set("Authorization", "Bearer "+credential)
+		default:
+			req.Header.Set("Authorization", scheme.Scheme+" "+credential)
+		}
+	case "oauth2", "openIdConnect":
+		req.Header.Set("Authorization", "Bearer "+credential)
+	default:
+		return fmt.Errorf("unsupported type %q", scheme.Type)
+	}
+	return nil
+}
+
+// result turns a response into a tool result. JSON objects are also given
+// as structured content, images as image content.
+func (t *Tool) result(resp *http.Response, body []byte, truncated bool) mcp.CallToolResult {
+	var result mcp.CallToolResult
+	if resp.StatusCode >= 400 {
+		result.IsError = true
+		result.Content = append(result.Content, mcp.ToolContent{Type: "text", Text: "HTTP " + resp.Status})
+	}
+
+	contentType := resp.Header.Get("Content-Type")
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch {
+	case len(body) == 0:
+		if !result.IsError {
+			result.Content = append(result.Content, mcp.ToolContent{Type: "text", Text: "HTTP " + resp.Status})
+		}
+	case strings.HasPrefix(mediaType, "image/") && !truncated:
+		result.Content = append(result.Content, mcp.ToolContent{
+			Type:     "image",
+			Data:     base64.StdEncoding.EncodeToString(body),
+			MimeType: mediaType,
+		})
+	case utf8.Valid(body):
+		text := string(body)
+		if truncated {
+			text += fmt.Sprintf("\n[Response cut at %d bytes]", len(body))
+		} else if isJSON(mediaType) && !result.IsError {
+			var v interface{}
+			if json.Unmarshal(body, &v) == nil {
+				if obj, ok := v.(map[string]interface{}); ok {
+					result.StructuredContent = obj
+				}
+			}
+		}
+		result.Content = append(result.Content, mcp.ToolContent{Type: "text", Text: text})
+	default:
+		result.Content = append(result.Content, mcp.ToolContent{
+			Type: "text",
+			Text: fmt.Sprintf("Response of %d bytes of type %s", len(body), contentType),
+		})
+	}
+	return result
+}
@@ -0,0 +1,329 @@
+package policy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"mcp-go-sdk"
+	"mcp-go-sdk/auth"
+	"mcp-go-sdk/server"
+)
+
+// Config configures an Engine
+type Config struct {
+	// Audit receives an entry for every decision, one JSON object per
+	// line. Nil turns the audit log off.
+	Audit io.Writer
+
+	// AuditArguments adds the arguments of each call to its audit entry.
+	// Leave it off when arguments may carry secrets.
+	AuditArguments bool
+
+	// ReloadInterval is how often Watch checks the policy file for
+	// changes, two seconds if zero
+	ReloadInterval time.Duration
+
+	// Logger receives reloads and policies that failed to load. Nil logs
+	// to standard error.
+	Logger *log.Logger
+}
+
+// Entry is an audit log entry
+type Entry struct {
+	// Time is when the decision was made
+	Time time.Time `json:"time"`
+
+	// Session is the ID of the session the call was made in
+	Session string `json:"session,omitempty"`
+
+	// Client is the identity of the caller, empty if it did not
+	// authenticate
+	Client string `json:"client,omitempty"`
+
+	// ClientName is the name the client initialized with. Clients choose
+	// it themselves, so it only helps to tell sessions apart.
+	ClientName string `json:"clientName,omitempty"`
+
+	// Tool is the name of the called tool
+	Tool string `json:"tool"`
+
+	// Arguments are the arguments of the call, if Config.AuditArguments is
+	// set
+	Arguments json.RawMessage `json:"arguments,omitempty"`
+
+	// Effect is the effect of the deciding rule or the default
+	Effect Effect `json:"effect"`
+
+	// Allowed reports whether the call ran
+	Allowed bool `json:"allowed"`
+
+	// Rule is the name of the deciding rule, empty for the default
+	Rule string `json:"rule,omitempty"`
+
+	// Explanation tells why the rule matched, and for calls that needed
+	// confirmation what the user answered
+	Explanation string `json:"explanation"`
+}
+
+// confirmSchema asks the user for a yes or no
+var confirmSchema = json.RawMessage(`{
+	"type": "object",
+	"properties": {
+		"allow": {"type": "boolean", "description": "Let the call run"}
+	},
+	"required": ["allow"]
+}`)
+
+// maxPromptArguments bounds the arguments shown when asking for
+// confirmation
+const maxPromptArguments = 500
+
+// Engine applies a policy to tool calls. It implements server.Policy and is
+// safe for concurrent use.
+type Engine struct {
+	config *Config
+
+	mu     sync.RWMutex
+	policy *Policy
+
+	auditMu sync.Mutex
+}
+
+// NewEngine creates an engine applying p. A nil config uses the zero Config.
+func NewEngine(p *Policy, config *Config) *Engine {
+	if config == nil {
+		config = &Config{}
+	}
+	c := *config
+	if c.ReloadInterval <= 0 {
+		c.ReloadInterval = 2 * time.Second
+	}
+	if c.Logger == nil {
+		c.Logger = log.New(os.Stderr, "[policy] ", log.LstdFlags)
+	}
+	return &Engine{config: &c, policy: p}
+}
+
+// Open loads the policy in the file at path and returns an engine applying
+// it, which reloads the file as it changes until ctx ends
+func Open(ctx context.Context, path string, config *Config) (*Engine, error) {
+	p, err := Load(path)
+	if err != nil {
+		return nil, err
+	}
+	e := NewEngine(p, config)
+	go func() {
+		if err := e.Watch(ctx, path); err != nil {
+			e.config.Logger.Printf("stopped watching %s: %v", path, err)
+		}
+	}()
+	return e, nil
+}
+
+// OpenEngine is Open with the audit log appended to the file at auditPath,
+// which is created if needed, or written to standard error if auditPath is
+// empty. It suits servers that take both paths from flags.
+func OpenEngine(ctx context.Context, path, auditPath string) (*Engine, error) {
+	if auditPath == "" {
+		return Open(ctx, path, &Config{Audit: os.Stderr})
+	}
+	f, err := os.OpenFile(auditPath, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+	e, err := Open(ctx, path, &Config{Audit: f})
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return e, nil
+}
+
+// Policy returns the policy in force
+func (e *Engine) Policy() *Policy {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.policy
+}
+
+// SetPolicy replaces the policy in force. Calls being decided finish under
+// the old one.
+func (e *Engine) SetPolicy(p *Policy) {
+	e.mu.Lock()
+	e.policy = p
+	e.mu.Unlock()
+}
+
+// Authorize decides a tool call under the policy in force, asks the user if
+// the deciding rule requires confirmation, and writes an audit entry. Calls
+// the user confirmed are marked server.ToolCall.Confirmed, so that tools
+// asking for confirmation themselves do not ask twice.
+func (e *Engine) Authorize(ctx context.Context, call *server.ToolCall) error {
+	c := &Call{
+		Tool:      call.Tool.Name(),
+		Client:    clientIdentity(ctx),
+		Arguments: call.Arguments,
+	}
+	if at, ok := call.Tool.(mcp.AnnotatedTool); ok {
+		c.ReadOnly = at.Annotations().ReadOnlyHint
+	}
+	d := e.Policy().Decide(c)
+
+	entry := Entry{
+		Time:        time.Now(),
+		Client:      c.Client,
+		Tool:        c.Tool,
+		Effect:      d.Effect,
+		Rule:        d.Rule,
+		Explanation: d.Explanation,
+	}
+	if call.Session != nil {
+		entry.Session = call.Session.ID()
+		entry.ClientName = call.Session.ClientInfo().Name
+	}
+	if e.config.AuditArguments {
+		entry.Arguments = call.Arguments
+	}
+
+	var err error
+	switch d.Effect {
+	case Allow:
+	case Confirm:
+		var answer string
+		answer, err = e.confirm(ctx, c, d)
+		entry.Explanation += "; " + answer
+		call.Confirmed = err == nil
+	default:
+		err = denied(c, d)
+	}
+	entry.Allowed = err == nil
+	e.audit(&entry)
+	return err
+}
+
+// confirm asks the user whether a call may run. It returns what the user
+// answered for the audit log, and an error unless the user allowed the call.
+func (e *Engine) confirm(ctx context.Context, call *Call, d Decision) (string, error) {
+	args := call.Arguments
+	if len(args) == 0 {
+		args = json.RawMessage("{}")
+	}
+	if len(args) > maxPromptArguments {
+		args = append(args[:maxPromptArguments:maxPromptArguments], "..."...)
+	}
+	message := fmt.Sprintf("Allow %s to run with %s?", call.Tool, args)
+	if d.Reason != "" {
+		message = d.Reason + "\n\n" + message
+	}
+
+	var answer struct {
+		Allow bool `json:"allow"`
+	}
+	action, err := server.Elicit(ctx, message, confirmSchema, &answer)
+	switch {
+	case errors.Is(err, server.ErrElicitationUnsupported):
+		return "client cannot ask for confirmation", fmt.Errorf("tool %s needs confirmation, but the client cannot ask the user", call.Tool)
+	case err != nil:
+		return "confirmation failed: " + err.Error(), fmt.Errorf("failed to ask for confirmation: %w", err)
+	case action != mcp.ElicitAccept || !answer.Allow:
+		return "not confirmed by the user", fmt.Errorf("tool %s was not confirmed by the user", call.Tool)
+	}
+	return "confirmed by the user", nil
+}
+
+// denied returns the error of a denied call
+func denied(call *Call, d Decision) error {
+	reason := d.Reason
+	if reason == "" {
+		reason = d.Explanation
+	}
+	if d.Rule == "" {
+		return fmt.Errorf("tool %s denied by policy: %s", call.Tool, reason)
+	}
+	return fmt.Errorf("tool %s denied by policy rule %s: %s", call.Tool, d.Rule, reason)
+}
+
+// audit writes an entry to the audit log
+func (e *Engine) audit(entry *Entry) {
+	if e.config.Audit == nil {
+		return
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	e.auditMu.Lock()
+	defer e.auditMu.Unlock()
+	if _, err := e.config.Audit.Write(append(data, '\n')); err != nil {
+		e.config.Logger.Printf("failed to write audit entry: %v", err)
+	}
+}
+
+// Watch applies the policy in the file at path, and reloads it whenever the
+// content of the file changes until ctx ends. Once Watch is running, a
+// policy that fails to load is logged and the one in force stays, so that a
+// bad edit does not open or close everything.
+func (e *Engine) Watch(ctx context.Context, path string) error {
+	last, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	// The file may have changed since the engine was created
+	p, err := Parse(last)
+	if err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+	e.SetPolicy(p)
+
+	ticker := time.NewTicker(e.config.ReloadInterval)
+	defer ticker.Stop()
+	missing := false
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			// Editors may replace the file, so it is missing for a moment.
+			// The failure is logged once.
+			if !missing {
+				e.config.Logger.Printf("keeping the current policy: %v", err)
+			}
+			missing = true
+			continue
+		}
+		missing = false
+		if bytes.Equal(data, last) {
+			continue
+		}
+		last = data
+		p, err := Parse(data)
+		if err != nil {
+			e.config.Logger.Printf("keeping the current policy, %s: %v", path, err)
+			continue
+		}
+		e.SetPolicy(p)
+		e.config.Logger.Printf("reloaded %s with %d rules", path, len(p.Rules))
+	}
+}
+
+// clientIdentity returns the identity of the caller: the subject of its
+// principal, or nothing if it did not authenticate. The name a client
+// initialized with is not used, since any client may claim any name.
+func clientIdentity(ctx context.Context) string {
+	if p := auth.PrincipalFromContext(ctx); p != nil {
+		return p.Subject
+	}
+	return ""
+}
@@ -0,0 +1,335 @@
+// Package policy decides which tool calls may run from rules kept in a
+// file. A rule matches calls by tool name, client and argument values, and
+// allows them, denies them or asks the user to confirm them:
+//
+//	{
+//	  "default": "allow",
+//	  "rules": [
+//	    {
+//	      "name": "no-destructive-sql",
+//	      "tools": ["query"],
+//	      "arguments": {"sql": "(?i)\\b(DROP|DELETE)\\b"},
+//	      "effect": "deny",
+//	      "reason": "Queries may not drop or delete data"
+//	    },
+//	    {"name": "confirm-deletes", "tools": ["delete_*"], "effect": "confirm"},
+//	    {"name": "reporting-reads-only", "clients": ["reporting-*"], "readOnly": false, "effect": "deny"}
+//	  ]
+//	}
+//
+// The first rule that matches a call decides it. An Engine applies a policy
+// as a server.Policy, reloads it when its file changes, and writes an audit
+// entry explaining every decision.
+package policy
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Effect is what a rule does with the calls it matches
+type Effect string
+
+const (
+	// Allow lets the call run
+	Allow Effect = "allow"
+
+	// Deny rejects the call with an isError result telling the reason
+	Deny Effect = "deny"
+
+	// Confirm asks the user whether the call may run. Calls from clients
+	// that cannot ask the user are denied.
+	Confirm Effect = "confirm"
+)
+
+// valid reports whether e is a known effect
+func (e Effect) valid() bool {
+	return e == Allow || e == Deny || e == Confirm
+}
+
+// Policy is an ordered list of rules. Policies read with Parse or Load are
+// ready to use; check others with Compile.
+type Policy struct {
+	// Default is the effect on calls no rule matches, Allow if empty
+	Default Effect `json:"default,omitempty"`
+
+	// Rules are tried in order, and the first that matches decides
+	Rules []*Rule `json:"rules"`
+}
+
+// Rule matches tool calls. Empty fields match every call, and a rule
+// matches a call when all of its fields do.
+type Rule struct {
+	// Name identifies the rule in audit entries, "rule N" if empty
+	Name string `json:"name,omitempty"`
+
+	// Tools are patterns of tool names, as for path.Match, such as
+	// "delete_*"
+	Tools []string `json:"tools,omitempty"`
+
+	// Clients are patterns of client identities. A client is known by the
+	// subject of its auth.Principal, so only authenticated clients match;
+	// the name a client initializes with is its own claim and is not
+	// trusted.
+	Clients []string `json:"clients,omitempty"`
+
+	// Arguments maps argument names to regular expressions that must be
+	// found in their values. Strings are searched as they are and other
+	// values in JSON; use ^ and $ to match whole values. A call without one
+	// of the arguments does not match. Names match regardless of case, as
+	// tools decoding arguments into Go structs see them.
+	Arguments map[string]string `json:"arguments,omitempty"`
+
+	// ReadOnly matches tools by their read-only annotation. Tools that do
+	// not implement mcp.AnnotatedTool are not read-only.
+	ReadOnly *bool `json:"readOnly,omitempty"`
+
+	// Effect is what happens to the calls the rule matches
+	Effect Effect `json:"effect"`
+
+	// Reason explains the rule. It is shown to the model when a call is
+	// denied and to the user when one must be confirmed.
+	Reason string `json:"reason,omitempty"`
+
+	arguments map[string]*regexp.Regexp
+}
+
+// Call is a tool call as rules see it
+type Call struct {
+	// Tool is the name of the called tool
+	Tool string
+
+	// Client is the identity of the caller, empty if it did not
+	// authenticate
+	Client string
+
+	// ReadOnly reports whether the tool is annotated as read-only
+	ReadOnly bool
+
+	// Arguments are the arguments of the call
+	Arguments json.RawMessage
+}
+
+// Decision is the outcome of a policy for a call
+type Decision struct {
+	// Effect is the effect of the deciding rule, or the default
+	Effect Effect
+
+	// Rule is the name of the deciding rule, empty for the default
+	Rule string
+
+	// Reason is the reason of the deciding rule, if it has one
+	Reason string
+
+	// Explanation tells why the rule matched, such as
+	// `tool "delete_entities" matches "delete_*"`
+	Explanation string
+}
+
+// Parse reads a policy in JSON and checks its rules. Unknown fields are
+// rejected, so that a misspelt condition does not widen a rule.
+func Parse(data []byte) (*Policy, error) {
+	var p Policy
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&p); err != nil {
+		return nil, fmt.Errorf("invalid policy: %v", err)
+	}
+	if err := p.Compile(); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// Load reads a policy from a JSON file
+func Load(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	p, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return p, nil
+}
+
+// Compile checks the policy, names its unnamed rules and compiles their
+// argument patterns. Patterns of rules that were not compiled are compiled
+// on every call, and invalid ones match nothing.
+func (p *Policy) Compile() error {
+	if p.Default == "" {
+		p.Default = Allow
+	}
+	if !p.Default.valid() {
+		return fmt.Errorf("invalid default effect %q, expected allow, deny or confirm", p.Default)
+	}
+	for i, r := range p.Rules {
+		if r == nil {
+			return fmt.Errorf("rule %d is empty", i+1)
+		}
+		if r.Name == "" {
+			r.Name = fmt.Sprintf("rule %d", i+1)
+		}
+		if !r.Effect.valid() {
+			return fmt.Errorf("%s: invalid effect %q, expected allow, deny or confirm", r.Name, r.Effect)
+		}
+		for _, pattern := range append(append([]string(nil), r.Tools...), r.Clients...) {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("%s: invalid pattern %q", r.Name, pattern)
+			}
+		}
+		r.arguments = make(map[string]*regexp.Regexp, len(r.Arguments))
+		for name, expr := range r.Arguments {
+			re, err := regexp.Compile(expr)
+			if err != nil {
+				return fmt.Errorf("%s: argument %s: %v", r.Name, name, err)
+			}
+			r.arguments[name] = re
+		}
+	}
+	return nil
+}
+
+// Decide returns the decision of the policy for a call. A nil policy
+// allows every call. Calls with two arguments whose names differ only in
+// case are denied, since rules could check one while the tool reads the
+// other.
+func (p *Policy) Decide(call *Call) Decision {
+	if p == nil {
+		return Decision{Effect: Allow, Explanation: "no policy"}
+	}
+	args, err := foldArguments(call.Arguments)
+	if err != nil {
+		return Decision{Effect: Deny, Reason: err.Error(), Explanation: err.Error()}
+	}
+	for _, r := range p.Rules {
+		if explanation, ok := r.match(call, args); ok {
+			return Decision{Effect: r.Effect, Rule: r.Name, Reason: r.Reason, Explanation: explanation}
+		}
+	}
+	effect := p.Default
+	if effect == "" {
+		effect = Allow
+	}
+	return Decision{Effect: effect, Explanation: "no rule matches"}
+}
+
+// match reports whether the rule matches a call, and explains why
+func (r *Rule) match(call *Call, args map[string]json.RawMessage) (string, bool) {
+	var why []string
+	if len(r.Tools) > 0 {
+		pattern, ok := matchAny(r.Tools, call.Tool)
+		if !ok {
+			return "", false
+		}
+		why = append(why, fmt.Sprintf("tool %q matches %q", call.Tool, pattern))
+	}
+	if len(r.Clients) > 0 {
+		pattern, ok := matchAny(r.Clients, call.Client)
+		if !ok {
+			return "", false
+		}
+		why = append(why, fmt.Sprintf("client %q matches %q", call.Client, pattern))
+	}
+	if r.ReadOnly != nil {
+		if call.ReadOnly != *r.ReadOnly {
+			return "", false
+		}
+		if call.ReadOnly {
+			why = append(why, "tool is read-only")
+		} else {
+			why = append(why, "tool is not read-only")
+		}
+	}
+	names := make([]string, 0, len(r.Arguments))
+	for name := range r.Arguments {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		raw, ok := args[foldName(name)]
+		if !ok {
+			return "", false
+		}
+		value := string(raw)
+		var s string
+		if json.Unmarshal(raw, &s) == nil {
+			value = s
+		}
+		re := r.pattern(name)
+		if re == nil || !re.MatchString(value) {
+			return "", false
+		}
+		why = append(why, fmt.Sprintf("argument %s matches %q", name, re.String()))
+	}
+	if len(why) == 0 {
+		return "rule matches every call", true
+	}
+	return strings.Join(why, ", "), true
+}
+
+// foldArguments decodes the arguments of a call by their names folded with
+// foldName. It fails if two names fold to the same one.
+func foldArguments(arguments json.RawMessage) (map[string]json.RawMessage, error) {
+	var raw map[string]json.RawMessage
+	json.Unmarshal(arguments, &raw)
+	names := make([]string, 0, len(raw))
+	for name := range raw {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	args := make(map[string]json.RawMessage, len(raw))
+	seen := make(map[string]string, len(raw))
+	for _, name := range names {
+		folded := foldName(name)
+		if other, ok := seen[folded]; ok {
+			return nil, fmt.Errorf("arguments %q and %q differ only in case", other, name)
+		}
+		seen[folded] = name
+		args[folded] = raw[name]
+	}
+	return args, nil
+}
+
+// foldName maps every letter of name to the smallest letter it equals
+// ignoring case, such as "K" for "k" and the Kelvin sign, so that names
+// encoding/json matches to the same field fold to the same string
+func foldName(name string) string {
+	return strings.Map(func(r rune) rune {
+		folded := r
+		for f := unicode.SimpleFold(r); f != r; f = unicode.SimpleFold(f) {
+			if f < folded {
+				folded = f
+			}
+		}
+		return folded
+	}, name)
+}
+
+// pattern returns the compiled pattern of an argument, or nil if it is
+// invalid
+func (r *Rule) pattern(name string) *regexp.Regexp {
+	if re, ok := r.arguments[name]; ok {
+		return re
+	}
+	re, _ := regexp.Compile(r.Arguments[name])
+	return re
+}
+
+// matchAny returns the first pattern that matches name
+func matchAny(patterns []string, name string) (string, bool) {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return pattern, true
+		}
+	}
+	return "", false
+}
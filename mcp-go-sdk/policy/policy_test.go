@@ -0,0 +1,391 @@
+package policy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"mcp-go-sdk"
+	"mcp-go-sdk/auth"
+	"mcp-go-sdk/client"
+	"mcp-go-sdk/server"
+	"mcp-go-sdk/transport"
+)
+
+const testPolicy = `{
+	"default": "allow",
+	"rules": [
+		{
+			"name": "no-destructive-sql",
+			"tools": ["query"],
+			"arguments": {"sql": "(?i)\\b(DROP|DELETE)\\b"},
+			"effect": "deny",
+			"reason": "Queries may not drop or delete data"
+		},
+		{"name": "confirm-deletes", "tools": ["delete_*"], "effect": "confirm"},
+		{"name": "reporting-reads-only", "clients": ["reporting-*"], "readOnly": false, "effect": "deny"},
+		{"tools": ["limit"], "arguments": {"n": "^[0-9]{1,2}$"}, "effect": "allow"},
+		{"tools": ["limit"], "effect": "deny"}
+	]
+}`
+
+func TestDecide(t *testing.T) {
+	p, err := Parse([]byte(testPolicy))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	tests := []struct {
+		call   Call
+		effect Effect
+		rule   string
+	}{
+		{Call{Tool: "query", Arguments: json.RawMessage(`{"sql":"drop table users"}`)}, Deny, "no-destructive-sql"},
+		{Call{Tool: "query", Arguments: json.RawMessage(`{"sql":"SELECT deleted FROM users"}`)}, Allow, ""},
+		{Call{Tool: "query"}, Allow, ""},
+		{Call{Tool: "delete_entities"}, Confirm, "confirm-deletes"},
+		{Call{Tool: "read_graph", Client: "reporting-bot", ReadOnly: true}, Allow, ""},
+		{Call{Tool: "create_entities", Client: "reporting-bot"}, Deny, "reporting-reads-only"},
+		{Call{Tool: "create_entities", Client: "desktop"}, Allow, ""},
+		{Call{Tool: "limit", Arguments: json.RawMessage(`{"n":42}`)}, Allow, "rule 4"},
+		{Call{Tool: "limit", Arguments: json.RawMessage(`{"n":420}`)}, Deny, "rule 5"},
+		// Argument names match regardless of case, as tools decode them
+		{Call{Tool: "query", Arguments: json.RawMessage(`{"SQL":"drop table users"}`)}, Deny, "no-destructive-sql"},
+		{Call{Tool: "query", Arguments: json.RawMessage(`{"\u017fql":"drop table users"}`)}, Deny, "no-destructive-sql"},
+		{Call{Tool: "limit", Arguments: json.RawMessage(`{"N":42}`)}, Allow, "rule 4"},
+		// Names that differ only in case could hide a value from the rules
+		{Call{Tool: "query", Arguments: json.RawMessage(`{"sql":"SELECT 1","Sql":"DROP TABLE users"}`)}, Deny, ""},
+		{Call{Tool: "limit", Arguments: json.RawMessage(`{"n":42,"N":420}`)}, Deny, ""},
+	}
+	for _, tt := range tests {
+		d := p.Decide(&tt.call)
+		if d.Effect != tt.effect || d.Rule != tt.rule {
+			t.Errorf("Expected %s by %q for %+v, got %s by %q (%s)", tt.effect, tt.rule, tt.call, d.Effect, d.Rule, d.Explanation)
+		}
+	}
+
+	d := p.Decide(&Call{Tool: "query", Arguments: json.RawMessage(`{"sql":"DELETE FROM users"}`)})
+	want := `tool "query" matches "query", argument sql matches "(?i)\\b(DROP|DELETE)\\b"`
+	if d.Explanation != want {
+		t.Errorf("Expected explanation %s, got %s", want, d.Explanation)
+	}
+
+	d = p.Decide(&Call{Tool: "query", Arguments: json.RawMessage(`{"sql":"SELECT 1","Sql":"DROP TABLE users"}`)})
+	if want := `arguments "Sql" and "sql" differ only in case`; d.Reason != want {
+		t.Errorf("Expected reason %s, got %s", want, d.Reason)
+	}
+
+	// Policies built in Go work without Compile
+	built := &Policy{Rules: []*Rule{{Arguments: map[string]string{"path": "^/etc/"}, Effect: Deny}}}
+	if d := built.Decide(&Call{Tool: "read", Arguments: json.RawMessage(`{"path":"/etc/passwd"}`)}); d.Effect != Deny {
+		t.Errorf("Expected an uncompiled rule to deny, got %+v", d)
+	}
+	if d := built.Decide(&Call{Tool: "read"}); d.Effect != Allow {
+		t.Errorf("Expected the empty default to allow, got %+v", d)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		policy string
+		want   string
+	}{
+		{`{"rules":[{"tool":["query"],"effect":"deny"}]}`, "unknown field"},
+		{`{"default":"maybe","rules":[]}`, "invalid default effect"},
+		{`{"rules":[{"name":"r","effect":"block"}]}`, `r: invalid effect "block"`},
+		{`{"rules":[{"tools":["[query"],"effect":"deny"}]}`, "invalid pattern"},
+		{`{"rules":[{"arguments":{"sql":"(DROP"},"effect":"deny"}]}`, "rule 1: argument sql"},
+		{`{"rules":[null]}`, "rule 1 is empty"},
+	}
+	for _, tt := range tests {
+		_, err := Parse([]byte(tt.policy))
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Expected an error containing %q for %s, got %v", tt.want, tt.policy, err)
+		}
+	}
+}
+
+// namedTool answers with its name, followed by " (confirmed)" if the user
+// confirmed the call
+type namedTool struct {
+	name     string
+	readOnly bool
+}
+
+func (t *namedTool) Name() string            { return t.name }
+func (t *namedTool) Description() string     { return "Answers with its name" }
+func (t *namedTool) Schema() json.RawMessage { return json.RawMessage(`{"type":"object"}`) }
+func (t *namedTool) Annotations() mcp.ToolAnnotations {
+	return mcp.ToolAnnotations{ReadOnlyHint: t.readOnly}
+}
+func (t *namedTool) Execute(params json.RawMessage) (interface{}, error) {
+	return t.ExecuteContext(context.Background(), params)
+}
+func (t *namedTool) ExecuteContext(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	text := t.name
+	if server.Confirmed(ctx) {
+		text += " (confirmed)"
+	}
+	return mcp.CallToolResult{Content: []mcp.ToolContent{{Type: "text", Text: text}}}, nil
+}
+
+// syncBuffer is a buffer safe for concurrent use
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+// entries decodes the audit entries written so far
+func (b *syncBuffer) entries(t *testing.T) []Entry {
+	t.Helper()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var entries []Entry
+	decoder := json.NewDecoder(bytes.NewReader(b.buf.Bytes()))
+	for decoder.More() {
+		var e Entry
+		if err := decoder.Decode(&e); err != nil {
+			t.Fatalf("Invalid audit entry: %v", err)
+		}
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+// startServer serves the tools under engine and connects a client named
+// name. confirm, if not nil, answers confirmations.
+func startServer(t *testing.T, engine *Engine, name, subject string, confirm func() bool) *client.Client {
+	t.Helper()
+	clientEnd, serverEnd := transport.NewInMemoryPair()
+	// Calls are made as the principal named subject, if any, as they would
+	// be behind authentication
+	policy := server.PolicyFunc(func(ctx context.Context, call *server.ToolCall) error {
+		if subject != "" {
+			ctx = auth.ContextWithPrincipal(ctx, &auth.Principal{Subject: subject})
+		}
+		return engine.Authorize(ctx, call)
+	})
+	srv := server.NewServerWithConfig(nil, &server.Config{Policy: policy})
+	srv.RegisterTool(&namedTool{name: "query", readOnly: true})
+	srv.RegisterTool(&namedTool{name: "delete_entities"})
+	srv.RegisterTool(&namedTool{name: "create_entities"})
+	go srv.ServeTransport(serverEnd)
+
+	c := client.NewClient(clientEnd, &client.Config{ClientInfo: mcp.ClientInfo{Name: name, Version: "1.0.0"}})
+	if confirm != nil {
+		c.HandleElicitation(func(ctx context.Context, p *mcp.ElicitParams) (*mcp.ElicitResult, error) {
+			return &mcp.ElicitResult{Action: mcp.ElicitAccept, Content: map[string]interface{}{"allow": confirm()}}, nil
+		})
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := c.Initialize(ctx); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+// callTool calls a tool and returns the text of its result and whether it
+// is an error
+func callTool(t *testing.T, c *client.Client, name string, args interface{}) (string, bool) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	result, err := c.CallTool(ctx, name, args)
+	if err != nil {
+		t.Fatalf("CallTool %s failed: %v", name, err)
+	}
+	return result.Content[0].Text, result.IsError
+}
+
+func TestEngine(t *testing.T) {
+	p, err := Parse([]byte(testPolicy))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	audit := &syncBuffer{}
+	engine := NewEngine(p, &Config{Audit: audit, AuditArguments: true})
+
+	answers := make(chan bool, 2)
+	answers <- true
+	answers <- false
+	desktop := startServer(t, engine, "desktop", "desktop", func() bool { return <-answers })
+
+	if text, isError := callTool(t, desktop, "query", map[string]string{"sql": "SELECT 1"}); isError || text != "query" {
+		t.Errorf("Expected the query to run, got %q", text)
+	}
+	text, isError := callTool(t, desktop, "query", map[string]string{"sql": "DROP TABLE users"})
+	if !isError || text != "tool query denied by policy rule no-destructive-sql: Queries may not drop or delete data" {
+		t.Errorf("Expected the query to be denied with the reason, got %q", text)
+	}
+	if text, isError := callTool(t, desktop, "delete_entities", nil); isError || text != "delete_entities (confirmed)" {
+		t.Errorf("Expected the confirmed deletion to run knowing it was confirmed, got %q", text)
+	}
+	if text, isError := callTool(t, desktop, "delete_entities", nil); !isError || !strings.Contains(text, "not confirmed") {
+		t.Errorf("Expected the declined deletion to be denied, got %q", text)
+	}
+
+	// Clients that cannot ask the user are denied calls needing confirmation
+	reporting := startServer(t, engine, "reporting-bot", "reporting-bot", nil)
+	if text, isError := callTool(t, reporting, "delete_entities", nil); !isError || !strings.Contains(text, "cannot ask the user") {
+		t.Errorf("Expected the deletion to be denied, got %q", text)
+	}
+	if text, isError := callTool(t, reporting, "create_entities", nil); !isError || !strings.Contains(text, "tool is not read-only") {
+		t.Errorf("Expected the write to be denied with the explanation, got %q", text)
+	}
+	if _, isError := callTool(t, reporting, "query", map[string]string{"sql": "SELECT 1"}); isError {
+		t.Error("Expected the read-only tool to be allowed")
+	}
+
+	// A client that did not authenticate is not known by the name it
+	// claims
+	spoofed := startServer(t, engine, "reporting-bot", "", nil)
+	if text, isError := callTool(t, spoofed, "create_entities", nil); isError {
+		t.Errorf("Expected the clients rule not to match a claimed name, got %q", text)
+	}
+
+	entries := audit.entries(t)
+	if len(entries) != 8 {
+		t.Fatalf("Expected 8 audit entries, got %d", len(entries))
+	}
+	denied := entries[1]
+	if denied.Allowed || denied.Effect != Deny || denied.Rule != "no-destructive-sql" || denied.Client != "desktop" ||
+		denied.Tool != "query" || denied.Session == "" || !strings.Contains(string(denied.Arguments), "DROP") {
+		t.Errorf("Unexpected audit entry %+v", denied)
+	}
+	if e := entries[2]; !e.Allowed || e.Effect != Confirm || !strings.HasSuffix(e.Explanation, "confirmed by the user") {
+		t.Errorf("Expected the confirmation in the audit entry, got %+v", e)
+	}
+	if e := entries[3]; e.Allowed || !strings.HasSuffix(e.Explanation, "not confirmed by the user") {
+		t.Errorf("Expected the declined confirmation in the audit entry, got %+v", e)
+	}
+	if e := entries[0]; !e.Allowed || e.Rule != "" || e.Explanation != "no rule matches" {
+		t.Errorf("Expected the default in the audit entry, got %+v", e)
+	}
+	if e := entries[7]; e.Client != "" || e.ClientName != "reporting-bot" {
+		t.Errorf("Expected the claimed name apart from the identity, got %+v", e)
+	}
+}
+
+func TestClientIdentity(t *testing.T) {
+	ctx := auth.ContextWithPrincipal(context.Background(), &auth.Principal{Subject: "reporting-service"})
+	if id := clientIdentity(ctx); id != "reporting-service" {
+		t.Errorf("Expected the principal's subject, got %q", id)
+	}
+	if id := clientIdentity(context.Background()); id != "" {
+		t.Errorf("Expected no identity, got %q", id)
+	}
+}
+
+func TestWatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.json")
+	write := func(data string) {
+		if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(`{"rules":[{"tools":["query"],"effect":"deny"}]}`)
+	p, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	logs := &syncBuffer{}
+	engine := NewEngine(p, &Config{ReloadInterval: 10 * time.Millisecond, Logger: log.New(logs, "", 0)})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- engine.Watch(ctx, path) }()
+	defer func() {
+		cancel()
+		if err := <-done; err != nil {
+			t.Errorf("Watch failed: %v", err)
+		}
+	}()
+
+	decide := func() Effect { return engine.Policy().Decide(&Call{Tool: "query"}).Effect }
+	waitFor := func(what string, ok func() bool) {
+		t.Helper()
+		deadline := time.Now().Add(2 * time.Second)
+		for !ok() {
+			if time.Now().After(deadline) {
+				t.Fatalf("Timed out waiting for %s", what)
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+
+	write(`{"rules":[{"tools":["query"],"effect":"allow"}]}`)
+	waitFor("the reload", func() bool { return decide() == Allow })
+
+	// A broken policy keeps the one in force
+	write(`{"rules":[{"tools":["query"],"effect":"deny"`)
+	waitFor("the failure to be logged", func() bool {
+		logs.mu.Lock()
+		defer logs.mu.Unlock()
+		return strings.Contains(logs.buf.String(), "keeping the current policy")
+	})
+	if decide() != Allow {
+		t.Error("Expected the policy in force to stay")
+	}
+
+	write(`{"default":"deny","rules":[]}`)
+	waitFor("the second reload", func() bool { return decide() == Deny })
+}
+
+var _ server.Policy = (*Engine)(nil)
+var _ io.Writer = (*syncBuffer)(nil)
+
+func TestOpenEngine(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "policy.json")
+	auditPath := filepath.Join(dir, "audit.log")
+	if err := os.WriteFile(path, []byte(`{"rules":[{"tools":["query"],"effect":"deny"}]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(auditPath, []byte("{}\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if _, err := OpenEngine(ctx, filepath.Join(dir, "missing.json"), auditPath); err == nil {
+		t.Error("Expected a missing policy to fail")
+	}
+	engine, err := OpenEngine(ctx, path, auditPath)
+	if err != nil {
+		t.Fatalf("OpenEngine failed: %v", err)
+	}
+	c := startServer(t, engine, "desktop", "desktop", nil)
+	if _, isError := callTool(t, c, "query", nil); !isError {
+		t.Error("Expected the query to be denied")
+	}
+
+	// Entries are appended to the audit log
+	data, err := os.ReadFile(auditPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 || lines[0] != "{}" {
+		t.Fatalf("Expected one entry after the existing line, got %q", data)
+	}
+	var entry Entry
+	if err := json.Unmarshal([]byte(lines[1]), &entry); err != nil || entry.Allowed || entry.Tool != "query" {
+		t.Errorf("Unexpected audit entry %s", lines[1])
+	}
+}
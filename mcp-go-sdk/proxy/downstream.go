@@ -0,0 +1,332 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"mcp-go-sdk"
+	"mcp-go-sdk/client"
+	"mcp-go-sdk/server"
+)
+
+// Notifications the proxy reacts to or forwards
+const (
+	notificationRootsChanged     = "notifications/roots/list_changed"
+	notificationResourcesUpdated = "notifications/resources/updated"
+	notificationMessage          = "notifications/message"
+	notificationProgress         = "notifications/progress"
+)
+
+// errNotConnected is reported for a server that has not connected yet
+var errNotConnected = errors.New("not connected")
+
+// downstream is the proxy's connection to one server
+type downstream struct {
+	proxy  *Proxy
+	config ServerConfig
+
+	// mu guards the connection
+	mu     sync.Mutex
+	client *client.Client
+	err    error
+
+	// refreshing serializes catalog refreshes
+	refreshing sync.Mutex
+
+	// The catalog, guarded by the proxy's mu
+	tools     []mcp.ToolInfo
+	resources []mcp.Resource
+	prompts   []exposedPrompt
+
+	// Tool calls in flight: the number made by each session, and where the
+	// progress of each call goes by the token sent to the server
+	callsMu   sync.Mutex
+	callers   map[*server.Session]int
+	progress  map[int]progressRoute
+	nextToken int
+}
+
+// progressRoute is the session that made a call and the progress token it
+// sent with it
+type progressRoute struct {
+	session *server.Session
+	token   int
+}
+
+// current returns the open connection, or why there is none
+func (d *downstream) current() (*client.Client, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.client == nil {
+		if d.err == nil {
+			return nil, errNotConnected
+		}
+		return nil, d.err
+	}
+	return d.client, nil
+}
+
+// run keeps the server connected until the proxy closes. The outcome of the
+// first attempt is sent to ready.
+func (d *downstream) run(ready chan<- error) {
+	defer d.proxy.running.Done()
+	config := d.proxy.config
+	delay := config.RestartDelay
+
+	for {
+		c, err := d.connect()
+		if ready != nil {
+			if err != nil {
+				ready <- fmt.Errorf("%s: %w", d.config.Name, err)
+			} else {
+				ready <- nil
+			}
+			ready = nil
+		}
+
+		if err == nil {
+			delay = config.RestartDelay
+			config.Logger.Printf("%s: connected", d.config.Name)
+			select {
+			case <-c.Done():
+				err = c.Err()
+				if err == nil {
+					err = errors.New("server closed the connection")
+				}
+			case <-d.proxy.done:
+				return
+			}
+		}
+		if d.proxy.closing() {
+			return
+		}
+
+		d.mu.Lock()
+		d.client = nil
+		d.err = err
+		d.mu.Unlock()
+		config.Logger.Printf("%s: %v; reconnecting in %v", d.config.Name, err, delay)
+
+		select {
+		case <-time.After(delay):
+		case <-d.proxy.done:
+			return
+		}
+		delay = min(delay*2, config.MaxRestartDelay)
+	}
+}
+
+// connect opens, initializes and catalogs a new connection
+func (d *downstream) connect() (*client.Client, error) {
+	config := d.proxy.config
+	ctx, cancel := context.WithTimeout(context.Background(), config.ConnectTimeout)
+	defer cancel()
+
+	t, err := d.config.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	c := client.NewClient(t, &client.Config{
+		ClientInfo: mcp.ClientInfo{Name: config.Info.Name, Version: config.Info.Version},
+	})
+	d.handleNotifications(c)
+
+	if _, err := c.Initialize(ctx); err != nil {
+		c.Close()
+		return nil, fmt.Errorf("initialize failed: %w", err)
+	}
+	if err := d.refresh(ctx, c); err != nil {
+		c.Close()
+		return nil, err
+	}
+
+	// Close may have run while connecting, in which case nobody else will
+	// close this connection
+	d.mu.Lock()
+	if d.proxy.closing() {
+		d.mu.Unlock()
+		c.Close()
+		return nil, errors.New("proxy is closed")
+	}
+	d.client = c
+	d.err = nil
+	d.mu.Unlock()
+
+	// Clients connected to the proxy may have seen an older catalog
+	d.proxy.server.Notify(server.NotificationToolsListChanged, nil)
+	d.proxy.server.Notify(server.NotificationResourcesListChanged, nil)
+	d.proxy.server.Notify(server.NotificationPromptsListChanged, nil)
+	return c, nil
+}
+
+// startCall records a tool call made by the session of ctx. It returns the
+// progress token to send to the server, zero if the caller asked for no
+// progress, and a function to call when the call has finished.
+func (d *downstream) startCall(ctx context.Context) (int, func()) {
+	sess := server.SessionFromContext(ctx)
+	if sess == nil {
+		return 0, func() {}
+	}
+	upstream, wantsProgress := server.ProgressTokenFromContext(ctx)
+
+	d.callsMu.Lock()
+	defer d.callsMu.Unlock()
+	if d.callers == nil {
+		d.callers = make(map[*server.Session]int)
+		d.progress = make(map[int]progressRoute)
+	}
+	d.callers[sess]++
+	token := 0
+	if wantsProgress {
+		d.nextToken++
+		token = d.nextToken
+		d.progress[token] = progressRoute{session: sess, token: upstream}
+	}
+	return token, func() {
+		d.callsMu.Lock()
+		defer d.callsMu.Unlock()
+		if d.callers[sess]--; d.callers[sess] == 0 {
+			delete(d.callers, sess)
+		}
+		delete(d.progress, token)
+	}
+}
+
+// progressRoute returns where the progress reported under token goes
+func (d *downstream) progressRoute(token int) (progressRoute, bool) {
+	d.callsMu.Lock()
+	defer d.callsMu.Unlock()
+	route, ok := d.progress[token]
+	return route, ok
+}
+
+// caller returns the session with calls in flight on the server, or nil if
+// there is none or more than one
+func (d *downstream) caller() *server.Session {
+	d.callsMu.Lock()
+	defer d.callsMu.Unlock()
+	if len(d.callers) != 1 {
+		return nil
+	}
+	for sess := range d.callers {
+		return sess
+	}
+	return nil
+}
+
+// refresh lists everything the server offers
+func (d *downstream) refresh(ctx context.Context, c *client.Client) error {
+	d.refreshing.Lock()
+	defer d.refreshing.Unlock()
+
+	caps := c.InitializeResult().Capabilities
+	if caps.Tools != nil {
+		if err := d.refreshTools(ctx, c); err != nil {
+			return err
+		}
+	}
+	if caps.Resources != nil {
+		if err := d.refreshResources(ctx, c); err != nil {
+			return err
+		}
+	}
+	if caps.Prompts != nil {
+		if err := d.refreshPrompts(ctx, c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *downstream) refreshTools(ctx context.Context, c *client.Client) error {
+	tools, err := c.ListTools(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list tools: %w", err)
+	}
+	d.proxy.setTools(d, tools)
+	return nil
+}
+
+func (d *downstream) refreshResources(ctx context.Context, c *client.Client) error {
+	resources, err := c.ListResources(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list resources: %w", err)
+	}
+	d.proxy.setResources(d, resources)
+	return nil
+}
+
+func (d *downstream) refreshPrompts(ctx context.Context, c *client.Client) error {
+	prompts, err := c.ListPrompts(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list prompts: %w", err)
+	}
+	d.proxy.setPrompts(d, prompts)
+	return nil
+}
+
+// handleNotifications routes the server's notifications to the proxy's
+// clients
+func (d *downstream) handleNotifications(c *client.Client) {
+	relist := func(method string, refresh func(context.Context, *client.Client) error) {
+		c.HandleNotification(method, func(ctx context.Context, params json.RawMessage) {
+			// Listing needs the read loop this handler runs on
+			go func() {
+				ctx, cancel := context.WithTimeout(context.Background(), d.proxy.config.ConnectTimeout)
+				defer cancel()
+				d.refreshing.Lock()
+				err := refresh(ctx, c)
+				d.refreshing.Unlock()
+				if err != nil {
+					d.proxy.config.Logger.Printf("%s: %v", d.config.Name, err)
+					return
+				}
+				d.proxy.server.Notify(method, nil)
+			}()
+		})
+	}
+	relist(server.NotificationToolsListChanged, d.refreshTools)
+	relist(server.NotificationResourcesListChanged, d.refreshResources)
+	relist(server.NotificationPromptsListChanged, d.refreshPrompts)
+
+	c.HandleNotification(notificationResourcesUpdated, func(ctx context.Context, params json.RawMessage) {
+		d.proxy.server.Notify(notificationResourcesUpdated, params)
+	})
+	// Progress goes back to the call it belongs to, under the caller's own
+	// token
+	c.HandleNotification(notificationProgress, func(ctx context.Context, params json.RawMessage) {
+		var progress map[string]interface{}
+		if err := json.Unmarshal(params, &progress); err != nil {
+			return
+		}
+		token, _ := progress["progressToken"].(float64)
+		route, ok := d.progressRoute(int(token))
+		if !ok {
+			return
+		}
+		progress["progressToken"] = route.token
+		route.session.Notify(notificationProgress, progress)
+	})
+	// Log messages do not say which call they belong to. They go to the
+	// client only while a single session has calls on the server, so that
+	// no client sees another's messages, and to the proxy's log otherwise.
+	c.HandleNotification(notificationMessage, func(ctx context.Context, params json.RawMessage) {
+		sess := d.caller()
+		if sess == nil {
+			d.proxy.config.Logger.Printf("%s: %s", d.config.Name, params)
+			return
+		}
+		// Name the server in log messages that do not say where they come
+		// from
+		var msg map[string]interface{}
+		if err := json.Unmarshal(params, &msg); err == nil && msg["logger"] == nil {
+			msg["logger"] = d.config.Name
+			params, _ = json.Marshal(msg)
+		}
+		sess.Notify(notificationMessage, params)
+	})
+}
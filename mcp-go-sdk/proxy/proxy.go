@@ -0,0 +1,507 @@
+// Package proxy serves several MCP servers as one. The tools, resources and
+// prompts of the downstream servers are merged, optionally under a namespace
+// per server, and every request is routed to the server that owns the tool,
+// resource or prompt it names. Progress and cancellation of tool calls are
+// passed between the calling client and the server that runs the call.
+// Downstream servers that go away are reconnected in the background without
+// taking the proxy down.
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"mcp-go-sdk"
+	"mcp-go-sdk/server"
+	"mcp-go-sdk/transport"
+)
+
+// ServerConfig describes a downstream server
+type ServerConfig struct {
+	// Name identifies the server in logs and error messages
+	Name string
+
+	// Namespace is prepended to the names of the server's tools, resources
+	// and prompts, joined by Config.Separator. Empty exposes the names
+	// unchanged. Resource URIs are never rewritten.
+	Namespace string
+
+	// Connect opens a transport to the server. It is called again to
+	// reconnect after the server has gone away.
+	Connect func(ctx context.Context) (mcp.Transport, error)
+}
+
+// Command returns a Connect function that starts the server as a subprocess
+// with the given configuration
+func Command(config *transport.CommandConfig) func(ctx context.Context) (mcp.Transport, error) {
+	return func(ctx context.Context) (mcp.Transport, error) {
+		return transport.NewCommandTransport(config)
+	}
+}
+
+// Config represents configuration options for a proxy
+type Config struct {
+	// Info identifies the proxy to its clients and to the downstream servers
+	Info mcp.ServerInfo
+
+	// Separator joins namespaces and names
+	Separator string
+
+	// ConnectTimeout bounds connecting to and initializing a downstream
+	// server
+	ConnectTimeout time.Duration
+
+	// RestartDelay is how long the proxy waits before reconnecting to a
+	// server that went away. The delay doubles with every failed attempt up
+	// to MaxRestartDelay.
+	RestartDelay    time.Duration
+	MaxRestartDelay time.Duration
+
+	// Logger receives connection events and naming conflicts
+	Logger *log.Logger
+}
+
+// DefaultConfig returns the default proxy configuration
+func DefaultConfig() *Config {
+	return &Config{
+		Info: mcp.ServerInfo{
+			Name:    "MCP Proxy",
+			Version: "1.0.0",
+		},
+		Separator:       "_",
+		ConnectTimeout:  30 * time.Second,
+		RestartDelay:    time.Second,
+		MaxRestartDelay: 30 * time.Second,
+		Logger:          log.New(os.Stderr, "[proxy] ", log.LstdFlags),
+	}
+}
+
+// Proxy is an MCP server backed by several downstream servers
+type Proxy struct {
+	config  *Config
+	server  server.Server
+	servers []*downstream
+
+	// mu guards the catalog: the exposed names and what the downstream
+	// servers last listed
+	mu    sync.Mutex
+	tools map[string]*proxyTool
+
+	done      chan struct{}
+	closeOnce sync.Once
+	running   sync.WaitGroup
+}
+
+// New creates a proxy for the given servers. Zero fields of config take their
+// values from DefaultConfig.
+func New(servers []ServerConfig, config *Config) (*Proxy, error) {
+	config = withDefaults(config)
+
+	p := &Proxy{
+		config: config,
+		tools:  make(map[string]*proxyTool),
+		done:   make(chan struct{}),
+	}
+	p.server = server.NewServerWithConfig(nil, &server.Config{
+		Info: config.Info,
+		// Proxied calls only wait on their servers, so one slow call need
+		// not hold up the others
+		ConcurrentRequests: true,
+		Capabilities: &mcp.ServerCapabilities{
+			Tools:     &mcp.ToolsCapability{ListChanged: true},
+			Resources: &mcp.ResourcesCapability{ListChanged: true},
+			Prompts:   &mcp.PromptsCapability{ListChanged: true},
+		},
+	})
+
+	names := make(map[string]bool)
+	for _, sc := range servers {
+		if sc.Name == "" {
+			return nil, errors.New("server name is required")
+		}
+		if names[sc.Name] {
+			return nil, fmt.Errorf("server %s is configured twice", sc.Name)
+		}
+		if sc.Connect == nil {
+			return nil, fmt.Errorf("server %s has no Connect function", sc.Name)
+		}
+		names[sc.Name] = true
+		p.servers = append(p.servers, &downstream{proxy: p, config: sc})
+	}
+
+	if err := p.registerHandlers(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// withDefaults fills the zero fields of config from DefaultConfig
+func withDefaults(config *Config) *Config {
+	defaults := DefaultConfig()
+	if config == nil {
+		return defaults
+	}
+	c := *config
+	if c.Info.Name == "" {
+		c.Info = defaults.Info
+	}
+	if c.Separator == "" {
+		c.Separator = defaults.Separator
+	}
+	if c.ConnectTimeout <= 0 {
+		c.ConnectTimeout = defaults.ConnectTimeout
+	}
+	if c.RestartDelay <= 0 {
+		c.RestartDelay = defaults.RestartDelay
+	}
+	if c.MaxRestartDelay < c.RestartDelay {
+		c.MaxRestartDelay = max(defaults.MaxRestartDelay, c.RestartDelay)
+	}
+	if c.Logger == nil {
+		c.Logger = defaults.Logger
+	}
+	return &c
+}
+
+// Server returns the server clients connect to. Serve it with ServeTransport,
+// Serve or ListenAndServe after calling Start.
+func (p *Proxy) Server() server.Server {
+	return p.server
+}
+
+// Start connects to all downstream servers and waits until each has either
+// connected or failed its first attempt, so that clients see a complete
+// catalog. Servers that failed are retried in the background; their errors
+// are returned for reporting only. Start must be called once.
+func (p *Proxy) Start(ctx context.Context) error {
+	results := make(chan error, len(p.servers))
+	for _, d := range p.servers {
+		p.running.Add(1)
+		go d.run(results)
+	}
+
+	var errs []error
+	for range p.servers {
+		select {
+		case err := <-results:
+			if err != nil {
+				errs = append(errs, err)
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Close disconnects from the downstream servers and stops the proxy's server
+func (p *Proxy) Close() error {
+	p.closeOnce.Do(func() {
+		close(p.done)
+		for _, d := range p.servers {
+			if c, _ := d.current(); c != nil {
+				c.Close()
+			}
+		}
+		p.running.Wait()
+	})
+	return p.server.Stop()
+}
+
+// closing reports whether Close has been called
+func (p *Proxy) closing() bool {
+	select {
+	case <-p.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// exposedName returns the name under which a downstream name is exposed
+func (p *Proxy) exposedName(d *downstream, name string) string {
+	if d.config.Namespace == "" {
+		return name
+	}
+	return d.config.Namespace + p.config.Separator + name
+}
+
+// setTools replaces the tools listed by d. The exposed names are worked out
+// again in the order of the configured servers, so that a name claimed by two
+// servers goes to the one configured first. Only the differences are applied
+// to the proxy's server: tools that stay are updated in place, so that
+// tools/list never misses them, and new tools are listed after them.
+func (p *Proxy) setTools(d *downstream, tools []mcp.ToolInfo) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	d.tools = tools
+
+	var want []*proxyTool
+	owners := make(map[string]*downstream)
+	for _, d := range p.servers {
+		for _, info := range d.tools {
+			exposed := info
+			exposed.Name = p.exposedName(d, info.Name)
+			if owner, ok := owners[exposed.Name]; ok {
+				p.config.Logger.Printf("%s: tool %s is hidden by server %s", d.config.Name, exposed.Name, owner.config.Name)
+				continue
+			}
+			owners[exposed.Name] = d
+			want = append(want, &proxyTool{server: d, name: info.Name, info: exposed})
+		}
+	}
+
+	for name := range p.tools {
+		if owners[name] == nil {
+			p.server.UnregisterTool(name)
+			delete(p.tools, name)
+		}
+	}
+	for _, t := range want {
+		if current, ok := p.tools[t.info.Name]; ok {
+			current.update(t)
+			continue
+		}
+		if err := p.server.RegisterTool(t); err != nil {
+			p.config.Logger.Printf("%s: failed to register tool %s: %v", t.server.config.Name, t.info.Name, err)
+			continue
+		}
+		p.tools[t.info.Name] = t
+	}
+}
+
+// setResources replaces the resources listed by d
+func (p *Proxy) setResources(d *downstream, resources []mcp.Resource) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	d.resources = make([]mcp.Resource, len(resources))
+	for i, r := range resources {
+		r.Name = p.exposedName(d, r.Name)
+		d.resources[i] = r
+	}
+}
+
+// setPrompts replaces the prompts listed by d
+func (p *Proxy) setPrompts(d *downstream, prompts []mcp.Prompt) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	d.prompts = make([]exposedPrompt, len(prompts))
+	for i, pr := range prompts {
+		original := pr.Name
+		pr.Name = p.exposedName(d, pr.Name)
+		d.prompts[i] = exposedPrompt{name: original, prompt: pr}
+	}
+}
+
+// registerHandlers installs the methods and notifications the proxy serves
+// besides tools
+func (p *Proxy) registerHandlers() error {
+	handlers := map[string]server.MethodHandler{
+		server.MethodListResources: server.TypedMethod(p.listResources),
+		server.MethodReadResource:  server.TypedMethod(p.readResource),
+		server.MethodListPrompts:   server.TypedMethod(p.listPrompts),
+		server.MethodGetPrompt:     server.TypedMethod(p.getPrompt),
+	}
+	for method, handler := range handlers {
+		if err := p.server.HandleMethod(method, handler); err != nil {
+			return err
+		}
+	}
+	return p.server.HandleNotification(notificationRootsChanged, p.forwardRootsChanged)
+}
+
+// listResources merges the resources of all servers into a single page
+func (p *Proxy) listResources(ctx context.Context, params mcp.PaginatedParams) (*mcp.ListResourcesResult, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	result := &mcp.ListResourcesResult{Resources: []mcp.Resource{}}
+	for _, d := range p.servers {
+		result.Resources = append(result.Resources, d.resources...)
+	}
+	return result, nil
+}
+
+// readResource reads a resource from the server that listed its URI
+func (p *Proxy) readResource(ctx context.Context, params mcp.ReadResourceParams) (*mcp.ReadResourceResult, error) {
+	d := p.resourceOwner(params.URI)
+	if d == nil {
+		return nil, &mcp.Error{Code: server.ErrInvalidParams, Message: "Unknown resource", Data: params.URI}
+	}
+	c, err := d.current()
+	if err != nil {
+		return nil, unavailableError(d, err)
+	}
+	return c.ReadResource(ctx, params.URI)
+}
+
+// resourceOwner returns the server that listed uri, or nil
+func (p *Proxy) resourceOwner(uri string) *downstream {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, d := range p.servers {
+		for _, r := range d.resources {
+			if r.URI == uri {
+				return d
+			}
+		}
+	}
+	return nil
+}
+
+// listPrompts merges the prompts of all servers into a single page
+func (p *Proxy) listPrompts(ctx context.Context, params mcp.PaginatedParams) (*mcp.ListPromptsResult, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	result := &mcp.ListPromptsResult{Prompts: []mcp.Prompt{}}
+	for _, d := range p.servers {
+		for _, pr := range d.prompts {
+			result.Prompts = append(result.Prompts, pr.prompt)
+		}
+	}
+	return result, nil
+}
+
+// getPrompt renders a prompt on the server that listed it
+func (p *Proxy) getPrompt(ctx context.Context, params mcp.GetPromptParams) (*mcp.GetPromptResult, error) {
+	d, name := p.promptOwner(params.Name)
+	if d == nil {
+		return nil, &mcp.Error{Code: server.ErrInvalidParams, Message: "Unknown prompt", Data: params.Name}
+	}
+	c, err := d.current()
+	if err != nil {
+		return nil, unavailableError(d, err)
+	}
+	return c.GetPrompt(ctx, name, params.Arguments)
+}
+
+// promptOwner returns the server that listed the exposed prompt name and the
+// prompt's name on that server
+func (p *Proxy) promptOwner(exposed string) (*downstream, string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, d := range p.servers {
+		for _, pr := range d.prompts {
+			if pr.prompt.Name == exposed {
+				return d, pr.name
+			}
+		}
+	}
+	return nil, ""
+}
+
+// forwardRootsChanged tells every connected server that the client's roots
+// have changed
+func (p *Proxy) forwardRootsChanged(ctx context.Context, params json.RawMessage) error {
+	for _, d := range p.servers {
+		if c, _ := d.current(); c != nil {
+			c.Notify(notificationRootsChanged, params)
+		}
+	}
+	return nil
+}
+
+// exposedPrompt is a prompt as listed by the proxy together with its name on
+// the downstream server
+type exposedPrompt struct {
+	name   string
+	prompt mcp.Prompt
+}
+
+// proxyTool exposes a tool of a downstream server. Its exposed name never
+// changes, but the rest is replaced when the server lists the tool again.
+type proxyTool struct {
+	mu     sync.RWMutex
+	server *downstream
+	name   string
+	info   mcp.ToolInfo
+}
+
+func (t *proxyTool) Name() string { return t.info.Name }
+
+func (t *proxyTool) Description() string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.info.Description
+}
+
+func (t *proxyTool) Schema() json.RawMessage {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.info.InputSchema
+}
+
+// update takes the server, name and description of another tool exposed
+// under the same name
+func (t *proxyTool) update(other *proxyTool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.server, t.name, t.info = other.server, other.name, other.info
+}
+
+// Execute implements mcp.Tool
+func (t *proxyTool) Execute(params json.RawMessage) (interface{}, error) {
+	return t.ExecuteContext(context.Background(), params)
+}
+
+// ExecuteContext calls the tool on its server and passes the result through
+// unchanged. Cancelling ctx cancels the call on the server, and progress the
+// server reports is sent to the session that made the call. While the
+// server is unavailable the call fails with a tool error, so the model sees
+// why.
+func (t *proxyTool) ExecuteContext(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	t.mu.RLock()
+	d, name := t.server, t.name
+	t.mu.RUnlock()
+
+	c, err := d.current()
+	if err != nil {
+		return unavailableResult(d, err), nil
+	}
+
+	args := map[string]interface{}{"name": name}
+	if len(params) > 0 {
+		args["arguments"] = params
+	}
+	token, done := d.startCall(ctx)
+	defer done()
+	if token != 0 {
+		args["_meta"] = &mcp.CallToolMeta{ProgressToken: token}
+	}
+
+	var result json.RawMessage
+	err = c.Call(ctx, server.MethodCallTool, args, &result)
+	var rpcErr *mcp.Error
+	if errors.As(err, &rpcErr) {
+		return nil, rpcErr
+	}
+	if err != nil {
+		return unavailableResult(d, err), nil
+	}
+	return result, nil
+}
+
+// unavailableResult is the tool result for a server that cannot be reached
+func unavailableResult(d *downstream, err error) *mcp.CallToolResult {
+	return &mcp.CallToolResult{
+		Content: []mcp.ToolContent{{
+			Type: "text",
+			Text: fmt.Sprintf("server %s is unavailable: %v", d.config.Name, err),
+		}},
+		IsError: true,
+	}
+}
+
+// unavailableError is the request error for a server that cannot be reached
+func unavailableError(d *downstream, err error) *mcp.Error {
+	return &mcp.Error{
+		Code:    server.ErrInternal,
+		Message: "Server unavailable",
+		Data:    fmt.Sprintf("%s: %v", d.config.Name, err),
+	}
+}
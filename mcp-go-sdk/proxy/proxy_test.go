@@ -0,0 +1,400 @@
+package proxy_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"mcp-go-sdk"
+	"mcp-go-sdk/client"
+	"mcp-go-sdk/proxy"
+	"mcp-go-sdk/server"
+	"mcp-go-sdk/transport"
+)
+
+// textTool implements mcp.Tool by answering with a fixed prefix and its input
+type textTool struct {
+	name   string
+	prefix string
+}
+
+func (t *textTool) Name() string            { return t.name }
+func (t *textTool) Description() string     { return "Answers with " + t.prefix }
+func (t *textTool) Schema() json.RawMessage { return json.RawMessage(`{"type":"object"}`) }
+func (t *textTool) Execute(params json.RawMessage) (interface{}, error) {
+	if t.name == "fail" {
+		return nil, &mcp.Error{Code: server.ErrInvalidParams, Message: "Bad input"}
+	}
+	return mcp.ToolResponse{Content: []mcp.ToolContent{{Type: "text", Text: t.prefix + string(params)}}}, nil
+}
+
+// fakeServer is a downstream server served over in-memory pairs. Every
+// connection gets a new session of the same server.
+type fakeServer struct {
+	server.Server
+
+	mu       sync.Mutex
+	connects int
+	conn     *transport.InMemoryTransport
+}
+
+func newFakeServer(tools ...mcp.Tool) *fakeServer {
+	f := &fakeServer{Server: server.NewServer(nil)}
+	for _, tool := range tools {
+		f.RegisterTool(tool)
+	}
+	return f
+}
+
+func (f *fakeServer) connect(ctx context.Context) (mcp.Transport, error) {
+	clientEnd, serverEnd := transport.NewInMemoryPair()
+	go f.ServeTransport(serverEnd)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.connects++
+	f.conn = serverEnd
+	return clientEnd, nil
+}
+
+// crash drops the current connection
+func (f *fakeServer) crash() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.conn.Close()
+}
+
+func (f *fakeServer) connections() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.connects
+}
+
+// startProxy starts a proxy for the servers and connects a client to it
+func startProxy(t *testing.T, servers ...proxy.ServerConfig) *client.Client {
+	t.Helper()
+	return connectClient(t, newProxy(t, servers...))
+}
+
+// newProxy starts a proxy for the servers
+func newProxy(t *testing.T, servers ...proxy.ServerConfig) *proxy.Proxy {
+	t.Helper()
+	p, err := proxy.New(servers, &proxy.Config{
+		RestartDelay: 10 * time.Millisecond,
+		Logger:       log.New(io.Discard, "", 0),
+	})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	if err := p.Start(testContext(t)); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	t.Cleanup(func() { p.Close() })
+	return p
+}
+
+// connectClient connects a new client to the proxy
+func connectClient(t *testing.T, p *proxy.Proxy) *client.Client {
+	t.Helper()
+	clientEnd, serverEnd := transport.NewInMemoryPair()
+	go p.Server().ServeTransport(serverEnd)
+	c := client.NewClient(clientEnd, nil)
+	t.Cleanup(func() { c.Close() })
+	if _, err := c.Initialize(testContext(t)); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	return c
+}
+
+func testContext(t *testing.T) context.Context {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	t.Cleanup(cancel)
+	return ctx
+}
+
+func toolNames(t *testing.T, c *client.Client) []string {
+	t.Helper()
+	tools, err := c.ListTools(testContext(t))
+	if err != nil {
+		t.Fatalf("ListTools failed: %v", err)
+	}
+	var names []string
+	for _, tool := range tools {
+		names = append(names, tool.Name)
+	}
+	return names
+}
+
+func callText(t *testing.T, c *client.Client, name string) (string, bool) {
+	t.Helper()
+	result, err := c.CallTool(testContext(t), name, map[string]int{"n": 1})
+	if err != nil {
+		t.Fatalf("CallTool %s failed: %v", name, err)
+	}
+	if len(result.Content) != 1 {
+		t.Fatalf("Unexpected result %+v", result)
+	}
+	return result.Content[0].Text, result.IsError
+}
+
+func TestProxyMergesServers(t *testing.T) {
+	memory := newFakeServer(&textTool{name: "echo", prefix: "memory:"}, &textTool{name: "fail"})
+	memory.HandleMethod(server.MethodListResources, server.TypedMethod(func(ctx context.Context, p mcp.PaginatedParams) (*mcp.ListResourcesResult, error) {
+		return &mcp.ListResourcesResult{Resources: []mcp.Resource{{URI: "memory://graph", Name: "graph"}}}, nil
+	}))
+	memory.HandleMethod(server.MethodReadResource, server.TypedMethod(func(ctx context.Context, p mcp.ReadResourceParams) (*mcp.ReadResourceResult, error) {
+		return &mcp.ReadResourceResult{Contents: []mcp.ResourceContents{{URI: p.URI, Text: "{}"}}}, nil
+	}))
+	memory.HandleMethod(server.MethodListPrompts, server.TypedMethod(func(ctx context.Context, p mcp.PaginatedParams) (*mcp.ListPromptsResult, error) {
+		return &mcp.ListPromptsResult{Prompts: []mcp.Prompt{{Name: "recall"}}}, nil
+	}))
+	memory.HandleMethod(server.MethodGetPrompt, server.TypedMethod(func(ctx context.Context, p mcp.GetPromptParams) (*mcp.GetPromptResult, error) {
+		return &mcp.GetPromptResult{Description: p.Name}, nil
+	}))
+	echo := newFakeServer(&textTool{name: "echo", prefix: "echo:"})
+
+	c := startProxy(t,
+		proxy.ServerConfig{Name: "memory", Namespace: "memory", Connect: memory.connect},
+		proxy.ServerConfig{Name: "echo", Connect: echo.connect},
+	)
+
+	// Servers connect concurrently, so their tools may come in any order
+	names := toolNames(t, c)
+	sort.Strings(names)
+	if names := strings.Join(names, ","); names != "echo,memory_echo,memory_fail" {
+		t.Errorf("Unexpected tools %s", names)
+	}
+	if text, _ := callText(t, c, "memory_echo"); text != `memory:{"n":1}` {
+		t.Errorf("Expected the call to reach memory, got %q", text)
+	}
+	if text, _ := callText(t, c, "echo"); text != `echo:{"n":1}` {
+		t.Errorf("Expected the call to reach echo, got %q", text)
+	}
+	var rpcErr *mcp.Error
+	if _, err := c.CallTool(testContext(t), "memory_fail", nil); !errors.As(err, &rpcErr) || rpcErr.Message != "Bad input" {
+		t.Errorf("Expected the downstream error to pass through, got %v", err)
+	}
+
+	resources, err := c.ListResources(testContext(t))
+	if err != nil || len(resources) != 1 || resources[0].Name != "memory_graph" || resources[0].URI != "memory://graph" {
+		t.Fatalf("Unexpected resources %+v, %v", resources, err)
+	}
+	contents, err := c.ReadResource(testContext(t), "memory://graph")
+	if err != nil || contents.Contents[0].Text != "{}" {
+		t.Errorf("Unexpected contents %+v, %v", contents, err)
+	}
+	if _, err := c.ReadResource(testContext(t), "memory://other"); !errors.As(err, &rpcErr) || rpcErr.Code != server.ErrInvalidParams {
+		t.Errorf("Expected unknown resource error, got %v", err)
+	}
+
+	prompt, err := c.GetPrompt(testContext(t), "memory_recall", nil)
+	if err != nil || prompt.Description != "recall" {
+		t.Errorf("Expected the prompt to be requested by its own name, got %+v, %v", prompt, err)
+	}
+}
+
+func TestProxyForwardsNotifications(t *testing.T) {
+	groq := newFakeServer(&textTool{name: "chat", prefix: "groq:"})
+	c := startProxy(t, proxy.ServerConfig{Name: "groq", Namespace: "groq", Connect: groq.connect})
+
+	changed := make(chan struct{}, 1)
+	c.HandleNotification(server.NotificationToolsListChanged, func(ctx context.Context, params json.RawMessage) {
+		changed <- struct{}{}
+	})
+	relist := func() {
+		t.Helper()
+		groq.Notify(server.NotificationToolsListChanged, nil)
+		select {
+		case <-changed:
+		case <-time.After(5 * time.Second):
+			t.Fatal("Expected tools/list_changed to be forwarded")
+		}
+	}
+
+	groq.RegisterTool(&textTool{name: "models", prefix: "groq:"})
+	relist()
+	if names := strings.Join(toolNames(t, c), ","); names != "groq_chat,groq_models" {
+		t.Errorf("Expected the new tool to be listed, got %s", names)
+	}
+
+	// Tools that change keep their place
+	groq.UnregisterTool("chat")
+	groq.RegisterTool(&textTool{name: "chat", prefix: "groq v2:"})
+	relist()
+	tools, err := c.ListTools(testContext(t))
+	if err != nil || len(tools) != 2 || tools[0].Name != "groq_chat" || tools[0].Description != "Answers with groq v2:" {
+		t.Errorf("Expected the changed tool to be updated in place, got %+v, %v", tools, err)
+	}
+}
+
+// reportTool implements mcp.ContextTool by reporting progress and logging
+// to the session that called it
+type reportTool struct{}
+
+func (t *reportTool) Name() string            { return "report" }
+func (t *reportTool) Description() string     { return "Reports progress" }
+func (t *reportTool) Schema() json.RawMessage { return json.RawMessage(`{"type":"object"}`) }
+func (t *reportTool) Execute(params json.RawMessage) (interface{}, error) {
+	return t.ExecuteContext(context.Background(), params)
+}
+func (t *reportTool) ExecuteContext(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	sess := server.SessionFromContext(ctx)
+	if token, ok := server.ProgressTokenFromContext(ctx); ok {
+		sess.Notify("notifications/progress", map[string]interface{}{"progressToken": token, "progress": 1, "total": 2})
+	}
+	sess.Notify("notifications/message", map[string]string{"level": "info", "data": "halfway"})
+	return mcp.ToolResponse{Content: []mcp.ToolContent{{Type: "text", Text: "done"}}}, nil
+}
+
+func TestProxyRoutesProgressToCaller(t *testing.T) {
+	groq := newFakeServer(&reportTool{})
+	p := newProxy(t, proxy.ServerConfig{Name: "groq", Connect: groq.connect})
+	caller, other := connectClient(t, p), connectClient(t, p)
+
+	notifications := func(c *client.Client) chan string {
+		ch := make(chan string, 4)
+		for _, method := range []string{"notifications/progress", "notifications/message"} {
+			method := method
+			c.HandleNotification(method, func(ctx context.Context, params json.RawMessage) {
+				ch <- method + " " + string(params)
+			})
+		}
+		return ch
+	}
+	callerGot, otherGot := notifications(caller), notifications(other)
+
+	args := map[string]interface{}{"name": "report", "_meta": map[string]int{"progressToken": 7}}
+	if err := caller.Call(testContext(t), server.MethodCallTool, args, nil); err != nil {
+		t.Fatalf("Call failed: %v", err)
+	}
+	for _, expected := range []string{
+		`notifications/progress {"progress":1,"progressToken":7,"total":2}`,
+		`notifications/message {"data":"halfway","level":"info","logger":"groq"}`,
+	} {
+		select {
+		case got := <-callerGot:
+			if got != expected {
+				t.Errorf("Expected %s, got %s", expected, got)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Expected %s", expected)
+		}
+	}
+
+	// Messages sent outside any call go nowhere
+	groq.Notify("notifications/message", map[string]string{"level": "info", "data": "idle"})
+	if err := other.Call(testContext(t), server.MethodPing, nil, nil); err != nil {
+		t.Fatalf("Ping failed: %v", err)
+	}
+	select {
+	case got := <-otherGot:
+		t.Errorf("Expected no notifications for another client, got %s", got)
+	case got := <-callerGot:
+		t.Errorf("Expected no notifications outside a call, got %s", got)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+// blockingTool implements mcp.ContextTool by waiting for cancellation
+type blockingTool struct {
+	started   chan struct{}
+	cancelled chan error
+}
+
+func (t *blockingTool) Name() string            { return "block" }
+func (t *blockingTool) Description() string     { return "Blocks until cancelled" }
+func (t *blockingTool) Schema() json.RawMessage { return json.RawMessage(`{"type":"object"}`) }
+func (t *blockingTool) Execute(params json.RawMessage) (interface{}, error) {
+	return t.ExecuteContext(context.Background(), params)
+}
+func (t *blockingTool) ExecuteContext(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	close(t.started)
+	<-ctx.Done()
+	t.cancelled <- ctx.Err()
+	return nil, ctx.Err()
+}
+
+func TestProxyForwardsCancellation(t *testing.T) {
+	tool := &blockingTool{started: make(chan struct{}), cancelled: make(chan error, 1)}
+	duckdb := newFakeServer(tool)
+	c := startProxy(t, proxy.ServerConfig{Name: "duckdb", Connect: duckdb.connect})
+
+	ctx, cancel := context.WithCancel(testContext(t))
+	go func() {
+		<-tool.started
+		cancel()
+	}()
+	if _, err := c.CallTool(ctx, "block", nil); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected the call to be cancelled, got %v", err)
+	}
+	select {
+	case err := <-tool.cancelled:
+		if err != context.Canceled {
+			t.Errorf("Expected context.Canceled downstream, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the cancellation to reach the downstream server")
+	}
+}
+
+func TestProxySurvivesCrash(t *testing.T) {
+	duckdb := newFakeServer(&textTool{name: "query", prefix: "duckdb:"})
+	echo := newFakeServer(&textTool{name: "echo", prefix: "echo:"})
+	c := startProxy(t,
+		proxy.ServerConfig{Name: "duckdb", Namespace: "duckdb", Connect: duckdb.connect},
+		proxy.ServerConfig{Name: "echo", Connect: echo.connect},
+	)
+
+	duckdb.crash()
+
+	// Calls fail as tool errors until the server is back, and the other
+	// server is not affected
+	deadline := time.Now().Add(5 * time.Second)
+	for duckdb.connections() < 2 {
+		if time.Now().After(deadline) {
+			t.Fatal("Proxy did not reconnect")
+		}
+		if text, isError := callText(t, c, "duckdb_query"); isError && !strings.Contains(text, "server duckdb is unavailable") {
+			t.Errorf("Unexpected error %q", text)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if text, isError := callText(t, c, "echo"); isError || text != `echo:{"n":1}` {
+		t.Errorf("Expected echo to keep working, got %q", text)
+	}
+
+	for {
+		text, isError := callText(t, c, "duckdb_query")
+		if !isError {
+			if text != `duckdb:{"n":1}` {
+				t.Errorf("Unexpected result %q", text)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Calls still fail after reconnecting: %s", text)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestNewValidatesServers(t *testing.T) {
+	connect := newFakeServer().connect
+	cases := [][]proxy.ServerConfig{
+		{{Name: "", Connect: connect}},
+		{{Name: "a"}},
+		{{Name: "a", Connect: connect}, {Name: "a", Connect: connect}},
+	}
+	for _, servers := range cases {
+		if _, err := proxy.New(servers, nil); err == nil {
+			t.Errorf("Expected error for %+v", servers)
+		}
+	}
+}
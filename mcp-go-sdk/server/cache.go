@@ -0,0 +1,219 @@
+package server
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"mcp-go-sdk"
+	"mcp-go-sdk/auth"
+)
+
+// CacheConfig configures the cache of tool results. Only the results of tools
+// that implement mcp.AnnotatedTool and declare themselves read-only or
+// idempotent are cached, since calling them again with the same arguments
+// would not change the outcome. Tools that change what such a tool returns
+// should implement mcp.CacheInvalidator. Results are kept apart for every
+// caller: the authenticated principal if there is one, and the session
+// otherwise.
+type CacheConfig struct {
+	// TTL is how long a result stays cached, one minute if zero
+	TTL time.Duration
+
+	// MaxEntries bounds the number of cached results, 1000 if zero. The
+	// least recently used result is dropped first.
+	MaxEntries int
+
+	// MaxBytes bounds the total size of the cached results in JSON. Zero
+	// turns the check off.
+	MaxBytes int
+}
+
+// resultCache holds the encoded results of tool calls, keyed by caller, tool
+// name and canonical arguments
+type resultCache struct {
+	config *CacheConfig
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List
+	size    int
+
+	// generation counts invalidations, so that a result computed before
+	// one is not stored after it
+	generation uint64
+}
+
+// cacheEntry is a cached result
+type cacheEntry struct {
+	key     string
+	tool    string
+	result  json.RawMessage
+	expires time.Time
+}
+
+// newResultCache creates an empty cache
+func newResultCache(config *CacheConfig) *resultCache {
+	return &resultCache{
+		config:  config,
+		entries: make(map[string]*list.Element),
+		lru:     list.New(),
+	}
+}
+
+// cacheScope returns who a cached result belongs to: the authenticated
+// principal of ctx, or the session if there is none
+func cacheScope(ctx context.Context, sess *Session) string {
+	if p := auth.PrincipalFromContext(ctx); p != nil && p.Subject != "" {
+		return "principal\x00" + p.Subject
+	}
+	return "session\x00" + sess.ID()
+}
+
+// cacheKey returns the key of a call made in scope, or false if the
+// arguments are not valid JSON. Arguments that only differ in key order or
+// whitespace share a key.
+func cacheKey(scope, tool string, args json.RawMessage) (string, bool) {
+	if len(bytes.TrimSpace(args)) == 0 {
+		args = json.RawMessage("null")
+	}
+	dec := json.NewDecoder(bytes.NewReader(args))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return "", false
+	}
+	if m, ok := v.(map[string]interface{}); ok && len(m) == 0 {
+		// No arguments and an empty object mean the same
+		v = nil
+	}
+	canonical, err := json.Marshal(v)
+	if err != nil {
+		return "", false
+	}
+	return scope + "\x00" + tool + "\x00" + string(canonical), true
+}
+
+// get returns the cached result for key
+func (c *resultCache) get(key string, now time.Time) (json.RawMessage, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := el.Value.(*cacheEntry)
+	if now.After(entry.expires) {
+		c.remove(el)
+		return nil, false
+	}
+	c.lru.MoveToFront(el)
+	return entry.result, true
+}
+
+// current returns the generation to pass to put for a result computed from
+// now on
+func (c *resultCache) current() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.generation
+}
+
+// put caches a result computed in the given generation, dropping the least
+// recently used results to stay within bounds. Results computed before an
+// invalidation are not cached, since they may already be stale.
+func (c *resultCache) put(key, tool string, result json.RawMessage, generation uint64, now time.Time) {
+	ttl := c.config.TTL
+	if ttl <= 0 {
+		ttl = time.Minute
+	}
+	maxEntries := c.config.MaxEntries
+	if maxEntries <= 0 {
+		maxEntries = 1000
+	}
+	if c.config.MaxBytes > 0 && len(result) > c.config.MaxBytes {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if generation != c.generation {
+		return
+	}
+	if el, ok := c.entries[key]; ok {
+		c.remove(el)
+	}
+	c.entries[key] = c.lru.PushFront(&cacheEntry{
+		key:     key,
+		tool:    tool,
+		result:  result,
+		expires: now.Add(ttl),
+	})
+	c.size += len(result)
+	for c.lru.Len() > maxEntries || (c.config.MaxBytes > 0 && c.size > c.config.MaxBytes) {
+		c.remove(c.lru.Back())
+	}
+}
+
+// invalidate drops the results of the named tools, or all results if no
+// tools are named
+func (c *resultCache) invalidate(tools ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.generation++
+	if len(tools) == 0 {
+		c.entries = make(map[string]*list.Element)
+		c.lru.Init()
+		c.size = 0
+		return
+	}
+	stale := make(map[string]bool, len(tools))
+	for _, name := range tools {
+		stale[name] = true
+	}
+	for el := c.lru.Front(); el != nil; {
+		next := el.Next()
+		if stale[el.Value.(*cacheEntry).tool] {
+			c.remove(el)
+		}
+		el = next
+	}
+}
+
+// remove drops an entry. The caller must hold c.mu.
+func (c *resultCache) remove(el *list.Element) {
+	entry := c.lru.Remove(el).(*cacheEntry)
+	delete(c.entries, entry.key)
+	c.size -= len(entry.result)
+}
+
+// cacheable reports whether the results of tool may be cached
+func cacheable(tool mcp.Tool) bool {
+	at, ok := tool.(mcp.AnnotatedTool)
+	if !ok {
+		return false
+	}
+	a := at.Annotations()
+	return a.ReadOnlyHint || a.IdempotentHint
+}
+
+// isErrorResult reports whether an encoded tool result has isError set
+func isErrorResult(result json.RawMessage) bool {
+	var r struct {
+		IsError bool `json:"isError"`
+	}
+	json.Unmarshal(result, &r)
+	return r.IsError
+}
+
+// InvalidateCache drops the cached results of the named tools, or of all
+// tools if none are named. Like ToolStats, it is reached through the
+// *MCPServer that NewServer returns.
+func (s *MCPServer) InvalidateCache(tools ...string) {
+	if s.cache != nil {
+		s.cache.invalidate(tools...)
+	}
+}
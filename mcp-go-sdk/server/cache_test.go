@@ -0,0 +1,233 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"mcp-go-sdk"
+	"mcp-go-sdk/auth"
+	"mcp-go-sdk/transport"
+)
+
+// counterTool implements mcp.AnnotatedTool by counting its calls. It fails
+// when called with {"fail":true}.
+type counterTool struct {
+	name        string
+	annotations mcp.ToolAnnotations
+	calls       int64
+}
+
+func (t *counterTool) Name() string                     { return t.name }
+func (t *counterTool) Description() string              { return "Counts its calls" }
+func (t *counterTool) Schema() json.RawMessage          { return json.RawMessage(`{"type":"object"}`) }
+func (t *counterTool) Annotations() mcp.ToolAnnotations { return t.annotations }
+func (t *counterTool) Execute(params json.RawMessage) (interface{}, error) {
+	n := atomic.AddInt64(&t.calls, 1)
+	var args struct {
+		Fail bool `json:"fail"`
+	}
+	json.Unmarshal(params, &args)
+	return mcp.CallToolResult{
+		Content: []mcp.ToolContent{{Type: "text", Text: fmt.Sprintf("call %d", n)}},
+		IsError: args.Fail,
+	}, nil
+}
+
+// writerTool implements mcp.CacheInvalidator
+type writerTool struct {
+	invalidates []string
+}
+
+func (t *writerTool) Name() string            { return "write" }
+func (t *writerTool) Description() string     { return "Writes" }
+func (t *writerTool) Schema() json.RawMessage { return json.RawMessage(`{"type":"object"}`) }
+func (t *writerTool) Invalidates() []string   { return t.invalidates }
+func (t *writerTool) Execute(params json.RawMessage) (interface{}, error) {
+	return mcp.CallToolResult{Content: []mcp.ToolContent{{Type: "text", Text: "written"}}}, nil
+}
+
+// callText calls a tool and returns the text of its result
+func callText(t *testing.T, client *transport.InMemoryTransport, tool, args string) string {
+	t.Helper()
+	sendRaw(t, client, fmt.Sprintf(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":%q,"arguments":%s}}`, tool, args))
+	var resp struct {
+		Result mcp.CallToolResult `json:"result"`
+	}
+	if err := json.Unmarshal(receiveMessage(t, client), &resp); err != nil {
+		t.Fatalf("Invalid response: %v", err)
+	}
+	if len(resp.Result.Content) == 0 {
+		return ""
+	}
+	return resp.Result.Content[0].Text
+}
+
+func TestResultCache(t *testing.T) {
+	client, serverEnd := transport.NewInMemoryPair()
+	srv := NewServerWithConfig(serverEnd, &Config{Cache: &CacheConfig{}})
+	reader := &counterTool{name: "read", annotations: mcp.ToolAnnotations{ReadOnlyHint: true}}
+	other := &counterTool{name: "other", annotations: mcp.ToolAnnotations{ReadOnlyHint: true}}
+	idempotent := &counterTool{name: "idempotent", annotations: mcp.ToolAnnotations{IdempotentHint: true}}
+	plain := &counterTool{name: "plain"}
+	srv.RegisterTool(reader)
+	srv.RegisterTool(other)
+	srv.RegisterTool(idempotent)
+	srv.RegisterTool(plain)
+	srv.RegisterTool(&writerTool{invalidates: []string{"read"}})
+	runTestServer(t, srv)
+
+	// Arguments that only differ in order and spacing share a result
+	if got := callText(t, client, "read", `{"a":1,"b":[1,2]}`); got != "call 1" {
+		t.Fatalf("Expected call 1, got %q", got)
+	}
+	if got := callText(t, client, "read", `{ "b":[1,2], "a":1 }`); got != "call 1" {
+		t.Errorf("Expected the cached result, got %q", got)
+	}
+	if got := callText(t, client, "read", `{"a":2}`); got != "call 2" {
+		t.Errorf("Expected new arguments to run the tool, got %q", got)
+	}
+
+	// Errors are not cached
+	callText(t, client, "read", `{"fail":true}`)
+	if got := callText(t, client, "read", `{"fail":true}`); got != "call 4" {
+		t.Errorf("Expected failed calls to run again, got %q", got)
+	}
+
+	// Tools without annotations are never cached
+	callText(t, client, "plain", `{}`)
+	if got := callText(t, client, "plain", `{}`); got != "call 2" {
+		t.Errorf("Expected an unannotated tool to run again, got %q", got)
+	}
+
+	// Calling an idempotent tool again has no further effect, so its
+	// result is reused too
+	callText(t, client, "idempotent", `{}`)
+	if got := callText(t, client, "idempotent", `{}`); got != "call 1" {
+		t.Errorf("Expected the cached result of the idempotent tool, got %q", got)
+	}
+
+	// A write drops the results of the tools it names only
+	callText(t, client, "other", `{}`)
+	callText(t, client, "write", `{}`)
+	if got := callText(t, client, "read", `{"a":1,"b":[1,2]}`); got != "call 5" {
+		t.Errorf("Expected the write to invalidate read, got %q", got)
+	}
+	if got := callText(t, client, "other", `{}`); got != "call 1" {
+		t.Errorf("Expected other to stay cached, got %q", got)
+	}
+
+	srv.(*MCPServer).InvalidateCache()
+	if got := callText(t, client, "other", `{}`); got != "call 2" {
+		t.Errorf("Expected InvalidateCache to drop every result, got %q", got)
+	}
+
+	if st := srv.(*MCPServer).ToolStats()["read"]; st.Calls != 5 || st.CacheHits != 1 {
+		t.Errorf("Unexpected stats for read: %+v", st)
+	}
+}
+
+func TestResultCacheBounds(t *testing.T) {
+	now := time.Now()
+	c := newResultCache(&CacheConfig{TTL: time.Second, MaxEntries: 2})
+	c.put("a", "tool", json.RawMessage(`1`), 0, now)
+	c.put("b", "tool", json.RawMessage(`2`), 0, now)
+	c.get("a", now)
+	c.put("c", "tool", json.RawMessage(`3`), 0, now)
+	if _, ok := c.get("b", now); ok {
+		t.Error("Expected the least recently used entry to be dropped")
+	}
+	if _, ok := c.get("a", now); !ok {
+		t.Error("Expected a recently used entry to stay")
+	}
+	if _, ok := c.get("c", now.Add(2*time.Second)); ok {
+		t.Error("Expected an expired entry to be dropped")
+	}
+
+	c = newResultCache(&CacheConfig{MaxBytes: 4})
+	c.put("a", "tool", json.RawMessage(`"ab"`), 0, now)
+	c.put("b", "tool", json.RawMessage(`"cd"`), 0, now)
+	c.put("big", "tool", json.RawMessage(`"too big"`), 0, now)
+	if _, ok := c.get("a", now); ok {
+		t.Error("Expected the oldest entry to make room")
+	}
+	if _, ok := c.get("big", now); ok {
+		t.Error("Expected a result over MaxBytes not to be cached")
+	}
+	if c.size != 4 {
+		t.Errorf("Expected 4 bytes cached, got %d", c.size)
+	}
+}
+
+func TestResultCacheSkipsStaleResults(t *testing.T) {
+	now := time.Now()
+	c := newResultCache(&CacheConfig{})
+	generation := c.current()
+	// The tool is invalidated while the call runs
+	c.invalidate("tool")
+	c.put("a", "tool", json.RawMessage(`1`), generation, now)
+	if _, ok := c.get("a", now); ok {
+		t.Error("Expected a result computed before an invalidation not to be cached")
+	}
+	c.put("a", "tool", json.RawMessage(`1`), c.current(), now)
+	if _, ok := c.get("a", now); !ok {
+		t.Error("Expected a result computed after the invalidation to be cached")
+	}
+}
+
+func TestCacheScope(t *testing.T) {
+	a, b := newSession(nil), newSession(nil)
+	ctx := context.Background()
+	if cacheScope(ctx, a) == cacheScope(ctx, b) {
+		t.Error("Expected sessions to have their own results")
+	}
+	alice := auth.ContextWithPrincipal(ctx, &auth.Principal{Subject: "alice"})
+	bob := auth.ContextWithPrincipal(ctx, &auth.Principal{Subject: "bob"})
+	if cacheScope(alice, a) != cacheScope(alice, b) {
+		t.Error("Expected a principal to share results across its sessions")
+	}
+	if cacheScope(alice, a) == cacheScope(bob, a) {
+		t.Error("Expected principals to have their own results")
+	}
+	if cacheScope(alice, a) == cacheScope(ctx, a) {
+		t.Error("Expected an authenticated caller not to see unauthenticated results")
+	}
+}
+
+func TestCacheKey(t *testing.T) {
+	a, _ := cacheKey("s", "t", json.RawMessage(`{"n":1.50,"s":"x"}`))
+	b, _ := cacheKey("s", "t", json.RawMessage(`{"s":"x","n":1.50}`))
+	if a != b {
+		t.Errorf("Expected equal keys, got %q and %q", a, b)
+	}
+	empty, _ := cacheKey("s", "t", nil)
+	obj, _ := cacheKey("s", "t", json.RawMessage(`{}`))
+	if empty != obj {
+		t.Errorf("Expected no arguments to match {}, got %q and %q", empty, obj)
+	}
+	if other, _ := cacheKey("s", "u", json.RawMessage(`{}`)); other == obj {
+		t.Error("Expected keys of different tools to differ")
+	}
+	if _, ok := cacheKey("s", "t", json.RawMessage(`{`)); ok {
+		t.Error("Expected invalid arguments to have no key")
+	}
+}
+
+func TestListToolAnnotations(t *testing.T) {
+	client, serverEnd := transport.NewInMemoryPair()
+	srv := NewServerWithConfig(serverEnd, nil)
+	srv.RegisterTool(&counterTool{name: "read", annotations: mcp.ToolAnnotations{Title: "Read", ReadOnlyHint: true}})
+	srv.RegisterTool(&counterTool{name: "plain"})
+	srv.RegisterTool(&writerTool{})
+	runTestServer(t, srv)
+
+	sendRaw(t, client, `{"jsonrpc":"2.0","id":1,"method":"tools/list"}`)
+	expectJSON(t, client, `{"jsonrpc":"2.0","id":1,"result":{"tools":[
+		{"name":"read","description":"Counts its calls","inputSchema":{"type":"object"},"annotations":{"title":"Read","readOnlyHint":true}},
+		{"name":"plain","description":"Counts its calls","inputSchema":{"type":"object"},"annotations":{}},
+		{"name":"write","description":"Writes","inputSchema":{"type":"object"}}
+	]}}`)
+}
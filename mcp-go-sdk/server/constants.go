@@ -3,15 +3,41 @@ package server
 // Protocol versions
 const (
 	Version         = "2.0"        // JSON-RPC version
-	ProtocolVersion = "2024-11-05" // MCP protocol version
+	ProtocolVersion = "2025-03-26" // Latest supported MCP protocol version
 )
 
+// SupportedProtocolVersions lists the MCP protocol versions the server can
+// negotiate, newest first
+var SupportedProtocolVersions = []string{
+	ProtocolVersion,
+	"2024-11-05",
+}
+
 // Method names
 const (
 	MethodInitialize  = "initialize"
 	MethodInitialized = "initialized"
 	MethodListTools   = "tools/list"
 	MethodCallTool    = "tools/call"
+	MethodPing        = "ping"
+
+	MethodListResources = "resources/list"
+	MethodReadResource  = "resources/read"
+	MethodListPrompts   = "prompts/list"
+	MethodGetPrompt     = "prompts/get"
+
+	// Sent by the server to the client
+	MethodElicit = "elicitation/create"
+)
+
+// Notification names
+const (
+	NotificationInitialized = "notifications/initialized"
+	NotificationCancelled   = "notifications/cancelled"
+
+	NotificationToolsListChanged     = "notifications/tools/list_changed"
+	NotificationResourcesListChanged = "notifications/resources/list_changed"
+	NotificationPromptsListChanged   = "notifications/prompts/list_changed"
 )
 
 // Error codes as per JSON-RPC 2.0 specification
@@ -22,3 +48,11 @@ const (
 	ErrInvalidParams  = -32602 // Invalid method parameter(s)
 	ErrInternal       = -32603 // Internal JSON-RPC error
 )
+
+// ErrResourceNotFound is sent for resources/read requests of unknown
+// resources
+const ErrResourceNotFound = -32002
+
+// ErrRateLimited is sent for tool calls over a Limit. Its data is a
+// RateLimitData telling the client when to retry.
+const ErrRateLimited = -32029
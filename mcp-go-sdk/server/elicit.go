@@ -0,0 +1,132 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"mcp-go-sdk"
+)
+
+// ErrElicitationUnsupported is returned by Elicit when the client did not
+// declare the elicitation capability. Tools then go on without asking, or
+// fail, whichever suits them.
+var ErrElicitationUnsupported = errors.New("client does not support elicitation")
+
+// elicitTypes lists the property types an elicitation schema may use
+var elicitTypes = map[string]bool{
+	"string":  true,
+	"number":  true,
+	"integer": true,
+	"boolean": true,
+}
+
+// elicitFormats lists the formats a string property may have
+var elicitFormats = map[string]bool{
+	"email":     true,
+	"uri":       true,
+	"date":      true,
+	"date-time": true,
+}
+
+// Elicit asks the user of the client that sent the current request for
+// input. See Session.Elicit. Outside a request handler it returns
+// ErrElicitationUnsupported.
+func Elicit(ctx context.Context, message string, schema json.RawMessage, v interface{}) (string, error) {
+	sess := SessionFromContext(ctx)
+	if sess == nil {
+		return "", ErrElicitationUnsupported
+	}
+	return sess.Elicit(ctx, message, schema, v)
+}
+
+// Elicit asks the user for input with an elicitation/create request and waits
+// for the answer. schema is the restricted JSON Schema of the input: an
+// object whose properties are strings, numbers, integers or booleans, with no
+// nesting. It returns the user's action, one of mcp.ElicitAccept,
+// mcp.ElicitDecline and mcp.ElicitCancel. Only accepted content is decoded
+// into v, which may be nil.
+//
+// Waiting for the user counts against the tool's timeout.
+func (s *Session) Elicit(ctx context.Context, message string, schema json.RawMessage, v interface{}) (string, error) {
+	if s.ClientCapabilities().Elicitation == nil {
+		return "", ErrElicitationUnsupported
+	}
+	required, err := checkElicitSchema(schema)
+	if err != nil {
+		return "", err
+	}
+
+	var result struct {
+		Action  string          `json:"action"`
+		Content json.RawMessage `json:"content"`
+	}
+	params := &mcp.ElicitParams{Message: message, RequestedSchema: schema}
+	if err := s.Call(ctx, MethodElicit, params, &result); err != nil {
+		return "", err
+	}
+
+	switch result.Action {
+	case mcp.ElicitAccept:
+	case mcp.ElicitDecline, mcp.ElicitCancel:
+		return result.Action, nil
+	default:
+		return "", fmt.Errorf("unknown elicitation action %q", result.Action)
+	}
+
+	var content map[string]json.RawMessage
+	if len(result.Content) > 0 {
+		if err := json.Unmarshal(result.Content, &content); err != nil {
+			return "", fmt.Errorf("invalid elicitation content: %w", err)
+		}
+	}
+	for _, name := range required {
+		if _, ok := content[name]; !ok {
+			return "", fmt.Errorf("elicitation content is missing %q", name)
+		}
+	}
+	if v != nil && content != nil {
+		if err := json.Unmarshal(result.Content, v); err != nil {
+			return "", fmt.Errorf("invalid elicitation content: %w", err)
+		}
+	}
+	return mcp.ElicitAccept, nil
+}
+
+// checkElicitSchema checks that schema is a flat object of primitive
+// properties and returns its required properties
+func checkElicitSchema(schema json.RawMessage) ([]string, error) {
+	var s struct {
+		Type       string `json:"type"`
+		Properties map[string]struct {
+			Type   string   `json:"type"`
+			Format string   `json:"format"`
+			Enum   []string `json:"enum"`
+		} `json:"properties"`
+		Required []string `json:"required"`
+	}
+	if err := json.Unmarshal(schema, &s); err != nil {
+		return nil, fmt.Errorf("invalid elicitation schema: %w", err)
+	}
+	if s.Type != "object" {
+		return nil, errors.New("invalid elicitation schema: type must be object")
+	}
+	for name, p := range s.Properties {
+		if !elicitTypes[p.Type] {
+			return nil, fmt.Errorf("invalid elicitation schema: property %q has unsupported type %q", name, p.Type)
+		}
+		if p.Format != "" && (p.Type != "string" || !elicitFormats[p.Format]) {
+			return nil, fmt.Errorf("invalid elicitation schema: property %q has unsupported format %q", name, p.Format)
+		}
+		if len(p.Enum) > 0 && p.Type != "string" {
+			return nil, fmt.Errorf("invalid elicitation schema: only string property %q may have an enum", name)
+		}
+	}
+	for _, name := range s.Required {
+		if _, ok := s.Properties[name]; !ok {
+			return nil, fmt.Errorf("invalid elicitation schema: required property %q is not defined", name)
+		}
+	}
+	return s.Required, nil
+}
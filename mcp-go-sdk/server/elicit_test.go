@@ -0,0 +1,180 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"mcp-go-sdk"
+	"mcp-go-sdk/transport"
+)
+
+const nameSchema = `{"type":"object","properties":{"name":{"type":"string"},"age":{"type":"integer"}},"required":["name"]}`
+
+// elicitTool implements mcp.ContextTool by asking the user for a name
+type elicitTool struct {
+	schema string
+}
+
+func (t *elicitTool) Name() string            { return "ask" }
+func (t *elicitTool) Description() string     { return "Asks for a name" }
+func (t *elicitTool) Schema() json.RawMessage { return json.RawMessage(`{"type":"object"}`) }
+func (t *elicitTool) Execute(params json.RawMessage) (interface{}, error) {
+	return t.ExecuteContext(context.Background(), params)
+}
+func (t *elicitTool) ExecuteContext(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var input struct {
+		Name string `json:"name"`
+		Age  int    `json:"age"`
+	}
+	action, err := Elicit(ctx, "Who are you?", json.RawMessage(t.schema), &input)
+	if err != nil {
+		return nil, err
+	}
+	return mcp.CallToolResult{Content: []mcp.ToolContent{{Type: "text", Text: fmt.Sprintf("%s %s %d", action, input.Name, input.Age)}}}, nil
+}
+
+// initializeWith performs the handshake declaring the given capabilities
+func initializeWith(t *testing.T, client *transport.InMemoryTransport, capabilities string) {
+	t.Helper()
+	sendRaw(t, client, `{"jsonrpc":"2.0","id":0,"method":"initialize","params":{"protocolVersion":"2025-03-26","capabilities":`+capabilities+`}}`)
+	receiveMessage(t, client)
+	receiveMessage(t, client)
+}
+
+// receiveRequest waits for a request from the server and returns its id and
+// params
+func receiveRequest(t *testing.T, client *transport.InMemoryTransport, method string) (json.RawMessage, json.RawMessage) {
+	t.Helper()
+	var req mcp.Request
+	if err := json.Unmarshal(receiveMessage(t, client), &req); err != nil {
+		t.Fatalf("Invalid request: %v", err)
+	}
+	if req.Method != method || len(req.ID) == 0 {
+		t.Fatalf("Expected a %s request, got %+v", method, req)
+	}
+	return req.ID, req.Params
+}
+
+func TestElicit(t *testing.T) {
+	client, serverEnd := transport.NewInMemoryPair()
+	srv := NewServer(serverEnd)
+	srv.RegisterTool(&elicitTool{schema: nameSchema})
+	runTestServer(t, srv)
+	initializeWith(t, client, `{"elicitation":{}}`)
+
+	answer := func(result string) string {
+		t.Helper()
+		sendRaw(t, client, `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"ask"}}`)
+		id, params := receiveRequest(t, client, MethodElicit)
+		var p struct {
+			Message         string      `json:"message"`
+			RequestedSchema interface{} `json:"requestedSchema"`
+		}
+		var schema interface{}
+		json.Unmarshal(params, &p)
+		json.Unmarshal([]byte(nameSchema), &schema)
+		if p.Message != "Who are you?" || !jsonEqual(schema, p.RequestedSchema) {
+			t.Errorf("Unexpected params %s", params)
+		}
+		sendRaw(t, client, fmt.Sprintf(`{"jsonrpc":"2.0","id":%s,"result":%s}`, id, result))
+		var resp struct {
+			Result mcp.CallToolResult `json:"result"`
+		}
+		json.Unmarshal(receiveMessage(t, client), &resp)
+		return resp.Result.Content[0].Text
+	}
+
+	if got := answer(`{"action":"accept","content":{"name":"Ada","age":36}}`); got != "accept Ada 36" {
+		t.Errorf("Expected the accepted content, got %q", got)
+	}
+	if got := answer(`{"action":"decline"}`); got != "decline  0" {
+		t.Errorf("Expected a decline, got %q", got)
+	}
+	if got := answer(`{"action":"cancel"}`); got != "cancel  0" {
+		t.Errorf("Expected a cancel, got %q", got)
+	}
+	if got := answer(`{"action":"accept","content":{"age":36}}`); !strings.Contains(got, `missing "name"`) {
+		t.Errorf("Expected missing required content to fail, got %q", got)
+	}
+	if got := answer(`{"action":"maybe"}`); !strings.Contains(got, "unknown elicitation action") {
+		t.Errorf("Expected an unknown action to fail, got %q", got)
+	}
+}
+
+func TestElicitCancelled(t *testing.T) {
+	client, serverEnd := transport.NewInMemoryPair()
+	srv := NewServer(serverEnd)
+	srv.RegisterTool(&elicitTool{schema: nameSchema})
+	runTestServer(t, srv)
+	initializeWith(t, client, `{"elicitation":{}}`)
+
+	// Cancelling the tool call withdraws the question
+	sendRaw(t, client, `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"ask"}}`)
+	id, _ := receiveRequest(t, client, MethodElicit)
+	sendRaw(t, client, `{"jsonrpc":"2.0","method":"notifications/cancelled","params":{"requestId":1}}`)
+	var note mcp.Request
+	json.Unmarshal(receiveMessage(t, client), &note)
+	var params struct {
+		RequestID json.RawMessage `json:"requestId"`
+	}
+	json.Unmarshal(note.Params, &params)
+	if note.Method != NotificationCancelled || string(params.RequestID) != string(id) {
+		t.Errorf("Expected the elicitation to be cancelled, got %+v", note)
+	}
+
+	// A late answer is dropped without a reply
+	sendRaw(t, client, fmt.Sprintf(`{"jsonrpc":"2.0","id":%s,"result":{"action":"accept","content":{"name":"Ada"}}}`, id))
+	sendRaw(t, client, `{"jsonrpc":"2.0","id":2,"method":"ping"}`)
+	expectJSON(t, client, `{"jsonrpc":"2.0","id":2,"result":{}}`)
+}
+
+func TestElicitUnsupported(t *testing.T) {
+	client, serverEnd := transport.NewInMemoryPair()
+	srv := NewServer(serverEnd)
+	srv.RegisterTool(&elicitTool{schema: nameSchema})
+	runTestServer(t, srv)
+	initializeWith(t, client, `{}`)
+
+	if got := callText(t, client, "ask", `{}`); got != ErrElicitationUnsupported.Error() {
+		t.Errorf("Expected ErrElicitationUnsupported, got %q", got)
+	}
+
+	var sess Session
+	if _, err := sess.Elicit(context.Background(), "", json.RawMessage(nameSchema), nil); !errors.Is(err, ErrElicitationUnsupported) {
+		t.Errorf("Expected ErrElicitationUnsupported, got %v", err)
+	}
+	if _, err := Elicit(context.Background(), "", json.RawMessage(nameSchema), nil); !errors.Is(err, ErrElicitationUnsupported) {
+		t.Errorf("Expected ErrElicitationUnsupported outside a request, got %v", err)
+	}
+}
+
+func TestCheckElicitSchema(t *testing.T) {
+	valid := []string{
+		nameSchema,
+		`{"type":"object","properties":{"when":{"type":"string","format":"date"},"color":{"type":"string","enum":["red","blue"]},"ok":{"type":"boolean"}}}`,
+	}
+	for _, schema := range valid {
+		if _, err := checkElicitSchema(json.RawMessage(schema)); err != nil {
+			t.Errorf("Expected %s to be valid, got %v", schema, err)
+		}
+	}
+
+	invalid := []string{
+		`{"type":"string"}`,
+		`{"type":"object","properties":{"address":{"type":"object","properties":{}}}}`,
+		`{"type":"object","properties":{"tags":{"type":"array"}}}`,
+		`{"type":"object","properties":{"n":{"type":"number","format":"email"}}}`,
+		`{"type":"object","properties":{"n":{"type":"integer","enum":["1"]}}}`,
+		`{"type":"object","properties":{},"required":["name"]}`,
+		`{`,
+	}
+	for _, schema := range invalid {
+		if _, err := checkElicitSchema(json.RawMessage(schema)); err == nil {
+			t.Errorf("Expected %s to be rejected", schema)
+		}
+	}
+}
@@ -1,48 +1,86 @@
 package server
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
 	"mcp-go-sdk"
 )
 
 // handleInitialize processes the initialize request
-func (s *MCPServer) handleInitialize(req *mcp.Request) error {
+func (s *MCPServer) handleInitialize(sess *Session, req *mcp.Request) error {
 	var params struct {
 		ProtocolVersion string `json:"protocolVersion"`
 	}
 	if err := json.Unmarshal(req.Params, &params); err != nil {
-		return s.sendError(&req.ID, ErrInvalidParams, "Invalid parameters", err.Error())
+		return sess.sendError(&req.ID, ErrInvalidParams, "Invalid parameters", err.Error())
 	}
 
 	// Validate protocol version
 	if params.ProtocolVersion == "" {
-		return s.sendError(&req.ID, ErrInvalidParams, "Invalid params", "protocolVersion is required")
+		return sess.sendError(&req.ID, ErrInvalidParams, "Invalid params", "protocolVersion is required")
 	}
 
+	// Capabilities and client info are informational, so malformed values
+	// are ignored rather than failing the handshake
+	var info mcp.InitializeParams
+	json.Unmarshal(req.Params, &info)
+	version := negotiateProtocolVersion(params.ProtocolVersion)
+
 	// Send initialize result
 	result := mcp.InitializeResult{
-		ProtocolVersion: ProtocolVersion,
-		ServerInfo: mcp.ServerInfo{
-			Name:    "MCP Server",
-			Version: "1.0.0",
-		},
-		Capabilities: mcp.ServerCapabilities{
-			Tools: &mcp.ToolsCapability{
-				ListChanged: false,
-			},
-		},
+		ProtocolVersion: version,
+		ServerInfo:      s.config.Info,
+		Capabilities:    s.capabilities(),
 	}
 
-	if err := s.sendResult(&req.ID, result); err != nil {
+	if err := sess.sendResult(&req.ID, result); err != nil {
 		return err
 	}
+	sess.setInitialized(version, info)
 
 	// Send initialized notification
-	return s.sendNotification(MethodInitialized, nil)
+	return sess.sendNotification(MethodInitialized, nil)
+}
+
+// capabilities returns the capabilities announced to clients
+func (s *MCPServer) capabilities() mcp.ServerCapabilities {
+	if s.config.Capabilities != nil {
+		return *s.config.Capabilities
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	caps := mcp.ServerCapabilities{
+		Tools: &mcp.ToolsCapability{
+			ListChanged: false,
+		},
+	}
+	if _, ok := s.methods[MethodListResources]; ok || s.outputs != nil {
+		caps.Resources = &mcp.ResourcesCapability{}
+	}
+	if _, ok := s.methods[MethodListPrompts]; ok {
+		caps.Prompts = &mcp.PromptsCapability{}
+	}
+	return caps
+}
+
+// negotiateProtocolVersion returns the requested version when it is supported
+// and the latest supported version otherwise
+func negotiateProtocolVersion(requested string) string {
+	for _, v := range SupportedProtocolVersions {
+		if v == requested {
+			return v
+		}
+	}
+	return ProtocolVersion
 }
 
 // handleListTools processes the tools/list request
-func (s *MCPServer) handleListTools(req *mcp.Request) error {
+func (s *MCPServer) handleListTools(sess *Session, req *mcp.Request) error {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
@@ -53,20 +91,26 @@ func (s *MCPServer) handleListTools(req *mcp.Request) error {
 			Description: tool.Description(),
 			InputSchema: tool.Schema(),
 		}
+		if at, ok := tool.(mcp.AnnotatedTool); ok {
+			annotations := at.Annotations()
+			tools[i].Annotations = &annotations
+		}
 	}
 
 	result := mcp.ListToolsResponse{
 		Tools: tools,
 	}
 
-	return s.sendResult(&req.ID, result)
+	return sess.sendResult(&req.ID, result)
 }
 
-// handleCallTool processes the tools/call request
-func (s *MCPServer) handleCallTool(req *mcp.Request) error {
+// handleCallTool processes the tools/call request. Failures of the tool are
+// reported in a result with isError set, so that the model can see them. Only
+// an *mcp.Error returned by the tool is sent as a protocol error.
+func (s *MCPServer) handleCallTool(ctx context.Context, sess *Session, req *mcp.Request) error {
 	var params mcp.CallToolRequest
 	if err := json.Unmarshal(req.Params, &params); err != nil {
-		return s.sendError(&req.ID, ErrInvalidParams, "Invalid parameters", err.Error())
+		return sess.sendError(&req.ID, ErrInvalidParams, "Invalid parameters", err.Error())
 	}
 
 	s.mu.RLock()
@@ -80,13 +124,154 @@ func (s *MCPServer) handleCallTool(req *mcp.Request) error {
 	s.mu.RUnlock()
 
 	if tool == nil {
-		return s.sendError(&req.ID, ErrMethodNotFound, "Tool not found", params.Name)
+		return sess.sendError(&req.ID, ErrMethodNotFound, "Tool not found", params.Name)
+	}
+	if params.Meta != nil && params.Meta.ProgressToken != 0 {
+		ctx = context.WithValue(ctx, progressTokenKey{}, params.Meta.ProgressToken)
+	}
+
+	if s.config.Policy != nil {
+		call := &ToolCall{Tool: tool, Arguments: params.Arguments, Session: sess}
+		if err := s.config.Policy.Authorize(ctx, call); err != nil {
+			s.metrics.update(params.Name, func(st *ToolStats) { st.Denied++ })
+			var rpcErr *mcp.Error
+			if errors.As(err, &rpcErr) {
+				return sess.sendError(&req.ID, rpcErr.Code, rpcErr.Message, rpcErr.Data)
+			}
+			return sess.sendResult(&req.ID, mcp.CallToolResult{
+				Content: []mcp.ToolContent{{Type: "text", Text: err.Error()}},
+				IsError: true,
+			})
+		}
+		if call.Confirmed {
+			ctx = context.WithValue(ctx, confirmedKey{}, true)
+		}
 	}
 
-	result, err := tool.Execute(params.Arguments)
+	// Cached results are served without counting against limits
+	var key string
+	var generation uint64
+	if s.cache != nil && cacheable(tool) {
+		key, _ = cacheKey(cacheScope(ctx, sess), params.Name, params.Arguments)
+		generation = s.cache.current()
+		if result, ok := s.cache.get(key, time.Now()); ok {
+			s.metrics.update(params.Name, func(st *ToolStats) { st.CacheHits++ })
+			return sess.sendResult(&req.ID, s.limitOutput(sess, params.Name, result))
+		}
+	}
+
+	release, err := s.admitCall(ctx, sess, params.Name)
 	if err != nil {
-		return s.sendError(&req.ID, ErrInternal, "Tool execution failed", err.Error())
+		var rpcErr *mcp.Error
+		if errors.As(err, &rpcErr) {
+			s.metrics.update(params.Name, func(st *ToolStats) { st.RateLimited++ })
+			return sess.sendError(&req.ID, rpcErr.Code, rpcErr.Message, rpcErr.Data)
+		}
+		// Cancelled while queued, so there is nobody to answer
+		return nil
 	}
 
-	return s.sendResult(&req.ID, result)
+	result, err := s.executeTool(ctx, tool, params.Arguments, release)
+	if err == nil {
+		result = s.updateCache(tool, key, generation, result)
+	} else {
+		var rpcErr *mcp.Error
+		if errors.As(err, &rpcErr) {
+			return sess.sendError(&req.ID, rpcErr.Code, rpcErr.Message, rpcErr.Data)
+		}
+		result = mcp.CallToolResult{
+			Content: []mcp.ToolContent{{Type: "text", Text: err.Error()}},
+			IsError: true,
+		}
+	}
+
+	return sess.sendResult(&req.ID, s.limitOutput(sess, params.Name, result))
+}
+
+// updateCache caches a successful result under key, unless key is empty or
+// the cache was invalidated since generation, and drops the results a
+// successful mcp.CacheInvalidator makes stale. It returns the result to
+// send, encoded if it had to be.
+func (s *MCPServer) updateCache(tool mcp.Tool, key string, generation uint64, result interface{}) interface{} {
+	invalidator, invalidates := tool.(mcp.CacheInvalidator)
+	if s.cache == nil || (key == "" && !invalidates) {
+		return result
+	}
+	encoded, err := json.Marshal(result)
+	if err != nil || isErrorResult(encoded) {
+		return result
+	}
+	if invalidates {
+		s.cache.invalidate(invalidator.Invalidates()...)
+	}
+	if key != "" {
+		s.cache.put(key, tool.Name(), encoded, generation, time.Now())
+	}
+	return json.RawMessage(encoded)
+}
+
+// executeTool runs a tool within its timeout and calls release once the tool
+// returns. A tool that runs out of time fails with a timeout error, and its
+// result is dropped whenever it arrives.
+func (s *MCPServer) executeTool(ctx context.Context, tool mcp.Tool, args json.RawMessage, release func()) (interface{}, error) {
+	name := tool.Name()
+	timeout := s.toolTimeout(name)
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	type outcome struct {
+		result interface{}
+		err    error
+	}
+	done := make(chan outcome, 1)
+	start := time.Now()
+	run := func() {
+		defer release()
+		var o outcome
+		if ct, ok := tool.(mcp.ContextTool); ok {
+			o.result, o.err = ct.ExecuteContext(ctx, args)
+		} else {
+			o.result, o.err = tool.Execute(args)
+		}
+		done <- o
+	}
+
+	// Without a timeout only the tool itself can end the call
+	if timeout <= 0 {
+		run()
+	} else {
+		go run()
+	}
+
+	select {
+	case o := <-done:
+		if ctx.Err() == context.DeadlineExceeded {
+			break
+		}
+		if ctx.Err() != nil {
+			s.metrics.update(name, func(st *ToolStats) { st.Calls++; st.Cancelled++ })
+		} else {
+			s.metrics.completed(name, time.Since(start), o.err != nil)
+		}
+		return o.result, o.err
+	case <-ctx.Done():
+		if ctx.Err() != context.DeadlineExceeded {
+			s.metrics.update(name, func(st *ToolStats) { st.Calls++; st.Cancelled++ })
+			return nil, ctx.Err()
+		}
+	}
+
+	s.metrics.update(name, func(st *ToolStats) { st.Calls++; st.Timeouts++ })
+	return nil, fmt.Errorf("tool %s timed out after %v", name, timeout)
+}
+
+// toolTimeout returns the time the named tool may take, or zero for no limit
+func (s *MCPServer) toolTimeout(name string) time.Duration {
+	if d, ok := s.config.ToolTimeouts[name]; ok {
+		return d
+	}
+	return s.config.ToolTimeouts["*"]
 }
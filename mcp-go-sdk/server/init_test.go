@@ -1,106 +1,103 @@
 package server
 
 import (
+	"context"
 	"encoding/json"
-	"io"
-	"sync"
 	"testing"
 	"time"
-)
 
-// mockTransport implements mcp.Transport for testing
-type mockTransport struct {
-	messages [][]byte
-	sent     []interface{}
-	mu       sync.Mutex
-	msgCh    chan struct{}
-	closed   bool
-	wg       sync.WaitGroup // Add WaitGroup for tracking goroutines
-	t        *testing.T     // Add testing.T for logging
-}
+	"mcp-go-sdk"
+	"mcp-go-sdk/transport"
+)
 
-func newMockTransport(t *testing.T, messages [][]byte) *mockTransport {
-	return &mockTransport{
-		messages: messages,
-		sent:     make([]interface{}, 0),
-		msgCh:    make(chan struct{}, 100),
-		closed:   false,
-		t:        t,
-	}
+// newTestServer creates a server on one end of an in-memory pair and returns
+// it with the client end
+func newTestServer(t *testing.T) (Server, *transport.InMemoryTransport) {
+	t.Helper()
+	client, serverEnd := transport.NewInMemoryPair()
+	srv := NewServer(serverEnd)
+	return srv, client
 }
 
-func (t *mockTransport) Send(msg interface{}) error {
-	t.mu.Lock()
-	defer t.mu.Unlock()
-
-	if t.closed {
-		return io.ErrClosedPipe
-	}
+// runTestServer starts srv and stops it when the test ends
+func runTestServer(t *testing.T, srv Server) {
+	t.Helper()
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- srv.Start()
+	}()
 
-	t.sent = append(t.sent, msg)
+	t.Cleanup(func() {
+		if err := srv.Stop(); err != nil {
+			t.Errorf("Failed to stop server: %v", err)
+		}
+		select {
+		case err := <-errCh:
+			if err != nil {
+				t.Errorf("Server error: %v", err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Error("Server did not exit within timeout")
+		}
+	})
+}
 
-	if testing.Verbose() {
-		jsonData, _ := json.Marshal(msg)
-		t.t.Logf(">>> %s", string(jsonData))
+// receiveMessage waits for the next message on the client end
+func receiveMessage(t *testing.T, client mcp.Transport) []byte {
+	t.Helper()
+	type result struct {
+		msg []byte
+		err error
 	}
+	ch := make(chan result, 1)
+	go func() {
+		msg, err := client.Receive()
+		ch <- result{msg, err}
+	}()
 
 	select {
-	case t.msgCh <- struct{}{}:
+	case r := <-ch:
+		if r.err != nil {
+			t.Fatalf("Receive failed: %v", r.err)
+		}
+		if testing.Verbose() {
+			t.Logf(">>> %s", r.msg)
+		}
+		return r.msg
+	case <-time.After(5 * time.Second):
+		client.Close()
+		t.Fatal("Timeout waiting for message")
 		return nil
-	default:
-		return nil // Channel full, but we don't want to block
 	}
 }
 
-func (t *mockTransport) Receive() ([]byte, error) {
-	t.mu.Lock()
-	defer t.mu.Unlock()
+// expectJSON waits for the next message and compares it to expected,
+// ignoring field order
+func expectJSON(t *testing.T, client mcp.Transport, expected string) {
+	t.Helper()
+	actual := receiveMessage(t, client)
 
-	if t.closed {
-		return nil, io.EOF
+	var expectedObj, actualObj interface{}
+	if err := json.Unmarshal([]byte(expected), &expectedObj); err != nil {
+		t.Fatalf("Failed to parse expected JSON: %v", err)
 	}
-
-	if len(t.messages) == 0 {
-		return nil, io.EOF
+	if err := json.Unmarshal(actual, &actualObj); err != nil {
+		t.Fatalf("Failed to parse actual JSON: %v", err)
 	}
-
-	msg := t.messages[0]
-	t.messages = t.messages[1:]
-
-	if testing.Verbose() {
-		t.t.Logf("<<< %s", string(msg))
+	if !jsonEqual(expectedObj, actualObj) {
+		t.Errorf("\nExpected: %s\nGot: %s", expected, actual)
 	}
-
-	return msg, nil
 }
 
-func (t *mockTransport) Close() error {
-	t.mu.Lock()
-	if !t.closed {
-		t.closed = true
-		close(t.msgCh)
+// sendRaw sends a raw JSON-RPC message from the client end
+func sendRaw(t *testing.T, client *transport.InMemoryTransport, msg string) {
+	t.Helper()
+	if testing.Verbose() {
+		t.Logf("<<< %s", msg)
 	}
-	t.mu.Unlock()
-
-	// Wait for any pending operations to complete
-	t.wg.Wait()
-	return nil
-}
-
-// waitForMessages waits for n messages to be sent or timeout
-func (t *mockTransport) waitForMessages(n int, timeout time.Duration) bool {
-	timer := time.NewTimer(timeout)
-	defer timer.Stop()
-
-	for i := 0; i < n; i++ {
-		select {
-		case <-t.msgCh:
-			continue
-		case <-timer.C:
-			return false
-		}
+	if err := client.SendRaw([]byte(msg)); err != nil {
+		t.Fatalf("Send failed: %v", err)
 	}
-	return true
 }
 
 // mockTool implements mcp.Tool for testing
@@ -116,16 +113,7 @@ func (t *mockTool) Schema() json.RawMessage                             { return
 func (t *mockTool) Execute(params json.RawMessage) (interface{}, error) { return nil, nil }
 
 func TestInitializationSequence(t *testing.T) {
-	// Create mock transport with a done channel
-	transport := newMockTransport(t, [][]byte{
-		// Initialize request
-		[]byte(`{"jsonrpc":"2.0","id":"1","method":"initialize","params":{"protocolVersion":"2024-11-05","clientInfo":{"name":"test-client","version":"1.0.0"},"capabilities":{"tools":true}}}`),
-		// tools/list request
-		[]byte(`{"jsonrpc":"2.0","id":"2","method":"tools/list"}`),
-	})
-
-	// Create server with mock transport
-	server := NewServer(transport)
+	server, client := newTestServer(t)
 
 	// Register a mock tool
 	tool := &mockTool{
@@ -136,72 +124,19 @@ func TestInitializationSequence(t *testing.T) {
 	if err := server.RegisterTool(tool); err != nil {
 		t.Fatalf("Failed to register tool: %v", err)
 	}
+	runTestServer(t, server)
 
-	// Start server in a goroutine
-	errCh := make(chan error, 1)
-	go func() {
-		errCh <- server.Start()
-	}()
-
-	// Wait for all expected messages
-	expectedResponses := []string{
-		// Initialize response
-		`{"jsonrpc":"2.0","result":{"protocolVersion":"2024-11-05","serverInfo":{"name":"MCP Server","version":"1.0.0"},"capabilities":{"tools":{"listChanged":false}}},"id":"1"}`,
-		// Initialized notification
-		`{"jsonrpc":"2.0","method":"initialized"}`,
-		// tools/list response
-		`{"jsonrpc":"2.0","result":{"tools":[{"name":"test-tool","description":"A test tool","inputSchema":{"type":"object"}}]},"id":"2"}`,
-	}
-
-	if !transport.waitForMessages(len(expectedResponses), 5*time.Second) {
-		t.Fatal("Timeout waiting for messages")
-	}
-
-	// Check sent messages
-	transport.mu.Lock()
-	for i, expected := range expectedResponses {
-		if i >= len(transport.sent) {
-			t.Fatalf("Expected %d messages, got %d", len(expectedResponses), len(transport.sent))
-		}
-
-		// Parse expected JSON
-		var expectedObj interface{}
-		if err := json.Unmarshal([]byte(expected), &expectedObj); err != nil {
-			t.Fatalf("Failed to parse expected JSON: %v", err)
-		}
-
-		// Compare JSON objects
-		actualJSON, err := json.Marshal(transport.sent[i])
-		if err != nil {
-			t.Fatalf("Failed to marshal sent message: %v", err)
-		}
-
-		var actualObj interface{}
-		if err := json.Unmarshal(actualJSON, &actualObj); err != nil {
-			t.Fatalf("Failed to parse actual JSON: %v", err)
-		}
+	// Initialize request
+	sendRaw(t, client, `{"jsonrpc":"2.0","id":"1","method":"initialize","params":{"protocolVersion":"2024-11-05","clientInfo":{"name":"test-client","version":"1.0.0"},"capabilities":{"tools":true}}}`)
+	// Initialize response
+	expectJSON(t, client, `{"jsonrpc":"2.0","result":{"protocolVersion":"2024-11-05","serverInfo":{"name":"MCP Server","version":"1.0.0"},"capabilities":{"tools":{"listChanged":false}}},"id":"1"}`)
+	// Initialized notification
+	expectJSON(t, client, `{"jsonrpc":"2.0","method":"initialized"}`)
 
-		// Compare objects
-		if !jsonEqual(expectedObj, actualObj) {
-			t.Errorf("Message %d:\nExpected: %s\nGot: %s", i+1, expected, string(actualJSON))
-		}
-	}
-	transport.mu.Unlock()
-
-	// Stop server
-	if err := server.Stop(); err != nil {
-		t.Fatalf("Failed to stop server: %v", err)
-	}
-
-	// Wait for server to exit
-	select {
-	case err := <-errCh:
-		if err != nil {
-			t.Fatalf("Server error: %v", err)
-		}
-	case <-time.After(5 * time.Second):
-		t.Fatal("Server did not exit within timeout")
-	}
+	// tools/list request
+	sendRaw(t, client, `{"jsonrpc":"2.0","id":"2","method":"tools/list"}`)
+	// tools/list response
+	expectJSON(t, client, `{"jsonrpc":"2.0","result":{"tools":[{"name":"test-tool","description":"A test tool","inputSchema":{"type":"object"}}]},"id":"2"}`)
 }
 
 func TestInitializationErrors(t *testing.T) {
@@ -213,7 +148,7 @@ func TestInitializationErrors(t *testing.T) {
 		{
 			name:     "invalid json",
 			request:  `{"jsonrpc":"2.0","id":"1","method":"initialize","params":{invalid json}`,
-			expected: `{"jsonrpc":"2.0","error":{"code":-32700,"message":"Parse error","data":"invalid character 'i' looking for beginning of object key string"},"id":"0"}`,
+			expected: `{"jsonrpc":"2.0","error":{"code":-32700,"message":"Parse error","data":"invalid character 'i' looking for beginning of object key string"},"id":null}`,
 		},
 		{
 			name:     "missing protocol version",
@@ -224,50 +159,13 @@ func TestInitializationErrors(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			transport := newMockTransport(t, [][]byte{[]byte(tt.request)})
-			server := NewServer(transport)
-
-			// Start server in a goroutine
-			errCh := make(chan error, 1)
-			go func() {
-				errCh <- server.Start()
-			}()
+			server, client := newTestServer(t)
+			runTestServer(t, server)
 
-			// Wait for error response
-			if !transport.waitForMessages(1, 5*time.Second) {
-				t.Fatal("Timeout waiting for error response")
-			}
-
-			// Check the response
-			transport.mu.Lock()
-			if len(transport.sent) == 0 {
-				t.Fatal("No response sent")
-			}
-
-			actual, err := json.Marshal(transport.sent[0])
-			if err != nil {
-				t.Fatalf("Failed to marshal sent message: %v", err)
-			}
-
-			if string(actual) != tt.expected {
+			sendRaw(t, client, tt.request)
+			if actual := receiveMessage(t, client); string(actual) != tt.expected {
 				t.Errorf("\nExpected: %s\nGot: %s", tt.expected, string(actual))
 			}
-			transport.mu.Unlock()
-
-			// Stop server
-			if err := server.Stop(); err != nil {
-				t.Fatalf("Failed to stop server: %v", err)
-			}
-
-			// Wait for server to exit
-			select {
-			case err := <-errCh:
-				if err != nil {
-					t.Fatalf("Server error: %v", err)
-				}
-			case <-time.After(5 * time.Second):
-				t.Fatal("Server did not exit within timeout")
-			}
 		})
 	}
 }
@@ -307,3 +205,36 @@ func jsonEqual(a, b interface{}) bool {
 		return a == b
 	}
 }
+
+func TestConfigNotifyAndUnregister(t *testing.T) {
+	client, serverEnd := transport.NewInMemoryPair()
+	server := NewServerWithConfig(serverEnd, &Config{Info: mcp.ServerInfo{Name: "proxy", Version: "2.0.0"}})
+	server.RegisterTool(&mockTool{name: "a", schema: json.RawMessage(`{}`)})
+	server.RegisterTool(&mockTool{name: "b", schema: json.RawMessage(`{}`)})
+	server.HandleMethod(MethodListResources, func(ctx context.Context, params json.RawMessage) (interface{}, error) {
+		return &mcp.ListResourcesResult{}, nil
+	})
+	runTestServer(t, server)
+
+	// Sessions are only notified once they are initialized
+	if err := server.Notify(NotificationToolsListChanged, nil); err != nil {
+		t.Fatalf("Notify failed: %v", err)
+	}
+	sendRaw(t, client, `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26"}}`)
+	expectJSON(t, client, `{"jsonrpc":"2.0","result":{"protocolVersion":"2025-03-26","serverInfo":{"name":"proxy","version":"2.0.0"},"capabilities":{"tools":{"listChanged":false},"resources":{}}},"id":1}`)
+	expectJSON(t, client, `{"jsonrpc":"2.0","method":"initialized"}`)
+
+	if err := server.UnregisterTool("a"); err != nil {
+		t.Fatalf("UnregisterTool failed: %v", err)
+	}
+	if err := server.UnregisterTool("a"); err == nil {
+		t.Error("Expected error for a tool that is not registered")
+	}
+	if err := server.Notify(NotificationToolsListChanged, nil); err != nil {
+		t.Fatalf("Notify failed: %v", err)
+	}
+	expectJSON(t, client, `{"jsonrpc":"2.0","method":"notifications/tools/list_changed"}`)
+
+	sendRaw(t, client, `{"jsonrpc":"2.0","id":2,"method":"tools/list"}`)
+	expectJSON(t, client, `{"jsonrpc":"2.0","result":{"tools":[{"name":"b","description":"","inputSchema":{}}]},"id":2}`)
+}
@@ -0,0 +1,277 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"mcp-go-sdk"
+)
+
+// Limit throttles tool calls. Zero fields turn the matching check off.
+type Limit struct {
+	// Requests is the number of calls allowed per Interval. Unused calls
+	// carry over up to Requests, so a quiet client may send a burst.
+	Requests int
+
+	// Interval is the window of Requests, one second if zero
+	Interval time.Duration
+
+	// MaxConcurrent is the number of calls that may execute at once
+	MaxConcurrent int
+
+	// MaxQueue is the number of calls that may wait for one of the
+	// MaxConcurrent slots. Calls beyond it are rejected.
+	MaxQueue int
+}
+
+// RateLimitData is the data of an ErrRateLimited error
+type RateLimitData struct {
+	// Scope is "tool" for limits on a tool and "session" for limits on the
+	// session
+	Scope string `json:"scope"`
+
+	// Tool is the name of the tool that was called
+	Tool string `json:"tool"`
+
+	// Reason is "rate" when too many calls were made in the interval and
+	// "concurrency" when too many calls are running or queued
+	Reason string `json:"reason"`
+
+	// RetryAfterMs is how long the client should wait before trying again
+	RetryAfterMs int64 `json:"retryAfterMs"`
+}
+
+// limiter enforces a Limit
+type limiter struct {
+	limit *Limit
+	scope string
+
+	mu       sync.Mutex
+	tokens   float64
+	last     time.Time
+	running  int
+	queue    []chan struct{}
+	avgTaken time.Duration
+}
+
+// newLimiter creates a limiter with a full budget
+func newLimiter(limit *Limit, scope string) *limiter {
+	return &limiter{
+		limit:  limit,
+		scope:  scope,
+		tokens: float64(limit.Requests),
+		last:   time.Now(),
+	}
+}
+
+// interval returns the window of the rate limit
+func (l *limiter) interval() time.Duration {
+	if l.limit.Interval > 0 {
+		return l.limit.Interval
+	}
+	return time.Second
+}
+
+// allow takes a call from the rate budget. If none is left, it returns how
+// long until there is.
+func (l *limiter) allow(now time.Time) (bool, time.Duration) {
+	if l.limit.Requests <= 0 {
+		return true, 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	rate := float64(l.limit.Requests) / float64(l.interval())
+	l.tokens = math.Min(float64(l.limit.Requests), l.tokens+float64(now.Sub(l.last))*rate)
+	l.last = now
+	if l.tokens >= 1 {
+		l.tokens--
+		return true, 0
+	}
+	return false, time.Duration(math.Ceil((1 - l.tokens) / rate))
+}
+
+// refund returns a call taken by allow that was not made
+func (l *limiter) refund() {
+	if l.limit.Requests <= 0 {
+		return
+	}
+	l.mu.Lock()
+	l.tokens = math.Min(float64(l.limit.Requests), l.tokens+1)
+	l.mu.Unlock()
+}
+
+// acquire waits for an execution slot. It fails right away if the queue is
+// full, returning a guess of when a slot will be free, and with the
+// context's error if ctx ends while waiting. On success the returned
+// function must be called once the call is done.
+func (l *limiter) acquire(ctx context.Context) (func(), time.Duration, error) {
+	if l.limit.MaxConcurrent <= 0 {
+		return func() {}, 0, nil
+	}
+
+	l.mu.Lock()
+	if l.running < l.limit.MaxConcurrent {
+		l.running++
+		l.mu.Unlock()
+		return l.releaser(), 0, nil
+	}
+	if len(l.queue) >= l.limit.MaxQueue {
+		// Every queued call and one running call have to finish first
+		wait := l.averageTaken() * time.Duration(len(l.queue)+1) / time.Duration(l.limit.MaxConcurrent)
+		l.mu.Unlock()
+		return nil, wait, nil
+	}
+	ready := make(chan struct{})
+	l.queue = append(l.queue, ready)
+	l.mu.Unlock()
+
+	select {
+	case <-ready:
+		return l.releaser(), 0, nil
+	case <-ctx.Done():
+		l.mu.Lock()
+		for i, ch := range l.queue {
+			if ch == ready {
+				l.queue = append(l.queue[:i], l.queue[i+1:]...)
+				l.mu.Unlock()
+				return nil, 0, ctx.Err()
+			}
+		}
+		l.mu.Unlock()
+		// The slot was handed over while giving up, so pass it on
+		l.releaser()()
+		return nil, 0, ctx.Err()
+	}
+}
+
+// releaser returns the function that frees a slot taken now, handing it to
+// the first queued call
+func (l *limiter) releaser() func() {
+	start := time.Now()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			taken := time.Since(start)
+			if l.avgTaken == 0 {
+				l.avgTaken = taken
+			} else {
+				l.avgTaken = (l.avgTaken*7 + taken) / 8
+			}
+
+			if len(l.queue) > 0 {
+				next := l.queue[0]
+				l.queue = l.queue[1:]
+				close(next)
+				return
+			}
+			l.running--
+		})
+	}
+}
+
+// averageTaken returns how long calls usually run, or a second before any
+// call has finished. The caller must hold l.mu.
+func (l *limiter) averageTaken() time.Duration {
+	if l.avgTaken <= 0 {
+		return time.Second
+	}
+	return l.avgTaken
+}
+
+// rateLimitError builds the error sent for a call over a limit
+func rateLimitError(scope, tool, reason string, retryAfter time.Duration) *mcp.Error {
+	retryMs := retryAfter.Milliseconds()
+	if retryMs < 1 {
+		retryMs = 1
+	}
+	what := "too many calls"
+	if reason == "concurrency" {
+		what = "too many concurrent calls"
+	}
+	return &mcp.Error{
+		Code:    ErrRateLimited,
+		Message: fmt.Sprintf("Rate limit exceeded: %s to %s for this %s, retry after %v", what, tool, scope, time.Duration(retryMs)*time.Millisecond),
+		Data: RateLimitData{
+			Scope:        scope,
+			Tool:         tool,
+			Reason:       reason,
+			RetryAfterMs: retryMs,
+		},
+	}
+}
+
+// toolLimiter returns the limiter for calls of the named tool, or nil if the
+// tool is not limited
+func (s *MCPServer) toolLimiter(name string) *limiter {
+	limit, ok := s.config.ToolLimits[name]
+	if !ok {
+		limit = s.config.ToolLimits["*"]
+	}
+	if limit == nil {
+		return nil
+	}
+
+	s.limitersMu.Lock()
+	defer s.limitersMu.Unlock()
+	l, ok := s.limiters[name]
+	if !ok {
+		l = newLimiter(limit, "tool")
+		s.limiters[name] = l
+	}
+	return l
+}
+
+// admitCall applies the session and tool limits to a call. On success the
+// returned function must be called once the call is done.
+func (s *MCPServer) admitCall(ctx context.Context, sess *Session, tool string) (func(), error) {
+	var limiters []*limiter
+	if sess.limiter != nil {
+		limiters = append(limiters, sess.limiter)
+	}
+	if l := s.toolLimiter(tool); l != nil {
+		limiters = append(limiters, l)
+	}
+	if len(limiters) == 0 {
+		return func() {}, nil
+	}
+
+	// Calls that do not run give back the rate budget they took
+	refund := func(charged []*limiter) {
+		for _, l := range charged {
+			l.refund()
+		}
+	}
+	now := time.Now()
+	for i, l := range limiters {
+		if ok, wait := l.allow(now); !ok {
+			refund(limiters[:i])
+			return nil, rateLimitError(l.scope, tool, "rate", wait)
+		}
+	}
+
+	var releases []func()
+	release := func() {
+		for i := len(releases) - 1; i >= 0; i-- {
+			releases[i]()
+		}
+	}
+	for _, l := range limiters {
+		r, wait, err := l.acquire(ctx)
+		if r == nil {
+			release()
+			refund(limiters)
+			if err != nil {
+				return nil, err
+			}
+			return nil, rateLimitError(l.scope, tool, "concurrency", wait)
+		}
+		releases = append(releases, r)
+	}
+	return release, nil
+}
@@ -0,0 +1,220 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"mcp-go-sdk"
+	"mcp-go-sdk/transport"
+)
+
+// gateTool implements mcp.ContextTool by waiting until the gate opens
+type gateTool struct {
+	started chan struct{}
+	gate    chan struct{}
+}
+
+func (t *gateTool) Name() string            { return "gate" }
+func (t *gateTool) Description() string     { return "Waits for the gate" }
+func (t *gateTool) Schema() json.RawMessage { return json.RawMessage(`{"type":"object"}`) }
+func (t *gateTool) Execute(params json.RawMessage) (interface{}, error) {
+	return t.ExecuteContext(context.Background(), params)
+}
+func (t *gateTool) ExecuteContext(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	t.started <- struct{}{}
+	select {
+	case <-t.gate:
+		return "passed", nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// rateLimitResponse reads a response and decodes its rate limit error
+func rateLimitResponse(t *testing.T, client mcp.Transport) (string, *RateLimitData) {
+	t.Helper()
+	var resp struct {
+		ID    json.RawMessage `json:"id"`
+		Error *struct {
+			Code int           `json:"code"`
+			Data RateLimitData `json:"data"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(receiveMessage(t, client), &resp); err != nil {
+		t.Fatalf("Invalid response: %v", err)
+	}
+	if resp.Error == nil {
+		return string(resp.ID), nil
+	}
+	if resp.Error.Code != ErrRateLimited {
+		t.Fatalf("Expected error code %d, got %d", ErrRateLimited, resp.Error.Code)
+	}
+	return string(resp.ID), &resp.Error.Data
+}
+
+func TestToolRateLimit(t *testing.T) {
+	client, serverEnd := transport.NewInMemoryPair()
+	srv := NewServerWithConfig(serverEnd, &Config{
+		ToolLimits: map[string]*Limit{
+			"fail": {Requests: 2, Interval: time.Hour},
+		},
+	})
+	srv.RegisterTool(&failingTool{})
+	srv.RegisterTool(&mockTool{name: "free", schema: json.RawMessage(`{"type":"object"}`)})
+	runTestServer(t, srv)
+
+	for i := 1; i <= 2; i++ {
+		sendRaw(t, client, `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"fail"}}`)
+		if _, data := rateLimitResponse(t, client); data != nil {
+			t.Fatalf("Call %d should be allowed, got %+v", i, data)
+		}
+	}
+
+	sendRaw(t, client, `{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"fail"}}`)
+	_, data := rateLimitResponse(t, client)
+	if data == nil {
+		t.Fatal("Expected the third call to be rate limited")
+	}
+	if data.Scope != "tool" || data.Tool != "fail" || data.Reason != "rate" {
+		t.Errorf("Unexpected rate limit data %+v", data)
+	}
+	// One call per half hour comes back
+	if data.RetryAfterMs < 29*60*1000 || data.RetryAfterMs > 30*60*1000 {
+		t.Errorf("Expected a retry after about 30 minutes, got %dms", data.RetryAfterMs)
+	}
+
+	// Tools without a limit are not affected
+	sendRaw(t, client, `{"jsonrpc":"2.0","id":4,"method":"tools/call","params":{"name":"free"}}`)
+	if _, data := rateLimitResponse(t, client); data != nil {
+		t.Errorf("Expected an unlimited tool to be allowed, got %+v", data)
+	}
+}
+
+func TestToolConcurrencyLimit(t *testing.T) {
+	client, serverEnd := transport.NewInMemoryPair()
+	srv := NewServerWithConfig(serverEnd, &Config{
+		ToolLimits: map[string]*Limit{
+			"*": {MaxConcurrent: 1, MaxQueue: 1},
+		},
+		ConcurrentRequests: true,
+	})
+	tool := &gateTool{started: make(chan struct{}, 2), gate: make(chan struct{})}
+	srv.RegisterTool(tool)
+	runTestServer(t, srv)
+
+	sendRaw(t, client, `{"jsonrpc":"2.0","id":"a","method":"tools/call","params":{"name":"gate"}}`)
+	<-tool.started
+	sendRaw(t, client, `{"jsonrpc":"2.0","id":"b","method":"tools/call","params":{"name":"gate"}}`)
+	// Give the second call time to join the queue
+	time.Sleep(50 * time.Millisecond)
+	sendRaw(t, client, `{"jsonrpc":"2.0","id":"c","method":"tools/call","params":{"name":"gate"}}`)
+
+	id, data := rateLimitResponse(t, client)
+	if id != `"c"` || data == nil || data.Reason != "concurrency" || data.RetryAfterMs <= 0 {
+		t.Fatalf("Expected call c to be rejected for concurrency, got %s %+v", id, data)
+	}
+
+	// The queued call runs once the first one is done
+	select {
+	case <-tool.started:
+		t.Fatal("Queued call started while the slot was taken")
+	default:
+	}
+	tool.gate <- struct{}{}
+	if id, data := rateLimitResponse(t, client); id != `"a"` || data != nil {
+		t.Fatalf("Expected the result of a, got %s %+v", id, data)
+	}
+	<-tool.started
+	tool.gate <- struct{}{}
+	if id, data := rateLimitResponse(t, client); id != `"b"` || data != nil {
+		t.Fatalf("Expected the result of b, got %s %+v", id, data)
+	}
+}
+
+func TestSessionLimit(t *testing.T) {
+	srv := NewServerWithConfig(nil, &Config{
+		SessionLimit: &Limit{Requests: 1, Interval: time.Hour},
+	})
+	srv.RegisterTool(&failingTool{})
+
+	for i := 0; i < 2; i++ {
+		client, serverEnd := transport.NewInMemoryPair()
+		go srv.ServeTransport(serverEnd)
+		defer client.Close()
+
+		sendRaw(t, client, `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"fail"}}`)
+		if _, data := rateLimitResponse(t, client); data != nil {
+			t.Fatalf("Session %d: expected its first call to be allowed, got %+v", i, data)
+		}
+		sendRaw(t, client, `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"fail"}}`)
+		if _, data := rateLimitResponse(t, client); data == nil || data.Scope != "session" {
+			t.Fatalf("Session %d: expected the session limit, got %+v", i, data)
+		}
+	}
+}
+
+func TestRejectedCallsKeepRateBudget(t *testing.T) {
+	srv := NewServerWithConfig(nil, &Config{
+		SessionLimit: &Limit{Requests: 2, Interval: time.Hour},
+		ToolLimits: map[string]*Limit{
+			"busy":   {Requests: 2, Interval: time.Hour, MaxConcurrent: 1},
+			"queued": {Requests: 2, Interval: time.Hour, MaxConcurrent: 1, MaxQueue: 1},
+		},
+	}).(*MCPServer)
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	for _, tool := range []string{"busy", "queued"} {
+		sess := newSession(nil)
+		sess.limiter = newLimiter(srv.config.SessionLimit, "session")
+		release, err := srv.admitCall(context.Background(), sess, tool)
+		if err != nil {
+			t.Fatalf("%s: expected the first call to be admitted, got %v", tool, err)
+		}
+		// Calls rejected for concurrency, or given up while queued, never run
+		for i := 0; i < 3; i++ {
+			if _, err := srv.admitCall(cancelled, sess, tool); err == nil {
+				t.Fatalf("%s: expected call %d to be rejected", tool, i)
+			}
+		}
+		release()
+		if _, err := srv.admitCall(context.Background(), sess, tool); err != nil {
+			t.Errorf("%s: expected the rate budget to be left for a second call, got %v", tool, err)
+		}
+	}
+}
+
+func TestLimiter(t *testing.T) {
+	l := newLimiter(&Limit{Requests: 2, Interval: time.Second}, "tool")
+	now := l.last
+	for i := 0; i < 2; i++ {
+		if ok, _ := l.allow(now); !ok {
+			t.Fatalf("Expected call %d to be allowed", i)
+		}
+	}
+	if ok, wait := l.allow(now); ok || wait != 500*time.Millisecond {
+		t.Errorf("Expected to wait 500ms, got %v, %v", ok, wait)
+	}
+	if ok, _ := l.allow(now.Add(500 * time.Millisecond)); !ok {
+		t.Error("Expected the budget to refill")
+	}
+	l.refund()
+	if ok, _ := l.allow(now.Add(500 * time.Millisecond)); !ok {
+		t.Error("Expected the refunded call to be allowed")
+	}
+
+	// A queued call gives up its place when its context ends
+	l = newLimiter(&Limit{MaxConcurrent: 1, MaxQueue: 1}, "tool")
+	release, _, _ := l.acquire(context.Background())
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if r, _, err := l.acquire(ctx); r != nil || err != context.DeadlineExceeded {
+		t.Errorf("Expected the queued call to time out, got %v", err)
+	}
+	release()
+	if r, _, err := l.acquire(context.Background()); r == nil || err != nil {
+		t.Errorf("Expected the slot to be free, got %v", err)
+	}
+}
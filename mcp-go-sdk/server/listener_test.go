@@ -0,0 +1,251 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"mcp-go-sdk"
+	"mcp-go-sdk/transport"
+)
+
+// lineClient speaks newline-delimited JSON-RPC over a connection
+type lineClient struct {
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+func (c *lineClient) call(t *testing.T, msg string) string {
+	t.Helper()
+	c.conn.SetDeadline(time.Now().Add(5 * time.Second))
+	if _, err := c.conn.Write([]byte(msg + "\n")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	line, err := c.reader.ReadString('\n')
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	return strings.TrimSpace(line)
+}
+
+func TestServeSessions(t *testing.T) {
+	for _, network := range []string{"tcp", "unix"} {
+		t.Run(network, func(t *testing.T) {
+			address := "127.0.0.1:0"
+			if network == "unix" {
+				address = filepath.Join(t.TempDir(), "mcp.sock")
+			}
+			l, err := net.Listen(network, address)
+			if err != nil {
+				t.Fatalf("Listen failed: %v", err)
+			}
+
+			srv := NewServer(nil)
+			srv.RegisterTool(&mockTool{name: "shared", schema: json.RawMessage(`{"type":"object"}`)})
+			srv.HandleMethod("session/client", func(ctx context.Context, params json.RawMessage) (interface{}, error) {
+				sess := SessionFromContext(ctx)
+				return map[string]interface{}{
+					"client":      sess.ClientInfo().Name,
+					"initialized": sess.Initialized(),
+				}, nil
+			})
+
+			errCh := make(chan error, 1)
+			go func() {
+				errCh <- srv.Serve(l)
+			}()
+
+			clients := make([]*lineClient, 2)
+			for i := range clients {
+				conn, err := net.Dial(network, l.Addr().String())
+				if err != nil {
+					t.Fatalf("Dial failed: %v", err)
+				}
+				defer conn.Close()
+				clients[i] = &lineClient{conn: conn, reader: bufio.NewReader(conn)}
+			}
+
+			// Only the first client initializes
+			resp := clients[0].call(t, `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2024-11-05","clientInfo":{"name":"editor-1","version":"1.0"}}}`)
+			if !strings.Contains(resp, `"protocolVersion":"2024-11-05"`) {
+				t.Fatalf("Unexpected initialize response: %s", resp)
+			}
+			clients[0].reader.ReadString('\n') // initialized notification
+
+			for i, client := range clients {
+				resp := client.call(t, `{"jsonrpc":"2.0","id":2,"method":"tools/list"}`)
+				if !strings.Contains(resp, `"name":"shared"`) {
+					t.Errorf("Client %d: unexpected tools/list response: %s", i, resp)
+				}
+			}
+
+			expected := []string{
+				`{"jsonrpc":"2.0","result":{"client":"editor-1","initialized":true},"id":3}`,
+				`{"jsonrpc":"2.0","result":{"client":"","initialized":false},"id":3}`,
+			}
+			for i, client := range clients {
+				if resp := client.call(t, `{"jsonrpc":"2.0","id":3,"method":"session/client"}`); resp != expected[i] {
+					t.Errorf("Client %d:\nExpected: %s\nGot: %s", i, expected[i], resp)
+				}
+			}
+
+			if err := srv.Stop(); err != nil {
+				t.Fatalf("Failed to stop server: %v", err)
+			}
+			select {
+			case err := <-errCh:
+				if err != nil {
+					t.Fatalf("Serve error: %v", err)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("Serve did not return after Stop")
+			}
+
+			// Sessions are closed on Stop
+			clients[0].conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+			if _, err := clients[0].reader.ReadString('\n'); err == nil {
+				t.Error("Expected connection to be closed after Stop")
+			}
+		})
+	}
+}
+
+func TestListenAndServeUnsupportedNetwork(t *testing.T) {
+	srv := NewServer(nil)
+	if err := srv.ListenAndServe("udp", "127.0.0.1:0"); err == nil || !strings.Contains(err.Error(), "unsupported network") {
+		t.Errorf("Expected unsupported network error, got %v", err)
+	}
+	if err := srv.Start(); err == nil {
+		t.Error("Expected error starting server without transport")
+	}
+}
+
+func TestListenAndServeUnixSocket(t *testing.T) {
+	address := filepath.Join(t.TempDir(), "mcp.sock")
+
+	// A socket a running server listens on is not taken over
+	l, err := net.Listen("unix", address)
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	srv := NewServer(nil)
+	if err := srv.ListenAndServe("unix", address); err == nil || !strings.Contains(err.Error(), "in use") {
+		t.Errorf("Expected the socket to be in use, got %v", err)
+	}
+	if conn, err := net.Dial("unix", address); err != nil {
+		t.Errorf("Expected the running server to keep its socket: %v", err)
+	} else {
+		conn.Close()
+	}
+
+	// A stale socket file is replaced
+	l.(*net.UnixListener).SetUnlinkOnClose(false)
+	l.Close()
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- srv.ListenAndServe("unix", address)
+	}()
+	var conn net.Conn
+	for i := 0; ; i++ {
+		if conn, err = net.Dial("unix", address); err == nil {
+			break
+		}
+		if i == 100 {
+			t.Fatalf("Expected the stale socket to be replaced: %v", err)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	client := &lineClient{conn: conn, reader: bufio.NewReader(conn)}
+	if resp := client.call(t, `{"jsonrpc":"2.0","id":1,"method":"ping"}`); !strings.Contains(resp, `"result":{}`) {
+		t.Errorf("Unexpected ping response: %s", resp)
+	}
+	conn.Close()
+	srv.Stop()
+	if err := <-errCh; err != nil {
+		t.Errorf("ListenAndServe failed: %v", err)
+	}
+}
+
+// flakyListener fails its first accepts with a temporary error
+type flakyListener struct {
+	net.Listener
+	failures int
+}
+
+type temporaryError struct{}
+
+func (temporaryError) Error() string   { return "too many open files" }
+func (temporaryError) Timeout() bool   { return false }
+func (temporaryError) Temporary() bool { return true }
+
+func (l *flakyListener) Accept() (net.Conn, error) {
+	if l.failures > 0 {
+		l.failures--
+		return nil, temporaryError{}
+	}
+	return l.Listener.Accept()
+}
+
+func TestServeRetriesTemporaryErrors(t *testing.T) {
+	inner, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	srv := NewServer(nil)
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- srv.Serve(&flakyListener{Listener: inner, failures: 3})
+	}()
+
+	conn, err := net.Dial("tcp", inner.Addr().String())
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer conn.Close()
+	client := &lineClient{conn: conn, reader: bufio.NewReader(conn)}
+	if resp := client.call(t, `{"jsonrpc":"2.0","id":1,"method":"ping"}`); !strings.Contains(resp, `"result":{}`) {
+		t.Errorf("Unexpected ping response: %s", resp)
+	}
+	srv.Stop()
+	if err := <-errCh; err != nil {
+		t.Errorf("Serve failed: %v", err)
+	}
+}
+
+func TestMessageSizeLimits(t *testing.T) {
+	serverConn, clientConn := net.Pipe()
+	config := &mcp.TransportConfig{BufferSize: 64, MaxMessageSize: 128, MaxSendSize: 256}
+
+	srv := NewServer(transport.NewBaseTransport(serverConn, serverConn, serverConn, config))
+	srv.HandleMethod("vendor/echo", func(ctx context.Context, params json.RawMessage) (interface{}, error) {
+		return params, nil
+	})
+	srv.HandleMethod("vendor/big", func(ctx context.Context, params json.RawMessage) (interface{}, error) {
+		return strings.Repeat("x", 512), nil
+	})
+	runTestServer(t, srv)
+	defer clientConn.Close()
+
+	client := &lineClient{conn: clientConn, reader: bufio.NewReader(clientConn)}
+	big := `{"jsonrpc":"2.0","id":1,"method":"vendor/echo","params":"` + strings.Repeat("x", 200) + `"}`
+	if resp := client.call(t, big); !strings.Contains(resp, `"code":-32600`) || !strings.Contains(resp, `"message":"Message too large"`) {
+		t.Errorf("Expected message too large error, got %s", resp)
+	}
+	if resp := client.call(t, `not json`); !strings.Contains(resp, `"code":-32700`) {
+		t.Errorf("Expected parse error, got %s", resp)
+	}
+	if resp := client.call(t, `{"jsonrpc":"2.0","id":3,"method":"vendor/big"}`); !strings.Contains(resp, `"message":"Response too large"`) || !strings.Contains(resp, `"id":3`) {
+		t.Errorf("Expected response too large error, got %s", resp)
+	}
+
+	// The session is still usable after rejecting oversized input
+	expected := `{"jsonrpc":"2.0","result":"ok","id":4}`
+	if resp := client.call(t, `{"jsonrpc":"2.0","id":4,"method":"vendor/echo","params":"ok"}`); !jsonEqual(resp, expected) {
+		t.Errorf("Expected %s, got %s", expected, resp)
+	}
+}
@@ -0,0 +1,163 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"mcp-go-sdk"
+)
+
+// MethodHandler handles a JSON-RPC request. The returned value is sent as the
+// result. A returned *mcp.Error is sent to the client as-is, any other error
+// is reported as an internal error.
+type MethodHandler func(ctx context.Context, params json.RawMessage) (interface{}, error)
+
+// NotificationHandler handles a JSON-RPC notification. Notifications never
+// get a response, so a returned error is only logged.
+type NotificationHandler func(ctx context.Context, params json.RawMessage) error
+
+// builtinMethods lists the methods handled by the server itself
+var builtinMethods = map[string]bool{
+	MethodInitialize: true,
+	MethodListTools:  true,
+	MethodCallTool:   true,
+	MethodPing:       true,
+}
+
+// HandleMethod implements Server
+func (s *MCPServer) HandleMethod(method string, handler MethodHandler) error {
+	if method == "" {
+		return errors.New("method name is required")
+	}
+	if handler == nil {
+		return fmt.Errorf("handler for %s is nil", method)
+	}
+	if builtinMethods[method] {
+		return fmt.Errorf("method %s is handled by the server", method)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, exists := s.methods[method]; exists {
+		return fmt.Errorf("method %s is already registered", method)
+	}
+	s.methods[method] = handler
+	return nil
+}
+
+// HandleNotification implements Server
+func (s *MCPServer) HandleNotification(method string, handler NotificationHandler) error {
+	if method == "" {
+		return errors.New("notification name is required")
+	}
+	if handler == nil {
+		return fmt.Errorf("handler for %s is nil", method)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, exists := s.notifications[method]; exists {
+		return fmt.Errorf("notification %s is already registered", method)
+	}
+	s.notifications[method] = handler
+	return nil
+}
+
+// DecodeParams unmarshals request parameters into a value of type T. Absent
+// parameters decode to the zero value. Decoding failures are returned as an
+// invalid params error ready to be sent to the client.
+func DecodeParams[T any](params json.RawMessage) (T, error) {
+	var v T
+	if len(params) == 0 || string(params) == "null" {
+		return v, nil
+	}
+	if err := json.Unmarshal(params, &v); err != nil {
+		return v, &mcp.Error{
+			Code:    ErrInvalidParams,
+			Message: "Invalid parameters",
+			Data:    err.Error(),
+		}
+	}
+	return v, nil
+}
+
+// TypedMethod adapts a function taking decoded parameters to a MethodHandler
+func TypedMethod[P any, R any](fn func(ctx context.Context, params P) (R, error)) MethodHandler {
+	return func(ctx context.Context, raw json.RawMessage) (interface{}, error) {
+		params, err := DecodeParams[P](raw)
+		if err != nil {
+			return nil, err
+		}
+		return fn(ctx, params)
+	}
+}
+
+// TypedNotification adapts a function taking decoded parameters to a
+// NotificationHandler
+func TypedNotification[P any](fn func(ctx context.Context, params P) error) NotificationHandler {
+	return func(ctx context.Context, raw json.RawMessage) error {
+		params, err := DecodeParams[P](raw)
+		if err != nil {
+			return err
+		}
+		return fn(ctx, params)
+	}
+}
+
+// handleCustomMethod dispatches a request to a registered method handler
+func (s *MCPServer) handleCustomMethod(ctx context.Context, sess *Session, req *mcp.Request) error {
+	s.mu.RLock()
+	handler, ok := s.methods[req.Method]
+	s.mu.RUnlock()
+
+	if !ok {
+		return sess.sendError(&req.ID, ErrMethodNotFound, "Method not found", req.Method)
+	}
+
+	result, err := handler(ctx, req.Params)
+	if err != nil {
+		var rpcErr *mcp.Error
+		if errors.As(err, &rpcErr) {
+			return sess.sendError(&req.ID, rpcErr.Code, rpcErr.Message, rpcErr.Data)
+		}
+		return sess.sendError(&req.ID, ErrInternal, "Internal error", err.Error())
+	}
+	if result == nil {
+		// A response must carry either a result or an error
+		result = struct{}{}
+	}
+
+	return sess.sendResult(&req.ID, result)
+}
+
+// handleNotification dispatches a notification. Unknown notifications are
+// ignored as required by JSON-RPC.
+func (s *MCPServer) handleNotification(ctx context.Context, sess *Session, req *mcp.Request) error {
+	switch req.Method {
+	case NotificationInitialized, MethodInitialized:
+		return nil
+	case NotificationCancelled:
+		var params struct {
+			RequestID json.RawMessage `json:"requestId"`
+		}
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return fmt.Errorf("notification %s: %w", req.Method, err)
+		}
+		sess.cancelRequest(params.RequestID)
+		return nil
+	}
+
+	s.mu.RLock()
+	handler, ok := s.notifications[req.Method]
+	s.mu.RUnlock()
+
+	if !ok {
+		return nil
+	}
+	if err := handler(ctx, req.Params); err != nil {
+		return fmt.Errorf("notification %s: %w", req.Method, err)
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"mcp-go-sdk"
+	"mcp-go-sdk/transport"
+)
+
+func TestCustomMethodsAndNotifications(t *testing.T) {
+	server, client := newTestServer(t)
+
+	type exportParams struct {
+		Format string `json:"format"`
+	}
+	err := server.HandleMethod("memory/export", TypedMethod(func(ctx context.Context, p exportParams) (map[string]string, error) {
+		if p.Format != "json" {
+			return nil, &mcp.Error{Code: ErrInvalidParams, Message: "Unsupported format", Data: p.Format}
+		}
+		return map[string]string{"format": p.Format}, nil
+	}))
+	if err != nil {
+		t.Fatalf("Failed to register method: %v", err)
+	}
+
+	pings := make(chan int, 1)
+	err = server.HandleNotification("vendor/ping", TypedNotification(func(ctx context.Context, p struct {
+		Seq int `json:"seq"`
+	}) error {
+		pings <- p.Seq
+		return nil
+	}))
+	if err != nil {
+		t.Fatalf("Failed to register notification: %v", err)
+	}
+	runTestServer(t, server)
+
+	sendRaw(t, client, `{"jsonrpc":"2.0","id":1,"method":"memory/export","params":{"format":"json"}}`)
+	expectJSON(t, client, `{"jsonrpc":"2.0","result":{"format":"json"},"id":1}`)
+
+	// Notifications are never answered, so the next message is the response
+	// to the request that follows them
+	sendRaw(t, client, `{"jsonrpc":"2.0","method":"notifications/initialized"}`)
+	sendRaw(t, client, `{"jsonrpc":"2.0","method":"vendor/ping","params":{"seq":7}}`)
+	sendRaw(t, client, `{"jsonrpc":"2.0","method":"vendor/unknown"}`)
+	sendRaw(t, client, `{"jsonrpc":"2.0","id":2,"method":"memory/export","params":{"format":"xml"}}`)
+	expectJSON(t, client, `{"jsonrpc":"2.0","error":{"code":-32602,"message":"Unsupported format","data":"xml"},"id":2}`)
+
+	select {
+	case seq := <-pings:
+		if seq != 7 {
+			t.Errorf("Expected ping seq 7, got %d", seq)
+		}
+	default:
+		t.Error("Notification handler was not called")
+	}
+
+	sendRaw(t, client, `{"jsonrpc":"2.0","id":3,"method":"memory/export","params":{"format":42}}`)
+	expectJSON(t, client, `{"jsonrpc":"2.0","error":{"code":-32602,"message":"Invalid parameters","data":"json: cannot unmarshal number into Go struct field exportParams.format of type string"},"id":3}`)
+
+	sendRaw(t, client, `{"jsonrpc":"2.0","id":4,"method":"vendor/missing"}`)
+	expectJSON(t, client, `{"jsonrpc":"2.0","error":{"code":-32601,"message":"Method not found","data":"vendor/missing"},"id":4}`)
+}
+
+func TestConcurrentRequests(t *testing.T) {
+	client, serverEnd := transport.NewInMemoryPair()
+	server := NewServerWithConfig(serverEnd, &Config{ConcurrentRequests: true})
+	tool := &blockingTool{started: make(chan struct{}), cancelled: make(chan error, 1)}
+	server.RegisterTool(tool)
+	server.RegisterTool(&failingTool{})
+	runTestServer(t, server)
+
+	// A blocked request does not hold up the ones after it
+	sendRaw(t, client, `{"jsonrpc":"2.0","id":"slow","method":"tools/call","params":{"name":"block"}}`)
+	<-tool.started
+	sendRaw(t, client, `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"fail"}}`)
+	expectJSON(t, client, `{"jsonrpc":"2.0","result":{"content":[{"type":"text","text":"disk full"}],"isError":true},"id":1}`)
+
+	sendRaw(t, client, `{"jsonrpc":"2.0","method":"notifications/cancelled","params":{"requestId":"slow","reason":"user"}}`)
+	if err := <-tool.cancelled; err != context.Canceled {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
+
+func TestHandleMethodRegistration(t *testing.T) {
+	server := NewServer(nil)
+	noop := func(ctx context.Context, params json.RawMessage) (interface{}, error) { return nil, nil }
+
+	if err := server.HandleMethod(MethodCallTool, noop); err == nil {
+		t.Error("Expected error when overriding a built-in method")
+	}
+	if err := server.HandleMethod("", noop); err == nil {
+		t.Error("Expected error for empty method name")
+	}
+	if err := server.HandleMethod("vendor/a", nil); err == nil {
+		t.Error("Expected error for nil handler")
+	}
+	if err := server.HandleMethod("vendor/a", noop); err != nil {
+		t.Fatalf("Failed to register method: %v", err)
+	}
+	if err := server.HandleMethod("vendor/a", noop); err == nil {
+		t.Error("Expected error for duplicate registration")
+	}
+}
+
+// blockingTool implements mcp.ContextTool by waiting for cancellation
+type blockingTool struct {
+	started   chan struct{}
+	cancelled chan error
+}
+
+func (t *blockingTool) Name() string            { return "block" }
+func (t *blockingTool) Description() string     { return "Blocks until cancelled" }
+func (t *blockingTool) Schema() json.RawMessage { return json.RawMessage(`{"type":"object"}`) }
+func (t *blockingTool) Execute(params json.RawMessage) (interface{}, error) {
+	return nil, errors.New("Execute must not be called for a ContextTool")
+}
+func (t *blockingTool) ExecuteContext(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	close(t.started)
+	<-ctx.Done()
+	t.cancelled <- ctx.Err()
+	return "too late", nil
+}
+
+// failingTool implements mcp.Tool by failing
+type failingTool struct{}
+
+func (t *failingTool) Name() string            { return "fail" }
+func (t *failingTool) Description() string     { return "Always fails" }
+func (t *failingTool) Schema() json.RawMessage { return json.RawMessage(`{"type":"object"}`) }
+func (t *failingTool) Execute(params json.RawMessage) (interface{}, error) {
+	return nil, errors.New("disk full")
+}
+
+func TestCancellationAndToolErrors(t *testing.T) {
+	server, client := newTestServer(t)
+	tool := &blockingTool{started: make(chan struct{}), cancelled: make(chan error, 1)}
+	server.RegisterTool(tool)
+	server.RegisterTool(&failingTool{})
+	runTestServer(t, server)
+
+	// Requests run in order, but a blocked one can still be cancelled, and
+	// a cancelled request gets no response
+	sendRaw(t, client, `{"jsonrpc":"2.0","id":"slow","method":"tools/call","params":{"name":"block"}}`)
+	<-tool.started
+	sendRaw(t, client, `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"fail"}}`)
+	sendRaw(t, client, `{"jsonrpc":"2.0","method":"notifications/cancelled","params":{"requestId":"slow","reason":"user"}}`)
+	if err := <-tool.cancelled; err != context.Canceled {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+	expectJSON(t, client, `{"jsonrpc":"2.0","result":{"content":[{"type":"text","text":"disk full"}],"isError":true},"id":1}`)
+	sendRaw(t, client, `{"jsonrpc":"2.0","id":2,"method":"ping"}`)
+	expectJSON(t, client, `{"jsonrpc":"2.0","result":{},"id":2}`)
+
+	sendRaw(t, client, `{"jsonrpc":"2.0","id":null,"method":"ping"}`)
+	expectJSON(t, client, `{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid request","data":"id must not be null"},"id":null}`)
+	sendRaw(t, client, `{"jsonrpc":"2.0","id":3}`)
+	expectJSON(t, client, `{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid request","data":"method is required"},"id":3}`)
+}
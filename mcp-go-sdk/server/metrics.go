@@ -0,0 +1,95 @@
+package server
+
+import (
+	"sync"
+	"time"
+)
+
+// ToolStats counts the calls of one tool since the server started
+type ToolStats struct {
+	// Calls is the number of calls that were executed
+	Calls int64
+
+	// Errors is the number of calls that returned an error, not counting
+	// timeouts
+	Errors int64
+
+	// Timeouts is the number of calls that ran out of time
+	Timeouts int64
+
+	// Cancelled is the number of calls the client cancelled
+	Cancelled int64
+
+	// RateLimited is the number of calls rejected by a Limit
+	RateLimited int64
+
+	// Denied is the number of calls the Policy did not allow
+	Denied int64
+
+	// CacheHits is the number of calls answered from the cache, which are
+	// not counted in Calls
+	CacheHits int64
+
+	// Truncated is the number of results cut to the output budget
+	Truncated int64
+
+	// TotalTime is the time spent in calls that completed, so that
+	// TotalTime / (Calls - Timeouts - Cancelled) is the average duration
+	TotalTime time.Duration
+
+	// MaxTime is the duration of the slowest completed call
+	MaxTime time.Duration
+}
+
+// toolMetrics collects ToolStats for every tool
+type toolMetrics struct {
+	mu    sync.Mutex
+	tools map[string]*ToolStats
+}
+
+// update changes the stats of the named tool
+func (m *toolMetrics) update(name string, f func(st *ToolStats)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.tools == nil {
+		m.tools = make(map[string]*ToolStats)
+	}
+	st, ok := m.tools[name]
+	if !ok {
+		st = &ToolStats{}
+		m.tools[name] = st
+	}
+	f(st)
+}
+
+// completed records a call that returned within its time
+func (m *toolMetrics) completed(name string, took time.Duration, failed bool) {
+	m.update(name, func(st *ToolStats) {
+		st.Calls++
+		if failed {
+			st.Errors++
+		}
+		st.TotalTime += took
+		if took > st.MaxTime {
+			st.MaxTime = took
+		}
+	})
+}
+
+// snapshot returns a copy of all stats
+func (m *toolMetrics) snapshot() map[string]ToolStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	stats := make(map[string]ToolStats, len(m.tools))
+	for name, st := range m.tools {
+		stats[name] = *st
+	}
+	return stats
+}
+
+// ToolStats returns the call statistics of every tool that was called. It
+// is not part of Server, so reach it through the *MCPServer that NewServer
+// returns.
+func (s *MCPServer) ToolStats() map[string]ToolStats {
+	return s.metrics.snapshot()
+}
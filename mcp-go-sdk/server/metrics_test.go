@@ -0,0 +1,110 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"mcp-go-sdk/transport"
+)
+
+// deadlineTool implements mcp.ContextTool by reporting its deadline and
+// waiting for the context to end
+type deadlineTool struct {
+	deadline chan time.Duration
+	err      chan error
+}
+
+func (t *deadlineTool) Name() string            { return "wait" }
+func (t *deadlineTool) Description() string     { return "Waits for its deadline" }
+func (t *deadlineTool) Schema() json.RawMessage { return json.RawMessage(`{"type":"object"}`) }
+func (t *deadlineTool) Execute(params json.RawMessage) (interface{}, error) {
+	return t.ExecuteContext(context.Background(), params)
+}
+func (t *deadlineTool) ExecuteContext(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	deadline, _ := ctx.Deadline()
+	t.deadline <- time.Until(deadline)
+	<-ctx.Done()
+	t.err <- ctx.Err()
+	return "too late", nil
+}
+
+// sleepTool implements mcp.Tool by sleeping without a context
+type sleepTool struct{}
+
+func (t *sleepTool) Name() string            { return "sleep" }
+func (t *sleepTool) Description() string     { return "Sleeps" }
+func (t *sleepTool) Schema() json.RawMessage { return json.RawMessage(`{"type":"object"}`) }
+func (t *sleepTool) Execute(params json.RawMessage) (interface{}, error) {
+	time.Sleep(200 * time.Millisecond)
+	return "too late", nil
+}
+
+func TestToolTimeouts(t *testing.T) {
+	client, serverEnd := transport.NewInMemoryPair()
+	srv := NewServerWithConfig(serverEnd, &Config{
+		ToolTimeouts: map[string]time.Duration{
+			"wait": time.Second,
+			"*":    50 * time.Millisecond,
+		},
+	})
+	tool := &deadlineTool{deadline: make(chan time.Duration, 1), err: make(chan error, 1)}
+	srv.RegisterTool(tool)
+	srv.RegisterTool(&sleepTool{})
+	srv.RegisterTool(&failingTool{})
+	runTestServer(t, srv)
+
+	// The deadline reaches the tool's context
+	start := time.Now()
+	sendRaw(t, client, `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"wait"}}`)
+	if d := <-tool.deadline; d <= 0 || d > time.Second {
+		t.Errorf("Expected a deadline within a second, got %v", d)
+	}
+	expectJSON(t, client, `{"jsonrpc":"2.0","result":{"content":[{"type":"text","text":"tool wait timed out after 1s"}],"isError":true},"id":1}`)
+	if err := <-tool.err; err != context.DeadlineExceeded {
+		t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+	}
+	if took := time.Since(start); took > 2*time.Second {
+		t.Errorf("Timeout took %v", took)
+	}
+
+	// Tools that ignore contexts time out too, and their late result is
+	// dropped
+	sendRaw(t, client, `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"sleep"}}`)
+	expectJSON(t, client, `{"jsonrpc":"2.0","result":{"content":[{"type":"text","text":"tool sleep timed out after 50ms"}],"isError":true},"id":2}`)
+	time.Sleep(300 * time.Millisecond)
+	sendRaw(t, client, `{"jsonrpc":"2.0","id":3,"method":"ping"}`)
+	expectJSON(t, client, `{"jsonrpc":"2.0","result":{},"id":3}`)
+
+	sendRaw(t, client, `{"jsonrpc":"2.0","id":4,"method":"tools/call","params":{"name":"fail"}}`)
+	receiveMessage(t, client)
+
+	stats := srv.(*MCPServer).ToolStats()
+	if st := stats["wait"]; st.Calls != 1 || st.Timeouts != 1 || st.Errors != 0 {
+		t.Errorf("Unexpected stats for wait: %+v", st)
+	}
+	if st := stats["sleep"]; st.Calls != 1 || st.Timeouts != 1 {
+		t.Errorf("Unexpected stats for sleep: %+v", st)
+	}
+	if st := stats["fail"]; st.Calls != 1 || st.Errors != 1 || st.Timeouts != 0 || st.MaxTime <= 0 || st.TotalTime < st.MaxTime {
+		t.Errorf("Unexpected stats for fail: %+v", st)
+	}
+}
+
+func TestToolStatsCountsLimits(t *testing.T) {
+	client, serverEnd := transport.NewInMemoryPair()
+	srv := NewServerWithConfig(serverEnd, &Config{
+		ToolLimits: map[string]*Limit{"fail": {Requests: 1, Interval: time.Hour}},
+	})
+	srv.RegisterTool(&failingTool{})
+	runTestServer(t, srv)
+
+	for i := 0; i < 3; i++ {
+		sendRaw(t, client, `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"fail"}}`)
+		receiveMessage(t, client)
+	}
+	if st := srv.(*MCPServer).ToolStats()["fail"]; st.Calls != 1 || st.RateLimited != 2 {
+		t.Errorf("Unexpected stats %+v", st)
+	}
+}
@@ -0,0 +1,417 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"mcp-go-sdk"
+)
+
+// OutputScheme is the URI scheme of the resources that hold the full output
+// of truncated tool results
+const OutputScheme = "tool-output"
+
+// OutputConfig bounds the text content of tool results. Text past the budget
+// is cut and replaced by a note saying where the rest is: the full text is
+// kept as a resource that the client reads page by page with resources/read.
+// The resource is only readable by the session that made the call.
+type OutputConfig struct {
+	// MaxBytes is the number of text content bytes a result may carry.
+	// Zero turns the check off.
+	MaxBytes int
+
+	// MaxTokens is the number of text content tokens a result may carry, as
+	// counted by CountTokens. Zero turns the check off.
+	MaxTokens int
+
+	// CountTokens counts the tokens of a text. Nil estimates four bytes per
+	// token.
+	CountTokens func(text string) int
+
+	// PageSize is the number of bytes in each page of a kept output,
+	// MaxBytes if zero, or 64KB if MaxBytes is zero too
+	PageSize int
+
+	// TTL is how long the full output of a truncated result can be read, ten
+	// minutes if zero
+	TTL time.Duration
+
+	// MaxOutputs bounds the number of full outputs kept, 100 if zero. The
+	// oldest output is dropped first.
+	MaxOutputs int
+
+	// MaxKeptBytes bounds the total size of the full outputs kept, 64MB if
+	// zero. The oldest outputs are dropped first, and an output larger than
+	// the bound is not kept at all.
+	MaxKeptBytes int
+}
+
+// countTokens counts the tokens of text
+func (c *OutputConfig) countTokens(text string) int {
+	if c.CountTokens != nil {
+		return c.CountTokens(text)
+	}
+	return (len(text) + 3) / 4
+}
+
+// pageSize returns the number of bytes per page
+func (c *OutputConfig) pageSize() int {
+	switch {
+	case c.PageSize > 0:
+		return c.PageSize
+	case c.MaxBytes > 0:
+		return c.MaxBytes
+	}
+	return 64 << 10
+}
+
+// within reports whether text fits the budget
+func (c *OutputConfig) within(text string) bool {
+	return (c.MaxBytes <= 0 || len(text) <= c.MaxBytes) &&
+		(c.MaxTokens <= 0 || c.countTokens(text) <= c.MaxTokens)
+}
+
+// fit returns the longest prefix of text within bytes and tokens, where a
+// limit below zero is off. The prefix ends at a line break if that keeps at
+// least half of it, so that tables and lists are not cut mid-line.
+func (c *OutputConfig) fit(text string, bytes, tokens int) string {
+	n := len(text)
+	if bytes >= 0 && n > bytes {
+		n = bytes
+	}
+	if tokens >= 0 && c.countTokens(text[:n]) > tokens {
+		lo, hi := 0, n
+		for lo < hi {
+			mid := (lo + hi + 1) / 2
+			if c.countTokens(text[:mid]) <= tokens {
+				lo = mid
+			} else {
+				hi = mid - 1
+			}
+		}
+		n = lo
+	}
+	for n > 0 && n < len(text) && !utf8.RuneStart(text[n]) {
+		n--
+	}
+	if n < len(text) {
+		if i := strings.LastIndexByte(text[:n], '\n'); i >= n/2 {
+			n = i + 1
+		}
+	}
+	return text[:n]
+}
+
+// keptOutput is the full text of a truncated result
+type keptOutput struct {
+	id      string
+	session string
+	tool    string
+	text    string
+	pages   []int
+	expires time.Time
+}
+
+// outputStore keeps the full text of truncated results
+type outputStore struct {
+	config *OutputConfig
+
+	mu      sync.Mutex
+	outputs map[string]*keptOutput
+	order   []string
+	size    int
+}
+
+// newOutputStore creates an empty store
+func newOutputStore(config *OutputConfig) *outputStore {
+	return &outputStore{
+		config:  config,
+		outputs: make(map[string]*keptOutput),
+	}
+}
+
+// put keeps a full output and returns it, or returns nil if the output is
+// larger than the store may hold
+func (o *outputStore) put(session, tool, text string, now time.Time) *keptOutput {
+	ttl := o.config.TTL
+	if ttl <= 0 {
+		ttl = 10 * time.Minute
+	}
+	max := o.config.MaxOutputs
+	if max <= 0 {
+		max = 100
+	}
+	maxBytes := o.config.MaxKeptBytes
+	if maxBytes <= 0 {
+		maxBytes = 64 << 20
+	}
+	if len(text) > maxBytes {
+		return nil
+	}
+	out := &keptOutput{
+		id:      newSessionID(),
+		session: session,
+		tool:    tool,
+		text:    text,
+		pages:   pageOffsets(text, o.config.pageSize()),
+		expires: now.Add(ttl),
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.outputs[out.id] = out
+	o.order = append(o.order, out.id)
+	o.size += len(text)
+	for len(o.order) > 0 {
+		oldest, ok := o.outputs[o.order[0]]
+		if ok && len(o.outputs) <= max && o.size <= maxBytes && now.Before(oldest.expires) {
+			break
+		}
+		if ok {
+			o.drop(oldest)
+		}
+		o.order = o.order[1:]
+	}
+	return out
+}
+
+// drop forgets an output, leaving its id in the order to be skipped. The
+// caller must hold o.mu.
+func (o *outputStore) drop(out *keptOutput) {
+	delete(o.outputs, out.id)
+	o.size -= len(out.text)
+}
+
+// get returns an output of the session unless it expired
+func (o *outputStore) get(session, id string, now time.Time) (*keptOutput, bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	out, ok := o.outputs[id]
+	if !ok || out.session != session || !now.Before(out.expires) {
+		return nil, false
+	}
+	return out, true
+}
+
+// list returns the unexpired outputs of a session, oldest first
+func (o *outputStore) list(session string, now time.Time) []*keptOutput {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	var outs []*keptOutput
+	for _, id := range o.order {
+		if out, ok := o.outputs[id]; ok && out.session == session && now.Before(out.expires) {
+			outs = append(outs, out)
+		}
+	}
+	return outs
+}
+
+// dropSession forgets the outputs of a session that ended
+func (o *outputStore) dropSession(session string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	order := o.order[:0]
+	for _, id := range o.order {
+		if out, ok := o.outputs[id]; ok && out.session == session {
+			o.drop(out)
+			continue
+		}
+		order = append(order, id)
+	}
+	o.order = order
+}
+
+// uri returns the resource URI of an output
+func (out *keptOutput) uri() string {
+	return OutputScheme + "://" + out.id
+}
+
+// page returns the text of a page, numbered from 1
+func (out *keptOutput) page(n int) (string, bool) {
+	if n < 1 || n > len(out.pages) {
+		return "", false
+	}
+	end := len(out.text)
+	if n < len(out.pages) {
+		end = out.pages[n]
+	}
+	return out.text[out.pages[n-1]:end], true
+}
+
+// pageOffsets returns where each page of text starts. Pages end on rune
+// boundaries, so every page is valid UTF-8.
+func pageOffsets(text string, size int) []int {
+	offsets := []int{0}
+	for start := 0; len(text)-start > size; {
+		end := start + size
+		for end > start && !utf8.RuneStart(text[end]) {
+			end--
+		}
+		if end == start {
+			// A page smaller than a rune holds the whole rune
+			for end = start + 1; end < len(text) && !utf8.RuneStart(text[end]); end++ {
+			}
+			if end == len(text) {
+				break
+			}
+		}
+		offsets = append(offsets, end)
+		start = end
+	}
+	return offsets
+}
+
+// limitOutput cuts the text content of a result to the output budget and
+// keeps the full text for the session to read. Structured content is dropped
+// from cut results. Results within the budget and results that are not
+// objects with a content list are returned as they are.
+func (s *MCPServer) limitOutput(sess *Session, tool string, result interface{}) interface{} {
+	config := s.config.Output
+	if config == nil {
+		return result
+	}
+	encoded, err := json.Marshal(result)
+	if err != nil || config.within(string(encoded)) {
+		// The text can be no longer than the whole result
+		return result
+	}
+
+	var fields map[string]json.RawMessage
+	var content []json.RawMessage
+	if json.Unmarshal(encoded, &fields) != nil || json.Unmarshal(fields["content"], &content) != nil {
+		return result
+	}
+	type textItem struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	}
+	var texts []string
+	for _, raw := range content {
+		var item textItem
+		if json.Unmarshal(raw, &item) == nil && item.Type == "text" {
+			texts = append(texts, item.Text)
+		}
+	}
+	full := strings.Join(texts, "\n")
+	if config.within(full) {
+		return result
+	}
+
+	// Keep the leading text within the budget, then the other content
+	bytes, tokens := -1, -1
+	if config.MaxBytes > 0 {
+		bytes = config.MaxBytes
+	}
+	if config.MaxTokens > 0 {
+		tokens = config.MaxTokens
+	}
+	shown := 0
+	cut := false
+	kept := make([]json.RawMessage, 0, len(content)+1)
+	for _, raw := range content {
+		var item textItem
+		if json.Unmarshal(raw, &item) != nil || item.Type != "text" {
+			kept = append(kept, raw)
+			continue
+		}
+		if cut {
+			continue
+		}
+		text := config.fit(item.Text, bytes, tokens)
+		if len(text) < len(item.Text) {
+			cut = true
+		}
+		if text == "" {
+			continue
+		}
+		var fieldsOfItem map[string]json.RawMessage
+		json.Unmarshal(raw, &fieldsOfItem)
+		fieldsOfItem["text"], _ = json.Marshal(text)
+		raw, _ = json.Marshal(fieldsOfItem)
+		kept = append(kept, raw)
+		shown += len(text)
+		if bytes >= 0 {
+			bytes -= len(text)
+		}
+		if tokens >= 0 {
+			tokens -= config.countTokens(text)
+		}
+	}
+
+	note := fmt.Sprintf("[Output truncated: showing %d of %d bytes. The full output is too large to keep.]", shown, len(full))
+	if out := s.outputs.put(sess.id, tool, full, time.Now()); out != nil {
+		note = fmt.Sprintf("[Output truncated: showing %d of %d bytes. The full output is in resource %s, %d pages; read page N with resources/read on %s?page=N.]",
+			shown, len(full), out.uri(), len(out.pages), out.uri())
+	}
+	noteItem, _ := json.Marshal(mcp.ToolContent{Type: "text", Text: note})
+	kept = append(kept, noteItem)
+
+	fields["content"], _ = json.Marshal(kept)
+	// Structured content would carry the whole result past the budget
+	delete(fields, "structuredContent")
+	limited, err := json.Marshal(fields)
+	if err != nil {
+		return result
+	}
+	s.metrics.update(tool, func(st *ToolStats) { st.Truncated++ })
+	return json.RawMessage(limited)
+}
+
+// handleOutputRequest answers resources/read for kept outputs, and
+// resources/list if no handler is registered for it. It reports false for
+// requests that are left to the registered handlers.
+func (s *MCPServer) handleOutputRequest(sess *Session, req *mcp.Request) (bool, error) {
+	now := time.Now()
+	if req.Method == MethodListResources {
+		s.mu.RLock()
+		_, registered := s.methods[MethodListResources]
+		s.mu.RUnlock()
+		if registered {
+			return false, nil
+		}
+		resources := []mcp.Resource{}
+		for _, out := range s.outputs.list(sess.id, now) {
+			resources = append(resources, mcp.Resource{
+				URI:         out.uri(),
+				Name:        "Output of " + out.tool,
+				Description: fmt.Sprintf("%d bytes in %d pages", len(out.text), len(out.pages)),
+				MimeType:    "text/plain",
+			})
+		}
+		return true, sess.sendResult(&req.ID, mcp.ListResourcesResult{Resources: resources})
+	}
+
+	var params mcp.ReadResourceParams
+	if err := json.Unmarshal(req.Params, &params); err != nil {
+		return false, nil
+	}
+	u, err := url.Parse(params.URI)
+	if err != nil || u.Scheme != OutputScheme {
+		return false, nil
+	}
+
+	page := 1
+	if p := u.Query().Get("page"); p != "" {
+		if page, err = strconv.Atoi(p); err != nil {
+			return true, sess.sendError(&req.ID, ErrInvalidParams, "Invalid page", p)
+		}
+	}
+	out, ok := s.outputs.get(sess.id, u.Host, now)
+	if !ok {
+		return true, sess.sendError(&req.ID, ErrResourceNotFound, "Resource not found", params.URI)
+	}
+	text, ok := out.page(page)
+	if !ok {
+		return true, sess.sendError(&req.ID, ErrInvalidParams, "Invalid page",
+			fmt.Sprintf("page %d of %d", page, len(out.pages)))
+	}
+	return true, sess.sendResult(&req.ID, mcp.ReadResourceResult{
+		Contents: []mcp.ResourceContents{{URI: params.URI, MimeType: "text/plain", Text: text}},
+	})
+}
@@ -0,0 +1,240 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"mcp-go-sdk"
+	"mcp-go-sdk/transport"
+)
+
+// linesTool implements mcp.Tool by returning the given number of numbered
+// lines, followed by an image, with the count as structured content
+type linesTool struct{}
+
+func (t *linesTool) Name() string            { return "lines" }
+func (t *linesTool) Description() string     { return "Returns numbered lines" }
+func (t *linesTool) Schema() json.RawMessage { return json.RawMessage(`{"type":"object"}`) }
+func (t *linesTool) Execute(params json.RawMessage) (interface{}, error) {
+	var args struct {
+		N int `json:"n"`
+	}
+	json.Unmarshal(params, &args)
+	return mcp.CallToolResult{Content: []mcp.ToolContent{
+		{Type: "text", Text: numberedLines(args.N)},
+		{Type: "image", Data: "aGk=", MimeType: "image/png"},
+	}, StructuredContent: map[string]int{"n": args.N}}, nil
+}
+
+// numberedLines returns n lines of the form "line 01\n"
+func numberedLines(n int) string {
+	var b strings.Builder
+	for i := 1; i <= n; i++ {
+		fmt.Fprintf(&b, "line %02d\n", i)
+	}
+	return b.String()
+}
+
+// callContent calls a tool and returns the content of its result
+func callContent(t *testing.T, client *transport.InMemoryTransport, tool, args string) []mcp.ToolContent {
+	t.Helper()
+	sendRaw(t, client, fmt.Sprintf(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":%q,"arguments":%s}}`, tool, args))
+	var resp struct {
+		Result mcp.CallToolResult `json:"result"`
+	}
+	if err := json.Unmarshal(receiveMessage(t, client), &resp); err != nil {
+		t.Fatalf("Invalid response: %v", err)
+	}
+	return resp.Result.Content
+}
+
+// readResource reads a resource and returns its text or the error code
+func readResource(t *testing.T, client *transport.InMemoryTransport, uri string) (string, int) {
+	t.Helper()
+	sendRaw(t, client, fmt.Sprintf(`{"jsonrpc":"2.0","id":2,"method":"resources/read","params":{"uri":%q}}`, uri))
+	var resp struct {
+		Result mcp.ReadResourceResult `json:"result"`
+		Error  *mcp.Error             `json:"error"`
+	}
+	if err := json.Unmarshal(receiveMessage(t, client), &resp); err != nil {
+		t.Fatalf("Invalid response: %v", err)
+	}
+	if resp.Error != nil {
+		return "", resp.Error.Code
+	}
+	return resp.Result.Contents[0].Text, 0
+}
+
+func TestOutputBudget(t *testing.T) {
+	client, serverEnd := transport.NewInMemoryPair()
+	srv := NewServerWithConfig(serverEnd, &Config{Output: &OutputConfig{MaxBytes: 100, PageSize: 150}})
+	srv.RegisterTool(&linesTool{})
+	runTestServer(t, srv)
+
+	// Results within the budget are left alone
+	if content := callContent(t, client, "lines", `{"n":3}`); len(content) != 2 || content[0].Text != numberedLines(3) {
+		t.Errorf("Expected the result unchanged, got %+v", content)
+	}
+
+	// 40 lines of 8 bytes are cut after the last whole line within budget
+	content := callContent(t, client, "lines", `{"n":40}`)
+	if len(content) != 3 {
+		t.Fatalf("Expected text, image and note, got %+v", content)
+	}
+	if content[0].Text != numberedLines(12) {
+		t.Errorf("Expected 12 whole lines, got %q", content[0].Text)
+	}
+	if content[1].Type != "image" {
+		t.Errorf("Expected the image to stay, got %+v", content[1])
+	}
+	note := content[2].Text
+	uri := regexp.MustCompile(OutputScheme + `://[0-9a-f]+`).FindString(note)
+	if !strings.Contains(note, "showing 96 of 320 bytes") || !strings.Contains(note, "3 pages") || uri == "" {
+		t.Fatalf("Unexpected note %q", note)
+	}
+
+	// The pages add up to the full text
+	var full string
+	for page := 1; page <= 3; page++ {
+		text, code := readResource(t, client, fmt.Sprintf("%s?page=%d", uri, page))
+		if code != 0 {
+			t.Fatalf("Failed to read page %d: %d", page, code)
+		}
+		full += text
+	}
+	if full != numberedLines(40) {
+		t.Errorf("Expected the pages to hold the full text, got %q", full)
+	}
+	if first, _ := readResource(t, client, uri); first != numberedLines(40)[:150] {
+		t.Errorf("Expected the first page by default, got %q", first)
+	}
+	if _, code := readResource(t, client, uri+"?page=4"); code != ErrInvalidParams {
+		t.Errorf("Expected invalid params past the last page, got %d", code)
+	}
+	if _, code := readResource(t, client, OutputScheme+"://unknown"); code != ErrResourceNotFound {
+		t.Errorf("Expected resource not found, got %d", code)
+	}
+	if _, code := readResource(t, client, "file:///etc/hosts"); code != ErrMethodNotFound {
+		t.Errorf("Expected other resources to be left to handlers, got %d", code)
+	}
+
+	sendRaw(t, client, `{"jsonrpc":"2.0","id":3,"method":"resources/list"}`)
+	var list struct {
+		Result mcp.ListResourcesResult `json:"result"`
+	}
+	json.Unmarshal(receiveMessage(t, client), &list)
+	if len(list.Result.Resources) != 1 || list.Result.Resources[0].URI != uri {
+		t.Errorf("Expected the output to be listed, got %+v", list.Result)
+	}
+
+	// Structured content is only kept with whole results
+	sendRaw(t, client, `{"jsonrpc":"2.0","id":4,"method":"tools/call","params":{"name":"lines","arguments":{"n":3}}}`)
+	if msg := receiveMessage(t, client); !strings.Contains(string(msg), `"structuredContent":{"n":3}`) {
+		t.Errorf("Expected structured content with a whole result, got %s", msg)
+	}
+	sendRaw(t, client, `{"jsonrpc":"2.0","id":5,"method":"tools/call","params":{"name":"lines","arguments":{"n":40}}}`)
+	if msg := receiveMessage(t, client); strings.Contains(string(msg), "structuredContent") {
+		t.Errorf("Expected structured content to be dropped from a cut result, got %s", msg)
+	}
+
+	if st := srv.(*MCPServer).ToolStats()["lines"]; st.Truncated != 2 {
+		t.Errorf("Expected two truncated results, got %+v", st)
+	}
+}
+
+func TestOutputTokenBudget(t *testing.T) {
+	words := func(text string) int { return len(strings.Fields(text)) }
+	config := &OutputConfig{MaxTokens: 5, CountTokens: words}
+	if got := config.fit("one two three four five six seven", -1, 5); got != "one two three four five " {
+		t.Errorf("Expected five words, got %q", got)
+	}
+	if got := config.fit("a\nb c d e f g h i j", 100, -1); got != "a\nb c d e f g h i j" {
+		t.Errorf("Expected text within budget unchanged, got %q", got)
+	}
+	// Cuts keep whole runes
+	if got := (&OutputConfig{}).fit("héllo", 2, -1); got != "h" {
+		t.Errorf("Expected the cut before a partial rune, got %q", got)
+	}
+
+	client, serverEnd := transport.NewInMemoryPair()
+	srv := NewServerWithConfig(serverEnd, &Config{Output: &OutputConfig{MaxTokens: 10}})
+	srv.RegisterTool(&linesTool{})
+	runTestServer(t, srv)
+	content := callContent(t, client, "lines", `{"n":10}`)
+	if content[0].Text != numberedLines(5) {
+		t.Errorf("Expected 40 bytes of text for 10 tokens, got %q", content[0].Text)
+	}
+}
+
+func TestOutputStore(t *testing.T) {
+	now := time.Now()
+	store := newOutputStore(&OutputConfig{PageSize: 4, MaxOutputs: 2, TTL: time.Minute})
+	a := store.put("s1", "tool", "aaaa", now)
+	b := store.put("s1", "tool", "bbbb", now)
+	if _, ok := store.get("s2", a.id, now); ok {
+		t.Error("Expected outputs to be private to their session")
+	}
+	store.put("s2", "tool", "cccc", now)
+	if _, ok := store.get("s1", a.id, now); ok {
+		t.Error("Expected the oldest output to be dropped")
+	}
+	if _, ok := store.get("s1", b.id, now.Add(2*time.Minute)); ok {
+		t.Error("Expected an expired output to be gone")
+	}
+	store.dropSession("s1")
+	if outs := store.list("s1", now); len(outs) != 0 {
+		t.Errorf("Expected the outputs of an ended session to be dropped, got %d", len(outs))
+	}
+	if outs := store.list("s2", now); len(outs) != 1 {
+		t.Errorf("Expected other sessions to keep their outputs, got %d", len(outs))
+	}
+
+	// The total size is bounded too
+	store = newOutputStore(&OutputConfig{MaxKeptBytes: 8})
+	a = store.put("s1", "tool", "aaaa", now)
+	store.put("s1", "tool", "bbbb", now)
+	store.put("s1", "tool", "cc", now)
+	if _, ok := store.get("s1", a.id, now); ok {
+		t.Error("Expected the oldest output to make room")
+	}
+	if store.size != 6 {
+		t.Errorf("Expected 6 bytes kept, got %d", store.size)
+	}
+	if out := store.put("s1", "tool", "too large", now); out != nil {
+		t.Error("Expected an output over MaxKeptBytes not to be kept")
+	}
+
+	// Pages never split a rune
+	text := strings.Repeat("é", 5)
+	offsets := pageOffsets(text, 3)
+	for i, off := range offsets {
+		if !utf8.RuneStart(text[off]) {
+			t.Errorf("Page %d starts inside a rune", i+1)
+		}
+	}
+	if len(offsets) != 5 {
+		t.Errorf("Expected 5 pages, got %v", offsets)
+	}
+	if offsets := pageOffsets("é", 1); len(offsets) != 1 {
+		t.Errorf("Expected a rune wider than a page to fill one page, got %v", offsets)
+	}
+}
+
+func TestOutputCapability(t *testing.T) {
+	client, serverEnd := transport.NewInMemoryPair()
+	srv := NewServerWithConfig(serverEnd, &Config{Output: &OutputConfig{MaxBytes: 10}})
+	runTestServer(t, srv)
+	sendRaw(t, client, `{"jsonrpc":"2.0","id":0,"method":"initialize","params":{"protocolVersion":"2025-03-26"}}`)
+	var resp struct {
+		Result mcp.InitializeResult `json:"result"`
+	}
+	json.Unmarshal(receiveMessage(t, client), &resp)
+	if resp.Result.Capabilities.Resources == nil {
+		t.Error("Expected the resources capability with an output budget")
+	}
+}
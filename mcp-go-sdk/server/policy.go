@@ -0,0 +1,54 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+
+	"mcp-go-sdk"
+)
+
+// Policy decides whether tool calls may run. The server consults it before a
+// call is admitted or answered from the cache, so denied calls count against
+// no limit. The policy package implements rules loaded from a file.
+type Policy interface {
+	// Authorize returns nil if the call may run. Any other error denies
+	// it: an *mcp.Error is sent as a protocol error, and other errors give
+	// an isError result with their text, so that the model can see why.
+	// Authorize may ask the user with Elicit before it decides.
+	Authorize(ctx context.Context, call *ToolCall) error
+}
+
+// ToolCall is a tool call awaiting the decision of a Policy
+type ToolCall struct {
+	// Tool is the called tool
+	Tool mcp.Tool
+
+	// Arguments are the arguments of the call as sent by the client
+	Arguments json.RawMessage
+
+	// Session is the session the call was made in
+	Session *Session
+
+	// Confirmed is set by Authorize when the user confirmed the call, so
+	// that the tool does not ask again. The tool sees it with Confirmed.
+	Confirmed bool
+}
+
+// confirmedKey marks the context of a call the user confirmed
+type confirmedKey struct{}
+
+// Confirmed reports whether the user confirmed the current tool call when
+// the Policy asked. Tools that would ask for confirmation themselves may go
+// ahead without asking again.
+func Confirmed(ctx context.Context) bool {
+	confirmed, _ := ctx.Value(confirmedKey{}).(bool)
+	return confirmed
+}
+
+// PolicyFunc adapts a function to the Policy interface
+type PolicyFunc func(ctx context.Context, call *ToolCall) error
+
+// Authorize calls f
+func (f PolicyFunc) Authorize(ctx context.Context, call *ToolCall) error {
+	return f(ctx, call)
+}
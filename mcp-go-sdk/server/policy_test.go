@@ -0,0 +1,53 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"mcp-go-sdk"
+	"mcp-go-sdk/transport"
+)
+
+func TestPolicy(t *testing.T) {
+	client, serverEnd := transport.NewInMemoryPair()
+	var seen []*ToolCall
+	policy := PolicyFunc(func(ctx context.Context, call *ToolCall) error {
+		seen = append(seen, call)
+		switch {
+		case strings.Contains(string(call.Arguments), "drop"):
+			return errors.New("denied by policy: no dropping")
+		case strings.Contains(string(call.Arguments), "forbidden"):
+			return &mcp.Error{Code: ErrInvalidRequest, Message: "Forbidden"}
+		}
+		return nil
+	})
+	srv := NewServerWithConfig(serverEnd, &Config{Cache: &CacheConfig{}, Policy: policy})
+	reader := &counterTool{name: "read", annotations: mcp.ToolAnnotations{ReadOnlyHint: true}}
+	srv.RegisterTool(reader)
+	runTestServer(t, srv)
+
+	if text := callText(t, client, "read", `{"q":"keep"}`); text != "call 1" {
+		t.Fatalf("Expected an allowed call to run, got %q", text)
+	}
+	if len(seen) != 1 || seen[0].Tool != reader || string(seen[0].Arguments) != `{"q":"keep"}` || seen[0].Session == nil {
+		t.Errorf("Expected the policy to see the tool, arguments and session, got %+v", seen)
+	}
+
+	// Cached results are not served to calls the policy denies
+	if text := callText(t, client, "read", `{"q":"drop"}`); text != "denied by policy: no dropping" {
+		t.Errorf("Expected the denial as the result, got %q", text)
+	}
+	if reader.calls != 1 {
+		t.Errorf("Expected a denied call not to run, got %d calls", reader.calls)
+	}
+
+	sendRaw(t, client, `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"read","arguments":{"q":"forbidden"}}}`)
+	expectJSON(t, client, `{"jsonrpc":"2.0","id":2,"error":{"code":-32600,"message":"Forbidden"}}`)
+
+	stats := srv.(*MCPServer).ToolStats()["read"]
+	if stats.Denied != 2 || stats.Calls != 1 {
+		t.Errorf("Expected 2 denied calls and 1 call, got %+v", stats)
+	}
+}
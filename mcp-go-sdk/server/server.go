@@ -1,15 +1,19 @@
 package server
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"net"
 	"os"
 	"sync"
 	"syscall"
+	"time"
 
 	"mcp-go-sdk"
+	"mcp-go-sdk/transport"
 )
 
 // Server defines the interface for an MCP server
@@ -17,29 +21,147 @@ type Server interface {
 	// RegisterTool registers a new tool with the server
 	RegisterTool(tool mcp.Tool) error
 
-	// Start starts the server
+	// UnregisterTool removes the tool with the given name
+	UnregisterTool(name string) error
+
+	// HandleMethod registers a handler for a JSON-RPC request method
+	HandleMethod(method string, handler MethodHandler) error
+
+	// HandleNotification registers a handler for a JSON-RPC notification
+	HandleNotification(method string, handler NotificationHandler) error
+
+	// Start starts the server on its own transport
 	Start() error
 
+	// ServeTransport runs a session over the given transport until the
+	// client disconnects or the server stops
+	ServeTransport(t mcp.Transport) error
+
+	// Serve accepts connections on the listener and runs a session for each
+	Serve(l net.Listener) error
+
+	// ListenAndServe listens on a "tcp" or "unix" address and serves
+	// connections on it
+	ListenAndServe(network, address string) error
+
+	// Notify sends a notification to every initialized session, such as
+	// "notifications/tools/list_changed" after the tools have changed
+	Notify(method string, params interface{}) error
+
 	// Stop stops the server
 	Stop() error
 }
 
-// MCPServer implements the Server interface
+// Config represents configuration options for a server
+type Config struct {
+	// Info identifies the server to clients
+	Info mcp.ServerInfo
+
+	// Capabilities are announced to clients during initialization. Nil
+	// derives them from the server: tools are always announced, resources
+	// and prompts once handlers for resources/list and prompts/list are
+	// registered.
+	Capabilities *mcp.ServerCapabilities
+
+	// ToolLimits throttles the calls of each named tool across all
+	// sessions. The "*" entry applies to every tool without an entry of its
+	// own, each with its own budget.
+	ToolLimits map[string]*Limit
+
+	// SessionLimit throttles the tool calls of each session
+	SessionLimit *Limit
+
+	// ToolTimeouts bounds the run time of each named tool. The "*" entry
+	// applies to every tool without an entry of its own; zero means no
+	// limit. The deadline reaches tools through the context passed to
+	// mcp.ContextTool. A call that runs out of time gets an isError result.
+	ToolTimeouts map[string]time.Duration
+
+	// Cache caches the results of read-only and idempotent tools. Nil turns
+	// caching off.
+	Cache *CacheConfig
+
+	// Output bounds the text content of tool results and keeps the full
+	// text of truncated results as resources. Nil turns the budget off.
+	Output *OutputConfig
+
+	// Policy decides whether tool calls may run. Nil allows every call.
+	Policy Policy
+
+	// ConcurrentRequests lets the requests of a session run at the same
+	// time, so that a slow call does not hold up the others. Tools and
+	// handlers must then be safe for concurrent use. By default requests
+	// run one at a time in the order they arrive; the session keeps reading
+	// meanwhile, so that cancellations and answers to Session.Call still
+	// get through.
+	ConcurrentRequests bool
+}
+
+// DefaultConfig returns the default server configuration
+func DefaultConfig() *Config {
+	return &Config{
+		Info: mcp.ServerInfo{
+			Name:    "MCP Server",
+			Version: "1.0.0",
+		},
+	}
+}
+
+// MCPServer implements the Server interface. Its tools and handlers are
+// shared by all sessions.
 type MCPServer struct {
-	transport   mcp.Transport
-	tools       []mcp.Tool
-	mu          sync.RWMutex
-	initialized bool
-	done        chan struct{}
-	running     sync.WaitGroup
+	transport     mcp.Transport
+	config        *Config
+	tools         []mcp.Tool
+	methods       map[string]MethodHandler
+	notifications map[string]NotificationHandler
+	mu            sync.RWMutex
+	sessions      map[*Session]struct{}
+	listeners     map[net.Listener]struct{}
+	done          chan struct{}
+	running       sync.WaitGroup
+	limitersMu    sync.Mutex
+	limiters      map[string]*limiter
+	metrics       toolMetrics
+	cache         *resultCache
+	outputs       *outputStore
 }
 
-// NewServer creates a new MCP server with the given transport
+// NewServer creates a new MCP server with the given transport. The transport
+// may be nil for servers that only serve sessions through ServeTransport,
+// Serve or ListenAndServe.
 func NewServer(t mcp.Transport) Server {
+	return NewServerWithConfig(t, DefaultConfig())
+}
+
+// NewServerWithConfig creates a new MCP server with the given transport and
+// configuration. A nil config uses DefaultConfig.
+func NewServerWithConfig(t mcp.Transport, config *Config) Server {
+	if config == nil {
+		config = DefaultConfig()
+	}
+
+	var cache *resultCache
+	if config.Cache != nil {
+		cache = newResultCache(config.Cache)
+	}
+
+	var outputs *outputStore
+	if config.Output != nil {
+		outputs = newOutputStore(config.Output)
+	}
+
 	return &MCPServer{
-		transport:   t,
-		initialized: false,
-		done:        make(chan struct{}),
+		transport:     t,
+		config:        config,
+		methods:       make(map[string]MethodHandler),
+		notifications: make(map[string]NotificationHandler),
+		sessions:      make(map[*Session]struct{}),
+		listeners:     make(map[net.Listener]struct{}),
+		done:          make(chan struct{}),
+		limiters:      make(map[string]*limiter),
+		cache:         cache,
+		outputs:       outputs,
 	}
 }
 
@@ -51,21 +173,77 @@ func (s *MCPServer) RegisterTool(tool mcp.Tool) error {
 	return nil
 }
 
+// UnregisterTool implements Server
+func (s *MCPServer) UnregisterTool(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, t := range s.tools {
+		if t.Name() == name {
+			s.tools = append(s.tools[:i:i], s.tools[i+1:]...)
+			s.InvalidateCache(name)
+			return nil
+		}
+	}
+	return fmt.Errorf("tool %s is not registered", name)
+}
+
+// Notify implements Server. It tries every session and returns the first
+// error.
+func (s *MCPServer) Notify(method string, params interface{}) error {
+	s.mu.RLock()
+	sessions := make([]*Session, 0, len(s.sessions))
+	for sess := range s.sessions {
+		if sess.Initialized() {
+			sessions = append(sessions, sess)
+		}
+	}
+	s.mu.RUnlock()
+
+	var firstErr error
+	for _, sess := range sessions {
+		if err := sess.Notify(method, params); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
 // Start implements Server
 func (s *MCPServer) Start() error {
-	s.running.Add(1)
-	defer s.running.Done()
+	if s.transport == nil {
+		return errors.New("server has no transport")
+	}
+	return s.ServeTransport(s.transport)
+}
+
+// ServeTransport implements Server
+func (s *MCPServer) ServeTransport(t mcp.Transport) error {
+	sess := newSession(t)
+	if s.config.SessionLimit != nil {
+		sess.limiter = newLimiter(s.config.SessionLimit, "session")
+	}
+	if !s.addSession(sess) {
+		return nil
+	}
+	defer s.removeSession(sess)
+	defer sess.finishRequests()
+	if s.outputs != nil {
+		defer s.outputs.dropSession(sess.id)
+	}
 
 	for {
 		select {
 		case <-s.done:
 			return nil
 		default:
-			if err := s.handleNextMessage(); err != nil {
+			if err := s.handleNextMessage(sess); err != nil {
 				if err == io.EOF {
 					// Client closed connection normally
 					return nil
 				}
+				if s.stopping() {
+					return nil
+				}
 				if isConnectionError(err) {
 					// Client connection lost, exit gracefully
 					return fmt.Errorf("client connection lost: %v", err)
@@ -77,37 +255,182 @@ func (s *MCPServer) Start() error {
 	}
 }
 
+// Serve implements Server. Temporary accept errors, such as running out of
+// file descriptors, are retried with a growing delay as net/http does.
+func (s *MCPServer) Serve(l net.Listener) error {
+	s.mu.Lock()
+	if s.stopping() {
+		s.mu.Unlock()
+		l.Close()
+		return nil
+	}
+	s.listeners[l] = struct{}{}
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		delete(s.listeners, l)
+		s.mu.Unlock()
+		l.Close()
+	}()
+
+	var delay time.Duration
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if s.stopping() {
+				return nil
+			}
+			var ne net.Error
+			if errors.As(err, &ne) && ne.Temporary() {
+				if delay == 0 {
+					delay = 5 * time.Millisecond
+				} else if delay *= 2; delay > time.Second {
+					delay = time.Second
+				}
+				fmt.Fprintf(os.Stderr, "Accept failed: %v; retrying in %v\n", err, delay)
+				select {
+				case <-time.After(delay):
+				case <-s.done:
+				}
+				continue
+			}
+			return fmt.Errorf("accept failed: %w", err)
+		}
+		delay = 0
+
+		go func() {
+			defer conn.Close()
+			t := transport.NewBaseTransport(conn, conn, conn, nil)
+			if err := s.ServeTransport(t); err != nil {
+				fmt.Fprintf(os.Stderr, "Session for %s ended: %v\n", conn.RemoteAddr(), err)
+			}
+		}()
+	}
+}
+
+// ListenAndServe implements Server. A stale Unix socket file left behind by
+// a previous run is removed before listening, but one that a running server
+// still accepts connections on is left alone.
+func (s *MCPServer) ListenAndServe(network, address string) error {
+	switch network {
+	case "tcp", "tcp4", "tcp6":
+	case "unix":
+		if info, err := os.Stat(address); err == nil && info.Mode()&os.ModeSocket != 0 {
+			conn, err := net.DialTimeout("unix", address, time.Second)
+			if err == nil {
+				conn.Close()
+				return fmt.Errorf("listen unix %s: socket is in use by a running server", address)
+			}
+			os.Remove(address)
+		}
+	default:
+		return fmt.Errorf("unsupported network %q", network)
+	}
+
+	l, err := net.Listen(network, address)
+	if err != nil {
+		return err
+	}
+	return s.Serve(l)
+}
+
 // handleNextMessage processes a single message
-func (s *MCPServer) handleNextMessage() error {
-	msg, err := s.transport.Receive()
+func (s *MCPServer) handleNextMessage(sess *Session) error {
+	msg, err := sess.transport.Receive()
 	if err != nil {
+		// The transport has skipped the bad input, so tell the client and
+		// carry on with the next message
+		var malformed *mcp.MalformedMessageError
+		switch {
+		case errors.Is(err, mcp.ErrMessageTooLarge):
+			return sess.sendError(nil, ErrInvalidRequest, "Message too large", err.Error())
+		case errors.As(err, &malformed):
+			return sess.sendError(nil, ErrParseError, "Parse error", malformed.Err.Error())
+		}
 		return err
 	}
 
 	// Parse the request
 	var req mcp.Request
 	if err := json.Unmarshal(msg, &req); err != nil {
-		s.sendError(nil, ErrParseError, "Parse error", err.Error())
+		sess.sendError(nil, ErrParseError, "Parse error", err.Error())
+		return nil
+	}
+
+	ctx := sess.ctx
+
+	// Notifications carry no id and must never be answered
+	if len(req.ID) == 0 {
+		if err := s.handleNotification(ctx, sess, &req); err != nil {
+			fmt.Fprintf(os.Stderr, "Error handling notification: %v\n", err)
+		}
 		return nil
 	}
 
-	// Handle the request
-	var handleErr error
+	switch {
+	case string(req.ID) == "null":
+		return sess.sendError(nil, ErrInvalidRequest, "Invalid request", "id must not be null")
+	case req.Method == "":
+		if sess.deliver(msg) {
+			return nil
+		}
+		return sess.sendError(&req.ID, ErrInvalidRequest, "Invalid request", "method is required")
+	case req.Method == MethodInitialize:
+		// Later messages depend on the outcome, so initialization is
+		// handled before reading on
+		err := s.handleInitialize(sess, &req)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error handling request: %v\n", err)
+		}
+		return err
+	}
+
+	// Other requests are handled apart from the reading, so that they can
+	// be cancelled. Unless they may run concurrently, each waits for the
+	// one before it.
+	ctx, done := sess.startRequest(ctx, req.ID)
+	var previous, finished chan struct{}
+	if !s.config.ConcurrentRequests {
+		previous, finished = sess.previous, make(chan struct{})
+		sess.previous = finished
+	}
+	sess.handlers.Add(1)
+	go func() {
+		defer sess.handlers.Done()
+		if finished != nil {
+			defer close(finished)
+		}
+		defer done()
+		if previous != nil {
+			<-previous
+		}
+		if err := s.handleRequest(ctx, sess, &req); err != nil {
+			fmt.Fprintf(os.Stderr, "Error handling request: %v\n", err)
+		}
+	}()
+	return nil
+}
+
+// handleRequest dispatches a request to its handler
+func (s *MCPServer) handleRequest(ctx context.Context, sess *Session, req *mcp.Request) error {
 	switch req.Method {
-	case MethodInitialize:
-		handleErr = s.handleInitialize(&req)
 	case MethodListTools:
-		handleErr = s.handleListTools(&req)
+		return s.handleListTools(sess, req)
 	case MethodCallTool:
-		handleErr = s.handleCallTool(&req)
+		return s.handleCallTool(ctx, sess, req)
+	case MethodPing:
+		return sess.sendResult(&req.ID, struct{}{})
+	case MethodListResources, MethodReadResource:
+		if s.outputs != nil {
+			if handled, err := s.handleOutputRequest(sess, req); handled {
+				return err
+			}
+		}
+		return s.handleCustomMethod(ctx, sess, req)
 	default:
-		handleErr = s.sendError(&req.ID, ErrMethodNotFound, "Method not found", req.Method)
-	}
-
-	if handleErr != nil {
-		fmt.Fprintf(os.Stderr, "Error handling request: %v\n", handleErr)
+		return s.handleCustomMethod(ctx, sess, req)
 	}
-	return handleErr
 }
 
 // isConnectionError checks if the error is related to client disconnection
@@ -119,14 +442,21 @@ func isConnectionError(err error) bool {
 	if errors.Is(err, syscall.EPIPE) {
 		return true // Broken pipe
 	}
-	if errors.Is(err, os.ErrClosed) {
-		return true // File already closed
+	if errors.Is(err, syscall.ECONNRESET) {
+		return true // Connection reset by peer
+	}
+	if errors.Is(err, os.ErrClosed) || errors.Is(err, net.ErrClosed) {
+		return true // File or connection already closed
+	}
+	if errors.Is(err, io.ErrUnexpectedEOF) {
+		return true // Client went away mid-message
 	}
 	return false
 }
 
 // Stop implements Server
 func (s *MCPServer) Stop() error {
+	s.mu.Lock()
 	// Signal the server to stop
 	select {
 	case <-s.done:
@@ -135,48 +465,59 @@ func (s *MCPServer) Stop() error {
 		close(s.done)
 	}
 
-	// Wait for server to finish processing
-	s.running.Wait()
+	// Stop accepting connections and end all other sessions
+	for l := range s.listeners {
+		l.Close()
+	}
+	var transports []mcp.Transport
+	for sess := range s.sessions {
+		if sess.transport != s.transport {
+			transports = append(transports, sess.transport)
+		}
+	}
+	s.mu.Unlock()
 
-	// Close the transport
-	return s.transport.Close()
-}
+	for _, t := range transports {
+		t.Close()
+	}
 
-// Helper methods for sending responses
+	// Close the server's own transport
+	var err error
+	if s.transport != nil {
+		err = s.transport.Close()
+	}
 
-func (s *MCPServer) sendResult(id *json.RawMessage, result interface{}) error {
-	return s.transport.Send(&mcp.Response{
-		JsonRPC: Version,
-		Result:  result,
-		ID:      *id,
-	})
+	// Wait for sessions to finish processing
+	s.running.Wait()
+	return err
 }
 
-func (s *MCPServer) sendError(id *json.RawMessage, code int, message string, data interface{}) error {
-	var respID json.RawMessage
-	if id == nil {
-		respID = json.RawMessage(`"0"`)
-	} else {
-		respID = *id
+// stopping reports whether Stop has been called
+func (s *MCPServer) stopping() bool {
+	select {
+	case <-s.done:
+		return true
+	default:
+		return false
 	}
-	return s.transport.Send(&mcp.Response{
-		JsonRPC: Version,
-		Error: &mcp.Error{
-			Code:    code,
-			Message: message,
-			Data:    data,
-		},
-		ID: respID,
-	})
 }
 
-func (s *MCPServer) sendNotification(method string, params interface{}) error {
-	msg := map[string]interface{}{
-		"jsonrpc": Version,
-		"method":  method,
-	}
-	if params != nil {
-		msg["params"] = params
+// addSession tracks a session, unless the server is stopping
+func (s *MCPServer) addSession(sess *Session) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stopping() {
+		return false
 	}
-	return s.transport.Send(msg)
+	s.sessions[sess] = struct{}{}
+	s.running.Add(1)
+	return true
+}
+
+// removeSession forgets a finished session
+func (s *MCPServer) removeSession(sess *Session) {
+	s.mu.Lock()
+	delete(s.sessions, sess)
+	s.mu.Unlock()
+	s.running.Done()
 }
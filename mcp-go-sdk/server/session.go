@@ -0,0 +1,376 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"mcp-go-sdk"
+	"mcp-go-sdk/transport"
+)
+
+// ErrSessionClosed is returned by Session.Call when the session ends before
+// the client answers
+var ErrSessionClosed = errors.New("session closed")
+
+// Session holds the state of one client connection. All sessions of a server
+// share its tools and handlers, but each negotiates its own protocol version
+// and client capabilities.
+type Session struct {
+	id        string
+	transport mcp.Transport
+	ctx       context.Context
+
+	mu                 sync.RWMutex
+	initialized        bool
+	protocolVersion    string
+	clientInfo         mcp.ClientInfo
+	clientCapabilities mcp.ClientCapabilities
+
+	// Requests being handled, by normalized id
+	requestsMu sync.Mutex
+	requests   map[string]*request
+	handlers   sync.WaitGroup
+
+	// Closed when the last request read has been handled, for requests
+	// that run one at a time. Only the reading goroutine uses it.
+	previous chan struct{}
+
+	// Requests sent to the client, by normalized id
+	callsMu sync.Mutex
+	calls   map[string]chan *clientResponse
+	nextID  int64
+	closed  chan struct{}
+
+	// Throttles the session's tool calls, nil if unlimited
+	limiter *limiter
+}
+
+// request is a request being handled
+type request struct {
+	cancel    context.CancelFunc
+	cancelled bool
+}
+
+// clientResponse is the client's answer to a request sent by the server
+type clientResponse struct {
+	ID     json.RawMessage `json:"id"`
+	Result json.RawMessage `json:"result"`
+	Error  *mcp.Error      `json:"error"`
+}
+
+// newSession creates a session for the given transport
+func newSession(t mcp.Transport) *Session {
+	sess := &Session{
+		id:        newSessionID(),
+		transport: t,
+		requests:  make(map[string]*request),
+		calls:     make(map[string]chan *clientResponse),
+		closed:    make(chan struct{}),
+	}
+	// Handlers see the values of the HTTP request that opened the session,
+	// such as the authenticated principal
+	ctx := context.Background()
+	if c, ok := t.(transport.ContextCarrier); ok {
+		ctx = c.Context()
+	}
+	sess.ctx = contextWithSession(ctx, sess)
+	return sess
+}
+
+// ID returns the unique identifier of the session
+func (s *Session) ID() string {
+	return s.id
+}
+
+// Initialized reports whether the client has completed initialization
+func (s *Session) Initialized() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.initialized
+}
+
+// ProtocolVersion returns the protocol version negotiated for the session
+func (s *Session) ProtocolVersion() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.protocolVersion
+}
+
+// ClientInfo returns the name and version the client sent on initialization
+func (s *Session) ClientInfo() mcp.ClientInfo {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.clientInfo
+}
+
+// ClientCapabilities returns the capabilities the client declared on
+// initialization
+func (s *Session) ClientCapabilities() mcp.ClientCapabilities {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.clientCapabilities
+}
+
+// QueueStats returns the state of the outgoing message queue of the
+// session's transport. It reports false if the transport does not queue
+// messages.
+func (s *Session) QueueStats() (transport.QueueStats, bool) {
+	if r, ok := s.transport.(transport.QueueReporter); ok {
+		return r.QueueStats(), true
+	}
+	return transport.QueueStats{}, false
+}
+
+// Notify sends a notification to the client of this session
+func (s *Session) Notify(method string, params interface{}) error {
+	return s.sendNotification(method, params)
+}
+
+// Call sends a request to the client of this session and decodes its result
+// into result, which may be nil. JSON-RPC errors are returned as *mcp.Error.
+// If ctx is cancelled before the response arrives, the client is told to
+// stop working on the request.
+func (s *Session) Call(ctx context.Context, method string, params interface{}, result interface{}) error {
+	ch := make(chan *clientResponse, 1)
+	s.callsMu.Lock()
+	select {
+	case <-s.closed:
+		s.callsMu.Unlock()
+		return ErrSessionClosed
+	default:
+	}
+	s.nextID++
+	id := s.nextID
+	key := strconv.FormatInt(id, 10)
+	s.calls[key] = ch
+	s.callsMu.Unlock()
+
+	defer func() {
+		s.callsMu.Lock()
+		delete(s.calls, key)
+		s.callsMu.Unlock()
+	}()
+
+	req := map[string]interface{}{
+		"jsonrpc": Version,
+		"id":      id,
+		"method":  method,
+	}
+	if params != nil {
+		req["params"] = params
+	}
+	if err := s.transport.Send(req); err != nil {
+		return err
+	}
+
+	select {
+	case resp := <-ch:
+		if resp.Error != nil {
+			return resp.Error
+		}
+		if result == nil || len(resp.Result) == 0 {
+			return nil
+		}
+		if err := json.Unmarshal(resp.Result, result); err != nil {
+			return fmt.Errorf("failed to decode %s result: %w", method, err)
+		}
+		return nil
+	case <-ctx.Done():
+		s.sendNotification(NotificationCancelled, map[string]interface{}{
+			"requestId": id,
+			"reason":    ctx.Err().Error(),
+		})
+		return ctx.Err()
+	case <-s.closed:
+		return ErrSessionClosed
+	}
+}
+
+// deliver hands a response from the client to the call waiting for it. It
+// reports false if msg is not a response. Responses nobody waits for are
+// dropped, since responses are never answered.
+func (s *Session) deliver(msg []byte) bool {
+	var resp clientResponse
+	if err := json.Unmarshal(msg, &resp); err != nil || (resp.Result == nil && resp.Error == nil) {
+		return false
+	}
+	s.callsMu.Lock()
+	ch, ok := s.calls[idKey(resp.ID)]
+	s.callsMu.Unlock()
+	if ok {
+		// A duplicate response must not hold up the session
+		select {
+		case ch <- &resp:
+		default:
+		}
+	}
+	return true
+}
+
+// setInitialized records the outcome of the initialize handshake
+func (s *Session) setInitialized(version string, params mcp.InitializeParams) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.initialized = true
+	s.protocolVersion = version
+	s.clientInfo = params.ClientInfo
+	s.clientCapabilities = params.Capabilities
+}
+
+// startRequest tracks a request being handled. The returned context is
+// cancelled when the client cancels the request or the session ends; done
+// must be called once the request is answered.
+func (s *Session) startRequest(ctx context.Context, id json.RawMessage) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(ctx)
+	req := &request{cancel: cancel}
+	key := idKey(id)
+
+	s.requestsMu.Lock()
+	s.requests[key] = req
+	s.requestsMu.Unlock()
+
+	return ctx, func() {
+		cancel()
+		s.requestsMu.Lock()
+		if s.requests[key] == req {
+			delete(s.requests, key)
+		}
+		s.requestsMu.Unlock()
+	}
+}
+
+// cancelRequest cancels a request being handled. Its response is dropped,
+// since the client no longer expects one. Unknown ids are ignored, because
+// the request may have been answered already.
+func (s *Session) cancelRequest(id json.RawMessage) {
+	s.requestsMu.Lock()
+	defer s.requestsMu.Unlock()
+	if req, ok := s.requests[idKey(id)]; ok {
+		req.cancelled = true
+		req.cancel()
+	}
+}
+
+// cancelled reports whether the client cancelled the request with this id
+func (s *Session) cancelled(id json.RawMessage) bool {
+	s.requestsMu.Lock()
+	defer s.requestsMu.Unlock()
+	req, ok := s.requests[idKey(id)]
+	return ok && req.cancelled
+}
+
+// finishRequests fails the calls waiting for the client, cancels the
+// requests still being handled and waits for their handlers to return
+func (s *Session) finishRequests() {
+	s.callsMu.Lock()
+	close(s.closed)
+	s.callsMu.Unlock()
+
+	s.requestsMu.Lock()
+	for _, req := range s.requests {
+		req.cancel()
+	}
+	s.requestsMu.Unlock()
+	s.handlers.Wait()
+}
+
+// Helper methods for sending responses
+
+func (s *Session) sendResult(id *json.RawMessage, result interface{}) error {
+	if s.cancelled(*id) {
+		return nil
+	}
+	err := s.transport.Send(&mcp.Response{
+		JsonRPC: Version,
+		Result:  result,
+		ID:      *id,
+	})
+	if errors.Is(err, mcp.ErrMessageTooLarge) {
+		// Let the client know instead of leaving the request unanswered
+		return s.sendError(id, ErrInternal, "Response too large", err.Error())
+	}
+	return err
+}
+
+func (s *Session) sendError(id *json.RawMessage, code int, message string, data interface{}) error {
+	// Errors about messages whose id could not be read carry a null id
+	respID := json.RawMessage("null")
+	if id != nil {
+		if s.cancelled(*id) {
+			return nil
+		}
+		respID = *id
+	}
+	return s.transport.Send(&mcp.Response{
+		JsonRPC: Version,
+		Error: &mcp.Error{
+			Code:    code,
+			Message: message,
+			Data:    data,
+		},
+		ID: respID,
+	})
+}
+
+func (s *Session) sendNotification(method string, params interface{}) error {
+	msg := map[string]interface{}{
+		"jsonrpc": Version,
+		"method":  method,
+	}
+	if params != nil {
+		msg["params"] = params
+	}
+	return s.transport.Send(msg)
+}
+
+// sessionKey is the context key for the current session
+type sessionKey struct{}
+
+// SessionFromContext returns the session a request belongs to, or nil if the
+// context does not come from a request handler
+func SessionFromContext(ctx context.Context) *Session {
+	sess, _ := ctx.Value(sessionKey{}).(*Session)
+	return sess
+}
+
+// contextWithSession returns a context carrying sess
+func contextWithSession(ctx context.Context, sess *Session) context.Context {
+	return context.WithValue(ctx, sessionKey{}, sess)
+}
+
+// progressTokenKey is the context key for the progress token of a tool call
+type progressTokenKey struct{}
+
+// ProgressTokenFromContext returns the token the client sent with a tool call
+// to receive notifications/progress, or false if it asked for none
+func ProgressTokenFromContext(ctx context.Context) (int, bool) {
+	token, ok := ctx.Value(progressTokenKey{}).(int)
+	return token, ok
+}
+
+// idKey normalizes a JSON-RPC id so that 1 and "1" are told apart but
+// formatting differences are not
+func idKey(id json.RawMessage) string {
+	var v interface{}
+	if err := json.Unmarshal(id, &v); err != nil {
+		return string(id)
+	}
+	if s, ok := v.(string); ok {
+		return strconv.Quote(s)
+	}
+	b, _ := json.Marshal(v)
+	return string(b)
+}
+
+// newSessionID returns a random session identifier
+func newSessionID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
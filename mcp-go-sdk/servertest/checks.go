@@ -0,0 +1,287 @@
+package servertest
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"mcp-go-sdk"
+	"mcp-go-sdk/server"
+)
+
+// checkHandshake initializes a session and checks the result
+func checkHandshake(t *testing.T, c *conn, opts *Options) {
+	result := c.initialize()
+	if !supported(result.ProtocolVersion) {
+		t.Errorf("Server negotiated unknown protocol version %q", result.ProtocolVersion)
+	}
+	if result.ServerInfo.Name == "" {
+		t.Error("Server did not send its name in serverInfo")
+	}
+
+	c.request(`1`, server.MethodPing, nil)
+	c.result(`1`, nil)
+
+	// protocolVersion is required
+	c.request(`2`, server.MethodInitialize, map[string]interface{}{"capabilities": map[string]interface{}{}})
+	c.error(`2`, server.ErrInvalidParams)
+}
+
+// checkUnsupportedVersion asks for a protocol version the server cannot know
+// and expects it to offer one it supports instead
+func checkUnsupportedVersion(t *testing.T, c *conn, opts *Options) {
+	c.request(`1`, server.MethodInitialize, initializeParams("1999-01-01"))
+	var result mcp.InitializeResult
+	c.result(`1`, &result)
+	if !supported(result.ProtocolVersion) {
+		t.Errorf("Expected the server to offer a supported protocol version, got %q", result.ProtocolVersion)
+	}
+}
+
+// checkUnknownMethod expects method not found for unknown requests
+func checkUnknownMethod(t *testing.T, c *conn, opts *Options) {
+	c.initialize()
+	c.request(`1`, "servertest/unknown", map[string]int{"n": 1})
+	c.error(`1`, server.ErrMethodNotFound)
+}
+
+// checkMalformedJSON expects parse errors with a null id and invalid request
+// errors, after which the session keeps working
+func checkMalformedJSON(t *testing.T, c *conn, opts *Options) {
+	c.initialize()
+
+	c.send(`{"jsonrpc":"2.0","id":1,"method":"ping",}`)
+	c.error(`null`, server.ErrParseError)
+
+	c.send(`{"jsonrpc":"2.0","id":2}`)
+	c.error(`2`, server.ErrInvalidRequest)
+
+	c.request(`3`, server.MethodPing, nil)
+	c.result(`3`, nil)
+}
+
+// checkNotifications expects notifications, known or not, to go unanswered
+func checkNotifications(t *testing.T, c *conn, opts *Options) {
+	c.initialize()
+	c.notify("servertest/unknown", map[string]int{"n": 1})
+	c.notify(server.NotificationCancelled, map[string]string{"requestId": "servertest-unknown"})
+	c.notify("notifications/roots/list_changed", nil)
+
+	// The first response must be the one to this request
+	c.request(`1`, server.MethodPing, nil)
+	c.result(`1`, nil)
+}
+
+// checkIDs expects every id to be echoed exactly and null ids to be refused
+func checkIDs(t *testing.T, c *conn, opts *Options) {
+	c.initialize()
+	for _, id := range []string{`"servertest-id"`, `""`, `42`, `0`, `-7`, `9007199254740991`} {
+		c.request(id, server.MethodPing, nil)
+		c.result(id, nil)
+	}
+
+	// MCP does not allow null ids
+	c.request(`null`, server.MethodPing, nil)
+	c.error(`null`, server.ErrInvalidRequest)
+}
+
+// checkToolSchemas expects every tool to have a unique name and an object
+// schema for its arguments
+func checkToolSchemas(t *testing.T, c *conn, opts *Options) {
+	c.initialize()
+	tools := listTools(t, c)
+
+	names := make(map[string]bool)
+	for _, tool := range tools {
+		if tool.Name == "" {
+			t.Errorf("Tool without a name: %+v", tool)
+			continue
+		}
+		if names[tool.Name] {
+			t.Errorf("Tool %s is listed twice", tool.Name)
+		}
+		names[tool.Name] = true
+
+		if err := validateInputSchema(tool.InputSchema); err != nil {
+			t.Errorf("Tool %s has an invalid input schema: %v", tool.Name, err)
+		}
+	}
+}
+
+// checkToolErrors expects unknown tools to be protocol errors and tool
+// failures to be results with isError set
+func checkToolErrors(t *testing.T, c *conn, opts *Options) {
+	c.initialize()
+	c.request(`1`, server.MethodCallTool, map[string]interface{}{"name": "servertest-no-such-tool", "arguments": map[string]interface{}{}})
+	c.error(`1`, 0)
+
+	if opts.ErrorCall == nil {
+		return
+	}
+	c.request(`2`, server.MethodCallTool, callParams(opts.ErrorCall))
+	var result mcp.CallToolResult
+	c.result(`2`, &result)
+	if !result.IsError {
+		t.Errorf("Expected %s to report isError", opts.ErrorCall.Name)
+	}
+	if len(result.Content) == 0 {
+		t.Errorf("Expected %s to explain the error in its content", opts.ErrorCall.Name)
+	}
+}
+
+// checkCancellation expects cancelled requests to go unanswered and the
+// session to keep working
+func checkCancellation(t *testing.T, c *conn, opts *Options) {
+	c.initialize()
+
+	if opts.SlowCall == nil {
+		// The request may be answered before the cancellation arrives, so
+		// either outcome is fine
+		c.request(`"cancelled"`, server.MethodPing, nil)
+		c.notify(server.NotificationCancelled, map[string]string{"requestId": "cancelled", "reason": "servertest"})
+		c.request(`"after"`, server.MethodPing, nil)
+		msg := c.next(c.timeout)
+		if msg != nil && sameID(msg.ID, `"cancelled"`) {
+			msg = c.next(c.timeout)
+		}
+		if msg == nil || !sameID(msg.ID, `"after"`) {
+			t.Fatalf("Expected the response to request \"after\", got %v", msg)
+		}
+		return
+	}
+
+	c.request(`"slow"`, server.MethodCallTool, callParams(opts.SlowCall))
+	c.notify(server.NotificationCancelled, map[string]string{"requestId": "slow", "reason": "servertest"})
+
+	// A slow request must not hold up the session
+	c.request(`"after"`, server.MethodPing, nil)
+	c.result(`"after"`, nil)
+
+	if msg := c.next(opts.CancelWait); msg != nil {
+		t.Errorf("Expected no response to the cancelled request, got %s", msg.raw)
+	}
+}
+
+// listTools lists all tools, following pagination
+func listTools(t *testing.T, c *conn) []mcp.ToolInfo {
+	var tools []mcp.ToolInfo
+	cursor := ""
+	for page := 1; ; page++ {
+		var params interface{}
+		if cursor != "" {
+			params = mcp.PaginatedParams{Cursor: cursor}
+		}
+		id := fmt.Sprintf("%d", 100+page)
+		c.request(id, server.MethodListTools, params)
+		var result mcp.ListToolsResponse
+		c.result(id, &result)
+		tools = append(tools, result.Tools...)
+
+		if result.NextCursor == "" || result.NextCursor == cursor || page > 100 {
+			return tools
+		}
+		cursor = result.NextCursor
+	}
+}
+
+// callParams returns the params of a tools/call request
+func callParams(call *ToolCall) map[string]interface{} {
+	params := map[string]interface{}{"name": call.Name}
+	if call.Arguments != nil {
+		params["arguments"] = call.Arguments
+	}
+	return params
+}
+
+// supported reports whether version is a protocol version of the SDK
+func supported(version string) bool {
+	for _, v := range server.SupportedProtocolVersions {
+		if v == version {
+			return true
+		}
+	}
+	return false
+}
+
+// validateInputSchema checks that a tool's input schema is an object schema
+func validateInputSchema(raw json.RawMessage) error {
+	var schema map[string]interface{}
+	if err := json.Unmarshal(raw, &schema); err != nil {
+		return fmt.Errorf("not a JSON object: %v", err)
+	}
+	if schema["type"] != "object" {
+		return fmt.Errorf(`type must be "object", got %v`, schema["type"])
+	}
+	return validateSchema(schema, "")
+}
+
+// jsonTypes are the types a JSON schema may name
+var jsonTypes = map[string]bool{
+	"object": true, "array": true, "string": true, "number": true,
+	"integer": true, "boolean": true, "null": true,
+}
+
+// validateSchema checks the parts of a JSON schema that tools commonly get
+// wrong: type names, properties, required and items
+func validateSchema(schema map[string]interface{}, path string) error {
+	switch typ := schema["type"].(type) {
+	case nil:
+	case string:
+		if !jsonTypes[typ] {
+			return fmt.Errorf("%sunknown type %q", path, typ)
+		}
+	case []interface{}:
+		for _, t := range typ {
+			if s, ok := t.(string); !ok || !jsonTypes[s] {
+				return fmt.Errorf("%sunknown type %v", path, t)
+			}
+		}
+	default:
+		return fmt.Errorf("%stype must be a string or an array, got %v", path, typ)
+	}
+
+	var properties map[string]interface{}
+	if p, ok := schema["properties"]; ok {
+		if properties, ok = p.(map[string]interface{}); !ok {
+			return fmt.Errorf("%sproperties must be an object", path)
+		}
+		for name, prop := range properties {
+			sub, ok := prop.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("%sproperty %s must be a schema object", path, name)
+			}
+			if err := validateSchema(sub, path+name+"."); err != nil {
+				return err
+			}
+		}
+	}
+
+	if r, ok := schema["required"]; ok {
+		required, ok := r.([]interface{})
+		if !ok {
+			return fmt.Errorf("%srequired must be an array", path)
+		}
+		for _, name := range required {
+			s, ok := name.(string)
+			if !ok {
+				return fmt.Errorf("%srequired must list property names, got %v", path, name)
+			}
+			if properties != nil {
+				if _, ok := properties[s]; !ok {
+					return fmt.Errorf("%srequired property %s is not defined", path, s)
+				}
+			}
+		}
+	}
+
+	if items, ok := schema["items"]; ok {
+		sub, ok := items.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%sitems must be a schema object", path)
+		}
+		if err := validateSchema(sub, path+"items."); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,193 @@
+package servertest
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"mcp-go-sdk"
+	"mcp-go-sdk/server"
+)
+
+// message is any JSON-RPC message sent by the server
+type message struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Method  string          `json:"method"`
+	Result  json.RawMessage `json:"result"`
+	Error   *mcp.Error      `json:"error"`
+	raw     []byte
+}
+
+// isResponse reports whether the message answers a request
+func (m *message) isResponse() bool {
+	return m.Method == ""
+}
+
+// conn is a connection to the server under test
+type conn struct {
+	t         *testing.T
+	transport rawTransport
+	timeout   time.Duration
+	messages  chan *message
+}
+
+// newConn starts reading from tr, which is closed when the test ends
+func newConn(t *testing.T, tr rawTransport, timeout time.Duration) *conn {
+	c := &conn{
+		t:         t,
+		transport: tr,
+		timeout:   timeout,
+		messages:  make(chan *message, 64),
+	}
+	t.Cleanup(func() { tr.Close() })
+
+	go func() {
+		defer close(c.messages)
+		for {
+			data, err := tr.Receive()
+			if err != nil {
+				return
+			}
+			msg := &message{raw: data}
+			if err := json.Unmarshal(data, msg); err != nil {
+				msg.JSONRPC = "invalid: " + err.Error()
+			}
+			c.messages <- msg
+		}
+	}()
+	return c
+}
+
+// send writes a message as-is
+func (c *conn) send(msg string) {
+	c.t.Helper()
+	if err := c.transport.SendRaw([]byte(msg)); err != nil {
+		c.t.Fatalf("Failed to send %s: %v", msg, err)
+	}
+}
+
+// request sends a request with the given raw id
+func (c *conn) request(id, method string, params interface{}) {
+	c.t.Helper()
+	msg := map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      json.RawMessage(id),
+		"method":  method,
+	}
+	if params != nil {
+		msg["params"] = params
+	}
+	data, _ := json.Marshal(msg)
+	c.send(string(data))
+}
+
+// notify sends a notification
+func (c *conn) notify(method string, params interface{}) {
+	c.t.Helper()
+	msg := map[string]interface{}{"jsonrpc": "2.0", "method": method}
+	if params != nil {
+		msg["params"] = params
+	}
+	data, _ := json.Marshal(msg)
+	c.send(string(data))
+}
+
+// next returns the next response within wait, skipping notifications and
+// requests from the server. It returns nil if none arrives.
+func (c *conn) next(wait time.Duration) *message {
+	c.t.Helper()
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	for {
+		select {
+		case msg, ok := <-c.messages:
+			if !ok {
+				c.t.Fatal("Server closed the connection")
+			}
+			if msg.JSONRPC != "2.0" {
+				c.t.Fatalf("Message is not JSON-RPC 2.0 (%s): %s", msg.JSONRPC, msg.raw)
+			}
+			if msg.isResponse() {
+				return msg
+			}
+		case <-timer.C:
+			return nil
+		}
+	}
+}
+
+// response waits for the response to the request with the given raw id. A
+// response to any other request fails the test.
+func (c *conn) response(id string) *message {
+	c.t.Helper()
+	msg := c.next(c.timeout)
+	if msg == nil {
+		c.t.Fatalf("No response to request %s within %v", id, c.timeout)
+	}
+	if !sameID(msg.ID, id) {
+		c.t.Fatalf("Expected the response to request %s, got %s", id, msg.raw)
+	}
+	if (msg.Result == nil) == (msg.Error == nil) {
+		c.t.Fatalf("Response must carry either a result or an error: %s", msg.raw)
+	}
+	return msg
+}
+
+// result waits for a successful response and decodes its result into v,
+// which may be nil
+func (c *conn) result(id string, v interface{}) {
+	c.t.Helper()
+	msg := c.response(id)
+	if msg.Error != nil {
+		c.t.Fatalf("Request %s failed: %s", id, msg.raw)
+	}
+	if v != nil {
+		if err := json.Unmarshal(msg.Result, v); err != nil {
+			c.t.Fatalf("Invalid result for request %s: %v: %s", id, err, msg.raw)
+		}
+	}
+}
+
+// error waits for an error response with the given code, or any code if
+// code is 0
+func (c *conn) error(id string, code int) *mcp.Error {
+	c.t.Helper()
+	msg := c.response(id)
+	if msg.Error == nil {
+		c.t.Fatalf("Expected an error for request %s, got %s", id, msg.raw)
+	}
+	if code != 0 && msg.Error.Code != code {
+		c.t.Errorf("Expected error code %d for request %s, got %s", code, id, msg.raw)
+	}
+	return msg.Error
+}
+
+// initialize performs the handshake
+func (c *conn) initialize() *mcp.InitializeResult {
+	c.t.Helper()
+	c.request(`0`, server.MethodInitialize, initializeParams(server.ProtocolVersion))
+	var result mcp.InitializeResult
+	c.result(`0`, &result)
+	c.notify(server.NotificationInitialized, nil)
+	return &result
+}
+
+// initializeParams returns the params of an initialize request
+func initializeParams(version string) map[string]interface{} {
+	return map[string]interface{}{
+		"protocolVersion": version,
+		"capabilities":    map[string]interface{}{},
+		"clientInfo":      map[string]string{"name": "servertest", "version": "1.0.0"},
+	}
+}
+
+// sameID reports whether a response id is the raw request id
+func sameID(actual json.RawMessage, expected string) bool {
+	var a, e bytes.Buffer
+	if json.Compact(&a, actual) != nil || json.Compact(&e, []byte(expected)) != nil {
+		return false
+	}
+	return a.String() == e.String()
+}
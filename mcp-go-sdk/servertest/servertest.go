@@ -0,0 +1,149 @@
+// Package servertest checks that an MCP server follows the protocol. Run the
+// checks from a test of the server, either against a server.Server in the
+// same process or against the server's command:
+//
+//	func TestConformance(t *testing.T) {
+//		servertest.Run(t, newServer(), nil)
+//	}
+//
+//	func TestConformance(t *testing.T) {
+//		servertest.RunCommand(t, &transport.CommandConfig{Command: servertest.Build(t, ".")}, nil)
+//	}
+//
+// Every check runs as a subtest on a connection of its own.
+package servertest
+
+import (
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"mcp-go-sdk"
+	"mcp-go-sdk/server"
+	"mcp-go-sdk/transport"
+)
+
+// ToolCall names a tool and the arguments to call it with
+type ToolCall struct {
+	Name      string
+	Arguments interface{}
+}
+
+// Options tune the checks
+type Options struct {
+	// ErrorCall is a call that makes its tool fail, used to check that tool
+	// failures are reported as results with isError set. Nil skips the
+	// check.
+	ErrorCall *ToolCall
+
+	// SlowCall is a call that keeps its tool busy for at least CancelWait,
+	// used to check that a cancelled request is not answered. Nil checks
+	// cancellation with requests that complete right away.
+	SlowCall *ToolCall
+
+	// CancelWait is how long a cancelled SlowCall must stay unanswered
+	CancelWait time.Duration
+
+	// Timeout bounds the wait for every expected response
+	Timeout time.Duration
+}
+
+// DefaultOptions returns the default options
+func DefaultOptions() *Options {
+	return &Options{
+		CancelWait: time.Second,
+		Timeout:    10 * time.Second,
+	}
+}
+
+// rawTransport is a transport that can also send malformed input
+type rawTransport interface {
+	mcp.Transport
+	SendRaw(msg []byte) error
+}
+
+// Run runs the checks against srv. Every check connects a new session over
+// an in-memory transport.
+func Run(t *testing.T, srv server.Server, opts *Options) {
+	t.Helper()
+	run(t, func(t *testing.T) rawTransport {
+		clientEnd, serverEnd := transport.NewInMemoryPair()
+		go srv.ServeTransport(serverEnd)
+		return clientEnd
+	}, opts)
+}
+
+// RunCommand runs the checks against the server started by config. Every
+// check starts a new process.
+func RunCommand(t *testing.T, config *transport.CommandConfig, opts *Options) {
+	t.Helper()
+	run(t, func(t *testing.T) rawTransport {
+		tr, err := transport.NewCommandTransport(config)
+		if err != nil {
+			t.Fatalf("Failed to start server: %v", err)
+		}
+		return tr
+	}, opts)
+}
+
+// Build compiles the main package in dir and returns the path of the
+// binary, which is removed when the test ends
+func Build(t *testing.T, dir string) string {
+	t.Helper()
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		t.Fatalf("Failed to build %s: %v", dir, err)
+	}
+	binary := filepath.Join(t.TempDir(), filepath.Base(abs))
+
+	cmd := exec.Command("go", "build", "-o", binary, ".")
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("Failed to build %s: %v\n%s", dir, err, out)
+	}
+	return binary
+}
+
+// run runs every check on connections made by dial
+func run(t *testing.T, dial func(t *testing.T) rawTransport, opts *Options) {
+	t.Helper()
+	opts = withDefaults(opts)
+
+	checks := []struct {
+		name  string
+		check func(t *testing.T, c *conn, opts *Options)
+	}{
+		{"Handshake", checkHandshake},
+		{"UnsupportedVersion", checkUnsupportedVersion},
+		{"UnknownMethod", checkUnknownMethod},
+		{"MalformedJSON", checkMalformedJSON},
+		{"Notifications", checkNotifications},
+		{"IDs", checkIDs},
+		{"ToolSchemas", checkToolSchemas},
+		{"ToolErrors", checkToolErrors},
+		{"Cancellation", checkCancellation},
+	}
+	for _, tc := range checks {
+		t.Run(tc.name, func(t *testing.T) {
+			c := newConn(t, dial(t), opts.Timeout)
+			tc.check(t, c, opts)
+		})
+	}
+}
+
+// withDefaults fills the zero fields of opts from DefaultOptions
+func withDefaults(opts *Options) *Options {
+	defaults := DefaultOptions()
+	if opts == nil {
+		return defaults
+	}
+	o := *opts
+	if o.CancelWait <= 0 {
+		o.CancelWait = defaults.CancelWait
+	}
+	if o.Timeout <= 0 {
+		o.Timeout = defaults.Timeout
+	}
+	return &o
+}
@@ -0,0 +1,72 @@
+package servertest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"mcp-go-sdk/server"
+)
+
+// sleepTool implements mcp.ContextTool by sleeping until cancelled
+type sleepTool struct{}
+
+func (t *sleepTool) Name() string        { return "sleep" }
+func (t *sleepTool) Description() string { return "Sleeps for a number of seconds" }
+func (t *sleepTool) Schema() json.RawMessage {
+	return json.RawMessage(`{"type":"object","properties":{"seconds":{"type":"number"},"tags":{"type":"array","items":{"type":"string"}}},"required":["seconds"]}`)
+}
+func (t *sleepTool) Execute(params json.RawMessage) (interface{}, error) {
+	return t.ExecuteContext(context.Background(), params)
+}
+func (t *sleepTool) ExecuteContext(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var args struct {
+		Seconds float64 `json:"seconds"`
+	}
+	if err := json.Unmarshal(params, &args); err != nil {
+		return nil, err
+	}
+	if args.Seconds < 0 {
+		return nil, errors.New("seconds must not be negative")
+	}
+	select {
+	case <-time.After(time.Duration(args.Seconds * float64(time.Second))):
+		return "done", nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func TestRun(t *testing.T) {
+	srv := server.NewServer(nil)
+	srv.RegisterTool(&sleepTool{})
+	Run(t, srv, &Options{
+		ErrorCall:  &ToolCall{Name: "sleep", Arguments: map[string]int{"seconds": -1}},
+		SlowCall:   &ToolCall{Name: "sleep", Arguments: map[string]int{"seconds": 60}},
+		CancelWait: 200 * time.Millisecond,
+	})
+}
+
+func TestValidateInputSchema(t *testing.T) {
+	tests := []struct {
+		schema string
+		valid  bool
+	}{
+		{`{"type":"object"}`, true},
+		{`{"type":"object","properties":{"a":{"type":["string","null"]}},"required":["a"]}`, true},
+		{`{"type":"string"}`, false},
+		{`[]`, false},
+		{`{"type":"object","properties":{"a":"string"}}`, false},
+		{`{"type":"object","properties":{"a":{"type":"text"}}}`, false},
+		{`{"type":"object","properties":{"a":{"type":"string"}},"required":["b"]}`, false},
+		{`{"type":"object","properties":{"a":{"type":"array","items":{"type":"int"}}}}`, false},
+	}
+	for _, tt := range tests {
+		err := validateInputSchema(json.RawMessage(tt.schema))
+		if (err == nil) != tt.valid {
+			t.Errorf("Expected valid=%v for %s, got %v", tt.valid, tt.schema, err)
+		}
+	}
+}
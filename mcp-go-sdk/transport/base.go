@@ -1,20 +1,32 @@
 package transport
 
 import (
+	"bufio"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
+	"time"
 
 	"mcp-go-sdk"
 )
 
-// BaseTransport provides common functionality for transports
+// BaseTransport provides common functionality for transports. It is safe to
+// call Send from several goroutines; messages are written one at a time
+// through a bounded queue.
 type BaseTransport struct {
-	reader  io.Reader
-	writer  io.Writer
-	closer  io.Closer
-	config  *mcp.TransportConfig
-	encoder *json.Encoder
-	decoder *json.Decoder
+	reader   *bufio.Reader
+	writer   *bufio.Writer
+	deadline writeDeadliner
+	closer   io.Closer
+	config   *mcp.TransportConfig
+	queue    *writeQueue
+}
+
+// writeDeadliner is implemented by writers such as net.Conn that support
+// write deadlines
+type writeDeadliner interface {
+	SetWriteDeadline(t time.Time) error
 }
 
 // NewBaseTransport creates a new base transport
@@ -22,35 +34,265 @@ func NewBaseTransport(r io.Reader, w io.Writer, c io.Closer, config *mcp.Transpo
 	if config == nil {
 		config = mcp.DefaultTransportConfig()
 	}
+	size := config.BufferSize
+	if size <= 0 {
+		size = mcp.DefaultTransportConfig().BufferSize
+	}
 
-	return &BaseTransport{
-		reader:  r,
-		writer:  w,
-		closer:  c,
-		config:  config,
-		encoder: json.NewEncoder(w),
-		decoder: json.NewDecoder(r),
+	t := &BaseTransport{
+		reader: bufio.NewReaderSize(r, size),
+		writer: bufio.NewWriterSize(w, size),
+		closer: c,
+		config: config,
 	}
+	t.deadline, _ = w.(writeDeadliner)
+	t.queue = newWriteQueue(config.QueueSize, config.WriteTimeout, config.FlushInterval, t.flush)
+	return t
 }
 
-// Send implements Transport.Send
+// Send implements Transport.Send. Every message is written as a single line.
+// When the queue is full, Send blocks until there is room or the write
+// timeout passes.
 func (t *BaseTransport) Send(data interface{}) error {
-	return t.encoder.Encode(data)
+	msg, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	return t.SendRaw(msg)
+}
+
+// SendRaw writes bytes to the other end as-is, followed by a newline. It lets
+// tests feed malformed input that Send would refuse to marshal.
+func (t *BaseTransport) SendRaw(msg []byte) error {
+	if t.config.MaxSendSize > 0 && len(msg) > t.config.MaxSendSize {
+		return fmt.Errorf("%w: %d bytes exceeds the limit of %d", mcp.ErrMessageTooLarge, len(msg), t.config.MaxSendSize)
+	}
+
+	msg = append(msg[:len(msg):len(msg)], '\n')
+	return t.queue.write(func() error {
+		t.setWriteDeadline()
+		_, err := t.writer.Write(msg)
+		return err
+	})
+}
+
+// QueueStats implements QueueReporter
+func (t *BaseTransport) QueueStats() QueueStats {
+	return t.queue.stats()
+}
+
+// flush writes out buffered messages
+func (t *BaseTransport) flush() error {
+	t.setWriteDeadline()
+	return t.writer.Flush()
+}
+
+// setWriteDeadline bounds the next write when the writer supports deadlines
+func (t *BaseTransport) setWriteDeadline() {
+	if t.deadline != nil && t.config.WriteTimeout > 0 {
+		t.deadline.SetWriteDeadline(time.Now().Add(t.config.WriteTimeout))
+	}
 }
 
-// Receive implements Transport.Receive
+// Receive implements Transport.Receive. Oversized and malformed input is
+// skipped and reported as mcp.ErrMessageTooLarge or
+// *mcp.MalformedMessageError, after which the next call continues with the
+// following message.
 func (t *BaseTransport) Receive() ([]byte, error) {
-	var raw json.RawMessage
-	if err := t.decoder.Decode(&raw); err != nil {
-		return nil, err
+	if t.config.Framing == mcp.FramingNewline {
+		return t.receiveLine()
 	}
-	return raw, nil
+	return t.receiveValue()
 }
 
-// Close implements Transport.Close
+// Close implements Transport.Close. Messages still waiting for a deferred
+// flush are written first.
 func (t *BaseTransport) Close() error {
+	t.queue.close()
 	if t.closer != nil {
 		return t.closer.Close()
 	}
 	return nil
 }
+
+// receiveLine reads the next non-empty line as one message
+func (t *BaseTransport) receiveLine() ([]byte, error) {
+	max := t.config.MaxMessageSize
+	for {
+		var line []byte
+		tooLarge := false
+		var readErr error
+		for {
+			chunk, err := t.reader.ReadSlice('\n')
+			if !tooLarge {
+				if max > 0 && len(line)+len(trimNewline(chunk)) > max {
+					tooLarge = true
+					line = nil
+				} else {
+					line = append(line, chunk...)
+				}
+			}
+			if err == bufio.ErrBufferFull {
+				continue
+			}
+			readErr = err
+			break
+		}
+
+		if tooLarge {
+			return nil, fmt.Errorf("%w: exceeds the limit of %d bytes", mcp.ErrMessageTooLarge, max)
+		}
+		line = trimSpace(line)
+		if len(line) == 0 {
+			if readErr != nil {
+				return nil, readErr
+			}
+			continue
+		}
+		if !json.Valid(line) {
+			return nil, malformed(line)
+		}
+		return line, nil
+	}
+}
+
+// receiveValue reads the next JSON object or array from the stream,
+// regardless of line breaks
+func (t *BaseTransport) receiveValue() ([]byte, error) {
+	c, err := t.skipSpace()
+	if err != nil {
+		return nil, err
+	}
+
+	if c != '{' && c != '[' {
+		// Not the start of a message, resynchronise at the next line
+		skipped := t.discardLine(c)
+		return nil, &mcp.MalformedMessageError{Err: fmt.Errorf("unexpected input %q", skipped)}
+	}
+
+	msg, tooLarge, err := t.scanValue(c)
+	if err != nil {
+		if err == io.EOF {
+			return nil, io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	if tooLarge {
+		return nil, fmt.Errorf("%w: exceeds the limit of %d bytes", mcp.ErrMessageTooLarge, t.config.MaxMessageSize)
+	}
+	if !json.Valid(msg) {
+		return nil, malformed(msg)
+	}
+	return msg, nil
+}
+
+// skipSpace returns the first byte that is not JSON whitespace
+func (t *BaseTransport) skipSpace() (byte, error) {
+	for {
+		c, err := t.reader.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		switch c {
+		case ' ', '\t', '\r', '\n':
+			continue
+		}
+		return c, nil
+	}
+}
+
+// scanValue reads bytes until the brackets opened by first are balanced.
+// Bytes past the size limit are consumed but not kept.
+func (t *BaseTransport) scanValue(first byte) ([]byte, bool, error) {
+	max := t.config.MaxMessageSize
+	msg := []byte{first}
+	tooLarge := false
+	depth := 1
+	inString, escaped := false, false
+
+	for depth > 0 {
+		c, err := t.reader.ReadByte()
+		if err != nil {
+			return nil, false, err
+		}
+		if !tooLarge {
+			if max > 0 && len(msg) >= max {
+				tooLarge = true
+				msg = nil
+			} else {
+				msg = append(msg, c)
+			}
+		}
+
+		switch {
+		case inString && escaped:
+			escaped = false
+		case inString && c == '\\':
+			escaped = true
+		case inString && c == '"':
+			inString = false
+		case inString:
+		case c == '"':
+			inString = true
+		case c == '{' || c == '[':
+			depth++
+		case c == '}' || c == ']':
+			depth--
+		}
+	}
+	return msg, tooLarge, nil
+}
+
+// discardLine drops input up to and including the next newline and returns
+// the start of what was dropped
+func (t *BaseTransport) discardLine(first byte) []byte {
+	const keep = 32
+	skipped := []byte{first}
+	if first == '\n' {
+		return skipped
+	}
+	for {
+		c, err := t.reader.ReadByte()
+		if err != nil || c == '\n' {
+			return skipped
+		}
+		if len(skipped) < keep {
+			skipped = append(skipped, c)
+		}
+	}
+}
+
+// malformed builds the error for input that is not valid JSON
+func malformed(msg []byte) error {
+	var v interface{}
+	err := json.Unmarshal(msg, &v)
+	if err == nil {
+		err = errors.New("invalid JSON")
+	}
+	return &mcp.MalformedMessageError{Err: err}
+}
+
+// trimNewline strips a trailing line ending
+func trimNewline(b []byte) []byte {
+	if len(b) > 0 && b[len(b)-1] == '\n' {
+		b = b[:len(b)-1]
+	}
+	if len(b) > 0 && b[len(b)-1] == '\r' {
+		b = b[:len(b)-1]
+	}
+	return b
+}
+
+// trimSpace strips leading and trailing JSON whitespace
+func trimSpace(b []byte) []byte {
+	isSpace := func(c byte) bool {
+		return c == ' ' || c == '\t' || c == '\r' || c == '\n'
+	}
+	for len(b) > 0 && isSpace(b[0]) {
+		b = b[1:]
+	}
+	for len(b) > 0 && isSpace(b[len(b)-1]) {
+		b = b[:len(b)-1]
+	}
+	return b
+}
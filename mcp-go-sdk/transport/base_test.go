@@ -0,0 +1,111 @@
+package transport_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"mcp-go-sdk"
+	"mcp-go-sdk/transport"
+)
+
+func newReader(input string, config *mcp.TransportConfig) *transport.BaseTransport {
+	return transport.NewBaseTransport(strings.NewReader(input), io.Discard, nil, config)
+}
+
+func expectMessage(t *testing.T, tr *transport.BaseTransport, expected string) {
+	t.Helper()
+	msg, err := tr.Receive()
+	if err != nil {
+		t.Fatalf("Receive failed: %v", err)
+	}
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, msg); err != nil || compact.String() != expected {
+		t.Fatalf("Expected %s, got %s", expected, msg)
+	}
+}
+
+func expectMalformed(t *testing.T, tr *transport.BaseTransport) {
+	t.Helper()
+	var malformed *mcp.MalformedMessageError
+	if _, err := tr.Receive(); !errors.As(err, &malformed) {
+		t.Fatalf("Expected MalformedMessageError, got %v", err)
+	}
+}
+
+func expectTooLarge(t *testing.T, tr *transport.BaseTransport) {
+	t.Helper()
+	if _, err := tr.Receive(); !errors.Is(err, mcp.ErrMessageTooLarge) {
+		t.Fatalf("Expected ErrMessageTooLarge, got %v", err)
+	}
+}
+
+func TestBaseTransportNewlineFraming(t *testing.T) {
+	config := &mcp.TransportConfig{BufferSize: 16, MaxMessageSize: 32, Framing: mcp.FramingNewline}
+	input := `{"n":1}` + "\n" +
+		`{"data":"` + strings.Repeat("x", 100) + `"}` + "\n" +
+		"\n" +
+		`{"n":` + "\n" +
+		`{"n":2}` + "\r\n"
+	tr := newReader(input, config)
+
+	expectMessage(t, tr, `{"n":1}`)
+	expectTooLarge(t, tr)
+	expectMalformed(t, tr)
+	expectMessage(t, tr, `{"n":2}`)
+	if _, err := tr.Receive(); err != io.EOF {
+		t.Errorf("Expected io.EOF, got %v", err)
+	}
+}
+
+func TestBaseTransportStreamFraming(t *testing.T) {
+	config := &mcp.TransportConfig{MaxMessageSize: 64, Framing: mcp.FramingStream}
+	input := "{\n  \"text\": \"a } in a string\",\n  \"list\": [1, 2]\n}" +
+		`{"n":1}` + "\n" +
+		"garbage that is not json\n" +
+		`{"data":"` + strings.Repeat("x", 100) + `"}` +
+		` [{"n":2}]` + "\n" +
+		`{"n":`
+	tr := newReader(input, config)
+
+	expectMessage(t, tr, `{"text":"a } in a string","list":[1,2]}`)
+	expectMessage(t, tr, `{"n":1}`)
+	expectMalformed(t, tr)
+	expectTooLarge(t, tr)
+	expectMessage(t, tr, `[{"n":2}]`)
+	if _, err := tr.Receive(); err != io.ErrUnexpectedEOF {
+		t.Errorf("Expected io.ErrUnexpectedEOF, got %v", err)
+	}
+}
+
+func TestBaseTransportDefaultFraming(t *testing.T) {
+	// A truncated message costs only its own line by default
+	tr := newReader(`{"n":`+"\n"+`{"n":2}`+"\n", nil)
+	expectMalformed(t, tr)
+	expectMessage(t, tr, `{"n":2}`)
+
+	// and nothing limits what is sent
+	var out bytes.Buffer
+	tr = transport.NewBaseTransport(strings.NewReader(""), &out, nil, nil)
+	if err := tr.Send(map[string]string{"data": strings.Repeat("x", 8<<20)}); err != nil {
+		t.Errorf("Expected large messages to be sent by default, got %v", err)
+	}
+}
+
+func TestBaseTransportSendLimit(t *testing.T) {
+	var out bytes.Buffer
+	tr := transport.NewBaseTransport(strings.NewReader(""), &out, nil, &mcp.TransportConfig{MaxSendSize: 16})
+
+	if err := tr.Send(map[string]int{"n": 1}); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	if err := tr.Send(map[string]string{"data": strings.Repeat("x", 32)}); !errors.Is(err, mcp.ErrMessageTooLarge) {
+		t.Errorf("Expected ErrMessageTooLarge, got %v", err)
+	}
+	if out.String() != "{\"n\":1}\n" {
+		t.Errorf("Unexpected output %q", out.String())
+	}
+}
@@ -0,0 +1,291 @@
+package transport
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"mcp-go-sdk"
+)
+
+// CommandConfig describes a server process started by a CommandTransport
+type CommandConfig struct {
+	// Command is the program to run, looked up in PATH if it has no slash
+	Command string
+
+	// Args are the arguments passed to the program
+	Args []string
+
+	// Env is the environment of the process. Nil inherits the environment
+	// of the current process.
+	Env []string
+
+	// Dir is the working directory of the process, empty for the current one
+	Dir string
+
+	// Logger receives the process's stderr, one line at a time. Nil logs to
+	// os.Stderr prefixed with the command name.
+	Logger *log.Logger
+
+	// CloseTimeout is how long Close waits for the process to exit after
+	// closing its stdin, and again after sending SIGTERM, before killing it
+	CloseTimeout time.Duration
+
+	// Transport configures message framing and limits on stdin/stdout. Nil
+	// uses mcp.DefaultTransportConfig.
+	Transport *mcp.TransportConfig
+}
+
+// ExitError reports that the server process ended while the transport was
+// still open. It matches io.ErrUnexpectedEOF with errors.Is, so servers and
+// clients treat it as a lost connection.
+type ExitError struct {
+	// Err is the error from waiting for the process, such as an
+	// *exec.ExitError; nil if the process exited with status 0
+	Err error
+
+	// Stderr holds the last lines the process wrote to stderr
+	Stderr string
+}
+
+// Error implements the error interface
+func (e *ExitError) Error() string {
+	msg := "server process exited"
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	if e.Stderr != "" {
+		msg += "\n" + e.Stderr
+	}
+	return msg
+}
+
+// Unwrap returns io.ErrUnexpectedEOF and the wait error
+func (e *ExitError) Unwrap() []error {
+	return []error{io.ErrUnexpectedEOF, e.Err}
+}
+
+// CommandTransport runs an MCP server as a child process and talks to it
+// over its stdin and stdout, the way hosts such as Cursor do
+type CommandTransport struct {
+	*BaseTransport
+	cmd     *exec.Cmd
+	stdin   *os.File
+	stdout  *os.File
+	stderr  *lineLogger
+	timeout time.Duration
+
+	exited  chan struct{}
+	waitErr error
+
+	closeOnce sync.Once
+	closing   chan struct{}
+}
+
+// NewCommandTransport starts the process described by config
+func NewCommandTransport(config *CommandConfig) (*CommandTransport, error) {
+	if config == nil || config.Command == "" {
+		return nil, errors.New("command is required")
+	}
+	logger := config.Logger
+	if logger == nil {
+		logger = log.New(os.Stderr, "["+config.Command+"] ", log.LstdFlags)
+	}
+	timeout := config.CloseTimeout
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+
+	// Plain pipes instead of cmd.StdinPipe and cmd.StdoutPipe, so that
+	// reading stdout does not race with Wait closing it
+	stdinR, stdinW, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	stdoutR, stdoutW, err := os.Pipe()
+	if err != nil {
+		stdinR.Close()
+		stdinW.Close()
+		return nil, err
+	}
+
+	stderr := &lineLogger{logger: logger}
+	cmd := exec.Command(config.Command, config.Args...)
+	cmd.Env = config.Env
+	cmd.Dir = config.Dir
+	cmd.Stdin = stdinR
+	cmd.Stdout = stdoutW
+	cmd.Stderr = stderr
+	cmd.WaitDelay = timeout
+
+	if err := cmd.Start(); err != nil {
+		stdinR.Close()
+		stdinW.Close()
+		stdoutR.Close()
+		stdoutW.Close()
+		return nil, fmt.Errorf("failed to start %s: %w", config.Command, err)
+	}
+	// The child holds its own copies now
+	stdinR.Close()
+	stdoutW.Close()
+
+	t := &CommandTransport{
+		BaseTransport: NewBaseTransport(stdoutR, stdinW, nil, config.Transport),
+		cmd:           cmd,
+		stdin:         stdinW,
+		stdout:        stdoutR,
+		stderr:        stderr,
+		timeout:       timeout,
+		exited:        make(chan struct{}),
+		closing:       make(chan struct{}),
+	}
+	go func() {
+		t.waitErr = cmd.Wait()
+		stderr.flush()
+		close(t.exited)
+	}()
+	return t, nil
+}
+
+// Receive implements Transport.Receive. When the process goes away, it
+// reports io.EOF if it exited cleanly or was closed through the transport,
+// and an *ExitError otherwise.
+func (t *CommandTransport) Receive() ([]byte, error) {
+	if t.isClosing() {
+		return nil, io.EOF
+	}
+	msg, err := t.BaseTransport.Receive()
+	if err == nil || (err != io.EOF && err != io.ErrUnexpectedEOF) {
+		return msg, err
+	}
+	if t.isClosing() {
+		return nil, io.EOF
+	}
+
+	// Stdout is closed, the process is exiting
+	select {
+	case <-t.exited:
+	case <-time.After(t.timeout):
+		return nil, &ExitError{Err: errors.New("stdout closed"), Stderr: t.stderr.tail()}
+	}
+	if t.waitErr == nil && err == io.EOF {
+		return nil, io.EOF
+	}
+	return nil, &ExitError{Err: t.waitErr, Stderr: t.stderr.tail()}
+}
+
+// Close implements Transport.Close. It closes the process's stdin and waits
+// for it to exit, then sends SIGTERM and finally kills it if it does not
+// exit within the close timeout.
+func (t *CommandTransport) Close() error {
+	var err error
+	t.closeOnce.Do(func() {
+		close(t.closing)
+		t.BaseTransport.Close()
+		t.stdin.Close()
+
+		if !t.waitExit() {
+			t.cmd.Process.Signal(syscall.SIGTERM)
+			if !t.waitExit() {
+				t.cmd.Process.Kill()
+				<-t.exited
+				err = fmt.Errorf("server process did not exit and was killed")
+			}
+		}
+		t.stdout.Close()
+	})
+	return err
+}
+
+// Pid returns the process id of the server process
+func (t *CommandTransport) Pid() int {
+	return t.cmd.Process.Pid
+}
+
+// Exited returns a channel that is closed when the server process has exited
+func (t *CommandTransport) Exited() <-chan struct{} {
+	return t.exited
+}
+
+// isClosing reports whether Close has been called
+func (t *CommandTransport) isClosing() bool {
+	select {
+	case <-t.closing:
+		return true
+	default:
+		return false
+	}
+}
+
+// waitExit waits up to the close timeout for the process to exit
+func (t *CommandTransport) waitExit() bool {
+	select {
+	case <-t.exited:
+		return true
+	case <-time.After(t.timeout):
+		return false
+	}
+}
+
+// lineLogger writes each line of the process's stderr to a logger and keeps
+// the last few for error reports
+type lineLogger struct {
+	logger *log.Logger
+	mu     sync.Mutex
+	buf    bytes.Buffer
+	last   []string
+}
+
+// stderrTailLines is the number of stderr lines kept for ExitError
+const stderrTailLines = 10
+
+// Write implements io.Writer
+func (l *lineLogger) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.buf.Write(p)
+	for {
+		line, err := l.buf.ReadString('\n')
+		if err != nil {
+			// Keep the partial line for the next write
+			rest := line
+			l.buf.Reset()
+			l.buf.WriteString(rest)
+			return len(p), nil
+		}
+		l.emit(strings.TrimRight(line, "\r\n"))
+	}
+}
+
+// flush logs a final line without a newline
+func (l *lineLogger) flush() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.buf.Len() > 0 {
+		l.emit(l.buf.String())
+		l.buf.Reset()
+	}
+}
+
+// tail returns the last lines written
+func (l *lineLogger) tail() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return strings.Join(l.last, "\n")
+}
+
+func (l *lineLogger) emit(line string) {
+	l.logger.Println(line)
+	l.last = append(l.last, line)
+	if len(l.last) > stderrTailLines {
+		l.last = l.last[len(l.last)-stderrTailLines:]
+	}
+}
@@ -0,0 +1,132 @@
+package transport_test
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"mcp-go-sdk/transport"
+)
+
+// TestHelperProcess is not a real test. It is the server process started
+// by the command transport tests.
+func TestHelperProcess(t *testing.T) {
+	mode := os.Getenv("MCP_HELPER_PROCESS")
+	if mode == "" {
+		return
+	}
+
+	switch mode {
+	case "echo":
+		fmt.Fprintln(os.Stderr, "echo server starting")
+		serveEcho(transport.NewStdioTransport())
+		os.Exit(0)
+	case "crash":
+		bufio.NewReader(os.Stdin).ReadString('\n')
+		fmt.Fprintln(os.Stderr, "panic: something broke")
+		os.Exit(3)
+	case "stubborn":
+		signal.Ignore(syscall.SIGTERM)
+		time.Sleep(time.Minute)
+	}
+	os.Exit(0)
+}
+
+// startHelper runs this test binary as a server process in the given mode
+func startHelper(t *testing.T, mode string, logs io.Writer) *transport.CommandTransport {
+	t.Helper()
+	tr, err := transport.NewCommandTransport(&transport.CommandConfig{
+		Command:      os.Args[0],
+		Args:         []string{"-test.run=^TestHelperProcess$"},
+		Env:          append(os.Environ(), "MCP_HELPER_PROCESS="+mode),
+		Logger:       log.New(logs, "", 0),
+		CloseTimeout: 200 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("Failed to start helper: %v", err)
+	}
+	return tr
+}
+
+func TestCommandTransport(t *testing.T) {
+	var logs syncBuffer
+	tr := startHelper(t, "echo", &logs)
+
+	resp := request(t, tr, initializeBody)
+	if !strings.Contains(resp, `"protocolVersion":"2025-03-26"`) {
+		t.Errorf("Unexpected initialize response %s", resp)
+	}
+
+	if err := tr.Close(); err != nil {
+		t.Errorf("Expected graceful close, got %v", err)
+	}
+	if _, err := tr.Receive(); err != io.EOF {
+		t.Errorf("Expected io.EOF after close, got %v", err)
+	}
+	if !strings.Contains(logs.String(), "echo server starting") {
+		t.Errorf("Expected stderr to be logged, got %q", logs.String())
+	}
+}
+
+func TestCommandTransportCrash(t *testing.T) {
+	var logs syncBuffer
+	tr := startHelper(t, "crash", &logs)
+	defer tr.Close()
+
+	if err := tr.Send(rawMessage(initializeBody)); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	_, err := tr.Receive()
+	var exitErr *transport.ExitError
+	if !errors.As(err, &exitErr) {
+		t.Fatalf("Expected ExitError, got %v", err)
+	}
+	if !errors.Is(err, io.ErrUnexpectedEOF) || !strings.Contains(exitErr.Stderr, "panic: something broke") {
+		t.Errorf("Unexpected exit error %v", err)
+	}
+}
+
+func TestCommandTransportKillsStubbornProcess(t *testing.T) {
+	tr := startHelper(t, "stubborn", io.Discard)
+
+	start := time.Now()
+	if err := tr.Close(); err == nil {
+		t.Error("Expected an error for a process that had to be killed")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Close took %v", elapsed)
+	}
+	select {
+	case <-tr.Exited():
+	default:
+		t.Error("Process is still running")
+	}
+}
+
+// syncBuffer is a bytes.Buffer safe for concurrent use
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
@@ -0,0 +1,428 @@
+package transport
+
+import (
+	"bufio"
+	"bytes"
+	"container/list"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrUnknownStream is returned by EventStore.Replay for a stream that is not
+// stored, either because it never was or because all its events expired
+var ErrUnknownStream = errors.New("unknown event stream")
+
+// EventStore keeps the messages sent on resumable event streams, so that a
+// client that reconnects with a Last-Event-ID header receives the messages it
+// missed. Event ids are assigned by the transport and increase within a
+// stream. Implementations must be safe for concurrent use.
+type EventStore interface {
+	// Append stores a message sent on the named stream under the given
+	// event id
+	Append(stream, id string, msg []byte) error
+
+	// Replay calls fn with every message stored on the stream after the
+	// event with the given id, in the order they were appended. If that
+	// event is no longer stored, all stored messages are replayed.
+	Replay(stream, afterID string, fn func(id string, msg []byte) error) error
+
+	// Remove drops the messages of a stream that can no longer be resumed
+	Remove(stream string) error
+}
+
+// EventStoreConfig sets the retention limits of an event store. Zero fields
+// turn the matching limit off.
+type EventStoreConfig struct {
+	// MaxEvents is the number of messages kept per stream. Older messages
+	// are dropped first. FileEventStore keeps DefaultEventStoreConfig's
+	// limit if zero, since it only shrinks its files by count.
+	MaxEvents int
+
+	// MaxAge is how long a message is kept. FileEventStore also deletes
+	// the files of streams that have not been appended to for that long.
+	MaxAge time.Duration
+
+	// MaxBytes bounds the total size of the messages a MemoryEventStore
+	// holds across all streams. FileEventStore keeps its messages on disk
+	// and ignores it.
+	MaxBytes int
+}
+
+// DefaultEventStoreConfig returns the default event store configuration
+func DefaultEventStoreConfig() *EventStoreConfig {
+	return &EventStoreConfig{
+		MaxEvents: 1000,
+		MaxAge:    10 * time.Minute,
+		MaxBytes:  64 << 20,
+	}
+}
+
+// storedEvent is a message held by a MemoryEventStore
+type storedEvent struct {
+	stream string
+	id     string
+	msg    []byte
+	at     time.Time
+	el     *list.Element
+}
+
+// MemoryEventStore is an EventStore that keeps messages in memory
+type MemoryEventStore struct {
+	config *EventStoreConfig
+
+	mu      sync.Mutex
+	streams map[string][]*storedEvent
+	order   *list.List
+	size    int
+}
+
+// NewMemoryEventStore creates an empty store. A nil config uses
+// DefaultEventStoreConfig.
+func NewMemoryEventStore(config *EventStoreConfig) *MemoryEventStore {
+	if config == nil {
+		config = DefaultEventStoreConfig()
+	}
+	return &MemoryEventStore{
+		config:  config,
+		streams: make(map[string][]*storedEvent),
+		order:   list.New(),
+	}
+}
+
+// Append implements EventStore
+func (s *MemoryEventStore) Append(stream, id string, msg []byte) error {
+	now := time.Now()
+	ev := &storedEvent{
+		stream: stream,
+		id:     id,
+		msg:    append([]byte(nil), msg...),
+		at:     now,
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ev.el = s.order.PushBack(ev)
+	s.streams[stream] = append(s.streams[stream], ev)
+	s.size += len(ev.msg)
+
+	if max := s.config.MaxEvents; max > 0 {
+		for len(s.streams[stream]) > max {
+			s.drop(s.streams[stream][0])
+		}
+	}
+	for s.order.Len() > 0 {
+		oldest := s.order.Front().Value.(*storedEvent)
+		tooOld := s.config.MaxAge > 0 && now.Sub(oldest.at) > s.config.MaxAge
+		tooBig := s.config.MaxBytes > 0 && s.size > s.config.MaxBytes
+		if !tooOld && !tooBig {
+			break
+		}
+		s.drop(oldest)
+	}
+	return nil
+}
+
+// drop removes the oldest event of its stream. The caller must hold s.mu.
+func (s *MemoryEventStore) drop(ev *storedEvent) {
+	s.order.Remove(ev.el)
+	s.size -= len(ev.msg)
+	events := s.streams[ev.stream][1:]
+	if len(events) == 0 {
+		delete(s.streams, ev.stream)
+		return
+	}
+	s.streams[ev.stream] = events
+}
+
+// Replay implements EventStore
+func (s *MemoryEventStore) Replay(stream, afterID string, fn func(id string, msg []byte) error) error {
+	s.mu.Lock()
+	events, ok := s.streams[stream]
+	var replay []*storedEvent
+	for i := len(events) - 1; i >= 0; i-- {
+		if events[i].id == afterID {
+			replay = events[i+1:]
+			break
+		}
+	}
+	if replay == nil {
+		replay = events
+	}
+	// Events are never changed once stored, so they can be read unlocked
+	replay = append([]*storedEvent(nil), replay...)
+	s.mu.Unlock()
+
+	if !ok {
+		return ErrUnknownStream
+	}
+	for _, ev := range replay {
+		if err := fn(ev.id, ev.msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Remove implements EventStore
+func (s *MemoryEventStore) Remove(stream string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, ev := range s.streams[stream] {
+		s.order.Remove(ev.el)
+		s.size -= len(ev.msg)
+	}
+	delete(s.streams, stream)
+	return nil
+}
+
+// fileEvent is a line of a FileEventStore stream file
+type fileEvent struct {
+	ID   string          `json:"id"`
+	At   time.Time       `json:"at"`
+	Data json.RawMessage `json:"data"`
+}
+
+// FileEventStore is an EventStore that keeps each stream in a file of JSON
+// lines, so that stored messages do not take up memory. Files are compacted
+// once they hold twice MaxEvents messages, and deleted once all their
+// messages are older than MaxAge.
+type FileEventStore struct {
+	dir    string
+	config *EventStoreConfig
+
+	mu        sync.Mutex
+	counts    map[string]int
+	lastSweep time.Time
+}
+
+// fileSuffix ends the name of every stream file
+const fileSuffix = ".jsonl"
+
+// NewFileEventStore creates a store that keeps its files in dir, creating the
+// directory if needed, and picks up the streams already stored there. A nil
+// config uses DefaultEventStoreConfig.
+func NewFileEventStore(dir string, config *EventStoreConfig) (*FileEventStore, error) {
+	if config == nil {
+		config = DefaultEventStoreConfig()
+	}
+	c := *config
+	if c.MaxEvents <= 0 {
+		c.MaxEvents = DefaultEventStoreConfig().MaxEvents
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create event store directory: %w", err)
+	}
+	s := &FileEventStore{
+		dir:    dir,
+		config: &c,
+		counts: make(map[string]int),
+	}
+	if err := s.load(); err != nil {
+		return nil, fmt.Errorf("failed to read event store directory: %w", err)
+	}
+	return s, nil
+}
+
+// load counts the events of the streams left in the directory, so that they
+// are compacted and expired like new ones. Expired streams and files left by
+// an interrupted compaction are deleted.
+func (s *FileEventStore) load() error {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	for _, entry := range entries {
+		name := entry.Name()
+		if strings.HasSuffix(name, fileSuffix+".tmp") {
+			os.Remove(filepath.Join(s.dir, name))
+			continue
+		}
+		if !strings.HasSuffix(name, fileSuffix) || !entry.Type().IsRegular() {
+			continue
+		}
+		stream, err := url.PathUnescape(strings.TrimSuffix(name, fileSuffix))
+		if err != nil {
+			continue
+		}
+		if s.expired(stream, now) {
+			os.Remove(s.path(stream))
+			continue
+		}
+		data, err := os.ReadFile(s.path(stream))
+		if err != nil {
+			return err
+		}
+		s.counts[stream] = bytes.Count(data, []byte{'\n'})
+	}
+	s.lastSweep = now
+	return nil
+}
+
+// expired reports whether a stream file has not been appended to for
+// longer than MaxAge
+func (s *FileEventStore) expired(stream string, now time.Time) bool {
+	if s.config.MaxAge <= 0 {
+		return false
+	}
+	info, err := os.Stat(s.path(stream))
+	return err == nil && now.Sub(info.ModTime()) > s.config.MaxAge
+}
+
+// sweep deletes the files of expired streams, at most once every MaxAge.
+// The caller must hold s.mu.
+func (s *FileEventStore) sweep(now time.Time) {
+	if s.config.MaxAge <= 0 || now.Sub(s.lastSweep) < s.config.MaxAge {
+		return
+	}
+	s.lastSweep = now
+	for stream := range s.counts {
+		if s.expired(stream, now) {
+			delete(s.counts, stream)
+			os.Remove(s.path(stream))
+		}
+	}
+}
+
+// path returns the file of a stream
+func (s *FileEventStore) path(stream string) string {
+	return filepath.Join(s.dir, url.PathEscape(stream)+fileSuffix)
+}
+
+// Append implements EventStore
+func (s *FileEventStore) Append(stream, id string, msg []byte) error {
+	now := time.Now()
+	line, err := json.Marshal(fileEvent{ID: id, At: now, Data: msg})
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sweep(now)
+	f, err := os.OpenFile(s.path(stream), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(append(line, '\n'))
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	s.counts[stream]++
+	if s.counts[stream] > 2*s.config.MaxEvents {
+		return s.compact(stream)
+	}
+	return nil
+}
+
+// compact rewrites a stream file with only the events within the limits. The
+// caller must hold s.mu.
+func (s *FileEventStore) compact(stream string) error {
+	events, err := s.read(stream)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	for _, ev := range events {
+		line, err := json.Marshal(ev)
+		if err != nil {
+			return err
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+	tmp := s.path(stream) + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0o600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, s.path(stream)); err != nil {
+		return err
+	}
+	s.counts[stream] = len(events)
+	return nil
+}
+
+// read returns the events of a stream that are within the limits. The caller
+// must hold s.mu.
+func (s *FileEventStore) read(stream string) ([]fileEvent, error) {
+	f, err := os.Open(s.path(stream))
+	if os.IsNotExist(err) {
+		return nil, ErrUnknownStream
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var events []fileEvent
+	now := time.Now()
+	r := bufio.NewReader(f)
+	for {
+		line, err := r.ReadBytes('\n')
+		if len(bytes.TrimSpace(line)) > 0 {
+			var ev fileEvent
+			// A line cut short by a crash is skipped
+			if json.Unmarshal(line, &ev) == nil && (s.config.MaxAge <= 0 || now.Sub(ev.At) <= s.config.MaxAge) {
+				events = append(events, ev)
+			}
+		}
+		if err == io.EOF {
+			if max := s.config.MaxEvents; max > 0 && len(events) > max {
+				events = events[len(events)-max:]
+			}
+			return events, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// Replay implements EventStore
+func (s *FileEventStore) Replay(stream, afterID string, fn func(id string, msg []byte) error) error {
+	s.mu.Lock()
+	events, err := s.read(stream)
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	if len(events) == 0 {
+		return ErrUnknownStream
+	}
+
+	for i := len(events) - 1; i >= 0; i-- {
+		if events[i].ID == afterID {
+			events = events[i+1:]
+			break
+		}
+	}
+	for _, ev := range events {
+		if err := fn(ev.ID, ev.Data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Remove implements EventStore
+func (s *FileEventStore) Remove(stream string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.counts, stream)
+	if err := os.Remove(s.path(stream)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,394 @@
+package transport_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"mcp-go-sdk"
+	"mcp-go-sdk/server"
+	"mcp-go-sdk/transport"
+)
+
+// replayIDs returns the ids of the events replayed after afterID
+func replayIDs(t *testing.T, store transport.EventStore, stream, afterID string) []string {
+	t.Helper()
+	var ids []string
+	err := store.Replay(stream, afterID, func(id string, msg []byte) error {
+		ids = append(ids, id)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Replay failed: %v", err)
+	}
+	return ids
+}
+
+// testEventStore runs the checks every EventStore must pass. The store keeps
+// at most 3 events per stream.
+func testEventStore(t *testing.T, store transport.EventStore) {
+	for i := 1; i <= 5; i++ {
+		if err := store.Append("s", fmt.Sprintf("s:%d", i), []byte(fmt.Sprintf(`{"n":%d}`, i))); err != nil {
+			t.Fatalf("Append failed: %v", err)
+		}
+	}
+	store.Append("other", "other:1", []byte(`{}`))
+
+	if ids := replayIDs(t, store, "s", "s:4"); len(ids) != 1 || ids[0] != "s:5" {
+		t.Errorf("Expected to replay s:5, got %v", ids)
+	}
+	if ids := replayIDs(t, store, "s", "s:5"); len(ids) != 0 {
+		t.Errorf("Expected nothing after the last event, got %v", ids)
+	}
+	// Evicted events replay everything that is left
+	if ids := replayIDs(t, store, "s", "s:1"); strings.Join(ids, ",") != "s:3,s:4,s:5" {
+		t.Errorf("Expected the retained events, got %v", ids)
+	}
+
+	var data string
+	store.Replay("s", "s:3", func(id string, msg []byte) error {
+		data = string(msg)
+		return errors.New("stop")
+	})
+	if data != `{"n":4}` {
+		t.Errorf("Expected the message of s:4, got %s", data)
+	}
+
+	if err := store.Remove("s"); err != nil {
+		t.Fatalf("Remove failed: %v", err)
+	}
+	err := store.Replay("s", "s:4", func(string, []byte) error { return nil })
+	if !errors.Is(err, transport.ErrUnknownStream) {
+		t.Errorf("Expected ErrUnknownStream after Remove, got %v", err)
+	}
+	if ids := replayIDs(t, store, "other", ""); len(ids) != 1 {
+		t.Errorf("Expected other streams to stay, got %v", ids)
+	}
+}
+
+func TestMemoryEventStore(t *testing.T) {
+	testEventStore(t, transport.NewMemoryEventStore(&transport.EventStoreConfig{MaxEvents: 3}))
+
+	// The size bound drops the oldest events across streams
+	store := transport.NewMemoryEventStore(&transport.EventStoreConfig{MaxBytes: 8})
+	store.Append("a", "a:1", []byte(`"aa"`))
+	store.Append("b", "b:1", []byte(`"bb"`))
+	store.Append("b", "b:2", []byte(`"cc"`))
+	err := store.Replay("a", "", func(string, []byte) error { return nil })
+	if !errors.Is(err, transport.ErrUnknownStream) {
+		t.Errorf("Expected stream a to be evicted, got %v", err)
+	}
+	if ids := replayIDs(t, store, "b", ""); len(ids) != 2 {
+		t.Errorf("Expected both events of b, got %v", ids)
+	}
+
+	store = transport.NewMemoryEventStore(&transport.EventStoreConfig{MaxAge: 20 * time.Millisecond})
+	store.Append("a", "a:1", []byte(`1`))
+	time.Sleep(30 * time.Millisecond)
+	store.Append("a", "a:2", []byte(`2`))
+	if ids := replayIDs(t, store, "a", ""); len(ids) != 1 || ids[0] != "a:2" {
+		t.Errorf("Expected the old event to expire, got %v", ids)
+	}
+}
+
+func TestFileEventStore(t *testing.T) {
+	dir := t.TempDir()
+	store, err := transport.NewFileEventStore(dir, &transport.EventStoreConfig{MaxEvents: 3})
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	// Stream names may contain slashes
+	store.Append("sess/1", "sess/1:1", []byte(`{"x":1}`))
+	testEventStore(t, store)
+
+	// Events survive the store
+	reopened, err := transport.NewFileEventStore(dir, nil)
+	if err != nil {
+		t.Fatalf("Failed to reopen store: %v", err)
+	}
+	if ids := replayIDs(t, reopened, "sess/1", ""); len(ids) != 1 || ids[0] != "sess/1:1" {
+		t.Errorf("Expected the stored event, got %v", ids)
+	}
+}
+
+// countLines returns the number of lines in a file
+func countLines(t *testing.T, path string) int {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read %s: %v", path, err)
+	}
+	return strings.Count(string(data), "\n")
+}
+
+func TestFileEventStoreBounds(t *testing.T) {
+	dir := t.TempDir()
+	config := &transport.EventStoreConfig{MaxEvents: 2}
+	store, _ := transport.NewFileEventStore(dir, config)
+	for i := 1; i <= 4; i++ {
+		store.Append("s", fmt.Sprintf("s:%d", i), []byte(`{}`))
+	}
+
+	// A reopened store counts the events already on disk, so the next
+	// append compacts the file
+	store, err := transport.NewFileEventStore(dir, config)
+	if err != nil {
+		t.Fatalf("Failed to reopen store: %v", err)
+	}
+	store.Append("s", "s:5", []byte(`{}`))
+	if n := countLines(t, filepath.Join(dir, "s.jsonl")); n != 2 {
+		t.Errorf("Expected the file to be compacted to 2 events, got %d", n)
+	}
+
+	// Without MaxEvents files are still compacted
+	store, _ = transport.NewFileEventStore(dir, &transport.EventStoreConfig{})
+	for i := 0; i < 2*transport.DefaultEventStoreConfig().MaxEvents+1; i++ {
+		store.Append("unbounded", fmt.Sprintf("unbounded:%d", i), []byte(`{}`))
+	}
+	if n := countLines(t, filepath.Join(dir, "unbounded.jsonl")); n != transport.DefaultEventStoreConfig().MaxEvents {
+		t.Errorf("Expected the file to be compacted to the default limit, got %d events", n)
+	}
+
+	// Expired streams are deleted as the store goes, and when it opens
+	config = &transport.EventStoreConfig{MaxAge: 20 * time.Millisecond}
+	store, _ = transport.NewFileEventStore(dir, config)
+	store.Append("old", "old:1", []byte(`{}`))
+	os.WriteFile(filepath.Join(dir, "left.jsonl.tmp"), []byte(`{}`), 0o600)
+	time.Sleep(30 * time.Millisecond)
+	store.Append("new", "new:1", []byte(`{}`))
+	if _, err := os.Stat(filepath.Join(dir, "old.jsonl")); !os.IsNotExist(err) {
+		t.Errorf("Expected the expired stream to be deleted, got %v", err)
+	}
+	time.Sleep(30 * time.Millisecond)
+	transport.NewFileEventStore(dir, config)
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 0 {
+		t.Errorf("Expected every expired file to be deleted on open, got %d files", len(entries))
+	}
+}
+
+func TestStreamableHTTPStoredStreamsBounded(t *testing.T) {
+	config := transport.DefaultStreamableHTTPConfig()
+	config.EventStore = transport.NewMemoryEventStore(nil)
+	handler := transport.NewStreamableHTTPHandler(serveGate(&gateTool{}), config)
+	defer handler.Close()
+	ts := httptest.NewServer(handler)
+	defer ts.Close()
+
+	resp := post(t, ts.URL, "", initializeBody)
+	sessionID := resp.Header.Get(transport.HeaderSessionID)
+	resp.Body.Close()
+
+	// More streams than a session keeps in the event store
+	var ids []string
+	for i := 0; i < 70; i++ {
+		resp := post(t, ts.URL, sessionID, fmt.Sprintf(`{"jsonrpc":"2.0","id":%d,"method":"ping"}`, i+2))
+		id, _ := readEventWithID(t, bufio.NewReader(resp.Body))
+		resp.Body.Close()
+		ids = append(ids, id)
+	}
+
+	resume := func(id string) int {
+		headers := map[string]string{transport.HeaderSessionID: sessionID, "Last-Event-ID": id}
+		resp := getStream(t, context.Background(), ts.URL, headers)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	if status := resume(ids[0]); status != http.StatusNotFound {
+		t.Errorf("Expected the oldest stream to be dropped, got %d", status)
+	}
+	if status := resume(ids[len(ids)-1]); status != http.StatusOK {
+		t.Errorf("Expected the newest stream to resume, got %d", status)
+	}
+}
+
+// gateTool implements mcp.ContextTool by notifying the client that it
+// started and then waiting for the gate
+type gateTool struct {
+	gate chan struct{}
+}
+
+func (t *gateTool) Name() string            { return "gate" }
+func (t *gateTool) Description() string     { return "Waits for the gate" }
+func (t *gateTool) Schema() json.RawMessage { return json.RawMessage(`{"type":"object"}`) }
+func (t *gateTool) Execute(params json.RawMessage) (interface{}, error) {
+	return t.ExecuteContext(context.Background(), params)
+}
+func (t *gateTool) ExecuteContext(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	server.SessionFromContext(ctx).Notify("notifications/message", map[string]string{"level": "info", "data": "started"})
+	<-t.gate
+	return &mcp.CallToolResult{Content: []mcp.ToolContent{{Type: "text", Text: "done"}}}, nil
+}
+
+// serveGate returns a SessionFunc serving the gate tool
+func serveGate(tool *gateTool) transport.SessionFunc {
+	return func(t mcp.Transport) error {
+		srv := server.NewServer(t)
+		srv.RegisterTool(tool)
+		return srv.Start()
+	}
+}
+
+// readEventWithID reads the next SSE event and returns its id and data
+func readEventWithID(t *testing.T, r *bufio.Reader) (string, string) {
+	t.Helper()
+	var id string
+	var data []string
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatalf("Failed to read event: %v", err)
+		}
+		line = strings.TrimRight(line, "\n")
+		switch {
+		case line == "" && len(data) > 0:
+			return id, strings.Join(data, "\n")
+		case strings.HasPrefix(line, "id: "):
+			id = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "data: "):
+			data = append(data, strings.TrimPrefix(line, "data: "))
+		}
+	}
+}
+
+// getStream opens an event stream with the given headers
+func getStream(t *testing.T, ctx context.Context, url string, headers map[string]string) *http.Response {
+	t.Helper()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	req.Header.Set("Accept", "text/event-stream")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("GET failed: %v", err)
+	}
+	return resp
+}
+
+func TestStreamableHTTPResume(t *testing.T) {
+	tool := &gateTool{gate: make(chan struct{})}
+	config := transport.DefaultStreamableHTTPConfig()
+	config.EventStore = transport.NewMemoryEventStore(nil)
+	handler := transport.NewStreamableHTTPHandler(serveGate(tool), config)
+	defer handler.Close()
+	ts := httptest.NewServer(handler)
+	defer ts.Close()
+
+	resp := post(t, ts.URL, "", initializeBody)
+	sessionID := resp.Header.Get(transport.HeaderSessionID)
+	resp.Body.Close()
+
+	// The call's stream drops after its first event
+	ctx, cancel := context.WithCancel(context.Background())
+	req, _ := http.NewRequestWithContext(ctx, http.MethodPost, ts.URL, strings.NewReader(`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"gate"}}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json, text/event-stream")
+	req.Header.Set(transport.HeaderSessionID, sessionID)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("POST failed: %v", err)
+	}
+	lastID, event := readEventWithID(t, bufio.NewReader(resp.Body))
+	if lastID == "" || !strings.Contains(event, `"started"`) {
+		t.Fatalf("Expected the notification with an event id, got %q %s", lastID, event)
+	}
+	cancel()
+	resp.Body.Close()
+	time.Sleep(50 * time.Millisecond)
+	close(tool.gate)
+
+	// Resuming replays the response that was sent while the client was away
+	headers := map[string]string{transport.HeaderSessionID: sessionID, "Last-Event-ID": lastID}
+	resumed := getStream(t, context.Background(), ts.URL, headers)
+	defer resumed.Body.Close()
+	if resumed.StatusCode != http.StatusOK {
+		t.Fatalf("Expected 200 on resume, got %d", resumed.StatusCode)
+	}
+	id, event := readEventWithID(t, bufio.NewReader(resumed.Body))
+	if id == "" || id == lastID || !strings.Contains(event, `"id":2`) || !strings.Contains(event, `"done"`) {
+		t.Errorf("Expected the tool result, got %q %s", id, event)
+	}
+
+	// Event ids only resume streams of their own session
+	headers = map[string]string{transport.HeaderSessionID: sessionID, "Last-Event-ID": "other/1:1"}
+	bad := getStream(t, context.Background(), ts.URL, headers)
+	bad.Body.Close()
+	if bad.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected 400 for another session's event, got %d", bad.StatusCode)
+	}
+}
+
+func TestSSEResume(t *testing.T) {
+	tool := &gateTool{gate: make(chan struct{})}
+	config := transport.DefaultSSEConfig()
+	config.EventStore = transport.NewMemoryEventStore(nil)
+	config.ResumeTimeout = 200 * time.Millisecond
+	handler := transport.NewSSEHandler(serveGate(tool), config)
+	defer handler.Close()
+	mux := http.NewServeMux()
+	mux.Handle("/sse", handler)
+	mux.Handle("/messages", handler)
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	resp := getStream(t, ctx, ts.URL+"/sse", nil)
+	events := bufio.NewReader(resp.Body)
+	endpoint := readEvent(t, events)
+	postMessage := func(body string) int {
+		resp, err := http.Post(ts.URL+endpoint, "application/json", strings.NewReader(body))
+		if err != nil {
+			t.Fatalf("POST failed: %v", err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	postMessage(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"gate"}}`)
+	lastID, event := readEventWithID(t, events)
+	if lastID == "" || !strings.Contains(event, `"started"`) {
+		t.Fatalf("Expected the notification with an event id, got %q %s", lastID, event)
+	}
+	cancel()
+	resp.Body.Close()
+	time.Sleep(50 * time.Millisecond)
+	close(tool.gate)
+
+	// The session outlives its stream and continues on the new one
+	resumed := getStream(t, context.Background(), ts.URL+"/sse", map[string]string{"Last-Event-ID": lastID})
+	events = bufio.NewReader(resumed.Body)
+	if again := readEvent(t, events); again != endpoint {
+		t.Errorf("Expected the same endpoint, got %s", again)
+	}
+	if _, event := readEventWithID(t, events); !strings.Contains(event, `"id":1`) || !strings.Contains(event, `"done"`) {
+		t.Errorf("Expected the tool result, got %s", event)
+	}
+	if status := postMessage(`{"jsonrpc":"2.0","id":2,"method":"ping"}`); status != http.StatusAccepted {
+		t.Errorf("Expected the session to accept messages, got %d", status)
+	}
+	resumed.Body.Close()
+
+	// A session that is not resumed in time ends
+	deadline := time.Now().Add(5 * time.Second)
+	for postMessage(`{"jsonrpc":"2.0","id":3,"method":"ping"}`) != http.StatusNotFound {
+		if time.Now().After(deadline) {
+			t.Fatal("Session did not expire")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	expired := getStream(t, context.Background(), ts.URL+"/sse", map[string]string{"Last-Event-ID": lastID})
+	expired.Body.Close()
+	if expired.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected 404 for an expired session, got %d", expired.StatusCode)
+	}
+}
@@ -0,0 +1,276 @@
+package transport
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"mcp-go-sdk"
+	"mcp-go-sdk/auth"
+)
+
+// ContextCarrier is implemented by transports that were opened by an HTTP
+// request. Context returns the values of that request, such as the
+// authenticated principal, without its deadline or cancellation. The server
+// derives the context of every request handler from it.
+type ContextCarrier interface {
+	Context() context.Context
+}
+
+// SessionFunc runs an MCP session over the given transport. Network
+// transports call it in its own goroutine for every new session. It should
+// return once the transport reports io.EOF.
+type SessionFunc func(t mcp.Transport) error
+
+// envelope holds the fields used to route a JSON-RPC message
+type envelope struct {
+	ID     json.RawMessage `json:"id,omitempty"`
+	Method string          `json:"method,omitempty"`
+}
+
+// isRequest reports whether the message expects a response
+func (e *envelope) isRequest() bool {
+	return e.Method != "" && len(e.ID) > 0
+}
+
+// isResponse reports whether the message answers a request
+func (e *envelope) isResponse() bool {
+	return e.Method == "" && len(e.ID) > 0
+}
+
+// idKey returns a canonical form of the message id for map lookups
+func (e *envelope) idKey() string {
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, e.ID); err != nil {
+		return string(e.ID)
+	}
+	return buf.String()
+}
+
+// splitBatch splits a request body into individual JSON-RPC messages
+func splitBatch(body []byte) ([]json.RawMessage, bool, error) {
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) == 0 {
+		return nil, false, fmt.Errorf("empty body")
+	}
+	if trimmed[0] != '[' {
+		if !json.Valid(trimmed) {
+			return nil, false, fmt.Errorf("invalid JSON")
+		}
+		return []json.RawMessage{json.RawMessage(trimmed)}, false, nil
+	}
+
+	var batch []json.RawMessage
+	if err := json.Unmarshal(trimmed, &batch); err != nil {
+		return nil, true, err
+	}
+	if len(batch) == 0 {
+		return nil, true, fmt.Errorf("empty batch")
+	}
+	return batch, true, nil
+}
+
+// newSessionID returns a cryptographically random session identifier
+func newSessionID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate session id: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// acceptsEventStream reports whether the client accepts SSE responses
+func acceptsEventStream(r *http.Request) bool {
+	return strings.Contains(r.Header.Get("Accept"), "text/event-stream")
+}
+
+// writeSSEHeaders prepares the response for an event stream
+func writeSSEHeaders(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	if f, ok := w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// writeSSEEvent writes a single server-sent event and flushes it
+func writeSSEEvent(w http.ResponseWriter, event string, data []byte) error {
+	var buf bytes.Buffer
+	appendSSEEvent(&buf, "", event, data)
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		return err
+	}
+	if f, ok := w.(http.Flusher); ok {
+		f.Flush()
+	}
+	return nil
+}
+
+// appendSSEEvent encodes a server-sent event into buf. The id is left out if
+// empty.
+func appendSSEEvent(buf *bytes.Buffer, id, event string, data []byte) {
+	if id != "" {
+		fmt.Fprintf(buf, "id: %s\n", id)
+	}
+	if event != "" {
+		fmt.Fprintf(buf, "event: %s\n", event)
+	}
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		buf.WriteString("data: ")
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+	buf.WriteByte('\n')
+}
+
+// streamEvents writes queued messages as SSE events until the stream is
+// finished, the session closes or the client goes away. Messages queued
+// together are written and flushed together, and every write is bounded by
+// the stream's write timeout. A resumable stream whose client goes away is
+// detached rather than closed.
+func streamEvents(w http.ResponseWriter, r *http.Request, closed <-chan struct{}, st *outStream) {
+	gone := false
+	defer func() {
+		if !gone || !st.detach() {
+			st.close()
+		}
+	}()
+	rc := http.NewResponseController(w)
+
+	write := func(msgs []event) error {
+		if len(msgs) == 0 {
+			return nil
+		}
+		var buf bytes.Buffer
+		for _, msg := range msgs {
+			appendSSEEvent(&buf, msg.id, "message", msg.data)
+		}
+		if st.timeout > 0 {
+			rc.SetWriteDeadline(time.Now().Add(st.timeout))
+		}
+		if _, err := w.Write(buf.Bytes()); err != nil {
+			return err
+		}
+		if err := rc.Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+			return err
+		}
+		if id := msgs[len(msgs)-1].id; id != "" {
+			st.sent(id)
+		}
+		return nil
+	}
+
+	for {
+		msgs, finished := st.drain()
+		if err := write(msgs); err != nil {
+			gone = true
+			return
+		}
+		if finished {
+			return
+		}
+
+		select {
+		case <-st.signal:
+		case <-closed:
+			msgs, _ := st.drain()
+			write(msgs)
+			return
+		case <-r.Context().Done():
+			gone = true
+			return
+		}
+	}
+}
+
+// eventStream returns the name of the stream an event id belongs to. Ids
+// are the stream name and a sequence number, separated by a colon.
+func eventStream(id string) (string, bool) {
+	i := strings.LastIndex(id, ":")
+	if i <= 0 {
+		return "", false
+	}
+	return id[:i], true
+}
+
+// httpSession holds the inbound side shared by the HTTP session transports.
+// Client messages arrive through HTTP requests and are handed to the server
+// one at a time.
+type httpSession struct {
+	id        string
+	ctx       context.Context
+	incoming  chan []byte
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+func newHTTPSession(id string, r *http.Request) *httpSession {
+	return &httpSession{
+		id:       id,
+		ctx:      context.WithoutCancel(r.Context()),
+		incoming: make(chan []byte),
+		closed:   make(chan struct{}),
+	}
+}
+
+// Context implements ContextCarrier
+func (s *httpSession) Context() context.Context {
+	return s.ctx
+}
+
+// Receive implements Transport.Receive
+func (s *httpSession) Receive() ([]byte, error) {
+	select {
+	case msg := <-s.incoming:
+		return msg, nil
+	case <-s.closed:
+		return nil, io.EOF
+	}
+}
+
+// Close implements Transport.Close
+func (s *httpSession) Close() error {
+	s.closeOnce.Do(func() {
+		close(s.closed)
+	})
+	return nil
+}
+
+// isClosed reports whether the session has been closed
+func (s *httpSession) isClosed() bool {
+	select {
+	case <-s.closed:
+		return true
+	default:
+		return false
+	}
+}
+
+// sameCaller reports whether r was authenticated as the caller that opened
+// the session
+func (s *httpSession) sameCaller(r *http.Request) bool {
+	return auth.SameSubject(s.ctx, r.Context())
+}
+
+// deliver hands a client message to the server
+func (s *httpSession) deliver(r *http.Request, msg []byte) error {
+	select {
+	case s.incoming <- msg:
+		return nil
+	case <-s.closed:
+		return io.ErrClosedPipe
+	case <-r.Context().Done():
+		return r.Context().Err()
+	}
+}
@@ -0,0 +1,131 @@
+package transport
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+
+	"mcp-go-sdk"
+)
+
+// memoryPipe is the state shared by the two ends of an in-memory pair
+type memoryPipe struct {
+	mu     sync.Mutex
+	cond   *sync.Cond
+	config *mcp.TransportConfig
+}
+
+// InMemoryTransport is one end of a pair of connected in-memory transports.
+// Messages are marshaled once on Send and handed to the other end without any
+// pipe or framing in between.
+type InMemoryTransport struct {
+	pipe     *memoryPipe
+	peer     *InMemoryTransport
+	inbox    [][]byte
+	closed   bool
+	maxDepth int
+	timeouts int64
+}
+
+// NewInMemoryPair returns two connected transports. Whatever one end sends,
+// the other receives, in order. Closing either end closes the pair: the
+// closed end reports io.EOF right away, while the other end first receives
+// the messages already sent to it.
+func NewInMemoryPair() (*InMemoryTransport, *InMemoryTransport) {
+	return NewInMemoryPairWithConfig(mcp.DefaultTransportConfig())
+}
+
+// NewInMemoryPairWithConfig returns two connected transports whose queues
+// are bounded by config.QueueSize. A send to a full queue blocks until the
+// other end receives or config.WriteTimeout passes.
+func NewInMemoryPairWithConfig(config *mcp.TransportConfig) (*InMemoryTransport, *InMemoryTransport) {
+	if config == nil {
+		config = mcp.DefaultTransportConfig()
+	}
+	pipe := &memoryPipe{config: config}
+	pipe.cond = sync.NewCond(&pipe.mu)
+
+	a := &InMemoryTransport{pipe: pipe}
+	b := &InMemoryTransport{pipe: pipe}
+	a.peer, b.peer = b, a
+	return a, b
+}
+
+// Send implements Transport.Send
+func (t *InMemoryTransport) Send(data interface{}) error {
+	msg, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	return t.SendRaw(msg)
+}
+
+// SendRaw delivers bytes to the other end as-is. It lets tests feed
+// malformed input that Send would refuse to marshal.
+func (t *InMemoryTransport) SendRaw(msg []byte) error {
+	t.pipe.mu.Lock()
+	defer t.pipe.mu.Unlock()
+
+	size := t.pipe.config.QueueSize
+	ready := func() bool {
+		return t.closed || t.peer.closed || size <= 0 || len(t.peer.inbox) < size
+	}
+	if !waitCond(t.pipe.cond, t.pipe.config.WriteTimeout, ready) {
+		t.timeouts++
+		return fmt.Errorf("%w after %v", mcp.ErrWriteTimeout, t.pipe.config.WriteTimeout)
+	}
+	if t.closed || t.peer.closed {
+		return io.ErrClosedPipe
+	}
+
+	t.peer.inbox = append(t.peer.inbox, append([]byte(nil), msg...))
+	if len(t.peer.inbox) > t.maxDepth {
+		t.maxDepth = len(t.peer.inbox)
+	}
+	t.pipe.cond.Broadcast()
+	return nil
+}
+
+// QueueStats implements QueueReporter. The depth is the number of messages
+// sent by this end that the other end has not received yet.
+func (t *InMemoryTransport) QueueStats() QueueStats {
+	t.pipe.mu.Lock()
+	defer t.pipe.mu.Unlock()
+	return QueueStats{
+		Depth:    len(t.peer.inbox),
+		Capacity: t.pipe.config.QueueSize,
+		MaxDepth: t.maxDepth,
+		Timeouts: t.timeouts,
+	}
+}
+
+// Receive implements Transport.Receive. It blocks until a message arrives or
+// the pair is closed.
+func (t *InMemoryTransport) Receive() ([]byte, error) {
+	t.pipe.mu.Lock()
+	defer t.pipe.mu.Unlock()
+	for len(t.inbox) == 0 && !t.closed && !t.peer.closed {
+		t.pipe.cond.Wait()
+	}
+	if t.closed || len(t.inbox) == 0 {
+		return nil, io.EOF
+	}
+
+	msg := t.inbox[0]
+	t.inbox[0] = nil
+	t.inbox = t.inbox[1:]
+	// Wake up senders waiting for room
+	t.pipe.cond.Broadcast()
+	return msg, nil
+}
+
+// Close implements Transport.Close
+func (t *InMemoryTransport) Close() error {
+	t.pipe.mu.Lock()
+	defer t.pipe.mu.Unlock()
+	t.closed = true
+	t.inbox = nil
+	t.pipe.cond.Broadcast()
+	return nil
+}
@@ -0,0 +1,58 @@
+package transport_test
+
+import (
+	"io"
+	"testing"
+
+	"mcp-go-sdk/transport"
+)
+
+func TestInMemoryPair(t *testing.T) {
+	a, b := transport.NewInMemoryPair()
+
+	for _, msg := range []string{`{"n":1}`, `{"n":2}`} {
+		if err := a.Send(rawMessage(msg)); err != nil {
+			t.Fatalf("Send failed: %v", err)
+		}
+	}
+	if err := b.Send(map[string]int{"n": 3}); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	if msg, err := a.Receive(); err != nil || string(msg) != `{"n":3}` {
+		t.Errorf("Unexpected message: %s (%v)", msg, err)
+	}
+	if msg, err := b.Receive(); err != nil || string(msg) != `{"n":1}` {
+		t.Errorf("Unexpected message: %s (%v)", msg, err)
+	}
+
+	// The peer drains pending messages before reporting EOF
+	a.Close()
+	if _, err := a.Receive(); err != io.EOF {
+		t.Errorf("Expected io.EOF on closed end, got %v", err)
+	}
+	if err := a.Send(rawMessage(`{}`)); err != io.ErrClosedPipe {
+		t.Errorf("Expected io.ErrClosedPipe, got %v", err)
+	}
+	if err := b.Send(rawMessage(`{}`)); err != io.ErrClosedPipe {
+		t.Errorf("Expected io.ErrClosedPipe, got %v", err)
+	}
+	if msg, err := b.Receive(); err != nil || string(msg) != `{"n":2}` {
+		t.Errorf("Unexpected message: %s (%v)", msg, err)
+	}
+	if _, err := b.Receive(); err != io.EOF {
+		t.Errorf("Expected io.EOF after drain, got %v", err)
+	}
+}
+
+func TestInMemoryPairCloseUnblocksReceive(t *testing.T) {
+	a, b := transport.NewInMemoryPair()
+	done := make(chan error, 1)
+	go func() {
+		_, err := b.Receive()
+		done <- err
+	}()
+	a.Close()
+	if err := <-done; err != io.EOF {
+		t.Errorf("Expected io.EOF, got %v", err)
+	}
+}
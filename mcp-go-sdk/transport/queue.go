@@ -0,0 +1,240 @@
+package transport
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"mcp-go-sdk"
+)
+
+// QueueStats describes the outgoing message queue of a transport
+type QueueStats struct {
+	// Depth is the number of messages waiting to be written
+	Depth int
+
+	// Capacity is the depth at which sends start to block, zero means
+	// unbounded
+	Capacity int
+
+	// MaxDepth is the highest depth seen so far
+	MaxDepth int
+
+	// Timeouts is the number of sends that failed with mcp.ErrWriteTimeout
+	Timeouts int64
+}
+
+// QueueReporter is implemented by transports that queue outgoing messages.
+// Every transport in this package implements it.
+type QueueReporter interface {
+	QueueStats() QueueStats
+}
+
+// writeQueue serializes writes from concurrent senders. At most capacity
+// senders are queued at a time; the rest block until there is room or the
+// timeout passes. Flushing is either done after every write or deferred by
+// the flush interval so that bursts share a flush.
+type writeQueue struct {
+	slots    chan struct{}
+	lock     chan struct{}
+	capacity int
+	timeout  time.Duration
+	interval time.Duration
+	flush    func() error
+
+	mu        sync.Mutex
+	depth     int
+	maxDepth  int
+	timeouts  int64
+	dirty     bool
+	scheduled bool
+	flushErr  error
+}
+
+// newWriteQueue creates a queue. A nil flush function means writes need no
+// flushing.
+func newWriteQueue(capacity int, timeout, interval time.Duration, flush func() error) *writeQueue {
+	q := &writeQueue{
+		lock:     make(chan struct{}, 1),
+		capacity: capacity,
+		timeout:  timeout,
+		interval: interval,
+		flush:    flush,
+	}
+	if capacity > 0 {
+		q.slots = make(chan struct{}, capacity)
+	}
+	return q
+}
+
+// write waits for its turn and runs fn, which performs the actual write
+func (q *writeQueue) write(fn func() error) error {
+	var expired <-chan time.Time
+	if q.timeout > 0 {
+		timer := time.NewTimer(q.timeout)
+		defer timer.Stop()
+		expired = timer.C
+	}
+
+	if q.slots != nil {
+		select {
+		case q.slots <- struct{}{}:
+		case <-expired:
+			return q.timedOut()
+		}
+		defer func() { <-q.slots }()
+	}
+	q.enter()
+	defer q.leave()
+
+	select {
+	case q.lock <- struct{}{}:
+	case <-expired:
+		return q.timedOut()
+	}
+	defer func() { <-q.lock }()
+
+	if err := q.takeFlushErr(); err != nil {
+		return err
+	}
+	if err := fn(); err != nil {
+		return err
+	}
+	if q.flush == nil {
+		return nil
+	}
+	if q.interval <= 0 {
+		return q.flush()
+	}
+	q.scheduleFlush()
+	return nil
+}
+
+// close flushes anything written but not yet flushed
+func (q *writeQueue) close() error {
+	if q.flush == nil || q.interval <= 0 {
+		return nil
+	}
+	select {
+	case q.lock <- struct{}{}:
+	case <-time.After(q.flushTimeout()):
+		return q.timedOut()
+	}
+	defer func() { <-q.lock }()
+	return q.flushDirty()
+}
+
+// stats returns the current queue statistics
+func (q *writeQueue) stats() QueueStats {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return QueueStats{
+		Depth:    q.depth,
+		Capacity: q.capacity,
+		MaxDepth: q.maxDepth,
+		Timeouts: q.timeouts,
+	}
+}
+
+func (q *writeQueue) enter() {
+	q.mu.Lock()
+	q.depth++
+	if q.depth > q.maxDepth {
+		q.maxDepth = q.depth
+	}
+	q.mu.Unlock()
+}
+
+func (q *writeQueue) leave() {
+	q.mu.Lock()
+	q.depth--
+	q.mu.Unlock()
+}
+
+func (q *writeQueue) timedOut() error {
+	q.mu.Lock()
+	q.timeouts++
+	q.mu.Unlock()
+	return fmt.Errorf("%w after %v", mcp.ErrWriteTimeout, q.timeout)
+}
+
+// takeFlushErr returns and clears the error of the last deferred flush
+func (q *writeQueue) takeFlushErr() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	err := q.flushErr
+	q.flushErr = nil
+	return err
+}
+
+// scheduleFlush arranges a flush after the flush interval. Must be called
+// with the write lock held.
+func (q *writeQueue) scheduleFlush() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.dirty = true
+	if q.scheduled {
+		return
+	}
+	q.scheduled = true
+	time.AfterFunc(q.interval, func() {
+		q.lock <- struct{}{}
+		defer func() { <-q.lock }()
+		if err := q.flushDirty(); err != nil {
+			q.mu.Lock()
+			q.flushErr = err
+			q.mu.Unlock()
+		}
+	})
+}
+
+// flushDirty flushes if there are unflushed writes. Must be called with the
+// write lock held.
+func (q *writeQueue) flushDirty() error {
+	q.mu.Lock()
+	dirty := q.dirty
+	q.dirty = false
+	q.scheduled = false
+	q.mu.Unlock()
+	if !dirty {
+		return nil
+	}
+	return q.flush()
+}
+
+// flushTimeout bounds the final flush on close
+func (q *writeQueue) flushTimeout() time.Duration {
+	if q.timeout > 0 {
+		return q.timeout
+	}
+	return 5 * time.Second
+}
+
+// waitCond waits on c until ready reports true or the timeout passes, and
+// reports which happened. c.L must be held. A zero timeout waits forever.
+func waitCond(c *sync.Cond, timeout time.Duration, ready func() bool) bool {
+	if ready() {
+		return true
+	}
+	if timeout <= 0 {
+		for !ready() {
+			c.Wait()
+		}
+		return true
+	}
+
+	deadline := time.Now().Add(timeout)
+	timer := time.AfterFunc(timeout, func() {
+		c.L.Lock()
+		c.Broadcast()
+		c.L.Unlock()
+	})
+	defer timer.Stop()
+	for !ready() {
+		if !time.Now().Before(deadline) {
+			return false
+		}
+		c.Wait()
+	}
+	return true
+}
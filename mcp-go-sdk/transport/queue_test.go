@@ -0,0 +1,146 @@
+package transport_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"mcp-go-sdk"
+	"mcp-go-sdk/transport"
+)
+
+func TestConcurrentSendsDoNotInterleave(t *testing.T) {
+	serverConn, clientConn := net.Pipe()
+	defer clientConn.Close()
+	config := &mcp.TransportConfig{BufferSize: 16, QueueSize: 4, WriteTimeout: 5 * time.Second}
+	tr := transport.NewBaseTransport(serverConn, serverConn, serverConn, config)
+	defer tr.Close()
+
+	const senders, perSender = 8, 25
+	payload := strings.Repeat("x", 100)
+	var wg sync.WaitGroup
+	for i := 0; i < senders; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < perSender; j++ {
+				if err := tr.Send(map[string]interface{}{"sender": i, "seq": j, "data": payload}); err != nil {
+					t.Errorf("Send failed: %v", err)
+					return
+				}
+			}
+		}(i)
+	}
+
+	reader := bufio.NewReader(clientConn)
+	next := make(map[int]int)
+	for n := 0; n < senders*perSender; n++ {
+		line, err := reader.ReadBytes('\n')
+		if err != nil {
+			t.Fatalf("Read failed: %v", err)
+		}
+		var msg struct {
+			Sender int    `json:"sender"`
+			Seq    int    `json:"seq"`
+			Data   string `json:"data"`
+		}
+		if err := json.Unmarshal(line, &msg); err != nil {
+			t.Fatalf("Interleaved output %q: %v", line, err)
+		}
+		if msg.Seq != next[msg.Sender] {
+			t.Fatalf("Expected seq %d from sender %d, got %d", next[msg.Sender], msg.Sender, msg.Seq)
+		}
+		next[msg.Sender]++
+	}
+	wg.Wait()
+
+	stats := tr.QueueStats()
+	if stats.Depth != 0 || stats.Capacity != 4 || stats.MaxDepth < 1 || stats.MaxDepth > 4 {
+		t.Errorf("Unexpected queue stats %+v", stats)
+	}
+}
+
+func TestSendTimesOutWhenPeerStalls(t *testing.T) {
+	serverConn, clientConn := net.Pipe()
+	defer clientConn.Close()
+	config := &mcp.TransportConfig{QueueSize: 1, WriteTimeout: 50 * time.Millisecond}
+	tr := transport.NewBaseTransport(serverConn, serverConn, serverConn, config)
+	defer tr.Close()
+
+	// Nobody reads the other end of the pipe, so the write deadline fires
+	// for the first send and the queue fills up behind it
+	errs := make(chan error, 3)
+	for i := 0; i < 3; i++ {
+		go func() {
+			errs <- tr.Send(map[string]int{"n": 1})
+		}()
+	}
+	for i := 0; i < 3; i++ {
+		select {
+		case err := <-errs:
+			if err == nil {
+				t.Error("Expected send to fail")
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("Send blocked past the write timeout")
+		}
+	}
+	if stats := tr.QueueStats(); stats.Timeouts == 0 {
+		t.Errorf("Expected queue timeouts to be counted, got %+v", stats)
+	}
+}
+
+func TestFlushInterval(t *testing.T) {
+	serverConn, clientConn := net.Pipe()
+	defer clientConn.Close()
+	config := &mcp.TransportConfig{QueueSize: 8, WriteTimeout: time.Second, FlushInterval: 20 * time.Millisecond}
+	tr := transport.NewBaseTransport(serverConn, serverConn, serverConn, config)
+	defer tr.Close()
+
+	for i := 0; i < 3; i++ {
+		if err := tr.Send(map[string]int{"n": i}); err != nil {
+			t.Fatalf("Send failed: %v", err)
+		}
+	}
+
+	// All three messages arrive in a single write once the interval passes
+	clientConn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	buf := make([]byte, 1024)
+	n, err := clientConn.Read(buf)
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if got := string(buf[:n]); got != "{\"n\":0}\n{\"n\":1}\n{\"n\":2}\n" {
+		t.Errorf("Expected one batched write, got %q", got)
+	}
+}
+
+func TestInMemoryBackpressure(t *testing.T) {
+	a, b := transport.NewInMemoryPairWithConfig(&mcp.TransportConfig{QueueSize: 2, WriteTimeout: 50 * time.Millisecond})
+
+	for i := 0; i < 2; i++ {
+		if err := a.Send(map[string]int{"n": i}); err != nil {
+			t.Fatalf("Send failed: %v", err)
+		}
+	}
+	if err := a.Send(map[string]int{"n": 2}); !errors.Is(err, mcp.ErrWriteTimeout) {
+		t.Fatalf("Expected ErrWriteTimeout, got %v", err)
+	}
+	stats := a.QueueStats()
+	if stats.Depth != 2 || stats.MaxDepth != 2 || stats.Timeouts != 1 {
+		t.Errorf("Unexpected queue stats %+v", stats)
+	}
+
+	// Receiving makes room again
+	if _, err := b.Receive(); err != nil {
+		t.Fatalf("Receive failed: %v", err)
+	}
+	if err := a.Send(map[string]int{"n": 3}); err != nil {
+		t.Errorf("Send failed: %v", err)
+	}
+}
@@ -0,0 +1,239 @@
+package transport
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"mcp-go-sdk"
+)
+
+// Direction tells whether a recorded message was received or sent
+type Direction string
+
+const (
+	// DirectionIn marks messages received by the recorded side
+	DirectionIn Direction = "in"
+
+	// DirectionOut marks messages sent by the recorded side
+	DirectionOut Direction = "out"
+)
+
+// RedactedValue replaces the values of redacted fields in a recording
+const RedactedValue = "[REDACTED]"
+
+// RecordEntry is one line of a recording
+type RecordEntry struct {
+	Time      time.Time       `json:"time"`
+	Direction Direction       `json:"direction"`
+	Message   json.RawMessage `json:"message"`
+}
+
+// RecordConfig represents configuration options for a recording
+type RecordConfig struct {
+	// Redact lists field names whose values are replaced with RedactedValue
+	// wherever they appear in the params.arguments of a message
+	Redact []string
+}
+
+// RecordingTransport wraps a transport and writes every message it sends or
+// receives to a JSONL recording. Each line is a RecordEntry. Recording
+// errors never interrupt the session.
+type RecordingTransport struct {
+	inner  mcp.Transport
+	redact map[string]bool
+	mu     sync.Mutex
+	writer *bufio.Writer
+	closer io.Closer
+}
+
+// NewRecordingTransport records the traffic of t to w
+func NewRecordingTransport(t mcp.Transport, w io.Writer, config *RecordConfig) *RecordingTransport {
+	if config == nil {
+		config = &RecordConfig{}
+	}
+	redact := make(map[string]bool, len(config.Redact))
+	for _, field := range config.Redact {
+		redact[field] = true
+	}
+
+	return &RecordingTransport{
+		inner:  t,
+		redact: redact,
+		writer: bufio.NewWriter(w),
+	}
+}
+
+// RecordToFile records the traffic of t to a file, appending to it if it
+// exists. The file is closed with the transport.
+func RecordToFile(t mcp.Transport, path string, config *RecordConfig) (*RecordingTransport, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open recording: %w", err)
+	}
+	rt := NewRecordingTransport(t, f, config)
+	rt.closer = f
+	return rt, nil
+}
+
+// Send implements Transport.Send
+func (t *RecordingTransport) Send(data interface{}) error {
+	msg, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	if err := t.inner.Send(json.RawMessage(msg)); err != nil {
+		return err
+	}
+	t.record(DirectionOut, msg)
+	return nil
+}
+
+// Receive implements Transport.Receive
+func (t *RecordingTransport) Receive() ([]byte, error) {
+	msg, err := t.inner.Receive()
+	if err != nil {
+		return nil, err
+	}
+	t.record(DirectionIn, msg)
+	return msg, nil
+}
+
+// Close implements Transport.Close. It closes the wrapped transport and
+// flushes the recording.
+func (t *RecordingTransport) Close() error {
+	err := t.inner.Close()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.writer.Flush()
+	if t.closer != nil {
+		t.closer.Close()
+		t.closer = nil
+	}
+	return err
+}
+
+// QueueStats implements QueueReporter by reporting the wrapped transport
+func (t *RecordingTransport) QueueStats() QueueStats {
+	if r, ok := t.inner.(QueueReporter); ok {
+		return r.QueueStats()
+	}
+	return QueueStats{}
+}
+
+// Context implements ContextCarrier by passing on the wrapped transport's
+// context
+func (t *RecordingTransport) Context() context.Context {
+	if c, ok := t.inner.(ContextCarrier); ok {
+		return c.Context()
+	}
+	return context.Background()
+}
+
+// record appends a message to the recording
+func (t *RecordingTransport) record(dir Direction, msg []byte) {
+	entry := RecordEntry{
+		Time:      time.Now().UTC(),
+		Direction: dir,
+		Message:   t.redactMessage(msg),
+	}
+	if !json.Valid(entry.Message) {
+		// Keep malformed input readable instead of breaking the line format
+		quoted, _ := json.Marshal(string(msg))
+		entry.Message = quoted
+	}
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.writer.Write(append(line, '\n'))
+	t.writer.Flush()
+}
+
+// redactMessage replaces redacted fields in params.arguments
+func (t *RecordingTransport) redactMessage(msg []byte) json.RawMessage {
+	if len(t.redact) == 0 {
+		return msg
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(msg, &m); err != nil {
+		return msg
+	}
+	params, ok := m["params"].(map[string]interface{})
+	if !ok {
+		return msg
+	}
+	args, ok := params["arguments"]
+	if !ok {
+		return msg
+	}
+
+	params["arguments"] = t.redactValue(args)
+	out, err := json.Marshal(m)
+	if err != nil {
+		return msg
+	}
+	return out
+}
+
+// redactValue walks v and replaces the values of redacted fields
+func (t *RecordingTransport) redactValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, field := range v {
+			if t.redact[k] {
+				v[k] = RedactedValue
+			} else {
+				v[k] = t.redactValue(field)
+			}
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = t.redactValue(item)
+		}
+	}
+	return v
+}
+
+// ReadRecording parses a JSONL recording
+func ReadRecording(r io.Reader) ([]RecordEntry, error) {
+	var entries []RecordEntry
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 64<<20)
+	for line := 1; scanner.Scan(); line++ {
+		if len(trimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var entry RecordEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("recording line %d: %w", line, err)
+		}
+		if entry.Direction != DirectionIn && entry.Direction != DirectionOut {
+			return nil, fmt.Errorf("recording line %d: unknown direction %q", line, entry.Direction)
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// ReadRecordingFile parses a JSONL recording file
+func ReadRecordingFile(path string) ([]RecordEntry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadRecording(f)
+}
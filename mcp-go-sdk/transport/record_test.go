@@ -0,0 +1,103 @@
+package transport_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"mcp-go-sdk"
+	"mcp-go-sdk/server"
+	"mcp-go-sdk/transport"
+)
+
+// upperTool behaves like echoTool but changes its output
+type upperTool struct{ echoTool }
+
+func (t *upperTool) Execute(params json.RawMessage) (interface{}, error) {
+	return map[string]interface{}{
+		"content": []map[string]interface{}{{"type": "text", "text": strings.ToUpper(string(params))}},
+	}, nil
+}
+
+// recordSession runs a short session against the echo server and returns
+// the recording
+func recordSession(t *testing.T, config *transport.RecordConfig) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	client, serverEnd := transport.NewInMemoryPair()
+	recorder := transport.NewRecordingTransport(serverEnd, &buf, config)
+
+	done := make(chan error, 1)
+	go func() {
+		done <- serveEcho(recorder)
+	}()
+
+	request(t, client, initializeBody)
+	if _, err := client.Receive(); err != nil {
+		t.Fatalf("Receive failed: %v", err)
+	}
+	client.Send(rawMessage(`{"jsonrpc":"2.0","method":"notifications/initialized"}`))
+	request(t, client, `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"echo","arguments":{"user":"bob","password":"secret"}}}`)
+	client.Close()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Server did not stop")
+	}
+	recorder.Close()
+	return buf.Bytes()
+}
+
+func TestRecordingTransport(t *testing.T) {
+	recording := recordSession(t, &transport.RecordConfig{Redact: []string{"password"}})
+	entries, err := transport.ReadRecording(bytes.NewReader(recording))
+	if err != nil {
+		t.Fatalf("Failed to read recording: %v", err)
+	}
+	directions := make([]string, len(entries))
+	for i, entry := range entries {
+		directions[i] = string(entry.Direction)
+		if entry.Time.IsZero() {
+			t.Errorf("Entry %d has no timestamp", i)
+		}
+	}
+	expected := "in out out in in out"
+	if got := strings.Join(directions, " "); got != expected {
+		t.Errorf("Expected directions %q, got %q", expected, got)
+	}
+	if msg := string(entries[4].Message); strings.Contains(msg, "secret") || !strings.Contains(msg, `"password":"[REDACTED]"`) {
+		t.Errorf("Expected redacted arguments, got %s", entries[4].Message)
+	}
+}
+
+func TestReplayTransport(t *testing.T) {
+	entries, err := transport.ReadRecording(bytes.NewReader(recordSession(t, nil)))
+	if err != nil {
+		t.Fatalf("Failed to read recording: %v", err)
+	}
+
+	replay := func(tool mcp.Tool) []transport.ReplayDiff {
+		rt := transport.NewReplayTransport(entries, &transport.ReplayConfig{Timeout: time.Second})
+		srv := server.NewServer(rt)
+		srv.RegisterTool(tool)
+		if err := srv.Start(); err != nil {
+			t.Fatalf("Replay failed: %v", err)
+		}
+		return rt.Diffs()
+	}
+
+	if diffs := replay(&echoTool{}); len(diffs) != 0 {
+		t.Errorf("Expected no differences, got %v", diffs)
+	}
+
+	diffs := replay(&upperTool{})
+	if len(diffs) != 1 {
+		t.Fatalf("Expected one difference, got %v", diffs)
+	}
+	if diffs[0].Entry != 5 || diffs[0].Path != "result.content[0].text" {
+		t.Errorf("Unexpected difference %s", diffs[0])
+	}
+}
@@ -0,0 +1,277 @@
+package transport
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ReplayConfig represents configuration options for a replay
+type ReplayConfig struct {
+	// Timeout is how long to wait for each recorded response before it is
+	// reported as missing
+	Timeout time.Duration
+
+	// Ignore lists paths, such as "result.serverInfo.version", that are
+	// left out of the comparison together with everything below them
+	Ignore []string
+}
+
+// DefaultReplayConfig returns the default replay configuration
+func DefaultReplayConfig() *ReplayConfig {
+	return &ReplayConfig{
+		Timeout: 5 * time.Second,
+	}
+}
+
+// ReplayDiff describes one difference between a recorded message and the
+// message sent during the replay
+type ReplayDiff struct {
+	// Entry is the index of the recorded message, or -1 for a message that
+	// was not expected at all
+	Entry int
+
+	// Path locates the difference within the message, empty when a whole
+	// message is missing or unexpected
+	Path string
+
+	// Expected is the recorded value, nil if the message was unexpected
+	Expected json.RawMessage
+
+	// Actual is the replayed value, nil if the message is missing
+	Actual json.RawMessage
+}
+
+// String formats the difference for test output
+func (d ReplayDiff) String() string {
+	switch {
+	case d.Actual == nil:
+		return fmt.Sprintf("entry %d: missing message %s", d.Entry, d.Expected)
+	case d.Expected == nil:
+		return fmt.Sprintf("unexpected message %s", d.Actual)
+	default:
+		return fmt.Sprintf("entry %d: %s: expected %s, got %s", d.Entry, d.Path, d.Expected, d.Actual)
+	}
+}
+
+// ReplayTransport plays a recorded session back to a server. Receive returns
+// the recorded inbound messages in order, and every message the server sends
+// is compared with the recorded outbound messages. Before handing out the
+// next inbound message it waits for the server to send what it sent at that
+// point in the recording. Once the recording is exhausted Receive reports
+// io.EOF, so ServeTransport returns when the replay is complete.
+//
+// Responses are matched by id and other messages by method, so the server
+// may send them in a different order than recorded.
+type ReplayTransport struct {
+	entries []RecordEntry
+	config  *ReplayConfig
+	ignore  []string
+
+	mu      sync.Mutex
+	cond    *sync.Cond
+	pos     int
+	pending []int
+	diffs   []ReplayDiff
+	closed  bool
+}
+
+// NewReplayTransport creates a transport that replays the given recording
+func NewReplayTransport(entries []RecordEntry, config *ReplayConfig) *ReplayTransport {
+	if config == nil {
+		config = DefaultReplayConfig()
+	}
+	t := &ReplayTransport{
+		entries: entries,
+		config:  config,
+		ignore:  config.Ignore,
+	}
+	t.cond = sync.NewCond(&t.mu)
+	return t
+}
+
+// Send implements Transport.Send by comparing the message with the recording
+func (t *ReplayTransport) Send(data interface{}) error {
+	msg, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	match := t.match(msg)
+	if match < 0 {
+		t.diffs = append(t.diffs, ReplayDiff{Entry: -1, Actual: msg})
+		return nil
+	}
+	idx := t.pending[match]
+	t.pending = append(t.pending[:match], t.pending[match+1:]...)
+	t.compare(idx, msg)
+	t.cond.Broadcast()
+	return nil
+}
+
+// Receive implements Transport.Receive
+func (t *ReplayTransport) Receive() ([]byte, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for t.pos < len(t.entries) && t.entries[t.pos].Direction == DirectionOut {
+		t.pending = append(t.pending, t.pos)
+		t.pos++
+	}
+
+	ready := func() bool { return t.closed || len(t.pending) == 0 }
+	if !waitCond(t.cond, t.config.Timeout, ready) {
+		for _, idx := range t.pending {
+			t.diffs = append(t.diffs, ReplayDiff{Entry: idx, Expected: t.entries[idx].Message})
+		}
+		t.pending = nil
+	}
+
+	if t.closed || t.pos >= len(t.entries) {
+		return nil, io.EOF
+	}
+	msg := t.entries[t.pos].Message
+	t.pos++
+	// Expect whatever the server sent in reply before the next inbound message
+	for t.pos < len(t.entries) && t.entries[t.pos].Direction == DirectionOut {
+		t.pending = append(t.pending, t.pos)
+		t.pos++
+	}
+	return msg, nil
+}
+
+// Close implements Transport.Close
+func (t *ReplayTransport) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.closed = true
+	t.cond.Broadcast()
+	return nil
+}
+
+// QueueStats implements QueueReporter. Replayed messages are never queued.
+func (t *ReplayTransport) QueueStats() QueueStats {
+	return QueueStats{}
+}
+
+// Diffs returns the differences found so far
+func (t *ReplayTransport) Diffs() []ReplayDiff {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]ReplayDiff(nil), t.diffs...)
+}
+
+// match finds the pending recorded message that msg answers: the response
+// with the same id, or else the first message with the same method
+func (t *ReplayTransport) match(msg []byte) int {
+	var env envelope
+	if err := json.Unmarshal(msg, &env); err != nil {
+		return -1
+	}
+
+	for i, idx := range t.pending {
+		var expected envelope
+		if err := json.Unmarshal(t.entries[idx].Message, &expected); err != nil {
+			continue
+		}
+		if env.isResponse() {
+			if expected.isResponse() && expected.idKey() == env.idKey() {
+				return i
+			}
+		} else if !expected.isResponse() && expected.Method == env.Method {
+			return i
+		}
+	}
+	return -1
+}
+
+// compare records the differences between a recorded message and msg
+func (t *ReplayTransport) compare(idx int, msg []byte) {
+	var expected, actual interface{}
+	json.Unmarshal(t.entries[idx].Message, &expected)
+	if err := json.Unmarshal(msg, &actual); err != nil {
+		t.diffs = append(t.diffs, ReplayDiff{Entry: idx, Expected: t.entries[idx].Message, Actual: msg})
+		return
+	}
+	t.diffValues(idx, "", expected, actual)
+}
+
+// diffValues walks two decoded JSON values and records where they differ
+func (t *ReplayTransport) diffValues(idx int, path string, expected, actual interface{}) {
+	if t.ignored(path) {
+		return
+	}
+
+	switch e := expected.(type) {
+	case map[string]interface{}:
+		if a, ok := actual.(map[string]interface{}); ok {
+			keys := make(map[string]bool)
+			for k := range e {
+				keys[k] = true
+			}
+			for k := range a {
+				keys[k] = true
+			}
+			sorted := make([]string, 0, len(keys))
+			for k := range keys {
+				sorted = append(sorted, k)
+			}
+			sort.Strings(sorted)
+			for _, k := range sorted {
+				t.diffValues(idx, joinPath(path, k), e[k], a[k])
+			}
+			return
+		}
+	case []interface{}:
+		if a, ok := actual.([]interface{}); ok && len(a) == len(e) {
+			for i := range e {
+				t.diffValues(idx, fmt.Sprintf("%s[%d]", path, i), e[i], a[i])
+			}
+			return
+		}
+	}
+
+	if !reflect.DeepEqual(expected, actual) {
+		t.diffs = append(t.diffs, ReplayDiff{
+			Entry:    idx,
+			Path:     path,
+			Expected: encodeValue(expected),
+			Actual:   encodeValue(actual),
+		})
+	}
+}
+
+// ignored reports whether path is excluded from the comparison
+func (t *ReplayTransport) ignored(path string) bool {
+	for _, prefix := range t.ignore {
+		if path == prefix || strings.HasPrefix(path, prefix+".") || strings.HasPrefix(path, prefix+"[") {
+			return true
+		}
+	}
+	return false
+}
+
+// joinPath appends a field name to a path
+func joinPath(path, field string) string {
+	if path == "" {
+		return field
+	}
+	return path + "." + field
+}
+
+// encodeValue marshals a decoded JSON value, using null for absent values
+func encodeValue(v interface{}) json.RawMessage {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return json.RawMessage("null")
+	}
+	return b
+}
@@ -0,0 +1,338 @@
+package transport
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+)
+
+// SSEConfig represents configuration options for the legacy HTTP+SSE
+// transport
+type SSEConfig struct {
+	// MessagePath is the path announced to clients in the endpoint event.
+	// Clients POST their messages there.
+	MessagePath string
+
+	// MaxBodySize is the maximum size of a POST body in bytes, zero means
+	// unlimited
+	MaxBodySize int64
+
+	// QueueSize is the maximum number of messages waiting to be written to
+	// the event stream. Further sends block until the client catches up.
+	QueueSize int
+
+	// WriteTimeout bounds the time a send may wait for room in the queue and
+	// the time spent writing to the client. A stream that times out is
+	// closed. Zero means no limit.
+	WriteTimeout time.Duration
+
+	// EventStore makes event streams resumable. A session whose client
+	// goes away is kept for ResumeTimeout, storing the messages sent in
+	// the meantime. A client that opens a new event stream with a
+	// Last-Event-ID header gets them replayed and continues the session.
+	// Nil ends sessions when their stream drops.
+	EventStore EventStore
+
+	// ResumeTimeout is how long a session waits for its client to resume,
+	// one minute if zero
+	ResumeTimeout time.Duration
+}
+
+// DefaultSSEConfig returns the default HTTP+SSE configuration
+func DefaultSSEConfig() *SSEConfig {
+	return &SSEConfig{
+		MessagePath:  "/messages",
+		MaxBodySize:  4 << 20,
+		QueueSize:    64,
+		WriteTimeout: 30 * time.Second,
+	}
+}
+
+// SSEHandler implements the 2024-11-05 HTTP+SSE transport as an
+// http.Handler. A GET request opens an event stream that first announces the
+// message endpoint in an "endpoint" event; the client then POSTs its
+// messages to that endpoint. Every event stream is its own session and is
+// torn down when the connection drops.
+//
+// The same handler serves both sides, so mount it on the stream path and on
+// the message path:
+//
+//	mux.Handle("/sse", handler)
+//	mux.Handle("/messages", handler)
+type SSEHandler struct {
+	serve    SessionFunc
+	config   *SSEConfig
+	mu       sync.Mutex
+	sessions map[string]*sseSession
+}
+
+// NewSSEHandler creates a handler that runs serve for every event stream
+func NewSSEHandler(serve SessionFunc, config *SSEConfig) *SSEHandler {
+	if config == nil {
+		config = DefaultSSEConfig()
+	}
+
+	return &SSEHandler{
+		serve:    serve,
+		config:   config,
+		sessions: make(map[string]*sseSession),
+	}
+}
+
+// ServeHTTP implements http.Handler
+func (h *SSEHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.handleStream(w, r)
+	case http.MethodPost:
+		h.handleMessage(w, r)
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// Close terminates all sessions
+func (h *SSEHandler) Close() error {
+	h.mu.Lock()
+	sessions := make([]*sseSession, 0, len(h.sessions))
+	for id, sess := range h.sessions {
+		sessions = append(sessions, sess)
+		delete(h.sessions, id)
+	}
+	h.mu.Unlock()
+
+	for _, sess := range sessions {
+		sess.Close()
+	}
+	return nil
+}
+
+// handleStream opens a session and streams its messages until the client
+// disconnects or the session ends
+func (h *SSEHandler) handleStream(w http.ResponseWriter, r *http.Request) {
+	if _, ok := w.(http.Flusher); !ok {
+		http.Error(w, "Streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	if lastID := r.Header.Get("Last-Event-ID"); lastID != "" && h.config.EventStore != nil {
+		h.resumeStream(w, r, lastID)
+		return
+	}
+
+	id, err := newSessionID()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	sess := &sseSession{
+		httpSession: newHTTPSession(id, r),
+		stream:      newOutStream(0, h.config.QueueSize, h.config.WriteTimeout),
+	}
+	if h.config.EventStore != nil {
+		sess.stream.resumable(id, h.config.EventStore)
+	}
+
+	h.mu.Lock()
+	h.sessions[id] = sess
+	h.mu.Unlock()
+
+	go func() {
+		if err := h.serve(sess); err != nil {
+			fmt.Fprintf(os.Stderr, "Session %s ended with error: %v\n", id, err)
+		}
+		h.removeSession(id)
+		sess.Close()
+	}()
+
+	h.stream(w, r, sess)
+}
+
+// resumeStream continues the session of the given event on a new stream,
+// replaying the messages sent after that event
+func (h *SSEHandler) resumeStream(w http.ResponseWriter, r *http.Request, lastID string) {
+	id, _ := eventStream(lastID)
+	h.mu.Lock()
+	sess, ok := h.sessions[id]
+	h.mu.Unlock()
+	if !ok {
+		http.Error(w, "Session not found", http.StatusNotFound)
+		return
+	}
+	if !sess.sameCaller(r) {
+		http.Error(w, "Session belongs to another caller", http.StatusForbidden)
+		return
+	}
+
+	// Keep the session from expiring, unless it already has
+	h.mu.Lock()
+	_, ok = h.sessions[id]
+	if sess.expiry != nil {
+		sess.expiry.Stop()
+		sess.expiry = nil
+	}
+	h.mu.Unlock()
+	if !ok {
+		http.Error(w, "Session not found", http.StatusNotFound)
+		return
+	}
+
+	resumed, err := sess.stream.resume(lastID)
+	if err != nil && !errors.Is(err, ErrUnknownStream) {
+		h.expireLater(sess)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if !resumed {
+		http.Error(w, "Event stream already open for this session", http.StatusConflict)
+		return
+	}
+	h.stream(w, r, sess)
+}
+
+// stream announces the message endpoint and writes the session's messages
+// until the stream ends. The session ends with its stream, unless the client
+// went away from a resumable stream.
+func (h *SSEHandler) stream(w http.ResponseWriter, r *http.Request, sess *sseSession) {
+	endpoint := h.config.MessagePath + "?sessionId=" + url.QueryEscape(sess.id)
+	writeSSEHeaders(w)
+	if err := writeSSEEvent(w, "endpoint", []byte(endpoint)); err != nil {
+		sess.stream.detach()
+	} else {
+		streamEvents(w, r, sess.closed, sess.stream)
+	}
+
+	if sess.stream.isDetached() {
+		h.expireLater(sess)
+		return
+	}
+	h.removeSession(sess.id)
+	sess.Close()
+}
+
+// expireLater ends a detached session if its client does not resume it in
+// time. Resuming replaces the session's expiry, which turns the timer into a
+// no-op even if it already fired.
+func (h *SSEHandler) expireLater(sess *sseSession) {
+	timeout := h.config.ResumeTimeout
+	if timeout <= 0 {
+		timeout = time.Minute
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var timer *time.Timer
+	timer = time.AfterFunc(timeout, func() {
+		h.mu.Lock()
+		expired := sess.expiry == timer && sess.stream.isDetached()
+		if expired {
+			delete(h.sessions, sess.id)
+		}
+		h.mu.Unlock()
+		if expired {
+			sess.Close()
+		}
+	})
+	sess.expiry = timer
+}
+
+// handleMessage delivers a POSTed message to its session
+func (h *SSEHandler) handleMessage(w http.ResponseWriter, r *http.Request) {
+	id := r.URL.Query().Get("sessionId")
+	if id == "" {
+		http.Error(w, "Missing sessionId parameter", http.StatusBadRequest)
+		return
+	}
+
+	h.mu.Lock()
+	sess, ok := h.sessions[id]
+	h.mu.Unlock()
+	if !ok {
+		http.Error(w, "Session not found", http.StatusNotFound)
+		return
+	}
+	if !sess.sameCaller(r) {
+		http.Error(w, "Session belongs to another caller", http.StatusForbidden)
+		return
+	}
+
+	var reader io.Reader = r.Body
+	if h.config.MaxBodySize > 0 {
+		reader = http.MaxBytesReader(w, r.Body, h.config.MaxBodySize)
+	}
+	body, err := io.ReadAll(reader)
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			http.Error(w, "Request too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, "Failed to read body", http.StatusBadRequest)
+		return
+	}
+
+	msgs, _, err := splitBatch(body)
+	if err != nil {
+		http.Error(w, "Parse error", http.StatusBadRequest)
+		return
+	}
+	for _, msg := range msgs {
+		if err := sess.deliver(r, msg); err != nil {
+			http.Error(w, "Session closed", http.StatusNotFound)
+			return
+		}
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// removeSession forgets a session
+func (h *SSEHandler) removeSession(id string) {
+	h.mu.Lock()
+	delete(h.sessions, id)
+	h.mu.Unlock()
+}
+
+// sseSession is the mcp.Transport seen by the server for one HTTP+SSE
+// connection
+type sseSession struct {
+	*httpSession
+	stream *outStream
+
+	// Ends the session while its client is away, guarded by the handler's
+	// mutex
+	expiry *time.Timer
+}
+
+// Close implements Transport.Close and drops the stored messages of the
+// session
+func (s *sseSession) Close() error {
+	s.httpSession.Close()
+	if s.stream.store != nil {
+		s.stream.store.Remove(s.stream.name)
+	}
+	return nil
+}
+
+// Send implements Transport.Send. It blocks while the event stream queue is
+// full.
+func (s *sseSession) Send(data interface{}) error {
+	if s.isClosed() {
+		return io.ErrClosedPipe
+	}
+	msg, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	return s.stream.push(msg, false)
+}
+
+// QueueStats implements QueueReporter
+func (s *sseSession) QueueStats() QueueStats {
+	return s.stream.stats()
+}
@@ -0,0 +1,73 @@
+package transport_test
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"mcp-go-sdk/transport"
+)
+
+func TestSSESession(t *testing.T) {
+	handler := transport.NewSSEHandler(serveEcho, nil)
+	defer handler.Close()
+	mux := http.NewServeMux()
+	mux.Handle("/sse", handler)
+	mux.Handle("/messages", handler)
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL+"/sse", nil)
+	req.Header.Set("Accept", "text/event-stream")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("GET failed: %v", err)
+	}
+	defer resp.Body.Close()
+	events := bufio.NewReader(resp.Body)
+
+	endpoint := readEvent(t, events)
+	if !strings.HasPrefix(endpoint, "/messages?sessionId=") {
+		t.Fatalf("Unexpected endpoint event: %s", endpoint)
+	}
+
+	postMessage := func(body string) int {
+		resp, err := http.Post(ts.URL+endpoint, "application/json", strings.NewReader(body))
+		if err != nil {
+			t.Fatalf("POST failed: %v", err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	if status := postMessage(`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2024-11-05"}}`); status != http.StatusAccepted {
+		t.Fatalf("Expected 202, got %d", status)
+	}
+	if event := readEvent(t, events); !strings.Contains(event, `"protocolVersion":"2024-11-05"`) {
+		t.Errorf("Unexpected initialize response: %s", event)
+	}
+	if event := readEvent(t, events); event != `{"jsonrpc":"2.0","method":"initialized"}` {
+		t.Errorf("Unexpected notification: %s", event)
+	}
+
+	postMessage(`{"jsonrpc":"2.0","id":2,"method":"tools/list"}`)
+	if event := readEvent(t, events); !strings.Contains(event, `"name":"echo"`) {
+		t.Errorf("Unexpected tools/list response: %s", event)
+	}
+
+	// Dropping the stream ends the session
+	cancel()
+	deadline := time.Now().Add(5 * time.Second)
+	for postMessage(`{"jsonrpc":"2.0","id":3,"method":"tools/list"}`) != http.StatusNotFound {
+		if time.Now().After(deadline) {
+			t.Fatal("Session was not cleaned up after disconnect")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
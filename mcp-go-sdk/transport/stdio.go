@@ -13,8 +13,11 @@ type StdioTransport struct {
 
 // NewStdioTransport creates a new transport that uses stdin/stdout
 func NewStdioTransport() *BaseTransport {
-	config := &mcp.TransportConfig{
-		BufferSize: 4096,
-	}
+	return NewStdioTransportWithConfig(mcp.DefaultTransportConfig())
+}
+
+// NewStdioTransportWithConfig creates a stdin/stdout transport with the given
+// buffer sizes, message size limits and framing
+func NewStdioTransportWithConfig(config *mcp.TransportConfig) *BaseTransport {
 	return NewBaseTransport(os.Stdin, os.Stdout, nil, config)
 }
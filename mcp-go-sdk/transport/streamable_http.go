@@ -0,0 +1,974 @@
+package transport
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"mcp-go-sdk"
+)
+
+// HeaderSessionID carries the session identifier of a Streamable HTTP session
+const HeaderSessionID = "Mcp-Session-Id"
+
+// JSON-RPC error codes used when a request is rejected before it reaches the
+// server
+const (
+	errCodeParse          = -32700
+	errCodeInvalidRequest = -32600
+)
+
+// StreamableHTTPConfig represents configuration options for the Streamable
+// HTTP transport
+type StreamableHTTPConfig struct {
+	// JSONResponse answers POST requests with a single JSON body instead of
+	// an SSE stream
+	JSONResponse bool
+
+	// MaxBodySize is the maximum size of a POST body in bytes, zero means
+	// unlimited
+	MaxBodySize int64
+
+	// BacklogSize is the number of server-initiated messages kept for a
+	// session while no stream is open to deliver them
+	BacklogSize int
+
+	// QueueSize is the maximum number of server-initiated messages waiting
+	// on an open stream. Further sends block until the client catches up.
+	QueueSize int
+
+	// WriteTimeout bounds the time a send may wait for room on a stream and
+	// the time spent writing to the client. A stream that times out is
+	// closed. Zero means no limit.
+	WriteTimeout time.Duration
+
+	// EventStore makes event streams resumable. Every message sent on an
+	// event stream is stored under an event id; a client that loses the
+	// stream GETs the endpoint with a Last-Event-ID header to receive the
+	// messages it missed and the rest of the stream. The default keeps
+	// events in memory within DefaultEventStoreConfig; nil turns resumption
+	// off.
+	EventStore EventStore
+
+	// IdleTimeout closes sessions that have had no request in progress and
+	// no open stream for that long, so that clients which never DELETE
+	// their session do not keep it forever. Ten minutes if zero; negative
+	// keeps sessions until they are deleted.
+	IdleTimeout time.Duration
+}
+
+// DefaultStreamableHTTPConfig returns the default Streamable HTTP configuration
+func DefaultStreamableHTTPConfig() *StreamableHTTPConfig {
+	return &StreamableHTTPConfig{
+		MaxBodySize:  4 << 20,
+		BacklogSize:  100,
+		QueueSize:    64,
+		WriteTimeout: 30 * time.Second,
+		EventStore:   NewMemoryEventStore(nil),
+	}
+}
+
+// StreamableHTTPHandler implements the MCP Streamable HTTP transport as an
+// http.Handler. Clients send JSON-RPC messages with POST and may open a GET
+// event stream for server-initiated messages. Each session is identified by
+// the Mcp-Session-Id header and served by its own SessionFunc call.
+type StreamableHTTPHandler struct {
+	serve    SessionFunc
+	config   *StreamableHTTPConfig
+	mu       sync.Mutex
+	sessions map[string]*streamableSession
+}
+
+// NewStreamableHTTPHandler creates a handler that runs serve for every new
+// session
+func NewStreamableHTTPHandler(serve SessionFunc, config *StreamableHTTPConfig) *StreamableHTTPHandler {
+	if config == nil {
+		config = DefaultStreamableHTTPConfig()
+	}
+
+	return &StreamableHTTPHandler{
+		serve:    serve,
+		config:   config,
+		sessions: make(map[string]*streamableSession),
+	}
+}
+
+// ServeHTTP implements http.Handler
+func (h *StreamableHTTPHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		h.handlePost(w, r)
+	case http.MethodGet:
+		h.handleGet(w, r)
+	case http.MethodDelete:
+		h.handleDelete(w, r)
+	default:
+		w.Header().Set("Allow", "GET, POST, DELETE")
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// Close terminates all sessions
+func (h *StreamableHTTPHandler) Close() error {
+	h.mu.Lock()
+	sessions := make([]*streamableSession, 0, len(h.sessions))
+	for id, sess := range h.sessions {
+		sessions = append(sessions, sess)
+		delete(h.sessions, id)
+	}
+	h.mu.Unlock()
+
+	for _, sess := range sessions {
+		sess.Close()
+	}
+	return nil
+}
+
+// handlePost delivers client messages to the session and streams back the
+// responses
+func (h *StreamableHTTPHandler) handlePost(w http.ResponseWriter, r *http.Request) {
+	var reader io.Reader = r.Body
+	if h.config.MaxBodySize > 0 {
+		reader = http.MaxBytesReader(w, r.Body, h.config.MaxBodySize)
+	}
+	body, err := io.ReadAll(reader)
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			writeJSONRPCError(w, http.StatusRequestEntityTooLarge, errCodeInvalidRequest, "Request too large")
+			return
+		}
+		writeJSONRPCError(w, http.StatusBadRequest, errCodeParse, "Failed to read body")
+		return
+	}
+
+	msgs, batch, err := splitBatch(body)
+	if err != nil {
+		writeJSONRPCError(w, http.StatusBadRequest, errCodeParse, "Parse error")
+		return
+	}
+
+	envs := make([]envelope, len(msgs))
+	hasInit := false
+	for i, msg := range msgs {
+		if err := json.Unmarshal(msg, &envs[i]); err != nil {
+			writeJSONRPCError(w, http.StatusBadRequest, errCodeInvalidRequest, "Invalid request")
+			return
+		}
+		if envs[i].Method == "initialize" {
+			hasInit = true
+		}
+	}
+
+	sess, status, reason := h.lookupSession(r, hasInit)
+	if sess == nil {
+		writeJSONRPCError(w, status, errCodeInvalidRequest, reason)
+		return
+	}
+	defer h.idle(sess)
+	w.Header().Set(HeaderSessionID, sess.id)
+
+	var keys []string
+	for i := range envs {
+		if envs[i].isRequest() {
+			keys = append(keys, envs[i].idKey())
+		}
+	}
+
+	// Notifications and responses only need to be accepted
+	if len(keys) == 0 {
+		for _, msg := range msgs {
+			if err := sess.deliver(r, msg); err != nil {
+				http.Error(w, "Session closed", http.StatusNotFound)
+				return
+			}
+		}
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	useSSE := !h.config.JSONResponse && acceptsEventStream(r)
+	var st *outStream
+	if useSSE {
+		st = sess.newEventStream(len(keys))
+	} else {
+		st = sess.newStream(len(keys))
+	}
+	sess.register(keys, st, useSSE)
+	defer sess.release(st)
+
+	for _, msg := range msgs {
+		if err := sess.deliver(r, msg); err != nil {
+			http.Error(w, "Session closed", http.StatusNotFound)
+			return
+		}
+	}
+
+	if useSSE {
+		writeSSEHeaders(w)
+		streamEvents(w, r, sess.closed, st)
+		return
+	}
+
+	var responses []json.RawMessage
+	closed := false
+	for {
+		queued, finished := st.drain()
+		for _, msg := range queued {
+			responses = append(responses, msg.data)
+		}
+		if finished || closed {
+			break
+		}
+		select {
+		case <-st.signal:
+		case <-sess.closed:
+			closed = true
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	if len(responses) == 0 {
+		http.Error(w, "Session closed", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if !batch && len(responses) == 1 {
+		w.Write(responses[0])
+		return
+	}
+	json.NewEncoder(w).Encode(responses)
+}
+
+// handleGet opens the standalone event stream for server-initiated messages
+func (h *StreamableHTTPHandler) handleGet(w http.ResponseWriter, r *http.Request) {
+	if !acceptsEventStream(r) {
+		http.Error(w, "Client must accept text/event-stream", http.StatusNotAcceptable)
+		return
+	}
+
+	sess, status, reason := h.lookupSession(r, false)
+	if sess == nil {
+		http.Error(w, reason, status)
+		return
+	}
+	defer h.idle(sess)
+
+	var st *outStream
+	if lastID := r.Header.Get("Last-Event-ID"); lastID != "" && h.config.EventStore != nil {
+		st, status, reason = sess.resumeStream(lastID)
+	} else {
+		st, status, reason = sess.attachStandalone()
+	}
+	if st == nil {
+		http.Error(w, reason, status)
+		return
+	}
+	defer sess.release(st)
+
+	w.Header().Set(HeaderSessionID, sess.id)
+	writeSSEHeaders(w)
+	streamEvents(w, r, sess.closed, st)
+}
+
+// handleDelete terminates a session at the client's request
+func (h *StreamableHTTPHandler) handleDelete(w http.ResponseWriter, r *http.Request) {
+	sess, status, reason := h.lookupSession(r, false)
+	if sess == nil {
+		http.Error(w, reason, status)
+		return
+	}
+	defer h.idle(sess)
+
+	h.removeSession(sess.id)
+	sess.Close()
+	w.WriteHeader(http.StatusOK)
+}
+
+// lookupSession finds the session named by the request header, creating a
+// new one for initialize requests without a session id. A session is only
+// found for the caller that opened it, and is kept from expiring until the
+// caller passes it to idle.
+func (h *StreamableHTTPHandler) lookupSession(r *http.Request, create bool) (*streamableSession, int, string) {
+	id := r.Header.Get(HeaderSessionID)
+	if id == "" {
+		if !create {
+			return nil, http.StatusBadRequest, "Bad Request: " + HeaderSessionID + " header is required"
+		}
+		sess, err := h.newSession(r)
+		if err != nil {
+			return nil, http.StatusInternalServerError, err.Error()
+		}
+		return sess, http.StatusOK, ""
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	sess, ok := h.sessions[id]
+	if !ok {
+		return nil, http.StatusNotFound, "Session not found"
+	}
+	if !sess.sameCaller(r) {
+		return nil, http.StatusForbidden, "Session belongs to another caller"
+	}
+	sess.busy()
+	return sess, http.StatusOK, ""
+}
+
+// idle ends a request on a session found by lookupSession. Once no request
+// is left, the session expires after the idle timeout. Starting a request
+// stops the expiry, which turns the timer into a no-op even if it already
+// fired.
+func (h *StreamableHTTPHandler) idle(sess *streamableSession) {
+	timeout := h.config.IdleTimeout
+	if timeout == 0 {
+		timeout = 10 * time.Minute
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	sess.active--
+	if sess.active > 0 || timeout < 0 || h.sessions[sess.id] != sess {
+		return
+	}
+	var timer *time.Timer
+	timer = time.AfterFunc(timeout, func() {
+		h.mu.Lock()
+		expired := sess.expiry == timer && sess.active == 0
+		if expired {
+			delete(h.sessions, sess.id)
+		}
+		h.mu.Unlock()
+		if expired {
+			sess.Close()
+		}
+	})
+	sess.expiry = timer
+}
+
+// newSession registers a session and starts serving it
+func (h *StreamableHTTPHandler) newSession(r *http.Request) (*streamableSession, error) {
+	id, err := newSessionID()
+	if err != nil {
+		return nil, err
+	}
+
+	sess := newStreamableSession(id, r, h.config)
+	h.mu.Lock()
+	h.sessions[id] = sess
+	sess.busy()
+	h.mu.Unlock()
+
+	go func() {
+		if err := h.serve(sess); err != nil {
+			fmt.Fprintf(os.Stderr, "Session %s ended with error: %v\n", id, err)
+		}
+		h.removeSession(id)
+		sess.Close()
+	}()
+
+	return sess, nil
+}
+
+// removeSession forgets a session
+func (h *StreamableHTTPHandler) removeSession(id string) {
+	h.mu.Lock()
+	delete(h.sessions, id)
+	h.mu.Unlock()
+}
+
+// writeJSONRPCError writes a JSON-RPC error response with a null id
+func writeJSONRPCError(w http.ResponseWriter, status int, code int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(&mcp.Response{
+		JsonRPC: "2.0",
+		Error: &mcp.Error{
+			Code:    code,
+			Message: message,
+		},
+		ID: json.RawMessage("null"),
+	})
+}
+
+// outStream queues messages for one HTTP response stream. Responses are
+// always accepted, since a POST expects a known number of them. Other
+// messages block while the queue is full, until the writer catches up or the
+// write timeout passes; a stream that times out is abandoned.
+//
+// A stream with an event store is resumable: every message is stored under an
+// event id before it is queued. When its client goes away the stream is
+// detached rather than closed, and keeps storing messages until the client
+// resumes it.
+type outStream struct {
+	mu       sync.Mutex
+	room     *sync.Cond
+	queue    []event
+	signal   chan struct{}
+	pending  int
+	bounded  bool
+	closed   bool
+	capacity int
+	timeout  time.Duration
+	maxDepth int
+	timeouts int64
+
+	name     string
+	store    EventStore
+	seq      int64
+	detached bool
+	written  string
+}
+
+// event is a message queued on a stream, with its event id if the stream is
+// resumable
+type event struct {
+	id   string
+	data []byte
+}
+
+// newOutStream creates a stream that finishes after the given number of
+// responses, or never if pending is zero
+func newOutStream(pending, capacity int, timeout time.Duration) *outStream {
+	s := &outStream{
+		signal:   make(chan struct{}, 1),
+		pending:  pending,
+		bounded:  pending > 0,
+		capacity: capacity,
+		timeout:  timeout,
+	}
+	s.room = sync.NewCond(&s.mu)
+	return s
+}
+
+// resumable makes the stream store its messages under the given name
+func (s *outStream) resumable(name string, store EventStore) *outStream {
+	s.name = name
+	s.store = store
+	return s
+}
+
+// push queues a message and wakes up the writer. Messages for a closed
+// stream are dropped.
+func (s *outStream) push(msg []byte, response bool) error {
+	s.mu.Lock()
+	if !response && s.capacity > 0 {
+		ready := func() bool {
+			return s.closed || s.detached || len(s.queue) < s.capacity
+		}
+		if !waitCond(s.room, s.timeout, ready) {
+			s.timeouts++
+			s.closed = true
+			s.mu.Unlock()
+			s.wake()
+			return fmt.Errorf("%w after %v", mcp.ErrWriteTimeout, s.timeout)
+		}
+	}
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.enqueue(msg)
+	if response && s.pending > 0 {
+		s.pending--
+	}
+	s.mu.Unlock()
+
+	s.wake()
+	return nil
+}
+
+// enqueue stores a message if the stream is resumable and queues it unless
+// the stream is detached. The caller must hold s.mu.
+func (s *outStream) enqueue(msg []byte) {
+	ev := event{data: msg}
+	if s.store != nil {
+		s.seq++
+		id := fmt.Sprintf("%s:%d", s.name, s.seq)
+		// A message that cannot be stored is still delivered, it just
+		// cannot be replayed
+		if s.store.Append(s.name, id, msg) == nil {
+			ev.id = id
+		}
+	}
+	if s.detached {
+		return
+	}
+	s.queue = append(s.queue, ev)
+	if len(s.queue) > s.maxDepth {
+		s.maxDepth = len(s.queue)
+	}
+}
+
+// finished reports whether a stream has delivered all its expected responses
+func (s *outStream) finished() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.bounded && s.pending == 0
+}
+
+// accepting reports whether the stream is still waiting for responses and can
+// carry other messages alongside them
+func (s *outStream) accepting() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return !s.closed && !s.detached && (!s.bounded || s.pending > 0)
+}
+
+// drain returns the queued messages and whether the stream is finished,
+// either because all expected responses have been delivered or because it
+// was closed
+func (s *outStream) drain() ([]event, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	msgs := s.queue
+	s.queue = nil
+	s.room.Broadcast()
+	return msgs, s.closed || (s.bounded && s.pending == 0)
+}
+
+// sent records the id of the last event written to the client
+func (s *outStream) sent(id string) {
+	s.mu.Lock()
+	s.written = id
+	s.mu.Unlock()
+}
+
+// close stops the stream, releasing senders waiting for room
+func (s *outStream) close() {
+	s.mu.Lock()
+	s.closed = true
+	s.room.Broadcast()
+	s.mu.Unlock()
+	s.wake()
+}
+
+// detach keeps a resumable stream alive after its client went away. Queued
+// messages are dropped, since they are stored. It reports false for streams
+// that cannot be resumed, which should be closed instead.
+func (s *outStream) detach() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.store == nil || s.closed {
+		return false
+	}
+	s.detached = true
+	s.queue = nil
+	s.room.Broadcast()
+	return true
+}
+
+// isDetached reports whether the stream waits for its client to resume it
+func (s *outStream) isDetached() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.detached
+}
+
+// resume reattaches a detached stream, queueing the stored messages after
+// the given event id, or after the last event written if afterID is empty.
+// It reports false if the stream is not detached. ErrUnknownStream is
+// passed on, after reattaching, when nothing is stored for the stream.
+func (s *outStream) resume(afterID string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.detached {
+		return false, nil
+	}
+	if afterID == "" {
+		afterID = s.written
+	}
+	var replay []event
+	err := s.store.Replay(s.name, afterID, func(id string, msg []byte) error {
+		replay = append(replay, event{id: id, data: msg})
+		return nil
+	})
+	if err != nil && !errors.Is(err, ErrUnknownStream) {
+		return false, err
+	}
+	// The replay is bounded by the store, so it bypasses the queue limit
+	s.queue = append(replay, s.queue...)
+	s.detached = false
+	s.wake()
+	return true, err
+}
+
+// depth returns the number of queued messages
+func (s *outStream) depth() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.queue)
+}
+
+// stats returns the queue statistics of the stream
+func (s *outStream) stats() QueueStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return QueueStats{
+		Depth:    len(s.queue),
+		Capacity: s.capacity,
+		MaxDepth: s.maxDepth,
+		Timeouts: s.timeouts,
+	}
+}
+
+// wake signals the writer that the stream has changed. It does not take
+// s.mu, so it may be called with the lock held.
+func (s *outStream) wake() {
+	select {
+	case s.signal <- struct{}{}:
+	default:
+	}
+}
+
+// maxStoredStreams bounds the event streams of a session whose messages are
+// kept in the event store. Beyond it, the oldest streams without a client
+// are forgotten, closing those still waiting to be resumed, so that a long
+// session whose clients keep dropping POST streams does not grow forever.
+const maxStoredStreams = 64
+
+// streamableSession is the mcp.Transport seen by the server for one
+// Streamable HTTP session
+type streamableSession struct {
+	*httpSession
+	config     *StreamableHTTPConfig
+	mu         sync.Mutex
+	requests   map[string]*outStream
+	posts      []*outStream
+	standalone *outStream
+	backlog    [][]byte
+	maxDepth   int
+	timeouts   int64
+
+	// Resumable streams by name, and the names of the streams with stored
+	// messages, oldest first and at most maxStoredStreams
+	streams    map[string]*outStream
+	stored     []string
+	nextStream int
+
+	// Requests in progress, and the timer that ends the session once
+	// there are none, guarded by the handler's mutex
+	active int
+	expiry *time.Timer
+}
+
+func newStreamableSession(id string, r *http.Request, config *StreamableHTTPConfig) *streamableSession {
+	return &streamableSession{
+		httpSession: newHTTPSession(id, r),
+		config:      config,
+		requests:    make(map[string]*outStream),
+		streams:     make(map[string]*outStream),
+	}
+}
+
+// busy marks a request in progress, stopping the expiry of the session. The
+// caller must hold the handler's mutex.
+func (s *streamableSession) busy() {
+	s.active++
+	if s.expiry != nil {
+		s.expiry.Stop()
+		s.expiry = nil
+	}
+}
+
+// newStream creates a response stream bounded by the session's queue size
+func (s *streamableSession) newStream(pending int) *outStream {
+	return newOutStream(pending, s.config.QueueSize, s.config.WriteTimeout)
+}
+
+// newEventStream creates a stream for an SSE response, which is resumable if
+// the session has an event store. Its name starts with the session id, so
+// that a Last-Event-ID only resumes streams of its own session.
+func (s *streamableSession) newEventStream(pending int) *outStream {
+	st := s.newStream(pending)
+	if s.config.EventStore == nil {
+		return st
+	}
+	s.mu.Lock()
+	s.nextStream++
+	name := fmt.Sprintf("%s/%d", s.id, s.nextStream)
+	s.streams[name] = st.resumable(name, s.config.EventStore)
+	s.stored = append(s.stored, name)
+	evicted := s.evict()
+	s.mu.Unlock()
+
+	for _, name := range evicted {
+		s.config.EventStore.Remove(name)
+	}
+	return st
+}
+
+// evict forgets the oldest stored streams beyond maxStoredStreams, and
+// returns their names for their messages to be removed from the event
+// store. Streams a client is reading are kept. The caller must hold s.mu.
+func (s *streamableSession) evict() []string {
+	excess := len(s.stored) - maxStoredStreams
+	if excess <= 0 {
+		return nil
+	}
+	var evicted []string
+	kept := make([]string, 0, len(s.stored))
+	for _, name := range s.stored {
+		st := s.streams[name]
+		if len(evicted) == excess || (st != nil && !st.isDetached()) {
+			kept = append(kept, name)
+			continue
+		}
+		if st != nil {
+			st.close()
+			s.forget(st)
+		}
+		evicted = append(evicted, name)
+	}
+	s.stored = kept
+	return evicted
+}
+
+// Close implements Transport.Close and drops the stored messages of the
+// session
+func (s *streamableSession) Close() error {
+	s.httpSession.Close()
+	s.mu.Lock()
+	stored := s.stored
+	s.stored = nil
+	s.mu.Unlock()
+	for _, name := range stored {
+		s.config.EventStore.Remove(name)
+	}
+	return nil
+}
+
+// Send implements Transport.Send. Responses go to the POST that carried the
+// request; other messages go to the standalone stream, an open POST stream,
+// or the backlog, in that order. It blocks while the chosen stream is full.
+func (s *streamableSession) Send(data interface{}) error {
+	msg, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	var env envelope
+	if err := json.Unmarshal(msg, &env); err != nil {
+		return err
+	}
+
+	st, response, err := s.route(msg, env)
+	if st == nil || err != nil {
+		return err
+	}
+	if err := st.push(msg, response); err != nil {
+		s.mu.Lock()
+		s.timeouts++
+		s.mu.Unlock()
+		return err
+	}
+	if response && st.isDetached() && st.finished() {
+		// Nothing more will be sent on a POST stream whose client went
+		// away, so resuming it only replays the event store
+		st.close()
+		s.mu.Lock()
+		s.forget(st)
+		s.mu.Unlock()
+	}
+
+	depth := s.QueueStats().Depth
+	s.mu.Lock()
+	if depth > s.maxDepth {
+		s.maxDepth = depth
+	}
+	s.mu.Unlock()
+	return nil
+}
+
+// route picks the stream for an outgoing message. Messages with nowhere to
+// go are kept in the backlog, or dropped if they are responses.
+func (s *streamableSession) route(msg []byte, env envelope) (*outStream, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.isClosed() {
+		return nil, false, io.ErrClosedPipe
+	}
+
+	if env.isResponse() {
+		key := env.idKey()
+		if st, ok := s.requests[key]; ok {
+			delete(s.requests, key)
+			return st, true, nil
+		}
+		// Responses for requests whose POST has gone away are dropped
+		return nil, true, nil
+	}
+
+	if s.standalone != nil {
+		return s.standalone, false, nil
+	}
+	for i := len(s.posts) - 1; i >= 0; i-- {
+		if s.posts[i].accepting() {
+			return s.posts[i], false, nil
+		}
+	}
+
+	s.backlog = append(s.backlog, msg)
+	if size := s.config.BacklogSize; size > 0 && len(s.backlog) > size {
+		s.backlog = s.backlog[len(s.backlog)-size:]
+	}
+	return nil, false, nil
+}
+
+// QueueStats implements QueueReporter. The depth counts the messages queued
+// on all open streams of the session and in its backlog.
+func (s *streamableSession) QueueStats() QueueStats {
+	s.mu.Lock()
+	streams := make(map[*outStream]struct{})
+	for _, st := range s.requests {
+		streams[st] = struct{}{}
+	}
+	for _, st := range s.posts {
+		streams[st] = struct{}{}
+	}
+	if s.standalone != nil {
+		streams[s.standalone] = struct{}{}
+	}
+	stats := QueueStats{
+		Depth:    len(s.backlog),
+		Capacity: s.config.QueueSize,
+		MaxDepth: s.maxDepth,
+		Timeouts: s.timeouts,
+	}
+	s.mu.Unlock()
+
+	for st := range streams {
+		stats.Depth += st.depth()
+	}
+	if stats.Depth > stats.MaxDepth {
+		stats.MaxDepth = stats.Depth
+	}
+	return stats
+}
+
+// register routes responses for the given request ids to st
+func (s *streamableSession) register(keys []string, st *outStream, stream bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, key := range keys {
+		s.requests[key] = st
+	}
+	if stream {
+		s.posts = append(s.posts, st)
+	}
+}
+
+// release closes a stream whose HTTP response has ended and removes all
+// routes to it. A detached stream is kept, with its routes, for its client to
+// resume, unless it has all its responses and only the event store is left
+// to replay.
+func (s *streamableSession) release(st *outStream) {
+	if st.isDetached() && !st.finished() {
+		return
+	}
+	st.close()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.forget(st)
+}
+
+// forget removes all routes to a closed stream. The caller must hold s.mu.
+func (s *streamableSession) forget(st *outStream) {
+	for key, r := range s.requests {
+		if r == st {
+			delete(s.requests, key)
+		}
+	}
+	for i, p := range s.posts {
+		if p == st {
+			s.posts = append(s.posts[:i], s.posts[i+1:]...)
+			break
+		}
+	}
+	if s.standalone == st {
+		s.standalone = nil
+	}
+	if s.streams[st.name] == st {
+		delete(s.streams, st.name)
+	}
+}
+
+// attachStandalone opens the standalone stream and flushes the backlog into
+// it. A standalone stream whose client went away is resumed after the last
+// event it wrote. It fails if another standalone stream is open.
+func (s *streamableSession) attachStandalone() (*outStream, int, string) {
+	const conflict = "Event stream already open for this session"
+	s.mu.Lock()
+	st := s.standalone
+	s.mu.Unlock()
+	if st != nil {
+		resumed, err := st.resume("")
+		if err != nil && !errors.Is(err, ErrUnknownStream) {
+			return nil, http.StatusInternalServerError, err.Error()
+		}
+		if !resumed {
+			return nil, http.StatusConflict, conflict
+		}
+		return st, http.StatusOK, ""
+	}
+
+	st = s.newEventStream(0)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.standalone != nil {
+		delete(s.streams, st.name)
+		return nil, http.StatusConflict, conflict
+	}
+	s.standalone = st
+	// The backlog is already bounded, so it bypasses the queue limit
+	st.mu.Lock()
+	for _, msg := range s.backlog {
+		st.enqueue(msg)
+	}
+	st.mu.Unlock()
+	st.wake()
+	s.backlog = nil
+	return st, http.StatusOK, ""
+}
+
+// resumeStream reattaches the stream of the given event and replays the
+// messages sent after it. A stream that has already finished is replayed
+// from the event store alone.
+func (s *streamableSession) resumeStream(lastID string) (*outStream, int, string) {
+	name, ok := eventStream(lastID)
+	if !ok || !strings.HasPrefix(name, s.id+"/") {
+		return nil, http.StatusBadRequest, "Bad Request: unknown Last-Event-ID"
+	}
+
+	s.mu.Lock()
+	st, live := s.streams[name]
+	s.mu.Unlock()
+	if !live {
+		st = s.newStream(0).resumable(name, s.config.EventStore)
+		st.detached = true
+		// Finish once the replay is written
+		defer st.close()
+	}
+
+	resumed, err := st.resume(lastID)
+	if errors.Is(err, ErrUnknownStream) {
+		if !live {
+			return nil, http.StatusNotFound, "Event stream not found"
+		}
+		err = nil
+	}
+	if err != nil {
+		return nil, http.StatusInternalServerError, err.Error()
+	}
+	if !resumed {
+		return nil, http.StatusConflict, "Event stream already open"
+	}
+	return st, http.StatusOK, ""
+}
@@ -0,0 +1,249 @@
+package transport_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"mcp-go-sdk"
+	"mcp-go-sdk/server"
+	"mcp-go-sdk/transport"
+)
+
+// echoTool implements mcp.Tool for testing
+type echoTool struct{}
+
+func (t *echoTool) Name() string            { return "echo" }
+func (t *echoTool) Description() string     { return "Echoes the input" }
+func (t *echoTool) Schema() json.RawMessage { return json.RawMessage(`{"type":"object"}`) }
+func (t *echoTool) Execute(params json.RawMessage) (interface{}, error) {
+	return map[string]interface{}{
+		"content": []map[string]interface{}{{"type": "text", "text": string(params)}},
+	}, nil
+}
+
+// serveEcho runs a server with the echo tool over t
+func serveEcho(t mcp.Transport) error {
+	srv := server.NewServer(t)
+	if err := srv.RegisterTool(&echoTool{}); err != nil {
+		return err
+	}
+	return srv.Start()
+}
+
+const initializeBody = `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26"}}`
+
+// post sends a JSON-RPC body to the handler
+func post(t *testing.T, url, sessionID, body string) *http.Response {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodPost, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json, text/event-stream")
+	if sessionID != "" {
+		req.Header.Set(transport.HeaderSessionID, sessionID)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	return resp
+}
+
+// readEvent reads the data of the next SSE event
+func readEvent(t *testing.T, r *bufio.Reader) string {
+	t.Helper()
+	var data []string
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatalf("Failed to read event: %v", err)
+		}
+		line = strings.TrimRight(line, "\n")
+		if line == "" && len(data) > 0 {
+			return strings.Join(data, "\n")
+		}
+		if strings.HasPrefix(line, "data: ") {
+			data = append(data, strings.TrimPrefix(line, "data: "))
+		}
+	}
+}
+
+func TestStreamableHTTPSession(t *testing.T) {
+	handler := transport.NewStreamableHTTPHandler(serveEcho, nil)
+	defer handler.Close()
+	ts := httptest.NewServer(handler)
+	defer ts.Close()
+
+	// Initialize creates a session and answers over SSE
+	resp := post(t, ts.URL, "", initializeBody)
+	sessionID := resp.Header.Get(transport.HeaderSessionID)
+	if sessionID == "" {
+		t.Fatal("Expected session id header")
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Expected event stream, got %q", ct)
+	}
+	event := readEvent(t, bufio.NewReader(resp.Body))
+	resp.Body.Close()
+	if !strings.Contains(event, `"protocolVersion":"2025-03-26"`) || !strings.Contains(event, `"id":1`) {
+		t.Errorf("Unexpected initialize response: %s", event)
+	}
+
+	// The standalone stream receives server-initiated messages sent while no
+	// stream was open
+	req, _ := http.NewRequest(http.MethodGet, ts.URL, nil)
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set(transport.HeaderSessionID, sessionID)
+	getResp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("GET failed: %v", err)
+	}
+	defer getResp.Body.Close()
+	if event := readEvent(t, bufio.NewReader(getResp.Body)); event != `{"jsonrpc":"2.0","method":"initialized"}` {
+		t.Errorf("Unexpected standalone event: %s", event)
+	}
+
+	// Only one standalone stream per session
+	req2, _ := http.NewRequest(http.MethodGet, ts.URL, nil)
+	req2.Header.Set("Accept", "text/event-stream")
+	req2.Header.Set(transport.HeaderSessionID, sessionID)
+	conflict, err := http.DefaultClient.Do(req2)
+	if err != nil {
+		t.Fatalf("GET failed: %v", err)
+	}
+	conflict.Body.Close()
+	if conflict.StatusCode != http.StatusConflict {
+		t.Errorf("Expected 409 for second stream, got %d", conflict.StatusCode)
+	}
+
+	// Notifications are accepted without a body
+	resp = post(t, ts.URL, sessionID, `{"jsonrpc":"2.0","method":"notifications/initialized"}`)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted {
+		t.Errorf("Expected 202 for notification, got %d", resp.StatusCode)
+	}
+
+	// Batches are answered on one stream
+	resp = post(t, ts.URL, sessionID, `[{"jsonrpc":"2.0","id":"a","method":"tools/list"},{"jsonrpc":"2.0","id":"b","method":"tools/call","params":{"name":"echo","arguments":{"x":1}}}]`)
+	body := bufio.NewReader(resp.Body)
+	first, second := readEvent(t, body), readEvent(t, body)
+	resp.Body.Close()
+	// Requests run concurrently, so the responses may come in either order
+	if strings.Contains(first, `"id":"b"`) {
+		first, second = second, first
+	}
+	if !strings.Contains(first, `"id":"a"`) || !strings.Contains(first, `"name":"echo"`) {
+		t.Errorf("Unexpected tools/list response: %s", first)
+	}
+	if !strings.Contains(second, `"id":"b"`) || !strings.Contains(second, `{\"x\":1}`) {
+		t.Errorf("Unexpected tools/call response: %s", second)
+	}
+
+	// Requests need a known session
+	resp = post(t, ts.URL, "", `{"jsonrpc":"2.0","id":2,"method":"tools/list"}`)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected 400 without session, got %d", resp.StatusCode)
+	}
+	resp = post(t, ts.URL, "unknown", `{"jsonrpc":"2.0","id":2,"method":"tools/list"}`)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected 404 for unknown session, got %d", resp.StatusCode)
+	}
+
+	// DELETE terminates the session and ends its streams
+	del, _ := http.NewRequest(http.MethodDelete, ts.URL, nil)
+	del.Header.Set(transport.HeaderSessionID, sessionID)
+	delResp, err := http.DefaultClient.Do(del)
+	if err != nil {
+		t.Fatalf("DELETE failed: %v", err)
+	}
+	delResp.Body.Close()
+	if delResp.StatusCode != http.StatusOK {
+		t.Errorf("Expected 200 for DELETE, got %d", delResp.StatusCode)
+	}
+	resp = post(t, ts.URL, sessionID, `{"jsonrpc":"2.0","id":3,"method":"tools/list"}`)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected 404 after DELETE, got %d", resp.StatusCode)
+	}
+}
+
+func TestStreamableHTTPJSONResponse(t *testing.T) {
+	config := transport.DefaultStreamableHTTPConfig()
+	config.JSONResponse = true
+	handler := transport.NewStreamableHTTPHandler(serveEcho, config)
+	defer handler.Close()
+	ts := httptest.NewServer(handler)
+	defer ts.Close()
+
+	resp := post(t, ts.URL, "", initializeBody)
+	resp.Body.Close()
+	sessionID := resp.Header.Get(transport.HeaderSessionID)
+
+	resp = post(t, ts.URL, sessionID, `{"jsonrpc":"2.0","id":7,"method":"tools/call","params":{"name":"echo","arguments":{}}}`)
+	defer resp.Body.Close()
+
+	if ct := resp.Header.Get("Content-Type"); ct != "application/json" {
+		t.Fatalf("Expected JSON response, got %q", ct)
+	}
+	var msg mcp.Response
+	if err := json.NewDecoder(resp.Body).Decode(&msg); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if string(msg.ID) != "7" || msg.Error != nil {
+		t.Errorf("Unexpected response: %+v", msg)
+	}
+}
+
+func TestStreamableHTTPIdleTimeout(t *testing.T) {
+	config := transport.DefaultStreamableHTTPConfig()
+	config.IdleTimeout = 100 * time.Millisecond
+	handler := transport.NewStreamableHTTPHandler(serveEcho, config)
+	defer handler.Close()
+	ts := httptest.NewServer(handler)
+	defer ts.Close()
+
+	resp := post(t, ts.URL, "", initializeBody)
+	sessionID := resp.Header.Get(transport.HeaderSessionID)
+	readEvent(t, bufio.NewReader(resp.Body))
+	resp.Body.Close()
+
+	// An open stream keeps the session alive
+	req, _ := http.NewRequest(http.MethodGet, ts.URL, nil)
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set(transport.HeaderSessionID, sessionID)
+	getResp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("GET failed: %v", err)
+	}
+	readEvent(t, bufio.NewReader(getResp.Body))
+	time.Sleep(300 * time.Millisecond)
+	resp = post(t, ts.URL, sessionID, `{"jsonrpc":"2.0","method":"notifications/initialized"}`)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("Expected the session to outlive its timeout while a stream is open, got status %d", resp.StatusCode)
+	}
+
+	// Without requests the session expires
+	getResp.Body.Close()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		resp = post(t, ts.URL, sessionID, `{"jsonrpc":"2.0","method":"notifications/initialized"}`)
+		resp.Body.Close()
+		if resp.StatusCode == http.StatusNotFound {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected the idle session to expire, got status %d", resp.StatusCode)
+		}
+		time.Sleep(300 * time.Millisecond)
+	}
+}
@@ -0,0 +1,656 @@
+package transport
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"mcp-go-sdk"
+)
+
+// WebSocket opcodes
+const (
+	wsOpContinuation = 0x0
+	wsOpText         = 0x1
+	wsOpBinary       = 0x2
+	wsOpClose        = 0x8
+	wsOpPing         = 0x9
+	wsOpPong         = 0xA
+)
+
+// WebSocket close codes
+const (
+	CloseNormal          = 1000
+	CloseGoingAway       = 1001
+	CloseProtocolError   = 1002
+	CloseUnsupportedData = 1003
+	CloseMessageTooBig   = 1009
+	CloseInternalError   = 1011
+)
+
+// WebSocketSubprotocol is the subprotocol negotiated for MCP connections
+const WebSocketSubprotocol = "mcp"
+
+// wsAcceptGUID is appended to the client key to compute the accept key
+const wsAcceptGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// WebSocketConfig represents configuration options for the WebSocket
+// transport
+type WebSocketConfig struct {
+	// MaxMessageSize is the maximum size of a message in bytes, in either
+	// direction, 4MB if zero. Larger incoming messages close the connection
+	// with 1009.
+	MaxMessageSize int64
+
+	// PingInterval is how often a ping is sent to keep the connection alive,
+	// zero disables keepalive
+	PingInterval time.Duration
+
+	// PongTimeout is how long to wait past a ping interval for any frame
+	// from the peer before the connection is considered dead
+	PongTimeout time.Duration
+
+	// WriteTimeout bounds the time spent writing a single frame, and the
+	// time a message may wait for room in the send queue
+	WriteTimeout time.Duration
+
+	// QueueSize is the maximum number of outgoing messages waiting to be
+	// written, zero means unbounded
+	QueueSize int
+
+	// CheckOrigin decides whether an upgrade request is allowed. Nil allows
+	// requests without an Origin header and those from the server's own
+	// host, so that web pages on other sites cannot open sessions in the
+	// user's browser. Set it to AllowAnyOrigin to accept every origin.
+	CheckOrigin func(r *http.Request) bool
+}
+
+// AllowAnyOrigin accepts upgrade requests from every origin. Use it as
+// WebSocketConfig.CheckOrigin only for servers that authenticate every
+// session, since any web page the user visits can reach the server.
+func AllowAnyOrigin(r *http.Request) bool {
+	return true
+}
+
+// sameOrigin accepts upgrade requests without an Origin header, which do
+// not come from browsers, and those whose origin host is the requested host
+func sameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(u.Host, r.Host)
+}
+
+// defaultWebSocketMessageSize bounds messages when no limit is configured,
+// since a frame header alone can announce a payload of any size
+const defaultWebSocketMessageSize = 4 << 20
+
+// DefaultWebSocketConfig returns the default WebSocket configuration
+func DefaultWebSocketConfig() *WebSocketConfig {
+	return &WebSocketConfig{
+		MaxMessageSize: defaultWebSocketMessageSize,
+		PingInterval:   30 * time.Second,
+		PongTimeout:    10 * time.Second,
+		WriteTimeout:   10 * time.Second,
+		QueueSize:      64,
+	}
+}
+
+// CloseError is returned by Receive when the peer closes the connection with
+// a code other than a normal closure
+type CloseError struct {
+	Code   int
+	Reason string
+}
+
+// Error implements the error interface
+func (e *CloseError) Error() string {
+	if e.Reason != "" {
+		return fmt.Sprintf("websocket closed with code %d: %s", e.Code, e.Reason)
+	}
+	return fmt.Sprintf("websocket closed with code %d", e.Code)
+}
+
+// WebSocketTransport implements Transport over a WebSocket connection. Every
+// JSON-RPC message travels in its own text message.
+type WebSocketTransport struct {
+	conn      net.Conn
+	reader    *bufio.Reader
+	client    bool
+	ctx       context.Context
+	config    *WebSocketConfig
+	queue     *writeQueue
+	writeMu   sync.Mutex
+	closeSent bool
+	closed    chan struct{}
+	closeOnce sync.Once
+	readErr   error
+}
+
+// newWebSocketTransport wraps an established connection
+func newWebSocketTransport(conn net.Conn, reader *bufio.Reader, client bool, config *WebSocketConfig) *WebSocketTransport {
+	t := &WebSocketTransport{
+		conn:   conn,
+		reader: reader,
+		client: client,
+		config: config,
+		closed: make(chan struct{}),
+		queue:  newWriteQueue(config.QueueSize, config.WriteTimeout, 0, nil),
+	}
+	t.extendReadDeadline()
+	if config.PingInterval > 0 {
+		go t.keepalive()
+	}
+	return t
+}
+
+// WebSocketHandler upgrades HTTP requests to WebSocket connections and runs
+// an MCP session over each of them
+type WebSocketHandler struct {
+	serve  SessionFunc
+	config *WebSocketConfig
+}
+
+// NewWebSocketHandler creates a handler that runs serve for every connection
+func NewWebSocketHandler(serve SessionFunc, config *WebSocketConfig) *WebSocketHandler {
+	if config == nil {
+		config = DefaultWebSocketConfig()
+	}
+	return &WebSocketHandler{
+		serve:  serve,
+		config: config,
+	}
+}
+
+// ServeHTTP implements http.Handler
+func (h *WebSocketHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	t, err := UpgradeWebSocket(w, r, h.config)
+	if err != nil {
+		return
+	}
+	defer t.Close()
+
+	if err := h.serve(t); err != nil {
+		fmt.Fprintf(os.Stderr, "WebSocket session ended with error: %v\n", err)
+	}
+}
+
+// UpgradeWebSocket performs the server side of the WebSocket handshake. On
+// failure an HTTP error has already been written to w.
+func UpgradeWebSocket(w http.ResponseWriter, r *http.Request, config *WebSocketConfig) (*WebSocketTransport, error) {
+	if config == nil {
+		config = DefaultWebSocketConfig()
+	}
+
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return nil, errors.New("websocket: upgrade requires GET")
+	}
+	if !headerContains(r.Header, "Connection", "upgrade") || !headerContains(r.Header, "Upgrade", "websocket") {
+		http.Error(w, "Expected WebSocket upgrade", http.StatusBadRequest)
+		return nil, errors.New("websocket: missing upgrade headers")
+	}
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		w.Header().Set("Sec-WebSocket-Version", "13")
+		http.Error(w, "Unsupported WebSocket version", http.StatusUpgradeRequired)
+		return nil, errors.New("websocket: unsupported version")
+	}
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if key == "" {
+		http.Error(w, "Missing Sec-WebSocket-Key", http.StatusBadRequest)
+		return nil, errors.New("websocket: missing key")
+	}
+	checkOrigin := config.CheckOrigin
+	if checkOrigin == nil {
+		checkOrigin = sameOrigin
+	}
+	if !checkOrigin(r) {
+		http.Error(w, "Origin not allowed", http.StatusForbidden)
+		return nil, errors.New("websocket: origin not allowed")
+	}
+
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "Connection cannot be upgraded", http.StatusInternalServerError)
+		return nil, errors.New("websocket: response writer does not support hijacking")
+	}
+	conn, rw, err := hijacker.Hijack()
+	if err != nil {
+		return nil, fmt.Errorf("websocket: hijack failed: %w", err)
+	}
+
+	var resp strings.Builder
+	resp.WriteString("HTTP/1.1 101 Switching Protocols\r\n")
+	resp.WriteString("Upgrade: websocket\r\n")
+	resp.WriteString("Connection: Upgrade\r\n")
+	resp.WriteString("Sec-WebSocket-Accept: " + wsAcceptKey(key) + "\r\n")
+	if headerContains(r.Header, "Sec-WebSocket-Protocol", WebSocketSubprotocol) {
+		resp.WriteString("Sec-WebSocket-Protocol: " + WebSocketSubprotocol + "\r\n")
+	}
+	resp.WriteString("\r\n")
+
+	if config.WriteTimeout > 0 {
+		conn.SetWriteDeadline(time.Now().Add(config.WriteTimeout))
+	}
+	if _, err := conn.Write([]byte(resp.String())); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("websocket: handshake failed: %w", err)
+	}
+	conn.SetWriteDeadline(time.Time{})
+
+	t := newWebSocketTransport(conn, rw.Reader, false, config)
+	t.ctx = context.WithoutCancel(r.Context())
+	return t, nil
+}
+
+// DialWebSocket connects to a WebSocket MCP server at a ws:// or wss:// URL
+func DialWebSocket(ctx context.Context, rawURL string, header http.Header, config *WebSocketConfig) (*WebSocketTransport, error) {
+	if config == nil {
+		config = DefaultWebSocketConfig()
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("websocket: invalid url: %w", err)
+	}
+	host := u.Host
+	var conn net.Conn
+	switch u.Scheme {
+	case "ws":
+		if u.Port() == "" {
+			host = net.JoinHostPort(u.Hostname(), "80")
+		}
+		var d net.Dialer
+		conn, err = d.DialContext(ctx, "tcp", host)
+	case "wss":
+		if u.Port() == "" {
+			host = net.JoinHostPort(u.Hostname(), "443")
+		}
+		d := tls.Dialer{Config: &tls.Config{ServerName: u.Hostname()}}
+		conn, err = d.DialContext(ctx, "tcp", host)
+	default:
+		return nil, fmt.Errorf("websocket: unsupported scheme %q", u.Scheme)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("websocket: dial failed: %w", err)
+	}
+
+	keyBytes := make([]byte, 16)
+	if _, err := rand.Read(keyBytes); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	key := base64.StdEncoding.EncodeToString(keyBytes)
+
+	req := &http.Request{
+		Method:     http.MethodGet,
+		URL:        u,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     make(http.Header),
+		Host:       u.Host,
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Sec-WebSocket-Key", key)
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	req.Header.Set("Sec-WebSocket-Protocol", WebSocketSubprotocol)
+
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	if err := req.Write(conn); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("websocket: handshake failed: %w", err)
+	}
+
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, req)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("websocket: handshake failed: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		conn.Close()
+		return nil, fmt.Errorf("websocket: handshake failed: unexpected status %s", resp.Status)
+	}
+	if resp.Header.Get("Sec-WebSocket-Accept") != wsAcceptKey(key) {
+		conn.Close()
+		return nil, errors.New("websocket: handshake failed: invalid accept key")
+	}
+	conn.SetDeadline(time.Time{})
+
+	return newWebSocketTransport(conn, reader, true, config), nil
+}
+
+// Send implements Transport.Send. It is safe for concurrent use; when the
+// send queue is full it blocks until there is room or the write timeout
+// passes.
+func (t *WebSocketTransport) Send(data interface{}) error {
+	msg, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	if int64(len(msg)) > t.maxMessageSize() {
+		return fmt.Errorf("websocket: %w (%d bytes)", mcp.ErrMessageTooLarge, len(msg))
+	}
+	return t.queue.write(func() error {
+		return t.writeFrame(wsOpText, msg)
+	})
+}
+
+// QueueStats implements QueueReporter
+func (t *WebSocketTransport) QueueStats() QueueStats {
+	return t.queue.stats()
+}
+
+// Context implements ContextCarrier. Client connections and connections
+// upgraded outside an HTTP handler have an empty context.
+func (t *WebSocketTransport) Context() context.Context {
+	if t.ctx == nil {
+		return context.Background()
+	}
+	return t.ctx
+}
+
+// Receive implements Transport.Receive. It answers pings and the closing
+// handshake while waiting for the next text message. A normal closure is
+// reported as io.EOF.
+func (t *WebSocketTransport) Receive() ([]byte, error) {
+	if t.readErr != nil {
+		return nil, io.EOF
+	}
+	msg, err := t.readMessage()
+	if err != nil {
+		t.readErr = err
+		t.shutdown()
+	}
+	return msg, err
+}
+
+// Close implements Transport.Close. It starts the closing handshake with a
+// normal closure code and releases the connection.
+func (t *WebSocketTransport) Close() error {
+	t.sendClose(CloseNormal, "")
+	t.shutdown()
+	return nil
+}
+
+// readMessage reads frames until a complete data message is assembled
+func (t *WebSocketTransport) readMessage() ([]byte, error) {
+	var message []byte
+	var started bool
+	for {
+		fin, opcode, payload, err := t.readFrame()
+		if err != nil {
+			return nil, err
+		}
+
+		switch opcode {
+		case wsOpPing:
+			if err := t.writeFrame(wsOpPong, payload); err != nil {
+				return nil, err
+			}
+			continue
+		case wsOpPong:
+			continue
+		case wsOpClose:
+			code := CloseNormal
+			reason := ""
+			if len(payload) >= 2 {
+				code = int(binary.BigEndian.Uint16(payload))
+				reason = string(payload[2:])
+			}
+			t.sendClose(code, "")
+			if code == CloseNormal || code == CloseGoingAway {
+				return nil, io.EOF
+			}
+			return nil, &CloseError{Code: code, Reason: reason}
+		case wsOpText:
+			if started {
+				return nil, t.fail(CloseProtocolError, "unexpected text frame")
+			}
+			started = true
+			message = payload
+		case wsOpContinuation:
+			if !started {
+				return nil, t.fail(CloseProtocolError, "unexpected continuation frame")
+			}
+			if int64(len(message)+len(payload)) > t.maxMessageSize() {
+				return nil, t.fail(CloseMessageTooBig, "message too large")
+			}
+			message = append(message, payload...)
+		case wsOpBinary:
+			return nil, t.fail(CloseUnsupportedData, "binary messages are not supported")
+		default:
+			return nil, t.fail(CloseProtocolError, "unknown opcode")
+		}
+
+		if fin {
+			return message, nil
+		}
+	}
+}
+
+// readFrame reads a single frame and unmasks its payload
+func (t *WebSocketTransport) readFrame() (bool, byte, []byte, error) {
+	var head [2]byte
+	if _, err := io.ReadFull(t.reader, head[:]); err != nil {
+		return false, 0, nil, err
+	}
+	t.extendReadDeadline()
+
+	fin := head[0]&0x80 != 0
+	opcode := head[0] & 0x0f
+	masked := head[1]&0x80 != 0
+	length := uint64(head[1] & 0x7f)
+
+	if head[0]&0x70 != 0 {
+		return false, 0, nil, t.fail(CloseProtocolError, "reserved bits set")
+	}
+	// Clients must mask their frames and servers must not
+	if masked == t.client {
+		return false, 0, nil, t.fail(CloseProtocolError, "invalid masking")
+	}
+	control := opcode >= wsOpClose
+	if control && (!fin || length > 125) {
+		return false, 0, nil, t.fail(CloseProtocolError, "invalid control frame")
+	}
+
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(t.reader, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(t.reader, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = binary.BigEndian.Uint64(ext[:])
+	}
+	if !control && length > uint64(t.maxMessageSize()) {
+		return false, 0, nil, t.fail(CloseMessageTooBig, "message too large")
+	}
+
+	var mask [4]byte
+	if masked {
+		if _, err := io.ReadFull(t.reader, mask[:]); err != nil {
+			return false, 0, nil, err
+		}
+	}
+
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(t.reader, payload); err != nil {
+		return false, 0, nil, err
+	}
+	if masked {
+		for i := range payload {
+			payload[i] ^= mask[i%4]
+		}
+	}
+	return fin, opcode, payload, nil
+}
+
+// maxMessageSize returns the configured message size limit or the default
+func (t *WebSocketTransport) maxMessageSize() int64 {
+	if t.config.MaxMessageSize > 0 {
+		return t.config.MaxMessageSize
+	}
+	return defaultWebSocketMessageSize
+}
+
+// writeFrame writes a single unfragmented frame, masking it on the client
+func (t *WebSocketTransport) writeFrame(opcode byte, payload []byte) error {
+	t.writeMu.Lock()
+	defer t.writeMu.Unlock()
+	return t.writeFrameLocked(opcode, payload)
+}
+
+func (t *WebSocketTransport) writeFrameLocked(opcode byte, payload []byte) error {
+	if t.closeSent {
+		return io.ErrClosedPipe
+	}
+
+	frame := make([]byte, 0, len(payload)+14)
+	frame = append(frame, 0x80|opcode)
+
+	var maskBit byte
+	if t.client {
+		maskBit = 0x80
+	}
+	switch n := len(payload); {
+	case n <= 125:
+		frame = append(frame, maskBit|byte(n))
+	case n <= 0xffff:
+		frame = append(frame, maskBit|126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(n))
+	default:
+		frame = append(frame, maskBit|127)
+		frame = binary.BigEndian.AppendUint64(frame, uint64(n))
+	}
+
+	if t.client {
+		var mask [4]byte
+		if _, err := rand.Read(mask[:]); err != nil {
+			return err
+		}
+		frame = append(frame, mask[:]...)
+		start := len(frame)
+		frame = append(frame, payload...)
+		for i := range payload {
+			frame[start+i] ^= mask[i%4]
+		}
+	} else {
+		frame = append(frame, payload...)
+	}
+
+	if t.config.WriteTimeout > 0 {
+		t.conn.SetWriteDeadline(time.Now().Add(t.config.WriteTimeout))
+	}
+	_, err := t.conn.Write(frame)
+	return err
+}
+
+// sendClose sends a close frame once; later frames are refused
+func (t *WebSocketTransport) sendClose(code int, reason string) {
+	t.writeMu.Lock()
+	defer t.writeMu.Unlock()
+	if t.closeSent {
+		return
+	}
+	payload := binary.BigEndian.AppendUint16(nil, uint16(code))
+	payload = append(payload, reason...)
+	t.writeFrameLocked(wsOpClose, payload)
+	t.closeSent = true
+}
+
+// fail closes the connection with the given code and returns the matching
+// error
+func (t *WebSocketTransport) fail(code int, reason string) error {
+	t.sendClose(code, reason)
+	if code == CloseMessageTooBig {
+		return fmt.Errorf("websocket: %w", mcp.ErrMessageTooLarge)
+	}
+	return &CloseError{Code: code, Reason: reason}
+}
+
+// shutdown stops keepalive and closes the connection
+func (t *WebSocketTransport) shutdown() {
+	t.closeOnce.Do(func() {
+		close(t.closed)
+		t.conn.Close()
+	})
+}
+
+// keepalive pings the peer until the transport is closed
+func (t *WebSocketTransport) keepalive() {
+	ticker := time.NewTicker(t.config.PingInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := t.writeFrame(wsOpPing, nil); err != nil {
+				t.shutdown()
+				return
+			}
+		case <-t.closed:
+			return
+		}
+	}
+}
+
+// extendReadDeadline gives the peer another ping interval to show signs of
+// life
+func (t *WebSocketTransport) extendReadDeadline() {
+	if t.config.PingInterval > 0 {
+		t.conn.SetReadDeadline(time.Now().Add(t.config.PingInterval + t.config.PongTimeout))
+	}
+}
+
+// wsAcceptKey computes the Sec-WebSocket-Accept value for a client key
+func wsAcceptKey(key string) string {
+	h := sha1.New()
+	h.Write([]byte(key + wsAcceptGUID))
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+// headerContains reports whether a comma-separated header contains token,
+// ignoring case
+func headerContains(h http.Header, name, token string) bool {
+	for _, value := range h.Values(name) {
+		for _, part := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(part), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
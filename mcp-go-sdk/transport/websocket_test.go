@@ -0,0 +1,189 @@
+package transport_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"mcp-go-sdk"
+	"mcp-go-sdk/transport"
+)
+
+// dialTestServer starts a WebSocket MCP server and connects a client to it
+func dialTestServer(t *testing.T, serverConfig *transport.WebSocketConfig) *transport.WebSocketTransport {
+	t.Helper()
+	ts := httptest.NewServer(transport.NewWebSocketHandler(serveEcho, serverConfig))
+	t.Cleanup(ts.Close)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	client, err := transport.DialWebSocket(ctx, "ws"+strings.TrimPrefix(ts.URL, "http"), nil, nil)
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+// request sends a raw JSON-RPC message and returns the next received message
+func request(t *testing.T, client mcp.Transport, msg string) string {
+	t.Helper()
+	if err := client.Send(rawMessage(msg)); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	resp, err := client.Receive()
+	if err != nil {
+		t.Fatalf("Receive failed: %v", err)
+	}
+	return string(resp)
+}
+
+// rawMessage is sent as-is by transports that marshal their input
+type rawMessage string
+
+func (m rawMessage) MarshalJSON() ([]byte, error) { return []byte(m), nil }
+
+func TestWebSocketSession(t *testing.T) {
+	client := dialTestServer(t, nil)
+
+	resp := request(t, client, `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26"}}`)
+	if !strings.Contains(resp, `"protocolVersion":"2025-03-26"`) {
+		t.Errorf("Unexpected initialize response: %s", resp)
+	}
+	if msg, err := client.Receive(); err != nil || string(msg) != `{"jsonrpc":"2.0","method":"initialized"}` {
+		t.Errorf("Unexpected notification: %s (%v)", msg, err)
+	}
+
+	resp = request(t, client, `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"echo","arguments":{"big":"`+strings.Repeat("x", 70000)+`"}}}`)
+	if !strings.Contains(resp, `"id":2`) || len(resp) < 70000 {
+		t.Errorf("Unexpected tools/call response of %d bytes", len(resp))
+	}
+}
+
+func TestWebSocketKeepalive(t *testing.T) {
+	config := transport.DefaultWebSocketConfig()
+	config.PingInterval = 20 * time.Millisecond
+	config.PongTimeout = 50 * time.Millisecond
+	client := dialTestServer(t, config)
+
+	// The client answers pings while it waits in Receive
+	received := make(chan string, 1)
+	go func() {
+		msg, _ := client.Receive()
+		received <- string(msg)
+	}()
+	time.Sleep(200 * time.Millisecond)
+
+	if err := client.Send(rawMessage(`{"jsonrpc":"2.0","id":1,"method":"tools/list"}`)); err != nil {
+		t.Fatalf("Send failed after keepalive period: %v", err)
+	}
+	select {
+	case msg := <-received:
+		if !strings.Contains(msg, `"name":"echo"`) {
+			t.Errorf("Unexpected response: %s", msg)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timeout waiting for response")
+	}
+}
+
+func TestWebSocketMessageTooLarge(t *testing.T) {
+	config := transport.DefaultWebSocketConfig()
+	config.MaxMessageSize = 64
+	client := dialTestServer(t, config)
+
+	if err := client.Send(rawMessage(`{"jsonrpc":"2.0","id":1,"method":"tools/list","params":{"pad":"` + strings.Repeat("x", 100) + `"}}`)); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	_, err := client.Receive()
+	var closeErr *transport.CloseError
+	if !errors.As(err, &closeErr) || closeErr.Code != transport.CloseMessageTooBig {
+		t.Fatalf("Expected close code %d, got %v", transport.CloseMessageTooBig, err)
+	}
+	if _, err := client.Receive(); err != io.EOF {
+		t.Errorf("Expected io.EOF after close, got %v", err)
+	}
+}
+
+func TestWebSocketFrameLengthLimitedByDefault(t *testing.T) {
+	// A server without a configured limit still refuses a frame announcing
+	// an enormous payload, rather than allocating it
+	ts := httptest.NewServer(transport.NewWebSocketHandler(serveEcho, &transport.WebSocketConfig{}))
+	defer ts.Close()
+	conn, err := net.Dial("tcp", ts.Listener.Addr().String())
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	fmt.Fprintf(conn, "GET / HTTP/1.1\r\nHost: %s\r\nConnection: Upgrade\r\nUpgrade: websocket\r\n"+
+		"Sec-WebSocket-Version: 13\r\nSec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\n\r\n", ts.Listener.Addr())
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, nil)
+	if err != nil || resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("Upgrade failed: %v %v", resp, err)
+	}
+
+	// A masked text frame with a 64-bit length of 2^62 bytes
+	frame := []byte{0x81, 0x80 | 127, 0x40, 0, 0, 0, 0, 0, 0, 0, 1, 2, 3, 4}
+	if _, err := conn.Write(frame); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	var head [4]byte
+	if _, err := io.ReadFull(reader, head[:]); err != nil {
+		t.Fatalf("Expected a close frame: %v", err)
+	}
+	if code := binary.BigEndian.Uint16(head[2:]); head[0] != 0x88 || int(code) != transport.CloseMessageTooBig {
+		t.Errorf("Expected close code %d, got frame %x", transport.CloseMessageTooBig, head)
+	}
+}
+
+func TestWebSocketOrigin(t *testing.T) {
+	upgrade := func(ts *httptest.Server, origin string) int {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodGet, ts.URL, nil)
+		req.Header.Set("Connection", "Upgrade")
+		req.Header.Set("Upgrade", "websocket")
+		req.Header.Set("Sec-WebSocket-Version", "13")
+		req.Header.Set("Sec-WebSocket-Key", "dGhlIHNhbXBsZSBub25jZQ==")
+		if origin != "" {
+			req.Header.Set("Origin", origin)
+		}
+		resp, err := http.DefaultTransport.RoundTrip(req)
+		if err != nil {
+			t.Fatalf("Upgrade request failed: %v", err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	ts := httptest.NewServer(transport.NewWebSocketHandler(serveEcho, nil))
+	defer ts.Close()
+	if code := upgrade(ts, "https://evil.example"); code != http.StatusForbidden {
+		t.Errorf("Expected a cross-origin upgrade to be rejected with 403, got %d", code)
+	}
+	if code := upgrade(ts, ts.URL); code != http.StatusSwitchingProtocols {
+		t.Errorf("Expected a same-origin upgrade to succeed, got %d", code)
+	}
+	if code := upgrade(ts, ""); code != http.StatusSwitchingProtocols {
+		t.Errorf("Expected an upgrade without an origin to succeed, got %d", code)
+	}
+
+	config := transport.DefaultWebSocketConfig()
+	config.CheckOrigin = transport.AllowAnyOrigin
+	open := httptest.NewServer(transport.NewWebSocketHandler(serveEcho, config))
+	defer open.Close()
+	if code := upgrade(open, "https://evil.example"); code != http.StatusSwitchingProtocols {
+		t.Errorf("Expected AllowAnyOrigin to accept any origin, got %d", code)
+	}
+}
@@ -1,9 +1,39 @@
 package mcp
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
 )
 
+// ErrMessageTooLarge is returned by transports for messages that exceed the
+// configured size limit
+var ErrMessageTooLarge = errors.New("message too large")
+
+// ErrWriteTimeout is returned by transports when a message could not be
+// queued or written before the write timeout, usually because the peer is
+// not reading
+var ErrWriteTimeout = errors.New("write timed out")
+
+// MalformedMessageError is returned by transports for input that is not a
+// valid JSON message. The transport has already skipped past the bad input,
+// so receiving can continue.
+type MalformedMessageError struct {
+	Err error
+}
+
+// Error implements the error interface
+func (e *MalformedMessageError) Error() string {
+	return fmt.Sprintf("malformed message: %v", e.Err)
+}
+
+// Unwrap returns the underlying error
+func (e *MalformedMessageError) Unwrap() error {
+	return e.Err
+}
+
 // Transport defines the interface for MCP communication
 type Transport interface {
 	// Send sends data through the transport
@@ -16,16 +46,59 @@ type Transport interface {
 	Close() error
 }
 
+// Framing selects how messages are delimited on a byte stream
+type Framing int
+
+const (
+	// FramingNewline requires every message to be on a single line. A
+	// malformed line is skipped and reading resumes with the next one.
+	FramingNewline Framing = iota
+
+	// FramingStream accepts JSON messages back to back, with or without
+	// newlines between them and inside them. Messages are delimited by
+	// balancing their brackets, so a truncated message takes in the rest of
+	// the stream; use it only with peers that may pretty-print messages.
+	FramingStream
+)
+
 // TransportConfig represents configuration options for a transport
 type TransportConfig struct {
 	// BufferSize is the size of the read/write buffers
 	BufferSize int
+
+	// MaxMessageSize is the maximum size of an incoming message in bytes,
+	// zero means unlimited
+	MaxMessageSize int
+
+	// MaxSendSize is the maximum size of an outgoing message in bytes, zero
+	// means unlimited
+	MaxSendSize int
+
+	// Framing selects how incoming messages are delimited, one per line by
+	// default
+	Framing Framing
+
+	// QueueSize is the maximum number of outgoing messages waiting to be
+	// written. Further sends block until there is room.
+	QueueSize int
+
+	// WriteTimeout bounds the time a send may wait for room in the queue
+	// and for its write to finish, zero means no limit
+	WriteTimeout time.Duration
+
+	// FlushInterval delays flushing so that messages sent in quick
+	// succession share a write, zero flushes after every message
+	FlushInterval time.Duration
 }
 
 // DefaultTransportConfig returns the default transport configuration
 func DefaultTransportConfig() *TransportConfig {
 	return &TransportConfig{
-		BufferSize: 4096,
+		BufferSize:     4096,
+		MaxMessageSize: 4 << 20,
+		Framing:        FramingNewline,
+		QueueSize:      64,
+		WriteTimeout:   30 * time.Second,
 	}
 }
 
@@ -44,6 +117,58 @@ type Tool interface {
 	Execute(params json.RawMessage) (interface{}, error)
 }
 
+// ContextTool is a Tool that can be cancelled. The server calls
+// ExecuteContext instead of Execute, with a context that is cancelled when
+// the client cancels the request or disconnects.
+type ContextTool interface {
+	Tool
+
+	// ExecuteContext runs the tool with the given arguments
+	ExecuteContext(ctx context.Context, params json.RawMessage) (interface{}, error)
+}
+
+// ToolAnnotations describe how a tool behaves. They are hints for clients and
+// are not enforced.
+type ToolAnnotations struct {
+	// Title is a human-readable name for the tool
+	Title string `json:"title,omitempty"`
+
+	// ReadOnlyHint says that the tool does not change its environment
+	ReadOnlyHint bool `json:"readOnlyHint,omitempty"`
+
+	// DestructiveHint says that a tool that is not read-only may delete or
+	// overwrite data. Clients assume true when it is unset.
+	DestructiveHint *bool `json:"destructiveHint,omitempty"`
+
+	// IdempotentHint says that calling the tool again with the same
+	// arguments has no further effect
+	IdempotentHint bool `json:"idempotentHint,omitempty"`
+
+	// OpenWorldHint says that the tool reaches outside the server, such as
+	// the web. Clients assume true when it is unset.
+	OpenWorldHint *bool `json:"openWorldHint,omitempty"`
+}
+
+// AnnotatedTool is a Tool that describes its behavior. The annotations are
+// listed with the tool, and servers may use them, for example to cache the
+// results of read-only tools.
+type AnnotatedTool interface {
+	Tool
+
+	// Annotations returns the tool's behavior hints
+	Annotations() ToolAnnotations
+}
+
+// CacheInvalidator is a Tool that changes data other tools read. After it
+// succeeds, servers drop the cached results of the tools it names.
+type CacheInvalidator interface {
+	Tool
+
+	// Invalidates returns the names of the tools whose cached results are
+	// stale after a call, or nil for all tools
+	Invalidates() []string
+}
+
 // Request represents a JSON-RPC request
 type Request struct {
 	JsonRPC string          `json:"jsonrpc"`
@@ -67,6 +192,15 @@ type Error struct {
 	Data    interface{} `json:"data,omitempty"`
 }
 
+// Error implements the error interface so that handlers can return a
+// JSON-RPC error object directly
+func (e *Error) Error() string {
+	if e.Data != nil {
+		return fmt.Sprintf("%s: %v", e.Message, e.Data)
+	}
+	return e.Message
+}
+
 // Notification represents a JSON-RPC notification
 type Notification struct {
 	JsonRPC string          `json:"jsonrpc"`
@@ -76,12 +210,43 @@ type Notification struct {
 
 // InitializeRequest represents an initialize request
 type InitializeRequest struct {
-	JsonRPC string          `json:"jsonrpc"`
-	ID      json.RawMessage `json:"id"`
-	Method  string          `json:"method"`
-	Params  struct {
-		ProtocolVersion string `json:"protocolVersion"`
-	} `json:"params"`
+	JsonRPC string           `json:"jsonrpc"`
+	ID      json.RawMessage  `json:"id"`
+	Method  string           `json:"method"`
+	Params  InitializeParams `json:"params"`
+}
+
+// InitializeParams represents the parameters of an initialize request
+type InitializeParams struct {
+	ProtocolVersion string             `json:"protocolVersion"`
+	Capabilities    ClientCapabilities `json:"capabilities"`
+	ClientInfo      ClientInfo         `json:"clientInfo"`
+}
+
+// ClientCapabilities represents the capabilities a client declares during
+// initialization
+type ClientCapabilities struct {
+	Roots        *RootsCapability           `json:"roots,omitempty"`
+	Sampling     *SamplingCapability        `json:"sampling,omitempty"`
+	Elicitation  *ElicitationCapability     `json:"elicitation,omitempty"`
+	Experimental map[string]json.RawMessage `json:"experimental,omitempty"`
+}
+
+// RootsCapability represents the client's roots capabilities
+type RootsCapability struct {
+	ListChanged bool `json:"listChanged,omitempty"`
+}
+
+// SamplingCapability represents the client's sampling capabilities
+type SamplingCapability struct{}
+
+// ElicitationCapability represents the client's elicitation capabilities
+type ElicitationCapability struct{}
+
+// ClientInfo represents information about the client
+type ClientInfo struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
 }
 
 // InitializeResult represents the result of an initialize request
@@ -93,7 +258,9 @@ type InitializeResult struct {
 
 // ServerCapabilities represents the server's capabilities
 type ServerCapabilities struct {
-	Tools *ToolsCapability `json:"tools"`
+	Tools     *ToolsCapability     `json:"tools"`
+	Resources *ResourcesCapability `json:"resources,omitempty"`
+	Prompts   *PromptsCapability   `json:"prompts,omitempty"`
 }
 
 // ToolsCapability represents the server's tool capabilities
@@ -101,6 +268,17 @@ type ToolsCapability struct {
 	ListChanged bool `json:"listChanged"`
 }
 
+// ResourcesCapability represents the server's resource capabilities
+type ResourcesCapability struct {
+	Subscribe   bool `json:"subscribe,omitempty"`
+	ListChanged bool `json:"listChanged,omitempty"`
+}
+
+// PromptsCapability represents the server's prompt capabilities
+type PromptsCapability struct {
+	ListChanged bool `json:"listChanged,omitempty"`
+}
+
 // ServerInfo represents information about the server
 type ServerInfo struct {
 	Name    string `json:"name"`
@@ -115,9 +293,10 @@ type ListToolsResponse struct {
 
 // ToolInfo represents information about a tool
 type ToolInfo struct {
-	Name        string          `json:"name"`
-	Description string          `json:"description"`
-	InputSchema json.RawMessage `json:"inputSchema"`
+	Name        string           `json:"name"`
+	Description string           `json:"description"`
+	InputSchema json.RawMessage  `json:"inputSchema"`
+	Annotations *ToolAnnotations `json:"annotations,omitempty"`
 }
 
 // CallToolRequest represents a tool call request
@@ -132,6 +311,17 @@ type CallToolMeta struct {
 	ProgressToken int `json:"progressToken,omitempty"`
 }
 
+// CallToolResult represents the result of a tools/call request as seen by a
+// client
+type CallToolResult struct {
+	Content []ToolContent `json:"content"`
+	IsError bool          `json:"isError,omitempty"`
+
+	// StructuredContent is the result as a JSON object, for clients that
+	// read it. Content carries the same result as text for the others.
+	StructuredContent interface{} `json:"structuredContent,omitempty"`
+}
+
 // ToolResponse represents a successful tool execution response
 type ToolResponse struct {
 	Content []ToolContent `json:"content"`
@@ -143,8 +333,147 @@ type ToolError struct {
 	IsError bool          `json:"isError"`
 }
 
-// ToolContent represents a piece of content in a tool response
+// ToolContent represents a piece of content in a tool response. Prompt and
+// sampling messages carry the same content types.
 type ToolContent struct {
-	Type string `json:"type"`
-	Text string `json:"text"`
+	Type     string            `json:"type"`
+	Text     string            `json:"text"`
+	Data     string            `json:"data,omitempty"`
+	MimeType string            `json:"mimeType,omitempty"`
+	Resource *ResourceContents `json:"resource,omitempty"`
+}
+
+// Resource represents a resource a server makes available to clients
+type Resource struct {
+	URI         string `json:"uri"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	MimeType    string `json:"mimeType,omitempty"`
+}
+
+// ListResourcesResult represents the result of a resources/list request
+type ListResourcesResult struct {
+	Resources  []Resource `json:"resources"`
+	NextCursor string     `json:"nextCursor,omitempty"`
+}
+
+// ReadResourceParams represents the parameters of a resources/read request
+type ReadResourceParams struct {
+	URI string `json:"uri"`
+}
+
+// ReadResourceResult represents the result of a resources/read request
+type ReadResourceResult struct {
+	Contents []ResourceContents `json:"contents"`
+}
+
+// ResourceContents represents the contents of a resource, either as text or
+// as base64-encoded binary data
+type ResourceContents struct {
+	URI      string `json:"uri"`
+	MimeType string `json:"mimeType,omitempty"`
+	Text     string `json:"text,omitempty"`
+	Blob     string `json:"blob,omitempty"`
+}
+
+// Prompt represents a prompt template a server makes available to clients
+type Prompt struct {
+	Name        string           `json:"name"`
+	Description string           `json:"description,omitempty"`
+	Arguments   []PromptArgument `json:"arguments,omitempty"`
+}
+
+// PromptArgument represents an argument a prompt accepts
+type PromptArgument struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Required    bool   `json:"required,omitempty"`
+}
+
+// ListPromptsResult represents the result of a prompts/list request
+type ListPromptsResult struct {
+	Prompts    []Prompt `json:"prompts"`
+	NextCursor string   `json:"nextCursor,omitempty"`
+}
+
+// GetPromptParams represents the parameters of a prompts/get request
+type GetPromptParams struct {
+	Name      string            `json:"name"`
+	Arguments map[string]string `json:"arguments,omitempty"`
+}
+
+// GetPromptResult represents the result of a prompts/get request
+type GetPromptResult struct {
+	Description string          `json:"description,omitempty"`
+	Messages    []PromptMessage `json:"messages"`
+}
+
+// PromptMessage represents one message of a prompt
+type PromptMessage struct {
+	Role    string      `json:"role"`
+	Content ToolContent `json:"content"`
+}
+
+// PaginatedParams represents the parameters of a list request
+type PaginatedParams struct {
+	Cursor string `json:"cursor,omitempty"`
+}
+
+// CreateMessageParams represents the parameters of a sampling/createMessage
+// request sent by a server to a client
+type CreateMessageParams struct {
+	Messages         []SamplingMessage `json:"messages"`
+	SystemPrompt     string            `json:"systemPrompt,omitempty"`
+	IncludeContext   string            `json:"includeContext,omitempty"`
+	Temperature      *float64          `json:"temperature,omitempty"`
+	MaxTokens        int               `json:"maxTokens"`
+	StopSequences    []string          `json:"stopSequences,omitempty"`
+	ModelPreferences json.RawMessage   `json:"modelPreferences,omitempty"`
+	Metadata         json.RawMessage   `json:"metadata,omitempty"`
+}
+
+// SamplingMessage represents one message of a sampling conversation
+type SamplingMessage struct {
+	Role    string      `json:"role"`
+	Content ToolContent `json:"content"`
+}
+
+// CreateMessageResult represents the result of a sampling/createMessage
+// request
+type CreateMessageResult struct {
+	Role       string      `json:"role"`
+	Content    ToolContent `json:"content"`
+	Model      string      `json:"model"`
+	StopReason string      `json:"stopReason,omitempty"`
+}
+
+// Root represents a directory or file the client exposes to the server
+type Root struct {
+	URI  string `json:"uri"`
+	Name string `json:"name,omitempty"`
+}
+
+// ListRootsResult represents the result of a roots/list request
+type ListRootsResult struct {
+	Roots []Root `json:"roots"`
+}
+
+// ElicitParams represents the parameters of an elicitation/create request,
+// with which a server asks the user for input through the client
+type ElicitParams struct {
+	Message         string          `json:"message"`
+	RequestedSchema json.RawMessage `json:"requestedSchema"`
+}
+
+// Elicitation actions
+const (
+	ElicitAccept  = "accept"
+	ElicitDecline = "decline"
+	ElicitCancel  = "cancel"
+)
+
+// ElicitResult represents the user's answer to an elicitation/create request
+type ElicitResult struct {
+	Action  string                 `json:"action"`
+	Content map[string]interface{} `json:"content,omitempty"`
 }
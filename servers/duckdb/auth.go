@@ -0,0 +1,57 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+
+	"mcp-go-sdk/auth"
+)
+
+// newHTTPHandler protects the MCP endpoints with the configured credentials.
+// AUTH_TOKEN is accepted as a static bearer token; with JWKS_FILE, JWTs from
+// the issuer are accepted too. baseURL is the public URL of the server, and
+// JWTs must be issued for it unless JWT_AUDIENCE names another audience.
+func newHTTPHandler(config *Config, mcp http.Handler, baseURL string) (http.Handler, error) {
+	if !config.Authenticated() {
+		return mcp, nil
+	}
+	baseURL = strings.TrimSuffix(baseURL, "/")
+
+	var verifiers []auth.TokenVerifier
+	if config.AuthToken != "" {
+		verifiers = append(verifiers, auth.StaticTokens{
+			config.AuthToken: {Subject: "token"},
+		})
+	}
+	if config.JWKSFile != "" {
+		// Tokens the issuer gave out for other resources are refused
+		audience := config.JWTAudience
+		if audience == "" {
+			audience = baseURL
+		}
+		jwt, err := auth.NewJWTVerifier(&auth.JWTConfig{
+			JWKSFile: config.JWKSFile,
+			Issuer:   config.JWTIssuer,
+			Audience: audience,
+			Leeway:   auth.DefaultJWTConfig().Leeway,
+		})
+		if err != nil {
+			return nil, err
+		}
+		verifiers = append(verifiers, jwt)
+	}
+
+	authConfig := auth.DefaultConfig()
+	mux := http.NewServeMux()
+	if config.AuthServer != "" {
+		authConfig.ResourceMetadataURL = baseURL + auth.MetadataPath
+		mux.Handle(auth.MetadataPath, auth.NewMetadataHandler(&auth.ResourceMetadata{
+			Resource:               baseURL,
+			AuthorizationServers:   []string{config.AuthServer},
+			BearerMethodsSupported: []string{"header"},
+			ResourceName:           "DuckDB MCP Server",
+		}))
+	}
+	mux.Handle("/", auth.NewHandler(mcp, auth.AnyOf(verifiers...), authConfig))
+	return mux, nil
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"mcp-go-sdk/auth"
+)
+
+func TestHTTPHandlerAuth(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(auth.PrincipalFromContext(r.Context()).Subject))
+	})
+	handler, err := newHTTPHandler(&Config{AuthToken: "secret", AuthServer: "https://issuer.test"}, ok, "http://db.test/")
+	if err != nil {
+		t.Fatalf("newHTTPHandler failed: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/sse", nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 without a token, got %d", rec.Code)
+	}
+	if got := rec.Header().Get("WWW-Authenticate"); !strings.Contains(got, `resource_metadata="http://db.test/.well-known/oauth-protected-resource"`) {
+		t.Errorf("Expected the metadata URL in the challenge, got %q", got)
+	}
+
+	rec = httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/sse", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || rec.Body.String() != "token" {
+		t.Errorf("Expected the token to be accepted, got %d %s", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, auth.MetadataPath, nil))
+	if !strings.Contains(rec.Body.String(), `"authorization_servers":["https://issuer.test"]`) {
+		t.Errorf("Unexpected metadata %s", rec.Body.String())
+	}
+
+	// Without credentials the endpoints stay open
+	open, err := newHTTPHandler(&Config{}, ok, "http://db.test")
+	if err != nil || open == nil {
+		t.Fatalf("newHTTPHandler failed: %v", err)
+	}
+}
+
+func TestHTTPHandlerAudience(t *testing.T) {
+	pub, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b64 := base64.RawURLEncoding.EncodeToString
+	jwks := filepath.Join(t.TempDir(), "jwks.json")
+	data := fmt.Sprintf(`{"keys":[{"kty":"OKP","crv":"Ed25519","kid":"k","x":%q}]}`, b64(pub))
+	if err := os.WriteFile(jwks, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	sign := func(audience string) string {
+		claims, _ := json.Marshal(map[string]interface{}{"sub": "alice", "aud": audience, "exp": time.Now().Add(time.Hour).Unix()})
+		signed := b64([]byte(`{"alg":"EdDSA","kid":"k"}`)) + "." + b64(claims)
+		return signed + "." + b64(ed25519.Sign(key, []byte(signed)))
+	}
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	status := func(handler http.Handler, token string) int {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/sse", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	// Without JWT_AUDIENCE, tokens must be issued for the public URL
+	handler, err := newHTTPHandler(&Config{JWKSFile: jwks}, ok, "https://db.test/")
+	if err != nil {
+		t.Fatalf("newHTTPHandler failed: %v", err)
+	}
+	if code := status(handler, sign("https://db.test")); code != http.StatusOK {
+		t.Errorf("Expected a token for the server to be accepted, got %d", code)
+	}
+	if code := status(handler, sign("https://other.test")); code != http.StatusUnauthorized {
+		t.Errorf("Expected a token for another resource to be refused, got %d", code)
+	}
+
+	handler, err = newHTTPHandler(&Config{JWKSFile: jwks, JWTAudience: "duckdb"}, ok, "https://db.test")
+	if err != nil {
+		t.Fatalf("newHTTPHandler failed: %v", err)
+	}
+	if code := status(handler, sign("duckdb")); code != http.StatusOK {
+		t.Errorf("Expected a token for JWT_AUDIENCE to be accepted, got %d", code)
+	}
+}
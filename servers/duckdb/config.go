@@ -11,7 +11,14 @@ type Config struct {
 	DatabasePath   string
 	MaxConnections int
 	QueryTimeout   time.Duration
+	MaxOutputBytes int
 	AuthToken      string
+	JWKSFile       string
+	JWTIssuer      string
+	JWTAudience    string
+	AuthServer     string
+	PolicyFile     string
+	AuditLog       string
 	LogLevel       string
 }
 
@@ -21,7 +28,14 @@ func LoadConfig() *Config {
 		DatabasePath:   getEnv("DUCKDB_PATH", ":memory:"),
 		MaxConnections: getEnvAsInt("MAX_CONNECTIONS", 10),
 		QueryTimeout:   time.Duration(getEnvAsInt("QUERY_TIMEOUT", 30)) * time.Second,
+		MaxOutputBytes: getEnvAsInt("MAX_OUTPUT_BYTES", 64<<10),
 		AuthToken:      getEnv("AUTH_TOKEN", ""),
+		JWKSFile:       getEnv("JWKS_FILE", ""),
+		JWTIssuer:      getEnv("JWT_ISSUER", ""),
+		JWTAudience:    getEnv("JWT_AUDIENCE", ""),
+		AuthServer:     getEnv("AUTH_SERVER", ""),
+		PolicyFile:     getEnv("POLICY_FILE", ""),
+		AuditLog:       getEnv("AUDIT_LOG", ""),
 		LogLevel:       getEnv("LOG_LEVEL", "info"),
 	}
 	return config
@@ -42,11 +56,22 @@ func (c *Config) GetQueryTimeout() time.Duration {
 	return c.QueryTimeout
 }
 
+// GetMaxOutputBytes returns the number of bytes of query output sent in a
+// result, or zero for no limit
+func (c *Config) GetMaxOutputBytes() int {
+	return c.MaxOutputBytes
+}
+
 // GetAuthToken returns the configured authentication token
 func (c *Config) GetAuthToken() string {
 	return c.AuthToken
 }
 
+// Authenticated reports whether HTTP clients must present a token
+func (c *Config) Authenticated() bool {
+	return c.AuthToken != "" || c.JWKSFile != ""
+}
+
 // Helper function to get environment variable with default value
 func getEnv(key, defaultValue string) string {
 	if value, exists := os.LookupEnv(key); exists {
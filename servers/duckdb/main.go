@@ -1,10 +1,14 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"log"
-	"os"
+	"net/http"
 	"strings"
+	"time"
 
+	"mcp-go-sdk/policy"
 	"mcp-go-sdk/server"
 	"mcp-go-sdk/transport"
 )
@@ -14,11 +18,17 @@ func main() {
 	log.SetPrefix("[DuckDB MCP] ")
 	log.SetFlags(log.LstdFlags | log.Lshortfile)
 
+	// Parse command-line flags
+	var sseAddr, publicURL string
+	flag.StringVar(&sseAddr, "sse-addr", "", "Serve the HTTP+SSE transport on this address instead of stdio")
+	flag.StringVar(&publicURL, "public-url", "", "URL clients use to reach the server, announced in OAuth metadata (default http://<sse-addr>)")
+	flag.Parse()
+
 	// Get database path from command line argument
-	if len(os.Args) != 2 {
-		log.Fatal("Usage: mcp-duckdb <path/to/database.duckdb>")
+	if flag.NArg() != 1 {
+		log.Fatal("Usage: mcp-duckdb [-sse-addr host:port] [-public-url url] <path/to/database.duckdb>")
 	}
-	dbPath := os.Args[1]
+	dbPath := flag.Arg(0)
 
 	// Create and configure DuckDB tool
 	tool := NewDuckDBTool(dbPath)
@@ -33,14 +43,52 @@ func main() {
 	// Clean up version string
 	version = strings.TrimPrefix(version, "v")
 
-	// Create server with stdio transport
-	srv := server.NewServer(transport.NewStdioTransport())
+	// Create server with stdio transport, cutting queries off after
+	// QUERY_TIMEOUT. Output past MAX_OUTPUT_BYTES is left for the client to
+	// read page by page.
+	config := LoadConfig()
+	serverConfig := server.DefaultConfig()
+	serverConfig.ToolTimeouts = map[string]time.Duration{tool.Name(): config.GetQueryTimeout()}
+	if config.GetMaxOutputBytes() > 0 {
+		serverConfig.Output = &server.OutputConfig{MaxBytes: config.GetMaxOutputBytes()}
+	}
+	// Calls are checked against POLICY_FILE, which is reloaded as it changes
+	if config.PolicyFile != "" {
+		engine, err := policy.OpenEngine(context.Background(), config.PolicyFile, config.AuditLog)
+		if err != nil {
+			log.Fatalf("Failed to load policy: %v", err)
+		}
+		serverConfig.Policy = engine
+	}
+	srv := server.NewServerWithConfig(transport.NewStdioTransport(), serverConfig)
 
 	// Register the DuckDB tool
 	if err := srv.RegisterTool(tool); err != nil {
 		log.Fatalf("Failed to register DuckDB tool: %v", err)
 	}
 
+	// Serve over HTTP+SSE, one session per connection
+	if sseAddr != "" {
+		handler := transport.NewSSEHandler(srv.ServeTransport, nil)
+		mux := http.NewServeMux()
+		mux.Handle("/sse", handler)
+		mux.Handle("/messages", handler)
+
+		if publicURL == "" {
+			publicURL = "http://" + sseAddr
+		}
+		protected, err := newHTTPHandler(config, mux, publicURL)
+		if err != nil {
+			log.Fatalf("Failed to set up authentication: %v", err)
+		}
+
+		log.Printf("Starting DuckDB MCP server (DuckDB v%s) with database %s on http://%s/sse", version, dbPath, sseAddr)
+		if err := http.ListenAndServe(sseAddr, protected); err != nil {
+			log.Fatalf("Server error: %v", err)
+		}
+		return
+	}
+
 	// Start the server
 	log.Printf("Starting DuckDB MCP server (DuckDB v%s) with database: %s", version, dbPath)
 	if err := srv.Start(); err != nil {
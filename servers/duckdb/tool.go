@@ -1,16 +1,33 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"strings"
 	"sync"
 	"time"
 
+	"mcp-go-sdk"
+	"mcp-go-sdk/server"
+
 	_ "github.com/marcboeker/go-duckdb/v2"
 )
 
+// attachSchema asks the user which database to attach
+var attachSchema = json.RawMessage(`{
+	"type": "object",
+	"properties": {
+		"path": {"type": "string", "description": "Path of the database file to attach"},
+		"alias": {"type": "string", "description": "Name to query the database by (defaults to the file name)"},
+		"readOnly": {"type": "boolean", "description": "Attach the database read-only"}
+	},
+	"required": ["path"]
+}`)
+
 // DuckDBTool implements the MCP tool interface for DuckDB
 type DuckDBTool struct {
 	db     *sql.DB
@@ -42,11 +59,23 @@ func (t *DuckDBTool) Schema() json.RawMessage {
 		"properties": {
 			"command": {
 				"type": "string",
-				"description": "The command to execute (query, explain, status)"
+				"description": "The command to execute (query, explain, status, attach)"
 			},
 			"query": {
 				"type": "string",
 				"description": "The SQL query to execute (required for query and explain commands)"
+			},
+			"path": {
+				"type": "string",
+				"description": "The database file to attach (attach command). If omitted, the user is asked."
+			},
+			"alias": {
+				"type": "string",
+				"description": "The name to query the attached database by (attach command)"
+			},
+			"readOnly": {
+				"type": "boolean",
+				"description": "Attach the database read-only (attach command)"
 			}
 		},
 		"required": ["command"]
@@ -55,9 +84,18 @@ func (t *DuckDBTool) Schema() json.RawMessage {
 
 // Execute handles the tool execution
 func (t *DuckDBTool) Execute(params json.RawMessage) (interface{}, error) {
+	return t.ExecuteContext(context.Background(), params)
+}
+
+// ExecuteContext handles the tool execution, cancelling the query when ctx
+// ends
+func (t *DuckDBTool) ExecuteContext(ctx context.Context, params json.RawMessage) (interface{}, error) {
 	var input struct {
-		Command string `json:"command"`
-		Query   string `json:"query,omitempty"`
+		Command  string `json:"command"`
+		Query    string `json:"query,omitempty"`
+		Path     string `json:"path,omitempty"`
+		Alias    string `json:"alias,omitempty"`
+		ReadOnly bool   `json:"readOnly,omitempty"`
 	}
 
 	if err := json.Unmarshal(params, &input); err != nil {
@@ -71,11 +109,13 @@ func (t *DuckDBTool) Execute(params json.RawMessage) (interface{}, error) {
 
 	switch input.Command {
 	case "query":
-		return t.handleQuery(input.Query)
+		return t.handleQuery(ctx, input.Query)
 	case "explain":
-		return t.handleExplain(input.Query)
+		return t.handleExplain(ctx, input.Query)
 	case "status":
 		return t.handleStatus()
+	case "attach":
+		return t.handleAttach(ctx, input.Path, input.Alias, input.ReadOnly)
 	default:
 		return nil, fmt.Errorf("unknown command: %s", input.Command)
 	}
@@ -106,7 +146,7 @@ func (t *DuckDBTool) ensureConnection() error {
 }
 
 // handleQuery executes a SQL query
-func (t *DuckDBTool) handleQuery(query string) (interface{}, error) {
+func (t *DuckDBTool) handleQuery(ctx context.Context, query string) (interface{}, error) {
 	t.mu.RLock()
 	defer t.mu.RUnlock()
 
@@ -118,6 +158,7 @@ func (t *DuckDBTool) handleQuery(query string) (interface{}, error) {
 					"text": "Error: Query cannot be empty",
 				},
 			},
+			"isError": true,
 			"metadata": map[string]interface{}{
 				"status": "error",
 				"error":  "Query cannot be empty",
@@ -126,7 +167,7 @@ func (t *DuckDBTool) handleQuery(query string) (interface{}, error) {
 	}
 
 	start := time.Now()
-	rows, err := t.db.Query(query)
+	rows, err := t.db.QueryContext(ctx, query)
 	if err != nil {
 		return map[string]interface{}{
 			"content": []map[string]interface{}{
@@ -135,6 +176,7 @@ func (t *DuckDBTool) handleQuery(query string) (interface{}, error) {
 					"text": fmt.Sprintf("Error executing query:\n%s\n\nDuckDB Error:\n%v", query, err),
 				},
 			},
+			"isError": true,
 			"metadata": map[string]interface{}{
 				"status": "error",
 				"error":  err.Error(),
@@ -153,6 +195,7 @@ func (t *DuckDBTool) handleQuery(query string) (interface{}, error) {
 					"text": fmt.Sprintf("Error getting columns:\n%v", err),
 				},
 			},
+			"isError": true,
 			"metadata": map[string]interface{}{
 				"status": "error",
 				"error":  err.Error(),
@@ -160,7 +203,24 @@ func (t *DuckDBTool) handleQuery(query string) (interface{}, error) {
 		}, nil
 	}
 
-	var resultRows [][]interface{}
+	// Write the table as the rows arrive, so that large results are built
+	// in one buffer rather than copied on every append
+	var table strings.Builder
+	for i, col := range columns {
+		if i > 0 {
+			table.WriteString(" | ")
+		}
+		fmt.Fprintf(&table, "%-15s", col)
+	}
+	table.WriteString("\n")
+	for i := range columns {
+		if i > 0 {
+			table.WriteString("-|-")
+		}
+		table.WriteString("---------------")
+	}
+	table.WriteString("\n")
+
 	values := make([]interface{}, len(columns))
 	valuePtrs := make([]interface{}, len(columns))
 
@@ -168,6 +228,7 @@ func (t *DuckDBTool) handleQuery(query string) (interface{}, error) {
 		valuePtrs[i] = &values[i]
 	}
 
+	rowCount := 0
 	for rows.Next() {
 		err := rows.Scan(valuePtrs...)
 		if err != nil {
@@ -178,6 +239,7 @@ func (t *DuckDBTool) handleQuery(query string) (interface{}, error) {
 						"text": fmt.Sprintf("Error scanning row:\n%v", err),
 					},
 				},
+				"isError": true,
 				"metadata": map[string]interface{}{
 					"status": "error",
 					"error":  err.Error(),
@@ -185,11 +247,14 @@ func (t *DuckDBTool) handleQuery(query string) (interface{}, error) {
 			}, nil
 		}
 
-		row := make([]interface{}, len(columns))
-		for i, v := range values {
-			row[i] = v
+		for i, val := range values {
+			if i > 0 {
+				table.WriteString(" | ")
+			}
+			fmt.Fprintf(&table, "%-15v", val)
 		}
-		resultRows = append(resultRows, row)
+		table.WriteString("\n")
+		rowCount++
 	}
 
 	if err := rows.Err(); err != nil {
@@ -200,6 +265,7 @@ func (t *DuckDBTool) handleQuery(query string) (interface{}, error) {
 					"text": fmt.Sprintf("Error during row iteration:\n%v", err),
 				},
 			},
+			"isError": true,
 			"metadata": map[string]interface{}{
 				"status": "error",
 				"error":  err.Error(),
@@ -209,60 +275,113 @@ func (t *DuckDBTool) handleQuery(query string) (interface{}, error) {
 
 	duration := time.Since(start)
 
-	// Format the table as text
-	var formattedText string
+	return map[string]interface{}{
+		"content": []map[string]interface{}{
+			{
+				"type": "text",
+				"text": table.String(),
+			},
+		},
+		"metadata": map[string]interface{}{
+			"duration": duration.Seconds(),
+			"rowCount": rowCount,
+			"status":   "success",
+			"query":    query,
+		},
+	}, nil
+}
 
-	// Add headers
-	for i, col := range columns {
-		if i > 0 {
-			formattedText += " | "
-		}
-		formattedText += fmt.Sprintf("%-15s", col)
+// handleExplain shows the query execution plan
+func (t *DuckDBTool) handleExplain(ctx context.Context, query string) (interface{}, error) {
+	if query == "" {
+		return nil, fmt.Errorf("query cannot be empty")
 	}
-	formattedText += "\n"
+	return t.handleQuery(ctx, fmt.Sprintf("EXPLAIN %s", query))
+}
 
-	// Add separator
-	for i := range columns {
-		if i > 0 {
-			formattedText += "-|-"
+// handleAttach attaches another database file. Without a path the user is
+// asked which database to attach, if the client can ask.
+func (t *DuckDBTool) handleAttach(ctx context.Context, path, alias string, readOnly bool) (interface{}, error) {
+	if path == "" {
+		var answer struct {
+			Path     string `json:"path"`
+			Alias    string `json:"alias"`
+			ReadOnly bool   `json:"readOnly"`
+		}
+		action, err := server.Elicit(ctx, "Which database should be attached?", attachSchema, &answer)
+		switch {
+		case errors.Is(err, server.ErrElicitationUnsupported):
+			return nil, fmt.Errorf("path is required for the attach command")
+		case err != nil:
+			return nil, fmt.Errorf("failed to ask for a database: %v", err)
+		case action != mcp.ElicitAccept || answer.Path == "":
+			return map[string]interface{}{
+				"content": []map[string]interface{}{
+					{
+						"type": "text",
+						"text": "No database was attached",
+					},
+				},
+				"isError": true,
+				"metadata": map[string]interface{}{
+					"status": "cancelled",
+				},
+			}, nil
 		}
-		formattedText += "---------------"
+		path, alias, readOnly = answer.Path, answer.Alias, answer.ReadOnly
 	}
-	formattedText += "\n"
 
-	// Add rows
-	for _, row := range resultRows {
-		for i, val := range row {
-			if i > 0 {
-				formattedText += " | "
-			}
-			formattedText += fmt.Sprintf("%-15v", val)
-		}
-		formattedText += "\n"
+	stmt := "ATTACH " + quoteLiteral(path)
+	if alias != "" {
+		stmt += " AS " + quoteIdentifier(alias)
+	}
+	if readOnly {
+		stmt += " (READ_ONLY)"
+	}
+
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	if _, err := t.db.ExecContext(ctx, stmt); err != nil {
+		return map[string]interface{}{
+			"content": []map[string]interface{}{
+				{
+					"type": "text",
+					"text": fmt.Sprintf("Error attaching database %s:\n%v", path, err),
+				},
+			},
+			"isError": true,
+			"metadata": map[string]interface{}{
+				"status": "error",
+				"error":  err.Error(),
+				"path":   path,
+			},
+		}, nil
 	}
 
 	return map[string]interface{}{
 		"content": []map[string]interface{}{
 			{
 				"type": "text",
-				"text": formattedText,
+				"text": fmt.Sprintf("Attached database %s", path),
 			},
 		},
 		"metadata": map[string]interface{}{
-			"duration": duration.Seconds(),
-			"rowCount": len(resultRows),
 			"status":   "success",
-			"query":    query,
+			"path":     path,
+			"alias":    alias,
+			"readOnly": readOnly,
 		},
 	}, nil
 }
 
-// handleExplain shows the query execution plan
-func (t *DuckDBTool) handleExplain(query string) (interface{}, error) {
-	if query == "" {
-		return nil, fmt.Errorf("query cannot be empty")
-	}
-	return t.handleQuery(fmt.Sprintf("EXPLAIN %s", query))
+// quoteLiteral quotes s as an SQL string literal
+func quoteLiteral(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// quoteIdentifier quotes s as an SQL identifier
+func quoteIdentifier(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
 }
 
 // handleStatus returns the current connection status
@@ -1,11 +1,21 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
 	"testing"
+	"time"
+
+	"mcp-go-sdk"
+	"mcp-go-sdk/client"
+	"mcp-go-sdk/server"
+	"mcp-go-sdk/servertest"
+	"mcp-go-sdk/transport"
 )
 
 func TestDuckDBTool(t *testing.T) {
@@ -231,4 +241,155 @@ func TestDuckDBTool(t *testing.T) {
 			t.Error("Expected error for invalid command")
 		}
 	})
+
+	// Test that queries stop with their context
+	t.Run("Cancelled query", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		queryJSON, _ := json.Marshal(map[string]interface{}{
+			"command": "query",
+			"query":   "SELECT 1",
+		})
+		result, err := tool.ExecuteContext(ctx, queryJSON)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		resultMap := result.(map[string]interface{})
+		if resultMap["isError"] != true {
+			t.Errorf("Expected an error result for a cancelled query, got %v", resultMap)
+		}
+	})
+}
+
+func TestAttach(t *testing.T) {
+	dir := t.TempDir()
+	tool := NewDuckDBTool(filepath.Join(dir, "main.duckdb"))
+	defer tool.Close()
+	run := func(args map[string]interface{}) map[string]interface{} {
+		t.Helper()
+		argsJSON, _ := json.Marshal(args)
+		result, err := tool.Execute(argsJSON)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		return result.(map[string]interface{})
+	}
+
+	result := run(map[string]interface{}{"command": "attach", "path": filepath.Join(dir, "it's.duckdb"), "alias": "other"})
+	if result["isError"] == true {
+		t.Fatalf("Failed to attach: %v", result)
+	}
+	run(map[string]interface{}{"command": "query", "query": "CREATE TABLE other.t AS SELECT 42 AS answer"})
+	result = run(map[string]interface{}{"command": "query", "query": "SELECT answer FROM other.t"})
+	text := result["content"].([]map[string]interface{})[0]["text"].(string)
+	if !strings.Contains(text, "42") {
+		t.Errorf("Expected to query the attached database, got %s", text)
+	}
+
+	// Without a path and a client to ask, the path is required
+	_, err := tool.Execute(json.RawMessage(`{"command":"attach"}`))
+	if err == nil || !strings.Contains(err.Error(), "path is required") {
+		t.Errorf("Expected an error without a path, got %v", err)
+	}
+}
+
+func TestAttachElicitation(t *testing.T) {
+	dir := t.TempDir()
+	tool := NewDuckDBTool(filepath.Join(dir, "main.duckdb"))
+	defer tool.Close()
+	srv := server.NewServer(nil)
+	srv.RegisterTool(tool)
+	clientEnd, serverEnd := transport.NewInMemoryPair()
+	go srv.ServeTransport(serverEnd)
+
+	// The user picks the database to attach
+	answers := make(chan *mcp.ElicitResult, 1)
+	c := client.NewClient(clientEnd, nil)
+	defer c.Close()
+	c.HandleElicitation(func(ctx context.Context, p *mcp.ElicitParams) (*mcp.ElicitResult, error) {
+		return <-answers, nil
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if _, err := c.Initialize(ctx); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+
+	answers <- &mcp.ElicitResult{Action: mcp.ElicitDecline}
+	result, err := c.CallTool(ctx, "duckdb", map[string]interface{}{"command": "attach"})
+	if err != nil {
+		t.Fatalf("CallTool failed: %v", err)
+	}
+	if !result.IsError || result.Content[0].Text != "No database was attached" {
+		t.Errorf("Expected a declined attach to fail, got %+v", result)
+	}
+
+	answers <- &mcp.ElicitResult{Action: mcp.ElicitAccept, Content: map[string]interface{}{
+		"path":  filepath.Join(dir, "picked.duckdb"),
+		"alias": "picked",
+	}}
+	result, err = c.CallTool(ctx, "duckdb", map[string]interface{}{"command": "attach"})
+	if err != nil {
+		t.Fatalf("CallTool failed: %v", err)
+	}
+	if result.IsError {
+		t.Fatalf("Failed to attach: %+v", result)
+	}
+	result, err = c.CallTool(ctx, "duckdb", map[string]interface{}{"command": "query", "query": "SELECT database_name FROM duckdb_databases() WHERE database_name = 'picked'"})
+	if err != nil || !strings.Contains(result.Content[0].Text, "picked") {
+		t.Errorf("Expected the picked database to be attached, got %+v %v", result, err)
+	}
+}
+
+func TestLargeResult(t *testing.T) {
+	tool := NewDuckDBTool(filepath.Join(t.TempDir(), "test.duckdb"))
+	defer tool.Close()
+	config := server.DefaultConfig()
+	config.Output = &server.OutputConfig{MaxBytes: 1000}
+	srv := server.NewServerWithConfig(nil, config)
+	srv.RegisterTool(tool)
+	clientEnd, serverEnd := transport.NewInMemoryPair()
+	go srv.ServeTransport(serverEnd)
+	c := client.NewClient(clientEnd, nil)
+	defer c.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if _, err := c.Initialize(ctx); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+
+	result, err := c.CallTool(ctx, "duckdb", map[string]interface{}{"command": "query", "query": "SELECT range AS n FROM range(1000)"})
+	if err != nil {
+		t.Fatalf("CallTool failed: %v", err)
+	}
+	if len(result.Content) != 2 || len(result.Content[0].Text) > 1000 {
+		t.Fatalf("Expected the table to be cut, got %d items", len(result.Content))
+	}
+	uri := regexp.MustCompile(`tool-output://[0-9a-f]+`).FindString(result.Content[1].Text)
+	if uri == "" {
+		t.Fatalf("Expected a note with the output resource, got %q", result.Content[1].Text)
+	}
+
+	// The pages hold every row
+	var table strings.Builder
+	for page := 1; ; page++ {
+		res, err := c.ReadResource(ctx, fmt.Sprintf("%s?page=%d", uri, page))
+		if err != nil {
+			break
+		}
+		table.WriteString(res.Contents[0].Text)
+	}
+	if lines := strings.Count(table.String(), "\n"); lines != 1002 {
+		t.Errorf("Expected a header, a separator and 1000 rows, got %d lines", lines)
+	}
+}
+
+func TestConformance(t *testing.T) {
+	tool := NewDuckDBTool(filepath.Join(t.TempDir(), "test.duckdb"))
+	defer tool.Close()
+	srv := server.NewServer(nil)
+	srv.RegisterTool(tool)
+	servertest.Run(t, srv, &servertest.Options{
+		ErrorCall: &servertest.ToolCall{Name: "duckdb", Arguments: map[string]string{"command": "query", "query": "SELEC 1"}},
+	})
 }
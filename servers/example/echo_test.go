@@ -0,0 +1,16 @@
+package main
+
+import (
+	"testing"
+
+	"mcp-go-sdk/server"
+	"mcp-go-sdk/servertest"
+)
+
+func TestConformance(t *testing.T) {
+	srv := server.NewServer(nil)
+	srv.RegisterTool(&EchoTool{})
+	servertest.Run(t, srv, &servertest.Options{
+		ErrorCall: &servertest.ToolCall{Name: "echo", Arguments: map[string]int{"message": 42}},
+	})
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+
+	"mcp-go-sdk/auth"
+)
+
+// authConfig holds the credentials HTTP clients must present
+type authConfig struct {
+	Token    string
+	JWKSFile string
+	Issuer   string
+	Audience string
+}
+
+// authConfigFromEnv reads the credentials from AUTH_TOKEN, JWKS_FILE,
+// JWT_ISSUER and JWT_AUDIENCE
+func authConfigFromEnv() *authConfig {
+	return &authConfig{
+		Token:    os.Getenv("AUTH_TOKEN"),
+		JWKSFile: os.Getenv("JWKS_FILE"),
+		Issuer:   os.Getenv("JWT_ISSUER"),
+		Audience: os.Getenv("JWT_AUDIENCE"),
+	}
+}
+
+// newHTTPHandler protects the MCP endpoints served on addr. The token is
+// accepted as a static bearer token; with a JWKS file, JWTs from the issuer
+// for the audience are accepted too. Tools run commands on this machine, so
+// without credentials only loopback addresses are served.
+func newHTTPHandler(addr string, config *authConfig, mcp http.Handler) (http.Handler, error) {
+	if config.Token == "" && config.JWKSFile == "" {
+		if !isLoopback(addr) {
+			return nil, fmt.Errorf("refusing to serve %s without AUTH_TOKEN or JWKS_FILE; listen on a loopback address such as 127.0.0.1:8080 instead", addr)
+		}
+		return mcp, nil
+	}
+
+	var verifiers []auth.TokenVerifier
+	if config.Token != "" {
+		verifiers = append(verifiers, auth.StaticTokens{
+			config.Token: {Subject: "token"},
+		})
+	}
+	if config.JWKSFile != "" {
+		// Without an audience, tokens the issuer gave out for any
+		// resource would be accepted
+		if config.Audience == "" {
+			return nil, errors.New("JWKS_FILE needs JWT_AUDIENCE, the audience tokens must be issued for, such as the URL of this server")
+		}
+		jwt, err := auth.NewJWTVerifier(&auth.JWTConfig{
+			JWKSFile: config.JWKSFile,
+			Issuer:   config.Issuer,
+			Audience: config.Audience,
+			Leeway:   auth.DefaultJWTConfig().Leeway,
+		})
+		if err != nil {
+			return nil, err
+		}
+		verifiers = append(verifiers, jwt)
+	}
+	return auth.NewHandler(mcp, auth.AnyOf(verifiers...), auth.DefaultConfig()), nil
+}
+
+// isLoopback reports whether addr only accepts connections from this
+// machine. An empty host listens on every interface.
+func isLoopback(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestHTTPHandlerAuth(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	// Without credentials only loopback addresses are served
+	for _, addr := range []string{"127.0.0.1:8080", "localhost:8080", "[::1]:8080"} {
+		if _, err := newHTTPHandler(addr, &authConfig{}, ok); err != nil {
+			t.Errorf("Expected %s to be served, got %v", addr, err)
+		}
+	}
+	for _, addr := range []string{":8080", "0.0.0.0:8080", "192.0.2.1:8080", "example.com:8080"} {
+		if _, err := newHTTPHandler(addr, &authConfig{}, ok); err == nil {
+			t.Errorf("Expected %s to be refused without credentials", addr)
+		}
+	}
+
+	// JWTs are only accepted for a configured audience
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwks := filepath.Join(t.TempDir(), "jwks.json")
+	data := fmt.Sprintf(`{"keys":[{"kty":"OKP","crv":"Ed25519","kid":"k","x":%q}]}`, base64.RawURLEncoding.EncodeToString(pub))
+	if err := os.WriteFile(jwks, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := newHTTPHandler(":8080", &authConfig{JWKSFile: jwks}, ok); err == nil || !strings.Contains(err.Error(), "JWT_AUDIENCE") {
+		t.Errorf("Expected JWKS_FILE without JWT_AUDIENCE to be refused, got %v", err)
+	}
+	if _, err := newHTTPHandler(":8080", &authConfig{JWKSFile: jwks, Audience: "https://exec.test"}, ok); err != nil {
+		t.Errorf("Expected JWKS_FILE with JWT_AUDIENCE to be served, got %v", err)
+	}
+
+	handler, err := newHTTPHandler(":8080", &authConfig{Token: "secret"}, ok)
+	if err != nil {
+		t.Fatalf("newHTTPHandler failed: %v", err)
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/sse", nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 without a token, got %d", rec.Code)
+	}
+	rec = httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/sse", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected the token to be accepted, got %d", rec.Code)
+	}
+}
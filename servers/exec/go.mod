@@ -0,0 +1,10 @@
+module mcp-exec
+
+go 1.21
+
+require (
+	gopkg.in/yaml.v3 v3.0.1
+	mcp-go-sdk v0.0.0
+)
+
+replace mcp-go-sdk => ../../mcp-go-sdk
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+
+	"mcp-go-sdk/server"
+	"mcp-go-sdk/transport"
+)
+
+func main() {
+	// Initialize logger
+	log.SetPrefix("[Exec MCP] ")
+	log.SetFlags(log.LstdFlags | log.Lshortfile)
+
+	// Parse command-line flags
+	var sseAddr string
+	var maxOutput int
+	flag.StringVar(&sseAddr, "sse-addr", "", "Serve the HTTP+SSE transport on this address instead of stdio")
+	flag.IntVar(&maxOutput, "max-output", 64<<10, "Bytes of text a tool result may carry before it is cut, 0 for no limit")
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		log.Fatal("Usage: mcp-exec [-sse-addr host:port] [-max-output bytes] <tools.yaml|tools.json>")
+	}
+	specPath := flag.Arg(0)
+
+	spec, err := loadSpec(specPath)
+	if err != nil {
+		log.Fatalf("Failed to load spec: %v", err)
+	}
+	tools, err := spec.NewTools()
+	if err != nil {
+		log.Fatalf("Failed to create tools: %v", err)
+	}
+
+	serverConfig := server.DefaultConfig()
+	serverConfig.Info.Name = "Exec MCP"
+	if spec.Name != "" {
+		serverConfig.Info.Name = spec.Name
+	}
+	if spec.Version != "" {
+		serverConfig.Info.Version = spec.Version
+	}
+	if maxOutput > 0 {
+		serverConfig.Output = &server.OutputConfig{MaxBytes: maxOutput}
+	}
+	srv := server.NewServerWithConfig(transport.NewStdioTransport(), serverConfig)
+
+	for _, tool := range tools {
+		if err := srv.RegisterTool(tool); err != nil {
+			log.Fatalf("Failed to register tool %s: %v", tool.Name(), err)
+		}
+	}
+
+	// Serve over HTTP+SSE, one session per connection
+	if sseAddr != "" {
+		handler := transport.NewSSEHandler(srv.ServeTransport, nil)
+		mux := http.NewServeMux()
+		mux.Handle("/sse", handler)
+		mux.Handle("/messages", handler)
+
+		protected, err := newHTTPHandler(sseAddr, authConfigFromEnv(), mux)
+		if err != nil {
+			log.Fatalf("Failed to set up authentication: %v", err)
+		}
+
+		log.Printf("Starting exec MCP server with %d tools from %s on http://%s/sse", len(tools), specPath, sseAddr)
+		if err := http.ListenAndServe(sseAddr, protected); err != nil {
+			log.Fatalf("Server error: %v", err)
+		}
+		return
+	}
+
+	// Start the server
+	log.Printf("Starting exec MCP server with %d tools from %s", len(tools), specPath)
+	if err := srv.Start(); err != nil {
+		log.Fatalf("Server error: %v", err)
+	}
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"mcp-go-sdk/client"
+	"mcp-go-sdk/server"
+	"mcp-go-sdk/servertest"
+	"mcp-go-sdk/transport"
+)
+
+// newServer creates a server with the tools of a spec file
+func newServer(t *testing.T, path string) server.Server {
+	t.Helper()
+	spec, err := loadSpec(path)
+	if err != nil {
+		t.Fatalf("Failed to load spec: %v", err)
+	}
+	tools, err := spec.NewTools()
+	if err != nil {
+		t.Fatalf("Failed to create tools: %v", err)
+	}
+	srv := server.NewServer(nil)
+	for _, tool := range tools {
+		srv.RegisterTool(tool)
+	}
+	return srv
+}
+
+func TestExampleSpec(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("Alpha\nbeta\n"), 0o644)
+
+	srv := newServer(t, "tools.yaml")
+	clientEnd, serverEnd := transport.NewInMemoryPair()
+	go srv.ServeTransport(serverEnd)
+	c := client.NewClient(clientEnd, nil)
+	defer c.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if _, err := c.Initialize(ctx); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+
+	result, err := c.CallTool(ctx, "grep", map[string]interface{}{"pattern": "alpha", "path": dir, "ignoreCase": true})
+	if err != nil || result.IsError || !strings.Contains(result.Content[0].Text, "notes.txt:1:Alpha") {
+		t.Errorf("Expected a case-insensitive match, got %+v, %v", result, err)
+	}
+
+	// No match is not an error, and the pattern is never read by a shell
+	result, err = c.CallTool(ctx, "grep", map[string]interface{}{"pattern": "x; touch pwned", "path": dir})
+	if err != nil || result.IsError || result.Content[0].Text != "" {
+		t.Errorf("Expected no match, got %+v, %v", result, err)
+	}
+
+	result, err = c.CallTool(ctx, "grep", map[string]interface{}{"pattern": "a", "path": filepath.Join(dir, "missing")})
+	if err != nil || !result.IsError || !strings.Contains(result.Content[0].Text, "exit status 2") {
+		t.Errorf("Expected a missing file to fail, got %+v, %v", result, err)
+	}
+
+	result, err = c.CallTool(ctx, "word_count", map[string]interface{}{"text": "one two\nthree\n"})
+	if err != nil || result.IsError || strings.Join(strings.Fields(result.Content[0].Text), " ") != "2 3 14" {
+		t.Errorf("Expected the text to be counted, got %+v, %v", result, err)
+	}
+
+	result, err = c.CallTool(ctx, "grep", map[string]interface{}{"pattern": "a", "path": "--help"})
+	if err != nil || !result.IsError || !strings.Contains(result.Content[0].Text, "may not start with a dash") {
+		t.Errorf("Expected an option to be rejected as a path, got %+v, %v", result, err)
+	}
+}
+
+func TestYAMLSpec(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "tools.yml")
+	os.WriteFile(path, []byte(`
+tools:
+  - name: pwd
+    description: Prints the working directory
+    command: [pwd]
+    dir: work
+    timeout: 1.5
+`), 0o644)
+	spec, err := loadSpec(path)
+	if err != nil {
+		t.Fatalf("Failed to load spec: %v", err)
+	}
+	if spec.Tools[0].Dir != filepath.Join(dir, "work") {
+		t.Errorf("Expected the directory to be resolved against the spec, got %s", spec.Tools[0].Dir)
+	}
+	if time.Duration(spec.Tools[0].Timeout) != 1500*time.Millisecond {
+		t.Errorf("Expected a timeout of 1.5s, got %v", spec.Tools[0].Timeout)
+	}
+
+	os.WriteFile(path, []byte("tools: [{name: x, command: [ls, '{missing}']}]"), 0o644)
+	if _, err := loadSpec(path); err == nil || !strings.Contains(err.Error(), "{missing}") {
+		t.Errorf("Expected an unknown placeholder to be rejected, got %v", err)
+	}
+	os.WriteFile(path, []byte("tools: [name: x"), 0o644)
+	if _, err := loadSpec(path); err == nil {
+		t.Error("Expected invalid YAML to be rejected")
+	}
+}
+
+func TestConformance(t *testing.T) {
+	servertest.Run(t, newServer(t, "tools.yaml"), &servertest.Options{
+		ErrorCall: &servertest.ToolCall{Name: "grep", Arguments: map[string]string{"pattern": "a", "path": "/nonexistent"}},
+	})
+}
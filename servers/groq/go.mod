@@ -7,7 +7,6 @@ toolchain go1.24.0
 require (
 	github.com/joho/godotenv v1.5.1
 	github.com/openai/openai-go v0.1.0-alpha.62
-	golang.org/x/time v0.11.0
 	mcp-go-sdk v0.0.0-00010101000000-000000000000
 )
 
@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strconv"
+	"time"
 
 	"mcp-go-sdk/server"
 	"mcp-go-sdk/transport"
@@ -44,11 +46,15 @@ func main() {
 		log.Fatal("Error: temperature must be between 0 and 1.5")
 	}
 
-	// Create a new MCP server with stdio transport
-	srv := server.NewServer(transport.NewStdioTransport())
-
-	// Create and register the Groq tool
 	tool := NewGroqTool(config)
+
+	// Create a new MCP server with stdio transport, throttling calls to
+	// stay within the API quota
+	serverConfig := server.DefaultConfig()
+	serverConfig.ToolLimits = map[string]*server.Limit{tool.Name(): limitFromEnv()}
+	srv := server.NewServerWithConfig(transport.NewStdioTransport(), serverConfig)
+
+	// Register the Groq tool
 	if err := srv.RegisterTool(tool); err != nil {
 		log.Fatalf("Failed to register tool: %v", err)
 	}
@@ -58,3 +64,21 @@ func main() {
 		log.Fatalf("Server error: %v", err)
 	}
 }
+
+// limitFromEnv returns the limit on calls of the tool. MAX_REQUESTS_PER_MINUTE
+// caps the calls per minute (20 by default) and MAX_CONCURRENT_REQUESTS the
+// calls waiting for the API at once (unlimited by default).
+func limitFromEnv() *server.Limit {
+	limit := &server.Limit{
+		Requests: 20,
+		Interval: time.Minute,
+	}
+	if rpm, err := strconv.Atoi(os.Getenv("MAX_REQUESTS_PER_MINUTE")); err == nil && rpm > 0 {
+		limit.Requests = rpm
+	}
+	if n, err := strconv.Atoi(os.Getenv("MAX_CONCURRENT_REQUESTS")); err == nil && n > 0 {
+		limit.MaxConcurrent = n
+		limit.MaxQueue = 2 * n
+	}
+	return limit
+}
@@ -6,13 +6,10 @@ import (
 	"fmt"
 	"log"
 	"os"
-	"strconv"
 	"strings"
-	"time"
 
 	"github.com/openai/openai-go"
 	"github.com/openai/openai-go/option"
-	"golang.org/x/time/rate"
 )
 
 // ToolParams represents the parameters for the Groq tool
@@ -32,21 +29,12 @@ type Config struct {
 
 // GroqTool implements the MCP Tool interface
 type GroqTool struct {
-	client    *openai.Client
-	rateLimit *rate.Limiter
-	config    Config
+	client *openai.Client
+	config Config
 }
 
 // NewGroqTool creates a new instance of the Groq tool
 func NewGroqTool(config Config) *GroqTool {
-	// Configure rate limiting
-	requestsPerMinute := 20
-	if rpmStr := os.Getenv("MAX_REQUESTS_PER_MINUTE"); rpmStr != "" {
-		if rpm, err := strconv.Atoi(rpmStr); err == nil {
-			requestsPerMinute = rpm
-		}
-	}
-
 	// Create OpenAI client with Groq configuration
 	baseURL := os.Getenv("GROQ_API_BASE")
 	if baseURL == "" {
@@ -64,9 +52,8 @@ func NewGroqTool(config Config) *GroqTool {
 	)
 
 	return &GroqTool{
-		client:    client,
-		rateLimit: rate.NewLimiter(rate.Every(time.Minute/time.Duration(requestsPerMinute)), 1),
-		config:    config,
+		client: client,
+		config: config,
 	}
 }
 
@@ -199,6 +186,11 @@ func extractFinalAnswer(response string) string {
 
 // Execute runs the tool with the given parameters
 func (t *GroqTool) Execute(params json.RawMessage) (interface{}, error) {
+	return t.ExecuteContext(context.Background(), params)
+}
+
+// ExecuteContext runs the tool, abandoning the API call when ctx ends
+func (t *GroqTool) ExecuteContext(ctx context.Context, params json.RawMessage) (interface{}, error) {
 	// Parse parameters
 	var toolParams ToolParams
 	if err := json.Unmarshal(params, &toolParams); err != nil {
@@ -213,12 +205,6 @@ func (t *GroqTool) Execute(params json.RawMessage) (interface{}, error) {
 		return nil, fmt.Errorf("context is required")
 	}
 
-	// Apply rate limiting with context
-	ctx := context.Background()
-	if err := t.rateLimit.Wait(ctx); err != nil {
-		return nil, fmt.Errorf("rate limit error: %v", err)
-	}
-
 	// Use config values or override with provided parameters
 	model := t.config.Model
 	if toolParams.Model != nil {
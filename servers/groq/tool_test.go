@@ -5,6 +5,10 @@ import (
 	"os"
 	"strings"
 	"testing"
+	"time"
+
+	"mcp-go-sdk/server"
+	"mcp-go-sdk/servertest"
 
 	"github.com/joho/godotenv"
 )
@@ -269,3 +273,26 @@ func stringPtr(s string) *string {
 func float64Ptr(f float64) *float64 {
 	return &f
 }
+
+func TestConformance(t *testing.T) {
+	// The checks never reach the API, so no key is needed
+	srv := server.NewServer(nil)
+	srv.RegisterTool(NewGroqTool(Config{APIKey: "test", Model: defaultModel, Temperature: defaultTemperature}))
+	servertest.Run(t, srv, &servertest.Options{
+		ErrorCall: &servertest.ToolCall{Name: "ask_groq", Arguments: map[string]string{"context": "no question"}},
+	})
+}
+
+func TestLimitFromEnv(t *testing.T) {
+	t.Setenv("MAX_REQUESTS_PER_MINUTE", "")
+	t.Setenv("MAX_CONCURRENT_REQUESTS", "")
+	if limit := limitFromEnv(); limit.Requests != 20 || limit.Interval != time.Minute || limit.MaxConcurrent != 0 {
+		t.Errorf("Unexpected default limit %+v", limit)
+	}
+
+	t.Setenv("MAX_REQUESTS_PER_MINUTE", "5")
+	t.Setenv("MAX_CONCURRENT_REQUESTS", "2")
+	if limit := limitFromEnv(); limit.Requests != 5 || limit.MaxConcurrent != 2 || limit.MaxQueue != 4 {
+		t.Errorf("Unexpected limit %+v", limit)
+	}
+}
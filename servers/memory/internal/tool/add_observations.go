@@ -1,8 +1,6 @@
 package tool
 
 import (
-	_ "embed" // Required for go:embed directive
-	"encoding/json"
 	"fmt"
 
 	"mcp-go-sdk"
@@ -10,9 +8,6 @@ import (
 	"mcp-memory/internal/types"
 )
 
-//go:embed schemas/add_observations.json
-var addObservationsSchemaJSON []byte // Use []byte for json.RawMessage
-
 // AddObservationsTool implements the Tool interface for adding observations
 type AddObservationsTool struct {
 	manager *graph.KnowledgeGraphManager
@@ -25,31 +20,20 @@ func NewAddObservationsTool(manager *graph.KnowledgeGraphManager) mcp.Tool {
 	}
 }
 
-// Name returns the name of the tool
-func (t *AddObservationsTool) Name() string {
-	return "add_observations"
-}
-
-// Description returns the description of the tool
-func (t *AddObservationsTool) Description() string {
-	return "Adds new observations to entities in the knowledge graph"
+// Invalidates implements mcp.CacheInvalidator
+func (t *AddObservationsTool) Invalidates() []string {
+	return nil
 }
 
-// Schema returns the JSON schema for the tool's parameters
-func (t *AddObservationsTool) Schema() json.RawMessage {
-	return addObservationsSchemaJSON
+type addObservationsInput struct {
+	// List of observations to add
+	Observations []types.Observation `json:"observations"`
 }
 
-// Execute adds new observations to the knowledge graph
-func (t *AddObservationsTool) Execute(params json.RawMessage) (interface{}, error) {
-	var input struct {
-		Observations []types.Observation `json:"observations"`
-	}
-
-	if err := json.Unmarshal(params, &input); err != nil {
-		return formatError(fmt.Errorf("failed to parse input: %w", err)), nil
-	}
-
+// addObservations adds new observations to entities in the knowledge graph
+//
+//mcp:tool add_observations
+func (t *AddObservationsTool) addObservations(input addObservationsInput) (interface{}, error) {
 	createdObservations, err := t.manager.AddObservations(input.Observations)
 	if err != nil {
 		return formatError(fmt.Errorf("failed to add observations: %w", err)), nil
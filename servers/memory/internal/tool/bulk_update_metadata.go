@@ -1,24 +1,23 @@
 package tool
 
 import (
-	_ "embed" // Required for go:embed directive
-	"encoding/json"
 	"fmt"
-	"strings"
 
 	"mcp-go-sdk"
 	"mcp-memory/internal/graph"
 	"mcp-memory/internal/types"
 )
 
-//go:embed schemas/bulk_update_metadata.json
-var bulkUpdateMetadataSchemaJSON []byte // Use []byte for json.RawMessage
-
 // BulkUpdateMetadataToolInput represents the input structure for the bulk_update_metadata tool.
 type BulkUpdateMetadataToolInput struct {
-	Filter    types.EntityFilterCriteria `json:"filter"` // Now required by schema, not a pointer
-	Updates   map[string]interface{}     `json:"updates"`
-	Operation string                     `json:"operation,omitempty"` // "merge", "replace", "delete". Defaults to "merge".
+	// Criteria to filter entities for bulk update. At least one is required.
+	Filter types.EntityFilterCriteria `json:"filter"`
+
+	// Metadata updates to apply (keys are dot-paths, values are data).
+	Updates map[string]interface{} `json:"updates"`
+
+	// Operation: 'merge' (default), 'replace', 'delete'.
+	Operation string `json:"operation,omitempty" mcp:"enum=merge|replace|delete"`
 }
 
 // BulkUpdateMetadataTool is a tool for updating metadata for multiple entities matching a filter.
@@ -33,41 +32,24 @@ func NewBulkUpdateMetadataTool(manager *graph.KnowledgeGraphManager) *BulkUpdate
 	}
 }
 
-// Name returns the name of the tool
-func (t *BulkUpdateMetadataTool) Name() string {
-	return "bulk_update_metadata"
-}
-
-// Description returns the description of the tool
-func (t *BulkUpdateMetadataTool) Description() string {
-	return "Updates metadata for multiple entities matching filter criteria (type, name_contains, etc.). Supports 'merge' (default), 'replace', 'delete' operations and nested paths."
+// Invalidates implements mcp.CacheInvalidator
+func (t *BulkUpdateMetadataTool) Invalidates() []string {
+	return nil
 }
 
-// Schema returns the JSON schema for the tool's parameters
-func (t *BulkUpdateMetadataTool) Schema() json.RawMessage {
-	return bulkUpdateMetadataSchemaJSON
-}
-
-// Execute performs the metadata update operation for multiple entities matching a filter
-func (t *BulkUpdateMetadataTool) Execute(params json.RawMessage) (interface{}, error) {
-	var input BulkUpdateMetadataToolInput
-	if err := json.Unmarshal(params, &input); err != nil {
-		// Basic validation happens via schema now, but keep unmarshal check
-		return formatError(fmt.Errorf("failed to parse input parameters: %w", err)), nil
-	}
-
-	// Schema ensures Filter and Updates are present.
+// bulkUpdateMetadata updates metadata for multiple entities matching filter criteria (type, name_contains, etc.). Supports 'merge' (default), 'replace', 'delete' operations and nested paths.
+//
+//mcp:tool bulk_update_metadata
+func (t *BulkUpdateMetadataTool) bulkUpdateMetadata(input BulkUpdateMetadataToolInput) (interface{}, error) {
 	// Runtime validation for filter content (at least one criterion)
 	if input.Filter.Type == "" && input.Filter.NameContains == "" && input.Filter.DescriptionContains == "" {
 		return formatError(fmt.Errorf("bulk update filter must contain at least one criterion (type, name_contains, description_contains)")), nil
 	}
 
-	// Validate the operation string.
-	op := strings.ToLower(input.Operation)
+	// The generated Execute checks the operation against the enum
+	op := input.Operation
 	if op == "" {
 		op = "merge" // Default operation
-	} else if op != "merge" && op != "replace" && op != "delete" {
-		return formatError(fmt.Errorf("invalid operation '%s'. Must be 'merge', 'replace', or 'delete'", input.Operation)), nil
 	}
 
 	// Execute the bulk update via the graph manager
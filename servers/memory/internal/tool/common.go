@@ -1,11 +1,52 @@
 package tool
 
+//go:generate go run mcp-go-sdk/cmd/mcp-toolgen
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"mcp-go-sdk"
+	"mcp-go-sdk/server"
+)
+
 // ContextKey defines a type for context keys to avoid collisions.
 type ContextKey string
 
 // GraphManagerKey is the key used to store the KnowledgeGraphManager in the runtime context.
 const GraphManagerKey ContextKey = "graphManager"
 
+// The tools that change the graph implement mcp.CacheInvalidator and return
+// nil from Invalidates, dropping every cached result: entities, relations and
+// observations show up together in every read, so any change may make any
+// cached read stale.
+var (
+	_ mcp.CacheInvalidator = (*AddObservationsTool)(nil)
+	_ mcp.CacheInvalidator = (*BulkUpdateMetadataTool)(nil)
+	_ mcp.CacheInvalidator = (*CreateEntitiesTool)(nil)
+	_ mcp.CacheInvalidator = (*CreateRelationsTool)(nil)
+	_ mcp.CacheInvalidator = (*DeleteEntitiesTool)(nil)
+	_ mcp.CacheInvalidator = (*DeleteObservationsTool)(nil)
+	_ mcp.CacheInvalidator = (*DeleteRelationsTool)(nil)
+	_ mcp.CacheInvalidator = (*UpdateEntitiesTool)(nil)
+	_ mcp.CacheInvalidator = (*UpdateEntityMetadataTool)(nil)
+)
+
+// The tools that only read the graph implement mcp.AnnotatedTool and are
+// annotated as read-only, so that the server may cache their results.
+var (
+	_ mcp.AnnotatedTool = (*FindPathsTool)(nil)
+	_ mcp.AnnotatedTool = (*GetEntityTimelineTool)(nil)
+	_ mcp.AnnotatedTool = (*GetSubgraphTool)(nil)
+	_ mcp.AnnotatedTool = (*OpenNodesTool)(nil)
+	_ mcp.AnnotatedTool = (*QueryTool)(nil)
+	_ mcp.AnnotatedTool = (*ReadGraphTool)(nil)
+	_ mcp.AnnotatedTool = (*SearchNodesTool)(nil)
+	_ mcp.AnnotatedTool = (*TraverseGraphTool)(nil)
+)
+
 // formatResponse formats a successful response in the MCP tool format
 func formatResponse(message string, metadata map[string]interface{}, data interface{}) interface{} {
 	response := map[string]interface{}{
@@ -38,3 +79,35 @@ func formatError(err error) interface{} {
 		"isError": true,
 	}
 }
+
+// confirmSchema asks the user for a yes or no
+var confirmSchema = json.RawMessage(`{
+	"type": "object",
+	"properties": {
+		"confirm": {"type": "boolean", "description": "Go ahead with the deletion"}
+	},
+	"required": ["confirm"]
+}`)
+
+// confirmDeletion asks the user to confirm a deletion described by message.
+// Clients that cannot ask the user go ahead without confirmation, and calls
+// the server's policy already had the user confirm are not asked again. The
+// returned error tells why the deletion must not happen.
+func confirmDeletion(ctx context.Context, message string) error {
+	if server.Confirmed(ctx) {
+		return nil
+	}
+	var answer struct {
+		Confirm bool `json:"confirm"`
+	}
+	action, err := server.Elicit(ctx, message, confirmSchema, &answer)
+	switch {
+	case errors.Is(err, server.ErrElicitationUnsupported):
+		return nil
+	case err != nil:
+		return fmt.Errorf("failed to ask for confirmation: %w", err)
+	case action != mcp.ElicitAccept || !answer.Confirm:
+		return errors.New("deletion was not confirmed by the user")
+	}
+	return nil
+}
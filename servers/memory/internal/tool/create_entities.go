@@ -1,8 +1,6 @@
 package tool
 
 import (
-	_ "embed" // Required for go:embed directive
-	"encoding/json"
 	"fmt"
 
 	"mcp-go-sdk"
@@ -10,9 +8,6 @@ import (
 	"mcp-memory/internal/types"
 )
 
-//go:embed schemas/create_entities.json
-var createEntitiesSchemaJSON []byte // Use []byte for json.RawMessage
-
 // CreateEntitiesTool implements the Tool interface for creating entities
 type CreateEntitiesTool struct {
 	manager *graph.KnowledgeGraphManager
@@ -25,31 +20,20 @@ func NewCreateEntitiesTool(manager *graph.KnowledgeGraphManager) mcp.Tool {
 	}
 }
 
-// Name returns the name of the tool
-func (t *CreateEntitiesTool) Name() string {
-	return "create_entities"
-}
-
-// Description returns the description of the tool
-func (t *CreateEntitiesTool) Description() string {
-	return `Creates new entities in the knowledge graph.`
+// Invalidates implements mcp.CacheInvalidator
+func (t *CreateEntitiesTool) Invalidates() []string {
+	return nil
 }
 
-// Schema returns the JSON schema for the tool's parameters
-func (t *CreateEntitiesTool) Schema() json.RawMessage {
-	return createEntitiesSchemaJSON
+type createEntitiesInput struct {
+	// List of entities to create
+	Entities []types.Entity `json:"entities"`
 }
 
-// Execute creates new entities in the knowledge graph
-func (t *CreateEntitiesTool) Execute(params json.RawMessage) (interface{}, error) {
-	var input struct {
-		Entities []types.Entity `json:"entities"`
-	}
-
-	if err := json.Unmarshal(params, &input); err != nil {
-		return formatError(fmt.Errorf("failed to parse input: %w", err)), nil
-	}
-
+// createEntities creates new entities in the knowledge graph.
+//
+//mcp:tool create_entities
+func (t *CreateEntitiesTool) createEntities(input createEntitiesInput) (interface{}, error) {
 	createdEntities, err := t.manager.CreateEntities(input.Entities)
 	if err != nil {
 		return formatError(fmt.Errorf("failed to create entities: %w", err)), nil
@@ -1,8 +1,6 @@
 package tool
 
 import (
-	_ "embed" // Required for go:embed directive
-	"encoding/json"
 	"fmt"
 
 	"mcp-go-sdk"
@@ -10,9 +8,6 @@ import (
 	"mcp-memory/internal/types"
 )
 
-//go:embed schemas/create_relations.json
-var createRelationsSchemaJSON []byte // Use []byte for json.RawMessage
-
 // CreateRelationsTool implements the Tool interface for creating relations
 type CreateRelationsTool struct {
 	manager *graph.KnowledgeGraphManager
@@ -25,31 +20,20 @@ func NewCreateRelationsTool(manager *graph.KnowledgeGraphManager) mcp.Tool {
 	}
 }
 
-// Name returns the name of the tool
-func (t *CreateRelationsTool) Name() string {
-	return "create_relations"
-}
-
-// Description returns the description of the tool
-func (t *CreateRelationsTool) Description() string {
-	return "Creates new relations between entities in the knowledge graph"
+// Invalidates implements mcp.CacheInvalidator
+func (t *CreateRelationsTool) Invalidates() []string {
+	return nil
 }
 
-// Schema returns the JSON schema for the tool's parameters
-func (t *CreateRelationsTool) Schema() json.RawMessage {
-	return createRelationsSchemaJSON
+type createRelationsInput struct {
+	// List of relations to create
+	Relations []types.Relation `json:"relations"`
 }
 
-// Execute creates new relations in the knowledge graph
-func (t *CreateRelationsTool) Execute(params json.RawMessage) (interface{}, error) {
-	var input struct {
-		Relations []types.Relation `json:"relations"`
-	}
-
-	if err := json.Unmarshal(params, &input); err != nil {
-		return formatError(fmt.Errorf("failed to parse input: %w", err)), nil
-	}
-
+// createRelations creates new relations between entities in the knowledge graph
+//
+//mcp:tool create_relations
+func (t *CreateRelationsTool) createRelations(input createRelationsInput) (interface{}, error) {
 	createdRelations, err := t.manager.CreateRelations(input.Relations)
 	if err != nil {
 		return formatError(fmt.Errorf("failed to create relations: %w", err)), nil
@@ -1,17 +1,14 @@
 package tool
 
 import (
-	_ "embed" // Required for go:embed directive
-	"encoding/json"
+	"context"
 	"fmt"
+	"strings"
 
 	"mcp-go-sdk"
 	"mcp-memory/internal/graph"
 )
 
-//go:embed schemas/delete_entities.json
-var deleteEntitiesSchemaJSON []byte // Use []byte for json.RawMessage
-
 // DeleteEntitiesTool implements the Tool interface for deleting entities
 type DeleteEntitiesTool struct {
 	manager *graph.KnowledgeGraphManager
@@ -24,29 +21,24 @@ func NewDeleteEntitiesTool(manager *graph.KnowledgeGraphManager) mcp.Tool {
 	}
 }
 
-// Name returns the name of the tool
-func (t *DeleteEntitiesTool) Name() string {
-	return "delete_entities"
-}
-
-// Description returns the description of the tool
-func (t *DeleteEntitiesTool) Description() string {
-	return "Deletes entities from the knowledge graph by their unique IDs"
+// Invalidates implements mcp.CacheInvalidator
+func (t *DeleteEntitiesTool) Invalidates() []string {
+	return nil
 }
 
-// Schema returns the JSON schema for the tool's parameters
-func (t *DeleteEntitiesTool) Schema() json.RawMessage {
-	return deleteEntitiesSchemaJSON
+type deleteEntitiesInput struct {
+	// An array of entity IDs to delete from the knowledge graph
+	EntityIds []string `json:"entityIds"`
 }
 
-// Execute deletes entities from the knowledge graph
-func (t *DeleteEntitiesTool) Execute(params json.RawMessage) (interface{}, error) {
-	var input struct {
-		EntityIds []string `json:"entityIds"`
-	}
-
-	if err := json.Unmarshal(params, &input); err != nil {
-		return formatError(fmt.Errorf("failed to parse input: %w", err)), nil
+// deleteEntities deletes entities from the knowledge graph by their unique IDs
+//
+//mcp:tool delete_entities
+func (t *DeleteEntitiesTool) deleteEntities(ctx context.Context, input deleteEntitiesInput) (interface{}, error) {
+	// Delete once the user confirms, if the client can ask
+	message := fmt.Sprintf("Delete %d entities (%s) from the knowledge graph?", len(input.EntityIds), strings.Join(input.EntityIds, ", "))
+	if err := confirmDeletion(ctx, message); err != nil {
+		return formatError(err), nil
 	}
 
 	if err := t.manager.DeleteEntities(input.EntityIds); err != nil {
@@ -1,17 +1,13 @@
 package tool
 
 import (
-	_ "embed" // Required for go:embed directive
-	"encoding/json"
+	"context"
 	"fmt"
 
 	"mcp-go-sdk"
 	"mcp-memory/internal/graph"
 )
 
-//go:embed schemas/delete_observations.json
-var deleteObservationsSchemaJSON []byte // Use []byte for json.RawMessage
-
 // DeleteObservationsTool implements the Tool interface for deleting observations
 type DeleteObservationsTool struct {
 	manager *graph.KnowledgeGraphManager
@@ -24,29 +20,23 @@ func NewDeleteObservationsTool(manager *graph.KnowledgeGraphManager) mcp.Tool {
 	}
 }
 
-// Name returns the name of the tool
-func (t *DeleteObservationsTool) Name() string {
-	return "delete_observations"
-}
-
-// Description returns the description of the tool
-func (t *DeleteObservationsTool) Description() string {
-	return "Deletes observations from the knowledge graph"
+// Invalidates implements mcp.CacheInvalidator
+func (t *DeleteObservationsTool) Invalidates() []string {
+	return nil
 }
 
-// Schema returns the JSON schema for the tool's parameters
-func (t *DeleteObservationsTool) Schema() json.RawMessage {
-	return deleteObservationsSchemaJSON
+type deleteObservationsInput struct {
+	// The IDs of the observations to delete
+	IDs []string `json:"ids"`
 }
 
-// Execute deletes observations from the knowledge graph
-func (t *DeleteObservationsTool) Execute(params json.RawMessage) (interface{}, error) {
-	var input struct {
-		IDs []string `json:"ids"`
-	}
-
-	if err := json.Unmarshal(params, &input); err != nil {
-		return formatError(fmt.Errorf("failed to parse input: %w", err)), nil
+// deleteObservations deletes observations from the knowledge graph
+//
+//mcp:tool delete_observations
+func (t *DeleteObservationsTool) deleteObservations(ctx context.Context, input deleteObservationsInput) (interface{}, error) {
+	// Delete once the user confirms, if the client can ask
+	if err := confirmDeletion(ctx, fmt.Sprintf("Delete %d observations from the knowledge graph?", len(input.IDs))); err != nil {
+		return formatError(err), nil
 	}
 
 	if err := t.manager.DeleteObservations(input.IDs); err != nil {
@@ -1,8 +1,7 @@
 package tool
 
 import (
-	_ "embed" // Required for go:embed directive
-	"encoding/json"
+	"context"
 	"fmt"
 
 	"mcp-go-sdk"
@@ -10,9 +9,6 @@ import (
 	"mcp-memory/internal/types"
 )
 
-//go:embed schemas/delete_relations.json
-var deleteRelationsSchemaJSON []byte // Use []byte for json.RawMessage
-
 // DeleteRelationsTool implements the Tool interface for deleting relations
 type DeleteRelationsTool struct {
 	manager *graph.KnowledgeGraphManager
@@ -25,35 +21,32 @@ func NewDeleteRelationsTool(manager *graph.KnowledgeGraphManager) mcp.Tool {
 	}
 }
 
-// Name returns the name of the tool
-func (t *DeleteRelationsTool) Name() string {
-	return "delete_relations"
+// Invalidates implements mcp.CacheInvalidator
+func (t *DeleteRelationsTool) Invalidates() []string {
+	return nil
 }
 
-// Description returns the description of the tool
-func (t *DeleteRelationsTool) Description() string {
-	return "Deletes relations from the knowledge graph"
-}
+// relationRef names the relations between two entities with a type
+type relationRef struct {
+	// The name of the entity where the relation starts
+	From string `json:"from"`
 
-// Schema returns the JSON schema for the tool's parameters
-func (t *DeleteRelationsTool) Schema() json.RawMessage {
-	return deleteRelationsSchemaJSON
-}
+	// The name of the entity where the relation ends
+	To string `json:"to"`
 
-// Execute deletes relations from the knowledge graph
-func (t *DeleteRelationsTool) Execute(params json.RawMessage) (interface{}, error) {
-	var input struct {
-		Relations []struct {
-			From         string `json:"from"`
-			To           string `json:"to"`
-			RelationType string `json:"relationType"`
-		} `json:"relations"`
-	}
+	// The type of the relation
+	RelationType string `json:"relationType"`
+}
 
-	if err := json.Unmarshal(params, &input); err != nil {
-		return nil, fmt.Errorf("failed to parse input: %w", err)
-	}
+type deleteRelationsInput struct {
+	// An array of relations to delete
+	Relations []relationRef `json:"relations"`
+}
 
+// deleteRelations deletes relations from the knowledge graph
+//
+//mcp:tool delete_relations
+func (t *DeleteRelationsTool) deleteRelations(ctx context.Context, input deleteRelationsInput) (interface{}, error) {
 	// Get current graph state to find relation IDs
 	graph := t.manager.ReadGraph()
 	relationsToDelete := make([]types.Relation, 0, len(input.Relations))
@@ -78,6 +71,13 @@ func (t *DeleteRelationsTool) Execute(params json.RawMessage) (interface{}, erro
 		}
 	}
 
+	if deletedCount > 0 {
+		// Delete once the user confirms, if the client can ask
+		if err := confirmDeletion(ctx, fmt.Sprintf("Delete %d relations from the knowledge graph?", deletedCount)); err != nil {
+			return formatError(err), nil
+		}
+	}
+
 	if err := t.manager.DeleteRelations(relationsToDelete); err != nil {
 		return nil, fmt.Errorf("failed to delete relations: %w", err)
 	}
@@ -7,37 +7,52 @@ import (
 // FilterCondition defines a basic filter condition for equality.
 // Property can use dot notation for simple nested metadata access (e.g., "metadata.key").
 type FilterCondition struct {
-	Property string      `json:"property"` // e.g., "type", "name", "metadata.department"
-	Value    interface{} `json:"value"`    // The value to match
+	// Node/Relation property to filter on (e.g., 'type', 'name', 'metadata.key').
+	Property string `json:"property"`
+
+	// Value to match.
+	Value interface{} `json:"value"`
+
 	// TODO: Add Operator field (e.g., "eq", "ne", "in", "contains") for more complex filters
 }
 
 // NodeFilter defines filters to apply to entities. Assumes AND logic between conditions.
 type NodeFilter struct {
+	// Conditions the entities must all match
 	Conditions []FilterCondition `json:"conditions,omitempty"`
 }
 
 // RelationFilter defines filters to apply to relations. Assumes AND logic between conditions.
 type RelationFilter struct {
+	// Conditions the relations must all match
 	Conditions []FilterCondition `json:"conditions,omitempty"`
 }
 
 // TraversalFilters bundles filters for traversal operations.
 type TraversalFilters struct {
-	NodeFilter     *NodeFilter     `json:"node_filter,omitempty"`     // Nodes must match to be visited/included in result.
-	RelationFilter *RelationFilter `json:"relation_filter,omitempty"` // Relations must match to be traversed.
+	// Nodes must match to be visited/included in result.
+	NodeFilter *NodeFilter `json:"node_filter,omitempty"`
+
+	// Relations must match to be traversed.
+	RelationFilter *RelationFilter `json:"relation_filter,omitempty"`
 }
 
 // SubgraphFilters bundles filters for subgraph extraction.
 type SubgraphFilters struct {
-	NodeFilter     *NodeFilter     `json:"node_filter,omitempty"`     // Nodes must match to be included in the initial BFS search *and* final result.
-	RelationFilter *RelationFilter `json:"relation_filter,omitempty"` // Relations must match to be included in the final result.
+	// Nodes must match to be included in the initial BFS search *and* final result.
+	NodeFilter *NodeFilter `json:"node_filter,omitempty"`
+
+	// Relations must match to be included in the final result.
+	RelationFilter *RelationFilter `json:"relation_filter,omitempty"`
 }
 
 // PathFilters bundles filters for path finding.
 type PathFilters struct {
-	NodeFilter     *NodeFilter     `json:"node_filter,omitempty"`     // Nodes must match to be part of the path.
-	RelationFilter *RelationFilter `json:"relation_filter,omitempty"` // Relations must match to be part of the path.
+	// Nodes must match to be part of the path.
+	NodeFilter *NodeFilter `json:"node_filter,omitempty"`
+
+	// Relations must match to be part of the path.
+	RelationFilter *RelationFilter `json:"relation_filter,omitempty"`
 }
 
 // translateConditions converts tool filter conditions to internal graph filter conditions.
@@ -1,17 +1,12 @@
 package tool
 
 import (
-	_ "embed" // Required for go:embed directive
-	"encoding/json"
 	"fmt"
 
 	"mcp-go-sdk"
 	"mcp-memory/internal/graph"
 )
 
-//go:embed schemas/find_paths.json
-var findPathsSchemaJSON []byte // Use []byte for json.RawMessage
-
 // FindPathsTool implements the Tool interface for finding paths between nodes
 type FindPathsTool struct {
 	manager *graph.KnowledgeGraphManager
@@ -24,35 +19,25 @@ func NewFindPathsTool(manager *graph.KnowledgeGraphManager) mcp.Tool {
 	}
 }
 
-// Name returns the name of the tool
-func (t *FindPathsTool) Name() string {
-	return "find_paths"
-}
+type findPathsInput struct {
+	// The ID of the entity to start the path search from.
+	StartNodeID string `json:"start_node_id"`
 
-// Description returns the description of the tool
-func (t *FindPathsTool) Description() string {
-	return "Finds all simple paths (no repeated nodes) between a start and end entity, up to a maximum length."
-}
+	// The ID of the entity to find paths to.
+	EndNodeID string `json:"end_node_id"`
 
-// Schema returns the JSON schema for the tool's parameters
-func (t *FindPathsTool) Schema() json.RawMessage {
-	return findPathsSchemaJSON
-}
+	// Maximum path length in terms of the number of relations (-1 for unlimited), unlimited if omitted.
+	MaxLength *int `json:"max_length,omitempty"`
 
-// Execute performs the path finding operation
-func (t *FindPathsTool) Execute(params json.RawMessage) (interface{}, error) {
-	// Define a temporary struct to unmarshal the full input including tool filters
-	var input struct {
-		StartNodeID string       `json:"start_node_id"`
-		EndNodeID   string       `json:"end_node_id"`
-		MaxLength   int          `json:"max_length"`
-		Filters     *PathFilters `json:"filters"` // Use tool filter type
-	}
-
-	if err := json.Unmarshal(params, &input); err != nil {
-		return formatError(fmt.Errorf("failed to parse input: %w", err)), nil
-	}
+	// Optional filters to apply to nodes and relations along the path.
+	Filters *PathFilters `json:"filters,omitempty"`
+}
 
+// findPaths finds all simple paths (no repeated nodes) between a start and end entity, up to a maximum length.
+//
+//mcp:tool find_paths
+//mcp:readonly
+func (t *FindPathsTool) findPaths(input findPathsInput) (interface{}, error) {
 	// Translate tool filters to internal graph filters
 	var internalFilters *graph.PathFiltersInternal
 	if input.Filters != nil {
@@ -73,9 +58,13 @@ func (t *FindPathsTool) Execute(params json.RawMessage) (interface{}, error) {
 	graphParams := graph.FindPathsParams{
 		StartNodeID: input.StartNodeID,
 		EndNodeID:   input.EndNodeID,
-		MaxLength:   input.MaxLength,
+		MaxLength:   -1,
 		Filters:     internalFilters,
 	}
+	// Paths are unlimited if max_length is not provided
+	if input.MaxLength != nil {
+		graphParams.MaxLength = *input.MaxLength
+	}
 
 	// Execute the path finding using the manager
 	result, err := t.manager.FindPaths(graphParams)
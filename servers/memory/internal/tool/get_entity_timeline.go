@@ -1,8 +1,6 @@
 package tool
 
 import (
-	_ "embed" // Required for go:embed directive
-	"encoding/json"
 	"fmt"
 	"time"
 
@@ -10,16 +8,22 @@ import (
 	"mcp-memory/internal/types"
 )
 
-//go:embed schemas/get_entity_timeline.json
-var getEntityTimelineSchemaJSON []byte // Use []byte for json.RawMessage
-
 // GetEntityTimelineToolInput defines the expected input structure for the tool
 type GetEntityTimelineToolInput struct {
-	EntityID        string    `json:"entity_id"`            // Required: The ID of the entity to retrieve the timeline for.
-	StartTime       *string   `json:"start_time,omitempty"` // Optional: The start of the time range (RFC3339 format). If omitted, no lower time bound is applied.
-	EndTime         *string   `json:"end_time,omitempty"`   // Optional: The end of the time range (RFC3339 format). If omitted, no upper time bound is applied.
-	ObservationType *string   `json:"type,omitempty"`       // Optional: Filter observations by this specific type.
-	Tags            *[]string `json:"tags,omitempty"`       // Optional: Filter observations that have ANY of these tags.
+	// The ID of the entity to retrieve the timeline for.
+	EntityID string `json:"entity_id"`
+
+	// Optional: The start of the time range (RFC3339 format). If omitted, no lower time bound is applied.
+	StartTime *string `json:"start_time,omitempty"`
+
+	// Optional: The end of the time range (RFC3339 format). If omitted, no upper time bound is applied.
+	EndTime *string `json:"end_time,omitempty"`
+
+	// Optional: Filter observations by this specific type.
+	ObservationType *string `json:"type,omitempty"`
+
+	// Optional: Filter observations that have ANY of these tags.
+	Tags *[]string `json:"tags,omitempty"`
 }
 
 // GetEntityTimelineToolOutput defines the expected output structure for the tool
@@ -37,29 +41,11 @@ func NewGetEntityTimelineTool(manager *graph.KnowledgeGraphManager) *GetEntityTi
 	return &GetEntityTimelineTool{Manager: manager}
 }
 
-// Name returns the name of the tool
-func (t *GetEntityTimelineTool) Name() string {
-	return "get_entity_timeline"
-}
-
-// Description returns the description of the tool
-func (t *GetEntityTimelineTool) Description() string {
-	return "Retrieves a chronological timeline of observations for a specific entity, optionally filtered by time range, observation type, and tags."
-}
-
-// Schema returns the JSON schema for the tool's parameters (input only).
-func (t *GetEntityTimelineTool) Schema() json.RawMessage {
-	// Return the embedded input schema content directly
-	return getEntityTimelineSchemaJSON
-}
-
-// Execute runs the tool with the given input, conforming to the mcp.Tool interface
-func (t *GetEntityTimelineTool) Execute(inputRaw json.RawMessage) (interface{}, error) {
-	var input GetEntityTimelineToolInput
-	if err := json.Unmarshal(inputRaw, &input); err != nil {
-		return formatError(fmt.Errorf("failed to unmarshal input JSON for %s: %w", t.Name(), err)), nil
-	}
-
+// getEntityTimeline retrieves a chronological timeline of observations for a specific entity, optionally filtered by time range, observation type, and tags.
+//
+//mcp:tool get_entity_timeline
+//mcp:readonly
+func (t *GetEntityTimelineTool) getEntityTimeline(input GetEntityTimelineToolInput) (interface{}, error) {
 	// Validate required fields
 	if input.EntityID == "" {
 		return formatError(fmt.Errorf("missing required field 'entity_id' in input for %s", t.Name())), nil
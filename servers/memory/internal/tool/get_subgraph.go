@@ -1,17 +1,12 @@
 package tool
 
 import (
-	_ "embed" // Required for go:embed directive
-	"encoding/json"
 	"fmt"
 
 	"mcp-go-sdk"
 	"mcp-memory/internal/graph"
 )
 
-//go:embed schemas/get_subgraph.json
-var getSubgraphSchemaJSON []byte // Use []byte for json.RawMessage
-
 // GetSubgraphTool implements the Tool interface for extracting subgraphs
 type GetSubgraphTool struct {
 	manager *graph.KnowledgeGraphManager
@@ -24,38 +19,22 @@ func NewGetSubgraphTool(manager *graph.KnowledgeGraphManager) mcp.Tool {
 	}
 }
 
-// Name returns the name of the tool
-func (t *GetSubgraphTool) Name() string {
-	return "get_subgraph"
-}
+type getSubgraphInput struct {
+	// List of entity IDs to define the center of the subgraph.
+	StartNodeIDs []string `json:"start_node_ids"`
 
-// Description returns the description of the tool
-func (t *GetSubgraphTool) Description() string {
-	return "Extracts a subgraph containing all entities within a specified radius (hops) of the start nodes, including the relations connecting them."
-}
+	// Maximum distance (hops) from the start nodes to include. Radius 0 includes only the start nodes and relations between them.
+	Radius int `json:"radius" mcp:"min=0"`
 
-// Schema returns the JSON schema for the tool's parameters
-func (t *GetSubgraphTool) Schema() json.RawMessage {
-	return getSubgraphSchemaJSON
+	// Optional filters to apply during subgraph extraction.
+	Filters *SubgraphFilters `json:"filters,omitempty"`
 }
 
-// Execute performs the subgraph extraction
-func (t *GetSubgraphTool) Execute(params json.RawMessage) (interface{}, error) {
-	// Define a temporary struct to unmarshal the full input including tool filters
-	var input struct {
-		StartNodeIDs []string         `json:"start_node_ids"`
-		Radius       int              `json:"radius"`
-		Filters      *SubgraphFilters `json:"filters"` // Use tool filter type
-	}
-
-	if err := json.Unmarshal(params, &input); err != nil {
-		return formatError(fmt.Errorf("failed to parse input: %w", err)), nil
-	}
-
-	if input.Radius < 0 {
-		return formatError(fmt.Errorf("radius cannot be negative")), nil
-	}
-
+// getSubgraph extracts a subgraph containing all entities within a specified radius (hops) of the start nodes, including the relations connecting them.
+//
+//mcp:tool get_subgraph
+//mcp:readonly
+func (t *GetSubgraphTool) getSubgraph(input getSubgraphInput) (interface{}, error) {
 	// Translate tool filters to internal graph filters
 	var internalFilters *graph.SubgraphFiltersInternal
 	if input.Filters != nil {
@@ -39,6 +39,11 @@ func (t *QueryTool) Schema() json.RawMessage {
 	return querySchemaJSON
 }
 
+// Annotations implements mcp.AnnotatedTool
+func (t *QueryTool) Annotations() mcp.ToolAnnotations {
+	return mcp.ToolAnnotations{ReadOnlyHint: true}
+}
+
 // Execute runs the query logic.
 // It conforms to the mcp.Tool interface.
 func (t *QueryTool) Execute(params json.RawMessage) (interface{}, error) {
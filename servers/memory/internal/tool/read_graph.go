@@ -1,16 +1,13 @@
 package tool
 
 import (
-	_ "embed" // Required for go:embed directive
 	"encoding/json"
+	"fmt"
 
 	"mcp-go-sdk"
 	"mcp-memory/internal/graph"
 )
 
-//go:embed schemas/read_graph.json
-var readGraphSchemaJSON []byte // Use []byte for json.RawMessage
-
 // ReadGraphTool implements the Tool interface for reading the entire graph
 type ReadGraphTool struct {
 	manager *graph.KnowledgeGraphManager
@@ -23,27 +20,25 @@ func NewReadGraphTool(manager *graph.KnowledgeGraphManager) mcp.Tool {
 	}
 }
 
-// Name returns the name of the tool
-func (t *ReadGraphTool) Name() string {
-	return "read_graph"
-}
-
-// Description returns the description of the tool
-func (t *ReadGraphTool) Description() string {
-	return "Returns the current state of the knowledge graph"
-}
-
-// Schema returns the JSON schema for the tool's parameters
-func (t *ReadGraphTool) Schema() json.RawMessage {
-	return readGraphSchemaJSON
-}
+type readGraphInput struct{}
 
-// Execute returns the current state of the knowledge graph
-func (t *ReadGraphTool) Execute(params json.RawMessage) (interface{}, error) {
+// readGraph returns the current state of the knowledge graph
+//
+//mcp:tool read_graph
+//mcp:readonly
+func (t *ReadGraphTool) readGraph(input readGraphInput) (interface{}, error) {
 	graph := t.manager.ReadGraph()
 
+	// The graph goes in the text so that the model sees it and the
+	// server's output budget applies to it, and in data for clients that
+	// read it structured
+	text, err := json.MarshalIndent(graph, "", "  ")
+	if err != nil {
+		return formatError(fmt.Errorf("failed to encode graph: %w", err)), nil
+	}
+
 	return formatResponse(
-		"Successfully retrieved graph state",
+		string(text),
 		map[string]interface{}{
 			"entity_count":      len(graph.Entities),
 			"relation_count":    len(graph.Relations),
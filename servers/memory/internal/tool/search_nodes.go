@@ -1,17 +1,12 @@
 package tool
 
 import (
-	_ "embed" // Required for go:embed directive
-	"encoding/json"
 	"fmt"
 
 	"mcp-go-sdk"
 	"mcp-memory/internal/graph"
 )
 
-//go:embed schemas/search_nodes.json
-var searchNodesSchemaJSON []byte // Use []byte for json.RawMessage
-
 // SearchNodesTool implements the Tool interface for searching nodes
 type SearchNodesTool struct {
 	manager *graph.KnowledgeGraphManager
@@ -24,32 +19,19 @@ func NewSearchNodesTool(manager *graph.KnowledgeGraphManager) mcp.Tool {
 	}
 }
 
-// Name returns the name of the tool
-func (t *SearchNodesTool) Name() string {
-	return "search_nodes"
-}
-
-// Description returns the description of the tool
-func (t *SearchNodesTool) Description() string {
-	return "Searches for entities based on type and metadata"
-}
+type searchNodesInput struct {
+	// Optional. The type of the entities to find
+	Type string `json:"type,omitempty"`
 
-// Schema returns the JSON schema for the tool's parameters
-func (t *SearchNodesTool) Schema() json.RawMessage {
-	return searchNodesSchemaJSON
+	// Optional. Metadata values the entities must have
+	Metadata map[string]interface{} `json:"metadata,omitempty"`
 }
 
-// Execute searches for entities based on type and metadata
-func (t *SearchNodesTool) Execute(params json.RawMessage) (interface{}, error) {
-	var input struct {
-		Type     string                 `json:"type"`
-		Metadata map[string]interface{} `json:"metadata"`
-	}
-
-	if err := json.Unmarshal(params, &input); err != nil {
-		return formatError(fmt.Errorf("failed to parse input: %w", err)), nil
-	}
-
+// searchNodes searches for entities based on type and metadata
+//
+//mcp:tool search_nodes
+//mcp:readonly
+func (t *SearchNodesTool) searchNodes(input searchNodesInput) (interface{}, error) {
 	results := t.manager.SearchNodes(input.Type, input.Metadata)
 
 	return formatResponse(
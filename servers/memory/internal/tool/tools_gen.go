@@ -0,0 +1,533 @@
+// Code generated by mcp-toolgen. DO NOT EDIT.
+
+package tool
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+
+	"mcp-go-sdk"
+)
+
+//go:embed schemas/add_observations.json
+var addObservationsSchemaJSON []byte
+
+// Name returns the name of the tool
+func (t *AddObservationsTool) Name() string {
+	return "add_observations"
+}
+
+// Description returns the description of the tool
+func (t *AddObservationsTool) Description() string {
+	return "Adds new observations to entities in the knowledge graph"
+}
+
+// Schema returns the JSON schema for the tool's parameters
+func (t *AddObservationsTool) Schema() json.RawMessage {
+	return addObservationsSchemaJSON
+}
+
+// Execute decodes and checks the input and calls addObservations
+func (t *AddObservationsTool) Execute(params json.RawMessage) (interface{}, error) {
+	var input addObservationsInput
+	if err := decodeToolInput(params, &input, "observations"); err != nil {
+		return nil, err
+	}
+	return t.addObservations(input)
+}
+
+//go:embed schemas/bulk_update_metadata.json
+var bulkUpdateMetadataSchemaJSON []byte
+
+// Name returns the name of the tool
+func (t *BulkUpdateMetadataTool) Name() string {
+	return "bulk_update_metadata"
+}
+
+// Description returns the description of the tool
+func (t *BulkUpdateMetadataTool) Description() string {
+	return "Updates metadata for multiple entities matching filter criteria (type, name_contains, etc.). Supports 'merge' (default), 'replace', 'delete' operations and nested paths."
+}
+
+// Schema returns the JSON schema for the tool's parameters
+func (t *BulkUpdateMetadataTool) Schema() json.RawMessage {
+	return bulkUpdateMetadataSchemaJSON
+}
+
+// Execute decodes and checks the input and calls bulkUpdateMetadata
+func (t *BulkUpdateMetadataTool) Execute(params json.RawMessage) (interface{}, error) {
+	var input BulkUpdateMetadataToolInput
+	if err := decodeToolInput(params, &input, "filter", "updates"); err != nil {
+		return nil, err
+	}
+	switch input.Operation {
+	case "merge", "replace", "delete", "":
+	default:
+		return nil, fmt.Errorf("argument %q must be one of %s", "operation", "merge, replace, delete")
+	}
+	return t.bulkUpdateMetadata(input)
+}
+
+//go:embed schemas/create_entities.json
+var createEntitiesSchemaJSON []byte
+
+// Name returns the name of the tool
+func (t *CreateEntitiesTool) Name() string {
+	return "create_entities"
+}
+
+// Description returns the description of the tool
+func (t *CreateEntitiesTool) Description() string {
+	return "Creates new entities in the knowledge graph."
+}
+
+// Schema returns the JSON schema for the tool's parameters
+func (t *CreateEntitiesTool) Schema() json.RawMessage {
+	return createEntitiesSchemaJSON
+}
+
+// Execute decodes and checks the input and calls createEntities
+func (t *CreateEntitiesTool) Execute(params json.RawMessage) (interface{}, error) {
+	var input createEntitiesInput
+	if err := decodeToolInput(params, &input, "entities"); err != nil {
+		return nil, err
+	}
+	return t.createEntities(input)
+}
+
+//go:embed schemas/create_relations.json
+var createRelationsSchemaJSON []byte
+
+// Name returns the name of the tool
+func (t *CreateRelationsTool) Name() string {
+	return "create_relations"
+}
+
+// Description returns the description of the tool
+func (t *CreateRelationsTool) Description() string {
+	return "Creates new relations between entities in the knowledge graph"
+}
+
+// Schema returns the JSON schema for the tool's parameters
+func (t *CreateRelationsTool) Schema() json.RawMessage {
+	return createRelationsSchemaJSON
+}
+
+// Execute decodes and checks the input and calls createRelations
+func (t *CreateRelationsTool) Execute(params json.RawMessage) (interface{}, error) {
+	var input createRelationsInput
+	if err := decodeToolInput(params, &input, "relations"); err != nil {
+		return nil, err
+	}
+	return t.createRelations(input)
+}
+
+//go:embed schemas/delete_entities.json
+var deleteEntitiesSchemaJSON []byte
+
+// Name returns the name of the tool
+func (t *DeleteEntitiesTool) Name() string {
+	return "delete_entities"
+}
+
+// Description returns the description of the tool
+func (t *DeleteEntitiesTool) Description() string {
+	return "Deletes entities from the knowledge graph by their unique IDs"
+}
+
+// Schema returns the JSON schema for the tool's parameters
+func (t *DeleteEntitiesTool) Schema() json.RawMessage {
+	return deleteEntitiesSchemaJSON
+}
+
+// Execute calls ExecuteContext without a deadline
+func (t *DeleteEntitiesTool) Execute(params json.RawMessage) (interface{}, error) {
+	return t.ExecuteContext(context.Background(), params)
+}
+
+// ExecuteContext decodes and checks the input and calls deleteEntities
+func (t *DeleteEntitiesTool) ExecuteContext(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var input deleteEntitiesInput
+	if err := decodeToolInput(params, &input, "entityIds"); err != nil {
+		return nil, err
+	}
+	return t.deleteEntities(ctx, input)
+}
+
+//go:embed schemas/delete_observations.json
+var deleteObservationsSchemaJSON []byte
+
+// Name returns the name of the tool
+func (t *DeleteObservationsTool) Name() string {
+	return "delete_observations"
+}
+
+// Description returns the description of the tool
+func (t *DeleteObservationsTool) Description() string {
+	return "Deletes observations from the knowledge graph"
+}
+
+// Schema returns the JSON schema for the tool's parameters
+func (t *DeleteObservationsTool) Schema() json.RawMessage {
+	return deleteObservationsSchemaJSON
+}
+
+// Execute calls ExecuteContext without a deadline
+func (t *DeleteObservationsTool) Execute(params json.RawMessage) (interface{}, error) {
+	return t.ExecuteContext(context.Background(), params)
+}
+
+// ExecuteContext decodes and checks the input and calls deleteObservations
+func (t *DeleteObservationsTool) ExecuteContext(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var input deleteObservationsInput
+	if err := decodeToolInput(params, &input, "ids"); err != nil {
+		return nil, err
+	}
+	return t.deleteObservations(ctx, input)
+}
+
+//go:embed schemas/delete_relations.json
+var deleteRelationsSchemaJSON []byte
+
+// Name returns the name of the tool
+func (t *DeleteRelationsTool) Name() string {
+	return "delete_relations"
+}
+
+// Description returns the description of the tool
+func (t *DeleteRelationsTool) Description() string {
+	return "Deletes relations from the knowledge graph"
+}
+
+// Schema returns the JSON schema for the tool's parameters
+func (t *DeleteRelationsTool) Schema() json.RawMessage {
+	return deleteRelationsSchemaJSON
+}
+
+// Execute calls ExecuteContext without a deadline
+func (t *DeleteRelationsTool) Execute(params json.RawMessage) (interface{}, error) {
+	return t.ExecuteContext(context.Background(), params)
+}
+
+// ExecuteContext decodes and checks the input and calls deleteRelations
+func (t *DeleteRelationsTool) ExecuteContext(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var input deleteRelationsInput
+	if err := decodeToolInput(params, &input, "relations"); err != nil {
+		return nil, err
+	}
+	return t.deleteRelations(ctx, input)
+}
+
+//go:embed schemas/find_paths.json
+var findPathsSchemaJSON []byte
+
+// Name returns the name of the tool
+func (t *FindPathsTool) Name() string {
+	return "find_paths"
+}
+
+// Description returns the description of the tool
+func (t *FindPathsTool) Description() string {
+	return "Finds all simple paths (no repeated nodes) between a start and end entity, up to a maximum length."
+}
+
+// Schema returns the JSON schema for the tool's parameters
+func (t *FindPathsTool) Schema() json.RawMessage {
+	return findPathsSchemaJSON
+}
+
+// Annotations returns the hints of the tool
+func (t *FindPathsTool) Annotations() mcp.ToolAnnotations {
+	return mcp.ToolAnnotations{ReadOnlyHint: true}
+}
+
+// Execute decodes and checks the input and calls findPaths
+func (t *FindPathsTool) Execute(params json.RawMessage) (interface{}, error) {
+	var input findPathsInput
+	if err := decodeToolInput(params, &input, "start_node_id", "end_node_id"); err != nil {
+		return nil, err
+	}
+	return t.findPaths(input)
+}
+
+//go:embed schemas/get_entity_timeline.json
+var getEntityTimelineSchemaJSON []byte
+
+// Name returns the name of the tool
+func (t *GetEntityTimelineTool) Name() string {
+	return "get_entity_timeline"
+}
+
+// Description returns the description of the tool
+func (t *GetEntityTimelineTool) Description() string {
+	return "Retrieves a chronological timeline of observations for a specific entity, optionally filtered by time range, observation type, and tags."
+}
+
+// Schema returns the JSON schema for the tool's parameters
+func (t *GetEntityTimelineTool) Schema() json.RawMessage {
+	return getEntityTimelineSchemaJSON
+}
+
+// Annotations returns the hints of the tool
+func (t *GetEntityTimelineTool) Annotations() mcp.ToolAnnotations {
+	return mcp.ToolAnnotations{ReadOnlyHint: true}
+}
+
+// Execute decodes and checks the input and calls getEntityTimeline
+func (t *GetEntityTimelineTool) Execute(params json.RawMessage) (interface{}, error) {
+	var input GetEntityTimelineToolInput
+	if err := decodeToolInput(params, &input, "entity_id"); err != nil {
+		return nil, err
+	}
+	return t.getEntityTimeline(input)
+}
+
+//go:embed schemas/get_subgraph.json
+var getSubgraphSchemaJSON []byte
+
+// Name returns the name of the tool
+func (t *GetSubgraphTool) Name() string {
+	return "get_subgraph"
+}
+
+// Description returns the description of the tool
+func (t *GetSubgraphTool) Description() string {
+	return "Extracts a subgraph containing all entities within a specified radius (hops) of the start nodes, including the relations connecting them."
+}
+
+// Schema returns the JSON schema for the tool's parameters
+func (t *GetSubgraphTool) Schema() json.RawMessage {
+	return getSubgraphSchemaJSON
+}
+
+// Annotations returns the hints of the tool
+func (t *GetSubgraphTool) Annotations() mcp.ToolAnnotations {
+	return mcp.ToolAnnotations{ReadOnlyHint: true}
+}
+
+// Execute decodes and checks the input and calls getSubgraph
+func (t *GetSubgraphTool) Execute(params json.RawMessage) (interface{}, error) {
+	var input getSubgraphInput
+	if err := decodeToolInput(params, &input, "start_node_ids", "radius"); err != nil {
+		return nil, err
+	}
+	if input.Radius < 0 {
+		return nil, fmt.Errorf("argument %q must be at least 0", "radius")
+	}
+	return t.getSubgraph(input)
+}
+
+//go:embed schemas/open_nodes.json
+var openNodesSchemaJSON []byte
+
+// Name returns the name of the tool
+func (t *OpenNodesTool) Name() string {
+	return "open_nodes"
+}
+
+// Description returns the description of the tool
+func (t *OpenNodesTool) Description() string {
+	return "Returns all entities connected to the given entity IDs"
+}
+
+// Schema returns the JSON schema for the tool's parameters
+func (t *OpenNodesTool) Schema() json.RawMessage {
+	return openNodesSchemaJSON
+}
+
+// Annotations returns the hints of the tool
+func (t *OpenNodesTool) Annotations() mcp.ToolAnnotations {
+	return mcp.ToolAnnotations{ReadOnlyHint: true}
+}
+
+// Execute decodes and checks the input and calls openNodes
+func (t *OpenNodesTool) Execute(params json.RawMessage) (interface{}, error) {
+	var input OpenNodesInput
+	if err := decodeToolInput(params, &input, "node_ids"); err != nil {
+		return nil, err
+	}
+	return t.openNodes(input)
+}
+
+//go:embed schemas/read_graph.json
+var readGraphSchemaJSON []byte
+
+// Name returns the name of the tool
+func (t *ReadGraphTool) Name() string {
+	return "read_graph"
+}
+
+// Description returns the description of the tool
+func (t *ReadGraphTool) Description() string {
+	return "Returns the current state of the knowledge graph"
+}
+
+// Schema returns the JSON schema for the tool's parameters
+func (t *ReadGraphTool) Schema() json.RawMessage {
+	return readGraphSchemaJSON
+}
+
+// Annotations returns the hints of the tool
+func (t *ReadGraphTool) Annotations() mcp.ToolAnnotations {
+	return mcp.ToolAnnotations{ReadOnlyHint: true}
+}
+
+// Execute decodes and checks the input and calls readGraph
+func (t *ReadGraphTool) Execute(params json.RawMessage) (interface{}, error) {
+	var input readGraphInput
+	if err := decodeToolInput(params, &input); err != nil {
+		return nil, err
+	}
+	return t.readGraph(input)
+}
+
+//go:embed schemas/search_nodes.json
+var searchNodesSchemaJSON []byte
+
+// Name returns the name of the tool
+func (t *SearchNodesTool) Name() string {
+	return "search_nodes"
+}
+
+// Description returns the description of the tool
+func (t *SearchNodesTool) Description() string {
+	return "Searches for entities based on type and metadata"
+}
+
+// Schema returns the JSON schema for the tool's parameters
+func (t *SearchNodesTool) Schema() json.RawMessage {
+	return searchNodesSchemaJSON
+}
+
+// Annotations returns the hints of the tool
+func (t *SearchNodesTool) Annotations() mcp.ToolAnnotations {
+	return mcp.ToolAnnotations{ReadOnlyHint: true}
+}
+
+// Execute decodes and checks the input and calls searchNodes
+func (t *SearchNodesTool) Execute(params json.RawMessage) (interface{}, error) {
+	var input searchNodesInput
+	if err := decodeToolInput(params, &input); err != nil {
+		return nil, err
+	}
+	return t.searchNodes(input)
+}
+
+//go:embed schemas/traverse_graph.json
+var traverseGraphSchemaJSON []byte
+
+// Name returns the name of the tool
+func (t *TraverseGraphTool) Name() string {
+	return "traverse_graph"
+}
+
+// Description returns the description of the tool
+func (t *TraverseGraphTool) Description() string {
+	return "Performs graph traversal (BFS or DFS) starting from given nodes, returning visited nodes and depths."
+}
+
+// Schema returns the JSON schema for the tool's parameters
+func (t *TraverseGraphTool) Schema() json.RawMessage {
+	return traverseGraphSchemaJSON
+}
+
+// Annotations returns the hints of the tool
+func (t *TraverseGraphTool) Annotations() mcp.ToolAnnotations {
+	return mcp.ToolAnnotations{ReadOnlyHint: true}
+}
+
+// Execute decodes and checks the input and calls traverseGraph
+func (t *TraverseGraphTool) Execute(params json.RawMessage) (interface{}, error) {
+	var input traverseGraphInput
+	if err := decodeToolInput(params, &input, "start_node_ids"); err != nil {
+		return nil, err
+	}
+	switch input.Algorithm {
+	case "BFS", "DFS", "":
+	default:
+		return nil, fmt.Errorf("argument %q must be one of %s", "algorithm", "BFS, DFS")
+	}
+	return t.traverseGraph(input)
+}
+
+//go:embed schemas/update_entities.json
+var updateEntitiesSchemaJSON []byte
+
+// Name returns the name of the tool
+func (t *UpdateEntitiesTool) Name() string {
+	return "update_entities"
+}
+
+// Description returns the description of the tool
+func (t *UpdateEntitiesTool) Description() string {
+	return "Performs partial updates on existing entities in the knowledge graph."
+}
+
+// Schema returns the JSON schema for the tool's parameters
+func (t *UpdateEntitiesTool) Schema() json.RawMessage {
+	return updateEntitiesSchemaJSON
+}
+
+// Execute decodes and checks the input and calls updateEntities
+func (t *UpdateEntitiesTool) Execute(params json.RawMessage) (interface{}, error) {
+	var input updateEntitiesInput
+	if err := decodeToolInput(params, &input, "entities"); err != nil {
+		return nil, err
+	}
+	return t.updateEntities(input)
+}
+
+//go:embed schemas/update_entity_metadata.json
+var updateEntityMetadataSchemaJSON []byte
+
+// Name returns the name of the tool
+func (t *UpdateEntityMetadataTool) Name() string {
+	return "update_entity_metadata"
+}
+
+// Description returns the description of the tool
+func (t *UpdateEntityMetadataTool) Description() string {
+	return "Updates metadata for a single entity specified by 'entity_id'. Supports 'merge' (default), 'replace', 'delete' operations and nested paths."
+}
+
+// Schema returns the JSON schema for the tool's parameters
+func (t *UpdateEntityMetadataTool) Schema() json.RawMessage {
+	return updateEntityMetadataSchemaJSON
+}
+
+// Execute decodes and checks the input and calls updateEntityMetadata
+func (t *UpdateEntityMetadataTool) Execute(params json.RawMessage) (interface{}, error) {
+	var input UpdateEntityMetadataToolInput
+	if err := decodeToolInput(params, &input, "entity_id", "updates"); err != nil {
+		return nil, err
+	}
+	switch input.Operation {
+	case "merge", "replace", "delete", "":
+	default:
+		return nil, fmt.Errorf("argument %q must be one of %s", "operation", "merge, replace, delete")
+	}
+	return t.updateEntityMetadata(input)
+}
+
+// decodeToolInput decodes the parameters of a tool into input after checking
+// that the required arguments are present
+func decodeToolInput(params json.RawMessage, input interface{}, required ...string) error {
+	if len(params) == 0 {
+		params = json.RawMessage("{}")
+	}
+	var args map[string]json.RawMessage
+	if err := json.Unmarshal(params, &args); err != nil {
+		return fmt.Errorf("failed to parse input: %w", err)
+	}
+	for _, name := range required {
+		if _, ok := args[name]; !ok {
+			return fmt.Errorf("missing required argument %q", name)
+		}
+	}
+	if err := json.Unmarshal(params, input); err != nil {
+		return fmt.Errorf("failed to parse input: %w", err)
+	}
+	return nil
+}
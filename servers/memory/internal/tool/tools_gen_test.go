@@ -0,0 +1,63 @@
+// Code generated by mcp-toolgen. DO NOT EDIT.
+
+package tool
+
+import (
+	"encoding/json"
+	"testing"
+
+	"mcp-go-sdk"
+)
+
+func TestGeneratedTools(t *testing.T) {
+	tests := []struct {
+		tool     mcp.Tool
+		name     string
+		required bool
+	}{
+		{&AddObservationsTool{}, "add_observations", true},
+		{&BulkUpdateMetadataTool{}, "bulk_update_metadata", true},
+		{&CreateEntitiesTool{}, "create_entities", true},
+		{&CreateRelationsTool{}, "create_relations", true},
+		{&DeleteEntitiesTool{}, "delete_entities", true},
+		{&DeleteObservationsTool{}, "delete_observations", true},
+		{&DeleteRelationsTool{}, "delete_relations", true},
+		{&FindPathsTool{}, "find_paths", true},
+		{&GetEntityTimelineTool{}, "get_entity_timeline", true},
+		{&GetSubgraphTool{}, "get_subgraph", true},
+		{&OpenNodesTool{}, "open_nodes", true},
+		{&ReadGraphTool{}, "read_graph", false},
+		{&SearchNodesTool{}, "search_nodes", false},
+		{&TraverseGraphTool{}, "traverse_graph", true},
+		{&UpdateEntitiesTool{}, "update_entities", true},
+		{&UpdateEntityMetadataTool{}, "update_entity_metadata", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.tool.Name() != tt.name {
+				t.Errorf("Expected name %s, got %s", tt.name, tt.tool.Name())
+			}
+			if tt.tool.Description() == "" {
+				t.Error("Expected a description")
+			}
+			var schema struct {
+				Type string `json:"type"`
+			}
+			if err := json.Unmarshal(tt.tool.Schema(), &schema); err != nil || schema.Type != "object" {
+				t.Errorf("Expected an object schema, got %s (%v)", tt.tool.Schema(), err)
+			}
+			if _, err := tt.tool.Execute(json.RawMessage("{")); err == nil {
+				t.Error("Expected invalid JSON to be rejected")
+			}
+			// The tools are zero values, so only inputs that are rejected
+			// before the method runs are tried
+			if !tt.required {
+				return
+			}
+			if _, err := tt.tool.Execute(json.RawMessage("{}")); err == nil {
+				t.Error("Expected missing arguments to be rejected")
+			}
+		})
+	}
+}
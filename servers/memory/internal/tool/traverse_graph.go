@@ -1,18 +1,12 @@
 package tool
 
 import (
-	_ "embed" // Required for go:embed directive
-	"encoding/json"
 	"fmt"
 
 	"mcp-go-sdk"
 	"mcp-memory/internal/graph"
-	// No need for types here, graph package provides params/results
 )
 
-//go:embed schemas/traverse_graph.json
-var traverseGraphSchemaJSON []byte // Use []byte for json.RawMessage
-
 // TraverseGraphTool implements the Tool interface for traversing the graph
 type TraverseGraphTool struct {
 	manager *graph.KnowledgeGraphManager
@@ -25,36 +19,25 @@ func NewTraverseGraphTool(manager *graph.KnowledgeGraphManager) mcp.Tool {
 	}
 }
 
-// Name returns the name of the tool
-func (t *TraverseGraphTool) Name() string {
-	return "traverse_graph"
-}
+type traverseGraphInput struct {
+	// IDs of the nodes to start the traversal from.
+	StartNodeIDs []string `json:"start_node_ids"`
 
-// Description returns the description of the tool
-func (t *TraverseGraphTool) Description() string {
-	return "Performs graph traversal (BFS or DFS) starting from given nodes, returning visited nodes and depths."
-}
+	// Traversal algorithm (BFS or DFS), BFS if omitted.
+	Algorithm graph.TraversalAlgorithm `json:"algorithm,omitempty" mcp:"enum=BFS|DFS"`
 
-// Schema returns the JSON schema for the tool's parameters
-func (t *TraverseGraphTool) Schema() json.RawMessage {
-	// Return the embedded schema content directly
-	return traverseGraphSchemaJSON
-}
+	// Maximum depth for the traversal (-1 for unlimited), 10 if omitted.
+	MaxDepth *int `json:"max_depth,omitempty"`
 
-// Execute performs the graph traversal
-func (t *TraverseGraphTool) Execute(params json.RawMessage) (interface{}, error) {
-	// Define a temporary struct to unmarshal the full input including tool filters
-	var input struct {
-		StartNodeIDs []string                 `json:"start_node_ids"`
-		Algorithm    graph.TraversalAlgorithm `json:"algorithm"`
-		MaxDepth     int                      `json:"max_depth"`
-		Filters      *TraversalFilters        `json:"filters"` // Use tool filter type
-	}
-
-	if err := json.Unmarshal(params, &input); err != nil {
-		return formatError(fmt.Errorf("failed to parse input: %w", err)), nil
-	}
+	// Filters to apply during traversal.
+	Filters *TraversalFilters `json:"filters,omitempty"`
+}
 
+// traverseGraph performs graph traversal (BFS or DFS) starting from given nodes, returning visited nodes and depths.
+//
+//mcp:tool traverse_graph
+//mcp:readonly
+func (t *TraverseGraphTool) traverseGraph(input traverseGraphInput) (interface{}, error) {
 	// Translate tool filters to internal graph filters
 	var internalFilters *graph.TraversalFiltersInternal
 	if input.Filters != nil {
@@ -75,9 +58,13 @@ func (t *TraverseGraphTool) Execute(params json.RawMessage) (interface{}, error)
 	graphParams := graph.TraverseParams{
 		StartNodeIDs: input.StartNodeIDs,
 		Algorithm:    input.Algorithm,
-		MaxDepth:     input.MaxDepth,
+		MaxDepth:     10,
 		Filters:      internalFilters,
 	}
+	// The schema promises a depth of 10 if max_depth is not provided
+	if input.MaxDepth != nil {
+		graphParams.MaxDepth = *input.MaxDepth
+	}
 
 	// Default algorithm if empty or not provided
 	if graphParams.Algorithm == "" {
@@ -1,8 +1,6 @@
 package tool
 
 import (
-	_ "embed" // Required for go:embed directive
-	"encoding/json"
 	"fmt"
 
 	"mcp-go-sdk"
@@ -10,9 +8,6 @@ import (
 	"mcp-memory/internal/types"
 )
 
-//go:embed schemas/update_entities.json
-var updateEntitiesSchemaJSON []byte // Use []byte for json.RawMessage
-
 // UpdateEntitiesTool implements the Tool interface for partially updating entities in batch
 type UpdateEntitiesTool struct {
 	manager *graph.KnowledgeGraphManager
@@ -25,44 +20,52 @@ func NewUpdateEntitiesTool(manager *graph.KnowledgeGraphManager) mcp.Tool {
 	}
 }
 
-// Name returns the name of the tool
-func (t *UpdateEntitiesTool) Name() string {
-	return "update_entities"
+// Invalidates implements mcp.CacheInvalidator
+func (t *UpdateEntitiesTool) Invalidates() []string {
+	return nil
 }
 
-// Description returns the description of the tool
-func (t *UpdateEntitiesTool) Description() string {
-	return "Performs partial updates on existing entities in the knowledge graph."
+// entityUpdate is a partial update of an entity
+type entityUpdate struct {
+	// The unique identifier of the entity to update.
+	ID string `json:"id"`
 
-}
+	// Optional. The new type of the entity.
+	Type string `json:"type,omitempty"`
 
-// Schema returns the JSON schema for the tool's parameters
-func (t *UpdateEntitiesTool) Schema() json.RawMessage {
-	return updateEntitiesSchemaJSON
-}
+	// Optional. The new name of the entity.
+	Name string `json:"name,omitempty"`
 
-// Execute performs partial updates on entities in batch
-func (t *UpdateEntitiesTool) Execute(params json.RawMessage) (interface{}, error) {
-	var input struct {
-		Entities []types.Entity `json:"entities"`
-	}
+	// Optional. The new description of the entity. Provide empty string to clear.
+	Description string `json:"description,omitempty"`
 
-	if err := json.Unmarshal(params, &input); err != nil {
-		return formatError(fmt.Errorf("failed to parse input: %w", err)), nil
-	}
+	// Optional. Metadata fields to merge into the entity's existing metadata.
+	Metadata map[string]interface{} `json:"metadata,omitempty"`
+}
+
+type updateEntitiesInput struct {
+	// An array of entity objects to partially update.
+	Entities []entityUpdate `json:"entities"`
+}
 
+// updateEntities performs partial updates on existing entities in the knowledge graph.
+//
+//mcp:tool update_entities
+func (t *UpdateEntitiesTool) updateEntities(input updateEntitiesInput) (interface{}, error) {
 	if len(input.Entities) == 0 {
 		return formatError(fmt.Errorf("entities array cannot be empty")), nil
 	}
 
 	// Basic validation: ensure all entities have an ID before calling manager
+	entities := make([]types.Entity, len(input.Entities))
 	for i, entity := range input.Entities {
 		if entity.ID == "" {
 			return formatError(fmt.Errorf("entity at index %d is missing the required 'id' field", i)), nil
 		}
+		entities[i] = types.Entity(entity)
 	}
 
-	updatedEntities, err := t.manager.UpdateEntities(input.Entities)
+	updatedEntities, err := t.manager.UpdateEntities(entities)
 	if err != nil {
 		// Error already includes specifics from the manager
 		return formatError(fmt.Errorf("failed to update entities: %w", err)), nil
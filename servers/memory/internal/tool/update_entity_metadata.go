@@ -1,24 +1,22 @@
 package tool
 
 import (
-	_ "embed" // Required for go:embed directive
-	"encoding/json"
 	"fmt"
-	"strings"
 
 	"mcp-go-sdk"
 	"mcp-memory/internal/graph"
-	// "mcp-memory/internal/types" // Removed as it's unused in this specific tool file
 )
 
-//go:embed schemas/update_entity_metadata.json
-var updateEntityMetadataSchemaJSON []byte // Use []byte for json.RawMessage
-
 // UpdateEntityMetadataToolInput represents the input structure for the update_entity_metadata tool.
 type UpdateEntityMetadataToolInput struct {
-	EntityID  string                 `json:"entity_id"` // Now required by schema
-	Updates   map[string]interface{} `json:"updates"`
-	Operation string                 `json:"operation,omitempty"` // "merge", "replace", "delete". Defaults to "merge".
+	// ID of the single entity to update.
+	EntityID string `json:"entity_id"`
+
+	// Metadata updates to apply (keys are dot-paths, values are data).
+	Updates map[string]interface{} `json:"updates"`
+
+	// Operation: 'merge' (default), 'replace', 'delete'.
+	Operation string `json:"operation,omitempty" mcp:"enum=merge|replace|delete"`
 }
 
 // UpdateEntityMetadataTool is a tool for updating a single entity's metadata.
@@ -33,36 +31,19 @@ func NewUpdateEntityMetadataTool(manager *graph.KnowledgeGraphManager) *UpdateEn
 	}
 }
 
-// Name returns the name of the tool
-func (t *UpdateEntityMetadataTool) Name() string {
-	return "update_entity_metadata"
-}
-
-// Description returns the description of the tool
-func (t *UpdateEntityMetadataTool) Description() string {
-	return "Updates metadata for a single entity specified by 'entity_id'. Supports 'merge' (default), 'replace', 'delete' operations and nested paths."
+// Invalidates implements mcp.CacheInvalidator
+func (t *UpdateEntityMetadataTool) Invalidates() []string {
+	return nil
 }
 
-// Schema returns the JSON schema for the tool's parameters
-func (t *UpdateEntityMetadataTool) Schema() json.RawMessage {
-	return updateEntityMetadataSchemaJSON
-}
-
-// Execute performs the metadata update operation for a single entity
-func (t *UpdateEntityMetadataTool) Execute(params json.RawMessage) (interface{}, error) {
-	var input UpdateEntityMetadataToolInput
-	if err := json.Unmarshal(params, &input); err != nil {
-		// Basic validation happens via schema now, but keep unmarshal check
-		return formatError(fmt.Errorf("failed to parse input parameters: %w", err)), nil
-	}
-
-	// Schema ensures EntityID and Updates are present.
-	// We still need to validate the operation string.
-	op := strings.ToLower(input.Operation)
+// updateEntityMetadata updates metadata for a single entity specified by 'entity_id'. Supports 'merge' (default), 'replace', 'delete' operations and nested paths.
+//
+//mcp:tool update_entity_metadata
+func (t *UpdateEntityMetadataTool) updateEntityMetadata(input UpdateEntityMetadataToolInput) (interface{}, error) {
+	// The generated Execute checks the operation against the enum
+	op := input.Operation
 	if op == "" {
 		op = "merge" // Default operation
-	} else if op != "merge" && op != "replace" && op != "delete" {
-		return formatError(fmt.Errorf("invalid operation '%s'. Must be 'merge', 'replace', or 'delete'", input.Operation)), nil
 	}
 
 	// Execute single update via the graph manager
@@ -4,35 +4,74 @@ import "time"
 
 // Entity represents a node in the knowledge graph
 type Entity struct {
-	ID          string                 `json:"id"`
-	Type        string                 `json:"type"`
-	Name        string                 `json:"name"`
-	Description string                 `json:"description,omitempty"`
-	Metadata    map[string]interface{} `json:"metadata,omitempty"`
+	// Optional. A unique identifier for the entity. If not provided, one will be generated.
+	ID string `json:"id" mcp:"optional"`
+
+	// The type of the entity
+	Type string `json:"type"`
+
+	// The name of the entity
+	Name string `json:"name"`
+
+	// Optional. A description of the entity
+	Description string `json:"description,omitempty"`
+
+	// Optional. Additional metadata for the entity
+	Metadata map[string]interface{} `json:"metadata,omitempty"`
 }
 
 // Relation represents a connection between two entities
 type Relation struct {
-	ID            string                 `json:"id"`
-	Type          string                 `json:"type"`
-	Source        string                 `json:"source"`
-	Target        string                 `json:"target"`
-	Description   string                 `json:"description,omitempty"`
-	Metadata      map[string]interface{} `json:"metadata,omitempty"`
-	Weight        float64                `json:"weight,omitempty"`
-	Bidirectional bool                   `json:"bidirectional,omitempty"`
+	// Optional. A unique identifier for the relation. If not provided, one will be generated.
+	ID string `json:"id" mcp:"optional"`
+
+	// The type of the relation
+	Type string `json:"type"`
+
+	// The ID of the source entity
+	Source string `json:"source"`
+
+	// The ID of the target entity
+	Target string `json:"target"`
+
+	// Optional. A description of the relation
+	Description string `json:"description,omitempty"`
+
+	// Optional. Additional metadata for the relation
+	Metadata map[string]interface{} `json:"metadata,omitempty"`
+
+	// Optional. The strength of the relation
+	Weight float64 `json:"weight,omitempty"`
+
+	// Optional. Whether the relation holds in both directions
+	Bidirectional bool `json:"bidirectional,omitempty"`
 }
 
 // Observation represents a piece of information about an entity
 type Observation struct {
-	ID          string                 `json:"id"`
-	EntityID    string                 `json:"entity_id"`
-	Type        string                 `json:"type"`
-	Content     string                 `json:"content"`
-	Description string                 `json:"description,omitempty"`
-	Metadata    map[string]interface{} `json:"metadata,omitempty"`
-	Timestamp   time.Time              `json:"timestamp"`
-	Tags        []string               `json:"tags,omitempty"`
+	// Optional. A unique identifier for the observation. If not provided, one will be generated.
+	ID string `json:"id" mcp:"optional"`
+
+	// The ID of the entity this observation belongs to
+	EntityID string `json:"entity_id"`
+
+	// The type of the observation
+	Type string `json:"type"`
+
+	// The content of the observation
+	Content string `json:"content"`
+
+	// Optional. A description of the observation
+	Description string `json:"description,omitempty"`
+
+	// Optional. Additional metadata for the observation
+	Metadata map[string]interface{} `json:"metadata,omitempty"`
+
+	// Optional. When the observation was made, the time it is added if not provided
+	Timestamp time.Time `json:"timestamp" mcp:"optional"`
+
+	// Optional. Tags for the observation
+	Tags []string `json:"tags,omitempty"`
 }
 
 // KnowledgeGraph represents the in-memory graph structure
@@ -50,8 +89,14 @@ type KnowledgeGraphResult struct {
 
 // EntityFilterCriteria defines criteria for filtering entities
 type EntityFilterCriteria struct {
-	Type                string `json:"type,omitempty"`
-	NameContains        string `json:"name_contains,omitempty"`
+	// Filter entities by type (exact match).
+	Type string `json:"type,omitempty"`
+
+	// Filter entities whose name contains this substring (case-sensitive).
+	NameContains string `json:"name_contains,omitempty"`
+
+	// Filter entities whose description contains this substring (case-sensitive).
 	DescriptionContains string `json:"description_contains,omitempty"`
+
 	// TODO: Add metadata filters? (e.g., MetadataHasKey, MetadataEquals)
 }
@@ -1,13 +1,17 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
+	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"mcp-go-sdk"
+	"mcp-go-sdk/policy"
 	"mcp-go-sdk/server"
 	"mcp-go-sdk/transport"
 	"mcp-memory/internal/graph"
@@ -43,8 +47,16 @@ func getDefaultMemoryPath() (string, error) {
 
 func main() {
 	// Parse command-line flags
-	var memoryPath string
+	var memoryPath, sseAddr, listenAddr, policyPath, auditPath string
+	var cacheTTL time.Duration
+	var maxOutput int
 	flag.StringVar(&memoryPath, "path", "", "Path to the memory file (required)")
+	flag.StringVar(&sseAddr, "sse-addr", "", "Serve the HTTP+SSE transport on this address instead of stdio")
+	flag.StringVar(&listenAddr, "listen", "", "Accept connections on a TCP address or unix:/path socket instead of stdio")
+	flag.DurationVar(&cacheTTL, "cache-ttl", time.Minute, "How long to cache the results of read-only tools, 0 to turn caching off")
+	flag.IntVar(&maxOutput, "max-output", 64<<10, "Bytes of text a tool result may carry before the rest is left for paged reading, 0 for no limit")
+	flag.StringVar(&policyPath, "policy", "", "Path to a policy file deciding which tool calls may run, reloaded as it changes")
+	flag.StringVar(&auditPath, "audit-log", "", "File the policy decisions are appended to (default standard error)")
 	flag.Parse()
 
 	// Trim any whitespace
@@ -92,8 +104,24 @@ func main() {
 	// Create knowledge graph manager
 	manager := graph.NewKnowledgeGraphManager(memoryPath)
 
-	// Create a new server with stdin/stdout transport
-	srv := server.NewServer(transport.NewStdioTransport())
+	// Create a server shared by all sessions. Writes drop the cached
+	// results of reads, and large graphs are read in pages.
+	config := server.DefaultConfig()
+	if cacheTTL > 0 {
+		config.Cache = &server.CacheConfig{TTL: cacheTTL}
+	}
+	if maxOutput > 0 {
+		config.Output = &server.OutputConfig{MaxBytes: maxOutput}
+	}
+	if policyPath != "" {
+		engine, err := policy.OpenEngine(context.Background(), policyPath, auditPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading policy: %v\n", err)
+			os.Exit(1)
+		}
+		config.Policy = engine
+	}
+	srv := server.NewServerWithConfig(nil, config)
 
 	// Register all tools
 	tools := []mcp.Tool{
@@ -123,9 +151,38 @@ func main() {
 		}
 	}
 
-	// Start the server
-	if err := srv.Start(); err != nil {
+	switch {
+	case sseAddr != "":
+		// Serve over HTTP+SSE, one session per connection
+		handler := transport.NewSSEHandler(srv.ServeTransport, nil)
+		mux := http.NewServeMux()
+		mux.Handle("/sse", handler)
+		mux.Handle("/messages", handler)
+
+		fmt.Fprintf(os.Stderr, "Serving memory at http://%s/sse\n", sseAddr)
+		err = http.ListenAndServe(sseAddr, mux)
+	case listenAddr != "":
+		// Serve newline-delimited JSON-RPC, one session per connection
+		network, address := parseListenAddr(listenAddr)
+		fmt.Fprintf(os.Stderr, "Serving memory on %s %s\n", network, address)
+		err = srv.ListenAndServe(network, address)
+	default:
+		// Serve a single client over stdin/stdout
+		err = srv.ServeTransport(transport.NewStdioTransport())
+	}
+
+	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error starting server: %v\n", err)
 		os.Exit(1)
 	}
 }
+
+// parseListenAddr splits a listen address such as "unix:/tmp/memory.sock" or
+// "tcp:localhost:7000" into network and address. Addresses without a prefix
+// are TCP.
+func parseListenAddr(addr string) (string, string) {
+	if strings.HasPrefix(addr, "unix:") {
+		return "unix", strings.TrimPrefix(addr, "unix:")
+	}
+	return "tcp", strings.TrimPrefix(addr, "tcp:")
+}
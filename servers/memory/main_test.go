@@ -0,0 +1,195 @@
+package main
+
+import (
+	"context"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"mcp-go-sdk"
+	"mcp-go-sdk/client"
+	"mcp-go-sdk/policy"
+	"mcp-go-sdk/server"
+	"mcp-go-sdk/servertest"
+	"mcp-go-sdk/transport"
+	"mcp-memory/internal/graph"
+	"mcp-memory/internal/tool"
+	"mcp-memory/internal/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestServerProcess drives the built server over stdio the way a host does
+func TestServerProcess(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds and runs the server binary")
+	}
+
+	dir := t.TempDir()
+	binary := filepath.Join(dir, "mcp-memory")
+	build := exec.Command("go", "build", "-o", binary, ".")
+	out, err := build.CombinedOutput()
+	require.NoError(t, err, "build failed: %s", out)
+
+	tr, err := transport.NewCommandTransport(&transport.CommandConfig{
+		Command: binary,
+		Args:    []string{"--path", filepath.Join(dir, "memory.json")},
+		Logger:  log.New(io.Discard, "", 0),
+	})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	c := client.NewClient(tr, nil)
+
+	// Deletions ask for confirmation, answered from confirmations
+	confirmations := make(chan bool, 1)
+	asked := make(chan string, 2)
+	c.HandleElicitation(func(ctx context.Context, p *mcp.ElicitParams) (*mcp.ElicitResult, error) {
+		asked <- p.Message
+		return &mcp.ElicitResult{Action: mcp.ElicitAccept, Content: map[string]interface{}{"confirm": <-confirmations}}, nil
+	})
+
+	_, err = c.Initialize(ctx)
+	require.NoError(t, err)
+
+	tools, err := c.ListTools(ctx)
+	require.NoError(t, err)
+	assert.Len(t, tools, 17)
+
+	result, err := c.CallTool(ctx, "create_entities", map[string]interface{}{
+		"entities": []map[string]interface{}{{"name": "Ada", "type": "person"}},
+	})
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+
+	// The entity count comes back in the non-standard metadata field
+	var graph struct {
+		Content  []mcp.ToolContent `json:"content"`
+		Metadata struct {
+			EntityCount int `json:"entity_count"`
+		} `json:"metadata"`
+	}
+	err = c.Call(ctx, "tools/call", map[string]interface{}{"name": "read_graph", "arguments": map[string]interface{}{}}, &graph)
+	require.NoError(t, err)
+	assert.Equal(t, 1, graph.Metadata.EntityCount)
+	require.Len(t, graph.Content, 1)
+	assert.Contains(t, graph.Content[0].Text, `"Ada"`, "the graph should be in the text")
+
+	// Reads are cached until the next write
+	result, err = c.CallTool(ctx, "create_entities", map[string]interface{}{
+		"entities": []map[string]interface{}{{"id": "grace", "name": "Grace", "type": "person"}},
+	})
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+	err = c.Call(ctx, "tools/call", map[string]interface{}{"name": "read_graph", "arguments": map[string]interface{}{}}, &graph)
+	require.NoError(t, err)
+	assert.Equal(t, 2, graph.Metadata.EntityCount)
+
+	deleteGrace := map[string]interface{}{"entityIds": []string{"grace"}}
+	confirmations <- false
+	result, err = c.CallTool(ctx, "delete_entities", deleteGrace)
+	require.NoError(t, err)
+	assert.True(t, result.IsError, "a declined deletion should fail")
+	assert.Contains(t, <-asked, "grace")
+	confirmations <- true
+	result, err = c.CallTool(ctx, "delete_entities", deleteGrace)
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+	err = c.Call(ctx, "tools/call", map[string]interface{}{"name": "read_graph", "arguments": map[string]interface{}{}}, &graph)
+	require.NoError(t, err)
+	assert.Equal(t, 1, graph.Metadata.EntityCount)
+
+	assert.NoError(t, c.Close(), "server should exit when its stdin closes")
+}
+
+func TestConformance(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds and runs the server binary")
+	}
+	servertest.RunCommand(t, &transport.CommandConfig{
+		Command: servertest.Build(t, "."),
+		Args:    []string{"--path", filepath.Join(t.TempDir(), "memory.json")},
+		Logger:  log.New(io.Discard, "", 0),
+	}, &servertest.Options{
+		ErrorCall: &servertest.ToolCall{Name: "open_nodes", Arguments: map[string]interface{}{"names": "not a list"}},
+	})
+}
+
+func TestPolicy(t *testing.T) {
+	dir := t.TempDir()
+	policyPath := filepath.Join(dir, "policy.json")
+	auditPath := filepath.Join(dir, "audit.log")
+	require.NoError(t, os.WriteFile(policyPath, []byte(`{
+		"rules": [{"name": "keep-everything", "tools": ["delete_*"], "effect": "deny", "reason": "Nothing is deleted"}]
+	}`), 0644))
+
+	engine, err := policy.OpenEngine(context.Background(), policyPath, auditPath)
+	require.NoError(t, err)
+	config := server.DefaultConfig()
+	config.Policy = engine
+	srv := server.NewServerWithConfig(nil, config)
+	manager := graph.NewKnowledgeGraphManager(filepath.Join(dir, "memory.json"))
+	require.NoError(t, srv.RegisterTool(tool.NewDeleteEntitiesTool(manager)))
+
+	clientEnd, serverEnd := transport.NewInMemoryPair()
+	go srv.ServeTransport(serverEnd)
+	c := client.NewClient(clientEnd, nil)
+	defer c.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err = c.Initialize(ctx)
+	require.NoError(t, err)
+
+	result, err := c.CallTool(ctx, "delete_entities", map[string]interface{}{"entityIds": []string{"ada"}})
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+	assert.Contains(t, result.Content[0].Text, "Nothing is deleted")
+
+	audit, err := os.ReadFile(auditPath)
+	require.NoError(t, err)
+	assert.Contains(t, string(audit), `"rule":"keep-everything"`)
+	assert.Contains(t, string(audit), `"allowed":false`)
+}
+
+func TestPolicyConfirmsOnce(t *testing.T) {
+	dir := t.TempDir()
+	policyPath := filepath.Join(dir, "policy.json")
+	require.NoError(t, os.WriteFile(policyPath, []byte(`{
+		"rules": [{"name": "confirm-deletes", "tools": ["delete_*"], "effect": "confirm"}]
+	}`), 0644))
+
+	engine, err := policy.OpenEngine(context.Background(), policyPath, filepath.Join(dir, "audit.log"))
+	require.NoError(t, err)
+	config := server.DefaultConfig()
+	config.Policy = engine
+	srv := server.NewServerWithConfig(nil, config)
+	manager := graph.NewKnowledgeGraphManager(filepath.Join(dir, "memory.json"))
+	_, err = manager.CreateEntities([]types.Entity{{ID: "ada", Name: "Ada", Type: "person"}})
+	require.NoError(t, err)
+	require.NoError(t, srv.RegisterTool(tool.NewDeleteEntitiesTool(manager)))
+
+	clientEnd, serverEnd := transport.NewInMemoryPair()
+	go srv.ServeTransport(serverEnd)
+	c := client.NewClient(clientEnd, nil)
+	defer c.Close()
+	asked := 0
+	c.HandleElicitation(func(ctx context.Context, p *mcp.ElicitParams) (*mcp.ElicitResult, error) {
+		asked++
+		return &mcp.ElicitResult{Action: mcp.ElicitAccept, Content: map[string]interface{}{"allow": true, "confirm": true}}, nil
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err = c.Initialize(ctx)
+	require.NoError(t, err)
+
+	result, err := c.CallTool(ctx, "delete_entities", map[string]interface{}{"entityIds": []string{"ada"}})
+	require.NoError(t, err)
+	assert.False(t, result.IsError, result.Content[0].Text)
+	assert.Equal(t, 1, asked, "a deletion the policy confirmed should not be confirmed again")
+}
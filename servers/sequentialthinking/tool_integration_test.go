@@ -628,13 +628,23 @@ func TestSchemaValidation(t *testing.T) {
 		t.Fatalf("Failed to read response: %v", err)
 	}
 
-	if resp.Error == nil {
-		t.Error("Expected error response for invalid field types")
+	// Tool failures are results with isError set, not protocol errors
+	if resp.Error != nil {
+		t.Fatalf("Unexpected error: %v", resp.Error)
 	}
-	if !strings.Contains(resp.Error.Message, "Tool execution failed") {
-		t.Errorf("Expected tool execution failure message, got: %s", resp.Error.Message)
+	result, ok = resp.Result.(map[string]interface{})
+	if !ok {
+		t.Fatalf("Expected map[string]interface{} result, got %T", resp.Result)
+	}
+	if result["isError"] != true {
+		t.Error("Expected isError for invalid field types")
+	}
+	content, _ = result["content"].([]interface{})
+	if len(content) == 0 {
+		t.Fatal("Expected content explaining the error")
 	}
-	if !strings.Contains(resp.Error.Data.(string), "failed to parse parameters") {
-		t.Errorf("Expected parameter parsing error, got: %s", resp.Error.Data.(string))
+	text, _ := content[0].(map[string]interface{})["text"].(string)
+	if !strings.Contains(text, "failed to parse parameters") {
+		t.Errorf("Expected parameter parsing error, got: %s", text)
 	}
 }
@@ -3,6 +3,9 @@ package main
 import (
 	"encoding/json"
 	"testing"
+
+	"mcp-go-sdk/server"
+	"mcp-go-sdk/servertest"
 )
 
 // Helper function to get numeric value from interface{}
@@ -258,3 +261,11 @@ func TestSchema(t *testing.T) {
 		}
 	}
 }
+
+func TestConformance(t *testing.T) {
+	srv := server.NewServer(nil)
+	srv.RegisterTool(NewTool())
+	servertest.Run(t, srv, &servertest.Options{
+		ErrorCall: &servertest.ToolCall{Name: "sequentialthinking", Arguments: map[string]interface{}{}},
+	})
+}